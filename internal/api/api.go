@@ -15,6 +15,7 @@ import (
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/httpserver"
 	"github.com/benthosdev/benthos/v4/internal/log"
 )
 
@@ -40,7 +41,10 @@ func OptWithTLS(tls *tls.Config) OptFunc {
 // Type implements the Benthos HTTP API.
 type Type struct {
 	conf         Config
+	auth         *httpserver.Authenticator
+	version      string
 	endpoints    map[string]string
+	operations   map[string][]OperationSpec
 	endpointsMut sync.Mutex
 
 	ctx    context.Context
@@ -78,17 +82,24 @@ func New(
 		}
 	}
 
-	if err := conf.BasicAuth.Validate(); err != nil {
+	auth, err := httpserver.NewAuthenticator(conf.AuthConfig)
+	if err != nil {
+		return nil, err
+	}
+	if server.TLSConfig, err = auth.TLSConfig(conf.CertFile, conf.KeyFile); err != nil {
 		return nil, err
 	}
 
 	t := &Type{
-		conf:      conf,
-		endpoints: map[string]string{},
-		handlers:  map[string]http.HandlerFunc{},
-		mux:       gMux,
-		server:    server,
-		log:       log,
+		conf:       conf,
+		auth:       auth,
+		version:    version,
+		endpoints:  map[string]string{},
+		operations: map[string][]OperationSpec{},
+		handlers:   map[string]http.HandlerFunc{},
+		mux:        gMux,
+		server:     server,
+		log:        log,
 	}
 	t.ctx, t.cancel = context.WithCancel(context.Background())
 
@@ -146,6 +157,16 @@ func New(
 		}
 	}
 
+	handleOpenAPI := func(w http.ResponseWriter, r *http.Request) {
+		resBytes, err := t.OpenAPI()
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+
 	if t.conf.DebugEndpoints {
 		t.RegisterEndpoint(
 			"/debug/config/json", "DEBUG: Returns the loaded config as JSON.",
@@ -198,14 +219,15 @@ func New(
 		)
 	}
 
-	t.RegisterEndpoint("/ping", "Ping me.", handlePing)
-	t.RegisterEndpoint("/version", "Returns the service version.", handleVersion)
-	t.RegisterEndpoint("/endpoints", "Returns this map of endpoints.", handleEndpoints)
+	t.RegisterEndpoint("/ping", "Ping me.", handlePing, pingOperations()...)
+	t.RegisterEndpoint("/version", "Returns the service version.", handleVersion, versionOperations()...)
+	t.RegisterEndpoint("/endpoints", "Returns this map of endpoints.", handleEndpoints, endpointsOperations()...)
+	t.RegisterEndpoint("/openapi.json", "Returns an OpenAPI document describing the registered endpoints.", handleOpenAPI, openAPIOperations()...)
 
 	// If we want to expose a stats endpoint we register the endpoints.
 	if wHandlerFunc := stats.HandlerFunc(); wHandlerFunc != nil {
-		t.RegisterEndpoint("/stats", "Exposes service-wide metrics in the format configured.", wHandlerFunc)
-		t.RegisterEndpoint("/metrics", "Exposes service-wide metrics in the format configured.", wHandlerFunc)
+		t.RegisterEndpoint("/stats", "Exposes service-wide metrics in the format configured.", wHandlerFunc, MetricsOperations()...)
+		t.RegisterEndpoint("/metrics", "Exposes service-wide metrics in the format configured.", wHandlerFunc, MetricsOperations()...)
 	}
 
 	for _, opt := range opts {
@@ -221,18 +243,21 @@ func (t *Type) Handler() http.Handler {
 }
 
 // RegisterEndpoint registers a http.HandlerFunc under a path with a
-// description that will be displayed under the /endpoints path.
-func (t *Type) RegisterEndpoint(path, desc string, handlerFunc http.HandlerFunc) {
+// description that will be displayed under the /endpoints path. The operations
+// supported by the endpoint are described within the OpenAPI document of the
+// API, where a single GET operation is assumed when none are provided.
+func (t *Type) RegisterEndpoint(path, desc string, handlerFunc http.HandlerFunc, ops ...OperationSpec) {
 	t.endpointsMut.Lock()
 	defer t.endpointsMut.Unlock()
 
 	t.endpoints[path] = desc
+	t.operations[path] = ops
 
 	t.handlersMut.Lock()
 	defer t.handlersMut.Unlock()
 
 	if _, exists := t.handlers[path]; !exists {
-		wrapHandler := t.conf.BasicAuth.WrapHandler(func(w http.ResponseWriter, r *http.Request) {
+		wrapHandler := t.auth.WrapHandler(path, func(w http.ResponseWriter, r *http.Request) {
 			t.handlersMut.RLock()
 			h := t.handlers[path]
 			t.handlersMut.RUnlock()
@@ -13,19 +13,19 @@ const (
 	fieldCertFile       = "cert_file"
 	fieldKeyFile        = "key_file"
 	fieldCORS           = "cors"
-	fieldBasicAuth      = "basic_auth"
 )
 
 // Config contains the configuration fields for the Benthos API.
 type Config struct {
-	Address        string                     `json:"address" yaml:"address"`
-	Enabled        bool                       `json:"enabled" yaml:"enabled"`
-	RootPath       string                     `json:"root_path" yaml:"root_path"`
-	DebugEndpoints bool                       `json:"debug_endpoints" yaml:"debug_endpoints"`
-	CertFile       string                     `json:"cert_file" yaml:"cert_file"`
-	KeyFile        string                     `json:"key_file" yaml:"key_file"`
-	CORS           httpserver.CORSConfig      `json:"cors" yaml:"cors"`
-	BasicAuth      httpserver.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	Address        string                `json:"address" yaml:"address"`
+	Enabled        bool                  `json:"enabled" yaml:"enabled"`
+	RootPath       string                `json:"root_path" yaml:"root_path"`
+	DebugEndpoints bool                  `json:"debug_endpoints" yaml:"debug_endpoints"`
+	CertFile       string                `json:"cert_file" yaml:"cert_file"`
+	KeyFile        string                `json:"key_file" yaml:"key_file"`
+	CORS           httpserver.CORSConfig `json:"cors" yaml:"cors"`
+
+	httpserver.AuthConfig `yaml:",inline"`
 }
 
 // NewConfig creates a new API config with default values.
@@ -38,7 +38,7 @@ func NewConfig() Config {
 		CertFile:       "",
 		KeyFile:        "",
 		CORS:           httpserver.NewServerCORSConfig(),
-		BasicAuth:      httpserver.NewBasicAuthConfig(),
+		AuthConfig:     httpserver.NewAuthConfig(),
 	}
 }
 
@@ -64,7 +64,7 @@ func FromParsed(pConf *docs.ParsedConfig) (conf Config, err error) {
 	if conf.CORS, err = httpserver.CORSConfigFromParsed(pConf); err != nil {
 		return
 	}
-	if conf.BasicAuth, err = httpserver.BasicAuthConfigFromParsed(pConf); err != nil {
+	if conf.AuthConfig, err = httpserver.AuthConfigFromParsed(pConf); err != nil {
 		return
 	}
 	return
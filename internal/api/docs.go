@@ -12,7 +12,7 @@ import (
 
 // Spec returns a field spec for the API configuration fields.
 func Spec() docs.FieldSpecs {
-	return docs.FieldSpecs{
+	spec := docs.FieldSpecs{
 		docs.FieldBool(fieldEnabled, "Whether to enable to HTTP server.").HasDefault(true),
 		docs.FieldString(fieldAddress, "The address to bind to.").HasDefault("0.0.0.0:4195"),
 		docs.FieldString(
@@ -24,8 +24,8 @@ func Spec() docs.FieldSpecs {
 		docs.FieldString(fieldCertFile, "An optional certificate file for enabling TLS.").Advanced().HasDefault(""),
 		docs.FieldString(fieldKeyFile, "An optional key file for enabling TLS.").Advanced().HasDefault(""),
 		httpserver.ServerCORSFieldSpec(),
-		httpserver.BasicAuthFieldSpec(),
 	}
+	return append(spec, httpserver.AuthFieldSpecs()...)
 }
 
 //go:embed docs.md
@@ -68,6 +68,21 @@ http:
     password_hash: ""
     algorithm: "sha256"
     salt: ""
+  token_auth:
+    enabled: false
+    tokens: []
+    tokens_file: ""
+  jwt_auth:
+    enabled: false
+    jwks_file: ""
+    issuer: ""
+    audience: ""
+    principal_claim: sub
+  client_cert_auth:
+    enabled: false
+    client_ca_file: ""
+    allowed_subjects: []
+  authorization: []
 `,
 	})
 
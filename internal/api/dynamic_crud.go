@@ -308,3 +308,74 @@ func (d *Dynamic) HandleCRUD(w http.ResponseWriter, r *http.Request) {
 		httpErr = fmt.Errorf("verb not supported: %v", r.Method)
 	}
 }
+
+// DynamicListOperations returns the operations of the endpoint that lists
+// dynamic components.
+func DynamicListOperations() []OperationSpec {
+	return []OperationSpec{{
+		Method: "GET",
+		Responses: []ResponseSpec{{
+			Status:      http.StatusOK,
+			Description: "A map of component ids to their uptime and config.",
+			Body: &BodySpec{Schema: map[string]any{
+				"type": "object",
+				"additionalProperties": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"uptime":     map[string]any{"type": "string"},
+						"config":     map[string]any{"type": "object"},
+						"config_raw": map[string]any{"type": "string"},
+					},
+				},
+			}},
+		}},
+	}}
+}
+
+// DynamicCRUDOperations returns the operations of the endpoint used for
+// performing CRUD operations on dynamic components.
+func DynamicCRUDOperations() []OperationSpec {
+	configBody := &BodySpec{ContentType: "application/yaml", Schema: map[string]any{"type": "object"}}
+	return []OperationSpec{
+		{
+			Method:  "GET",
+			Summary: "Read the config of a component.",
+			Responses: []ResponseSpec{
+				{Status: http.StatusOK, Description: "The config of the component.", Body: configBody},
+				dynamicNotFoundResponse(),
+			},
+		},
+		{
+			Method:  "POST",
+			Summary: "Create or replace a component.",
+			Request: configBody,
+			Responses: []ResponseSpec{
+				{Status: http.StatusOK, Description: "The component was set."},
+				{Status: http.StatusBadRequest, Description: "The config was invalid.", Body: textBodySpec},
+			},
+		},
+		{
+			Method:  "DELETE",
+			Summary: "Stop and remove a component.",
+			Responses: []ResponseSpec{
+				{Status: http.StatusOK, Description: "The component was removed."},
+			},
+		},
+	}
+}
+
+// DynamicUptimeOperations returns the operations of the endpoint that reports
+// the uptime of a dynamic component.
+func DynamicUptimeOperations() []OperationSpec {
+	return []OperationSpec{{
+		Method: "GET",
+		Responses: []ResponseSpec{
+			{Status: http.StatusOK, Description: `The uptime as a duration string, or "stopped".`, Body: textBodySpec},
+			dynamicNotFoundResponse(),
+		},
+	}}
+}
+
+func dynamicNotFoundResponse() ResponseSpec {
+	return ResponseSpec{Status: http.StatusNotFound, Description: "The component does not exist.", Body: textBodySpec}
+}
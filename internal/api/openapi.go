@@ -0,0 +1,221 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BodySpec describes the body of a request or response of an endpoint.
+type BodySpec struct {
+	// The media type of the body, defaults to application/json.
+	ContentType string
+
+	// A JSON schema describing the body.
+	Schema map[string]any
+}
+
+// ResponseSpec describes a response of an endpoint operation.
+type ResponseSpec struct {
+	Status      int
+	Description string
+	Body        *BodySpec
+}
+
+// OperationSpec describes an operation (HTTP method) supported by an endpoint.
+type OperationSpec struct {
+	Method    string
+	Summary   string
+	Request   *BodySpec
+	Responses []ResponseSpec
+}
+
+// EndpointSpec describes a registered endpoint and its operations for the
+// purpose of generating an OpenAPI document of the HTTP API.
+type EndpointSpec struct {
+	// The path of the endpoint, e.g. `/streams/{id}`.
+	Path        string
+	Description string
+
+	// The operations supported by the endpoint, when empty a single GET
+	// operation is assumed.
+	Operations []OperationSpec
+}
+
+//------------------------------------------------------------------------------
+
+var pathParamRegexp = regexp.MustCompile(`{([^}:]+)(:[^}]*)?}`)
+
+// OpenAPIDocument generates an OpenAPI 3 document in JSON format describing a
+// list of endpoints.
+func OpenAPIDocument(version string, endpoints []EndpointSpec) ([]byte, error) {
+	paths := map[string]any{}
+	for _, e := range endpoints {
+		// Strip any regular expressions from path variables.
+		path := pathParamRegexp.ReplaceAllString(e.Path, "{$1}")
+
+		var params []any
+		for _, match := range pathParamRegexp.FindAllStringSubmatch(path, -1) {
+			params = append(params, map[string]any{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+
+		pathObj := map[string]any{
+			"description": e.Description,
+		}
+		if len(params) > 0 {
+			pathObj["parameters"] = params
+		}
+
+		ops := e.Operations
+		if len(ops) == 0 {
+			ops = []OperationSpec{
+				{Method: "GET", Summary: e.Description, Responses: []ResponseSpec{{Status: http.StatusOK, Description: "OK"}}},
+			}
+		}
+		for _, op := range ops {
+			pathObj[strings.ToLower(op.Method)] = openAPIOperation(op)
+		}
+
+		paths[path] = pathObj
+	}
+
+	return json.Marshal(map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Benthos HTTP API",
+			"version": version,
+		},
+		"paths": paths,
+	})
+}
+
+func openAPIContent(b *BodySpec) map[string]any {
+	contentType := b.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	media := map[string]any{}
+	if b.Schema != nil {
+		media["schema"] = b.Schema
+	}
+	return map[string]any{contentType: media}
+}
+
+func openAPIOperation(op OperationSpec) map[string]any {
+	opObj := map[string]any{}
+	if op.Summary != "" {
+		opObj["summary"] = op.Summary
+	}
+	if op.Request != nil {
+		opObj["requestBody"] = map[string]any{
+			"required": true,
+			"content":  openAPIContent(op.Request),
+		}
+	}
+
+	responses := map[string]any{}
+	for _, res := range op.Responses {
+		resObj := map[string]any{"description": res.Description}
+		if res.Body != nil {
+			resObj["content"] = openAPIContent(res.Body)
+		}
+		responses[strconv.Itoa(res.Status)] = resObj
+	}
+	if len(responses) == 0 {
+		responses["default"] = map[string]any{"description": "Response"}
+	}
+	opObj["responses"] = responses
+	return opObj
+}
+
+// OpenAPI returns an OpenAPI 3 document in JSON format describing all
+// endpoints currently registered with the API.
+func (t *Type) OpenAPI() ([]byte, error) {
+	t.endpointsMut.Lock()
+	endpoints := make([]EndpointSpec, 0, len(t.endpoints))
+	for k, v := range t.endpoints {
+		endpoints = append(endpoints, EndpointSpec{
+			Path:        k,
+			Description: v,
+			Operations:  t.operations[k],
+		})
+	}
+	t.endpointsMut.Unlock()
+
+	return OpenAPIDocument(t.version, endpoints)
+}
+
+//------------------------------------------------------------------------------
+
+var textBodySpec = &BodySpec{ContentType: "text/plain", Schema: map[string]any{"type": "string"}}
+
+func pingOperations() []OperationSpec {
+	return []OperationSpec{{
+		Method:    "GET",
+		Responses: []ResponseSpec{{Status: http.StatusOK, Description: "The service is running.", Body: textBodySpec}},
+	}}
+}
+
+func versionOperations() []OperationSpec {
+	return []OperationSpec{{
+		Method: "GET",
+		Responses: []ResponseSpec{{Status: http.StatusOK, Description: "The version of the service.", Body: &BodySpec{
+			Schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"version": map[string]any{"type": "string"},
+					"built":   map[string]any{"type": "string"},
+				},
+			},
+		}}},
+	}}
+}
+
+func endpointsOperations() []OperationSpec {
+	return []OperationSpec{{
+		Method: "GET",
+		Responses: []ResponseSpec{{Status: http.StatusOK, Description: "A map of endpoint paths to their descriptions.", Body: &BodySpec{
+			Schema: map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "string"},
+			},
+		}}},
+	}}
+}
+
+func openAPIOperations() []OperationSpec {
+	return []OperationSpec{{
+		Method: "GET",
+		Responses: []ResponseSpec{{Status: http.StatusOK, Description: "An OpenAPI 3 document.", Body: &BodySpec{
+			Schema: map[string]any{"type": "object"},
+		}}},
+	}}
+}
+
+// MetricsOperations returns the operations of the endpoints that expose
+// service-wide metrics.
+func MetricsOperations() []OperationSpec {
+	return []OperationSpec{{
+		Method:    "GET",
+		Responses: []ResponseSpec{{Status: http.StatusOK, Description: "Metrics in the format configured.", Body: textBodySpec}},
+	}}
+}
+
+// ReadyOperations returns the operations of the endpoints that report whether
+// the inputs and outputs of a service are connected.
+func ReadyOperations() []OperationSpec {
+	return []OperationSpec{{
+		Method: "GET",
+		Responses: []ResponseSpec{
+			{Status: http.StatusOK, Description: "All inputs and outputs are connected.", Body: textBodySpec},
+			{Status: http.StatusServiceUnavailable, Description: "One or more inputs or outputs are not connected.", Body: textBodySpec},
+		},
+	}}
+}
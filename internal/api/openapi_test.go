@@ -0,0 +1,96 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jeffail/gabs/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	fooOps := []api.OperationSpec{
+		{
+			Method:  "POST",
+			Request: &api.BodySpec{Schema: map[string]any{"type": "object"}},
+			Responses: []api.ResponseSpec{
+				{Status: http.StatusOK, Description: "Created."},
+				{Status: http.StatusBadRequest, Description: "Bad."},
+			},
+		},
+		{
+			Method:    "DELETE",
+			Responses: []api.ResponseSpec{{Status: http.StatusOK, Description: "Deleted."}},
+		},
+	}
+
+	docBytes, err := api.OpenAPIDocument("1.2.3", []api.EndpointSpec{
+		{Path: "/foos/{id}", Description: "Foo CRUD.", Operations: fooOps},
+		{Path: "/prefix/foos/{id:.+}", Description: "Foo CRUD with a prefix.", Operations: fooOps},
+		{Path: "/unknown", Description: "Some unknown endpoint."},
+	})
+	require.NoError(t, err)
+
+	doc, err := gabs.ParseJSON(docBytes)
+	require.NoError(t, err)
+
+	assert.Equal(t, "3.0.3", doc.S("openapi").Data())
+	assert.Equal(t, "1.2.3", doc.S("info", "version").Data())
+
+	for _, p := range []string{"/foos/{id}", "/prefix/foos/{id}"} {
+		pathObj := doc.S("paths", p)
+		require.NotNil(t, pathObj.Data(), p)
+
+		assert.Equal(t, "id", pathObj.S("parameters", "0", "name").Data(), p)
+		assert.Equal(t, "path", pathObj.S("parameters", "0", "in").Data(), p)
+		assert.Equal(t, "object", pathObj.S("post", "requestBody", "content", "application/json", "schema", "type").Data(), p)
+		assert.Equal(t, "Bad.", pathObj.S("post", "responses", "400", "description").Data(), p)
+		assert.Equal(t, "Deleted.", pathObj.S("delete", "responses", "200", "description").Data(), p)
+		assert.Nil(t, pathObj.S("get").Data(), p)
+	}
+	assert.Equal(t, "Foo CRUD with a prefix.", doc.S("paths", "/prefix/foos/{id}", "description").Data())
+
+	unknown := doc.S("paths", "/unknown")
+	assert.Equal(t, "Some unknown endpoint.", unknown.S("get", "summary").Data())
+	assert.Equal(t, "OK", unknown.S("get", "responses", "200", "description").Data())
+	assert.Nil(t, unknown.S("parameters").Data())
+}
+
+func TestOpenAPIEndpoint(t *testing.T) {
+	s, err := api.New("4.5.6", "", api.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	s.RegisterEndpoint("/inputs/{id}", "Dynamic inputs.", func(w http.ResponseWriter, r *http.Request) {}, api.DynamicCRUDOperations()...)
+	s.RegisterEndpoint("/foo/inputs/{id}", "Not dynamic inputs.", func(w http.ResponseWriter, r *http.Request) {})
+
+	request, _ := http.NewRequest("GET", "/openapi.json", http.NoBody)
+	response := httptest.NewRecorder()
+	s.Handler().ServeHTTP(response, request)
+
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &doc))
+
+	doc2 := gabs.Wrap(doc)
+	assert.Equal(t, "4.5.6", doc2.S("info", "version").Data())
+	for _, p := range []string{"/ping", "/version", "/openapi.json", "/inputs/{id}"} {
+		assert.NotNil(t, doc2.S("paths", p).Data(), p)
+	}
+	assert.NotNil(t, doc2.S("paths", "/inputs/{id}", "delete").Data())
+	assert.NotNil(t, doc2.S("paths", "/ping", "get", "responses", "200", "content", "text/plain").Data())
+
+	// Endpoints are only described by the operations they were registered
+	// with, regardless of whether their path resembles another endpoint.
+	assert.NotNil(t, doc2.S("paths", "/foo/inputs/{id}", "get").Data())
+	assert.Nil(t, doc2.S("paths", "/foo/inputs/{id}", "delete").Data())
+	assert.Nil(t, doc2.S("paths", "/streams/{id}").Data())
+}
@@ -20,7 +20,7 @@ type Environment struct {
 // changes.
 func GlobalEnvironment() *Environment {
 	return &Environment{
-		pCtx: parser.GlobalContext(),
+		pCtx: parser.GlobalContext().WithImportCache(),
 	}
 }
 
@@ -43,7 +43,7 @@ func NewEnvironment() *Environment {
 // empty, where no functions or methods are initially available.
 func NewEmptyEnvironment() *Environment {
 	return &Environment{
-		pCtx: parser.EmptyContext(),
+		pCtx: parser.EmptyContext().WithImportCache(),
 	}
 }
 
@@ -77,6 +77,22 @@ func (e *Environment) NewMapping(blobl string) (*mapping.Executor, error) {
 	return exec, nil
 }
 
+// CheckMapping parses a Bloblang mapping using the Environment to determine
+// the features (functions and methods) available to the mapping, and performs
+// a static type check over it. Type errors are returned as a slice of
+// *parser.Error, each describing a definite type mismatch or unreachable code
+// at a position of the mapping.
+//
+// When a parsing error occurs the error will be the type *parser.Error and no
+// type errors are returned.
+func (e *Environment) CheckMapping(blobl string) ([]*parser.Error, error) {
+	_, typeErrs, err := parser.CheckMapping(e.pCtx, blobl)
+	if err != nil {
+		return nil, err
+	}
+	return typeErrs, nil
+}
+
 // Deactivated returns a version of the environment where constructors are
 // disabled for all functions and methods, allowing mappings to be parsed and
 // validated but not executed.
@@ -101,6 +117,7 @@ func (e *Environment) OnlyPure() *Environment {
 	env := *e
 	env.pCtx.Functions = env.pCtx.Functions.OnlyPure()
 	env.pCtx.Methods = env.pCtx.Methods.OnlyPure()
+	env.pCtx = env.pCtx.WithImportCache()
 	return &env
 }
 
@@ -156,6 +173,7 @@ func (e *Environment) WithCustomImporter(fn func(name string) ([]byte, error)) *
 func (e *Environment) WithoutMethods(names ...string) *Environment {
 	env := *e
 	env.pCtx.Methods = env.pCtx.Methods.Without(names...)
+	env.pCtx = env.pCtx.WithImportCache()
 	return &env
 }
 
@@ -165,6 +183,7 @@ func (e *Environment) WithoutMethods(names ...string) *Environment {
 func (e *Environment) WithoutFunctions(names ...string) *Environment {
 	env := *e
 	env.pCtx.Functions = env.pCtx.Functions.Without(names...)
+	env.pCtx = env.pCtx.WithImportCache()
 	return &env
 }
 
@@ -178,6 +197,14 @@ func (e *Environment) WithMaxMapRecursion(n int) *Environment {
 	return &env
 }
 
+// WithCoverage returns a copy of the environment where the executions of the
+// statements and branches of parsed mappings are recorded.
+func (e *Environment) WithCoverage(c *query.Coverage) *Environment {
+	env := *e
+	env.pCtx = env.pCtx.WithCoverage(c, "")
+	return &env
+}
+
 // WalkFunctions executes a provided function argument for every function that
 // has been registered to the environment.
 func (e *Environment) WalkFunctions(fn func(name string, spec query.FunctionSpec)) {
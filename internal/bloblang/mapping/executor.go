@@ -43,9 +43,11 @@ type Executor struct {
 	annotation string
 	input      []rune
 	maps       map[string]query.Function
+	functions  map[string]*query.UserFunction
 	statements []Statement
 
 	maxMapStacks int
+	coverage     *query.Coverage
 }
 
 const defaultMaxMapStacks = 5000
@@ -71,6 +73,21 @@ func (e *Executor) SetMaxMapRecursion(m int) {
 	e.maxMapStacks = m
 }
 
+// SetCoverage configures a coverage recorder where the statements and branches
+// executed by the mapping are recorded.
+func (e *Executor) SetCoverage(c *query.Coverage) {
+	e.coverage = c
+}
+
+// withCoverage returns a function context that records the executions of the
+// mapping, if a coverage recorder has been set.
+func (e *Executor) withCoverage(ctx query.FunctionContext) query.FunctionContext {
+	if e.coverage == nil {
+		return ctx
+	}
+	return ctx.WithCoverage(e.coverage)
+}
+
 // Annotation returns a string annotation that describes the mapping executor.
 func (e *Executor) Annotation() string {
 	return e.annotation
@@ -81,6 +98,17 @@ func (e *Executor) Maps() map[string]query.Function {
 	return e.maps
 }
 
+// SetFunctions sets the user defined functions contained within the mapping.
+func (e *Executor) SetFunctions(functions map[string]*query.UserFunction) {
+	e.functions = functions
+}
+
+// Functions returns any user defined function definitions contained within the
+// mapping.
+func (e *Executor) Functions() map[string]*query.UserFunction {
+	return e.functions
+}
+
 // QueryPart executes the bloblang mapping on a particular message index of a
 // batch. The message is parsed as a JSON document in order to provide the
 // mapping context. The result of the mapping is expected to be a boolean value
@@ -156,14 +184,14 @@ func (e *Executor) mapPart(appendTo *message.Part, index int, reference Message)
 	vars := map[string]any{}
 
 	for _, stmt := range e.statements {
-		err := stmt.Execute(query.FunctionContext{
+		err := stmt.Execute(e.withCoverage(query.FunctionContext{
 			Maps:     e.maps,
 			Vars:     vars,
 			Index:    index,
 			MsgBatch: reference,
 			NewMeta:  newPart,
 			NewValue: &newValue,
-		}.WithValueFunc(lazyValue),
+		}.WithValueFunc(lazyValue)),
 			AssignmentContext{
 				Vars:  vars,
 				Meta:  newPart,
@@ -241,6 +269,7 @@ func (e *Executor) Exec(ctx query.FunctionContext) (any, error) {
 
 	var newObj any = value.Nothing(nil)
 	ctx.NewValue = &newObj
+	ctx = e.withCoverage(ctx.WithTraceSource(e.input))
 
 	for _, stmt := range e.statements {
 		if err := stmt.Execute(ctx, AssignmentContext{
@@ -257,6 +286,7 @@ func (e *Executor) Exec(ctx query.FunctionContext) (any, error) {
 
 // ExecOnto a provided assignment context.
 func (e *Executor) ExecOnto(ctx query.FunctionContext, onto AssignmentContext) error {
+	ctx = e.withCoverage(ctx.WithTraceSource(e.input))
 	for _, stmt := range e.statements {
 		if err := stmt.Execute(ctx, onto); err != nil {
 			return formatExecErr(err, e.input, stmt.Input())
@@ -43,6 +43,7 @@ func (s *SingleStatement) Input() []rune {
 }
 
 func (s *SingleStatement) Execute(fnContext query.FunctionContext, asContext AssignmentContext) error {
+	fnContext.CoverStatement(s.input)
 	res, err := s.query.Exec(fnContext)
 	if err != nil {
 		return err
@@ -51,7 +52,22 @@ func (s *SingleStatement) Execute(fnContext query.FunctionContext, asContext Ass
 		// Skip assignment entirely
 		return nil
 	}
-	return s.assignment.Apply(res, asContext)
+	if err := s.assignment.Apply(res, asContext); err != nil {
+		return err
+	}
+	if fnContext.Tracing() {
+		target := s.assignment.Target()
+		eventType := query.TraceAssignment
+		if target.Type == TargetVariable {
+			eventType = query.TraceVariable
+		}
+		fnContext.AddTraceEvent(s.input, query.TraceEvent{
+			Type:   eventType,
+			Target: target.String(),
+			Value:  value.IClone(res),
+		})
+	}
+	return nil
 }
 
 //------------------------------------------------------------------------------
@@ -107,6 +123,7 @@ func (r *RootLevelIfStatement) Input() []rune {
 }
 
 func (r *RootLevelIfStatement) Execute(fnContext query.FunctionContext, asContext AssignmentContext) error {
+	fnContext.CoverStatement(r.input)
 	for i, p := range r.pairs {
 		if p.query != nil {
 			queryVal, err := p.query.Exec(fnContext)
@@ -121,6 +138,10 @@ func (r *RootLevelIfStatement) Execute(fnContext query.FunctionContext, asContex
 				continue
 			}
 		}
+		fnContext.CoverBranch(r.input, i)
+		if fnContext.Tracing() {
+			fnContext.AddTraceEvent(r.input, query.TraceEvent{Type: query.TraceIf, Case: i})
+		}
 		for _, stmt := range p.statements {
 			if err := stmt.Execute(fnContext, asContext); err != nil {
 				return err
@@ -128,5 +149,9 @@ func (r *RootLevelIfStatement) Execute(fnContext query.FunctionContext, asContex
 		}
 		return nil
 	}
+	fnContext.CoverBranch(r.input, len(r.pairs))
+	if fnContext.Tracing() {
+		fnContext.AddTraceEvent(r.input, query.TraceEvent{Type: query.TraceIf, Case: -1})
+	}
 	return nil
 }
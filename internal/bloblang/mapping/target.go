@@ -1,5 +1,9 @@
 package mapping
 
+import (
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
 // TargetType represents a mapping target type, which is a destination for a
 // query result to be mapped into a message.
 type TargetType int
@@ -26,3 +30,24 @@ func NewTargetPath(t TargetType, path ...string) TargetPath {
 		Path: path,
 	}
 }
+
+// String returns a representation of the target path in the form that it
+// would be written as the target of an assignment within a mapping.
+func (t TargetPath) String() string {
+	switch t.Type {
+	case TargetMetadata:
+		if len(t.Path) == 0 {
+			return "meta"
+		}
+		return "meta " + t.Path[0]
+	case TargetVariable:
+		if len(t.Path) == 0 {
+			return "let"
+		}
+		return "let " + t.Path[0]
+	}
+	if len(t.Path) == 0 {
+		return "root"
+	}
+	return "root." + query.SliceToDotPath(t.Path...)
+}
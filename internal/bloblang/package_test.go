@@ -269,3 +269,42 @@ func TestMappingParallelExecution(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvironmentImportCache(t *testing.T) {
+	files := map[string]string{
+		"lib.blobl": `map foo { root = "foo" }`,
+	}
+	var filesMut sync.Mutex
+
+	env := GlobalEnvironment().WithCustomImporter(func(name string) ([]byte, error) {
+		filesMut.Lock()
+		defer filesMut.Unlock()
+		return []byte(files[name]), nil
+	})
+
+	mapFoo := func() (query.Function, string) {
+		t.Helper()
+
+		exec, err := env.NewMapping(`import "lib.blobl"
+root = this.apply("foo")`)
+		require.NoError(t, err)
+
+		res, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+		require.NoError(t, err)
+		return exec.Maps()["foo"], string(res.AsBytes())
+	}
+
+	firstMap, firstRes := mapFoo()
+	secondMap, secondRes := mapFoo()
+	assert.Same(t, firstMap, secondMap)
+	assert.Equal(t, "foo", firstRes)
+	assert.Equal(t, "foo", secondRes)
+
+	filesMut.Lock()
+	files["lib.blobl"] = `map foo { root = "bar" }`
+	filesMut.Unlock()
+
+	changedMap, changedRes := mapFoo()
+	assert.NotSame(t, firstMap, changedMap)
+	assert.Equal(t, "bar", changedRes)
+}
@@ -16,6 +16,18 @@ type Context struct {
 	Methods      *query.MethodSet
 	namedContext *namedContext
 	importer     Importer
+
+	// User defined functions of the mapping being parsed.
+	userFunctions map[string]*query.UserFunction
+
+	imports     *importCache
+	importChain []string
+
+	coverage       *query.Coverage
+	coveragePath   string
+	coverageSource *query.CoverageSource
+
+	typeChecker *typeChecker
 }
 
 // EmptyContext returns a parser context with no functions, methods or import
@@ -62,6 +74,13 @@ func (pCtx Context) HasNamedContext(name string) bool {
 	return false
 }
 
+// withUserFunctions returns a Context where calls to functions are resolved
+// from a table of user defined functions before the function set.
+func (pCtx Context) withUserFunctions(functions map[string]*query.UserFunction) Context {
+	pCtx.userFunctions = functions
+	return pCtx
+}
+
 // InitFunction attempts to initialise a function from the available
 // constructors of the parser context.
 func (pCtx Context) InitFunction(name string, args *query.ParsedParams) (query.Function, error) {
@@ -78,7 +97,7 @@ func (pCtx Context) InitMethod(name string, target query.Function, args *query.P
 // Importer implementation.
 func (pCtx Context) WithImporter(importer Importer) Context {
 	pCtx.importer = importer
-	return pCtx
+	return pCtx.resetImportCache()
 }
 
 // WithImporterRelativeToFile returns a Context where any relative imports will
@@ -98,7 +117,7 @@ func (pCtx Context) Deactivated() Context {
 	nextCtx := pCtx
 	nextCtx.Functions = pCtx.Functions.Deactivated()
 	nextCtx.Methods = pCtx.Methods.Deactivated()
-	return nextCtx
+	return nextCtx.resetImportCache()
 }
 
 // CustomImporter returns a version of the parser context where file imports are
@@ -107,7 +126,7 @@ func (pCtx Context) Deactivated() Context {
 func (pCtx Context) CustomImporter(fn func(name string) ([]byte, error)) Context {
 	nextCtx := pCtx
 	nextCtx.importer = newCustomImporter(fn)
-	return nextCtx
+	return nextCtx.resetImportCache()
 }
 
 // DisabledImports returns a version of the parser context where file imports
@@ -116,7 +135,18 @@ func (pCtx Context) CustomImporter(fn func(name string) ([]byte, error)) Context
 func (pCtx Context) DisabledImports() Context {
 	nextCtx := pCtx
 	nextCtx.importer = disabledImporter{}
-	return nextCtx
+	return nextCtx.resetImportCache()
+}
+
+// WithCoverage returns a version of the parser context where the statements
+// and branches of parsed mappings are registered with a coverage recorder, and
+// the executions of parsed mappings are recorded by it. The path is the file
+// that parsed mappings originate from, and can be left empty when unknown.
+func (pCtx Context) WithCoverage(c *query.Coverage, path string) Context {
+	pCtx.coverage = c
+	pCtx.coveragePath = path
+	pCtx.coverageSource = nil
+	return pCtx
 }
 
 // ImportFile attempts to read a file for import via the customised Importer.
@@ -166,6 +196,13 @@ func (i *osImporter) Import(pathStr string) ([]byte, error) {
 	return io.ReadAll(f)
 }
 
+func (i *osImporter) resolvePath(pathStr string) string {
+	if !filepath.IsAbs(pathStr) {
+		pathStr = filepath.Join(i.relativePath, pathStr)
+	}
+	return pathStr
+}
+
 func (i *osImporter) RelativeToFile(filePath string) Importer {
 	dir := filepath.Dir(filePath)
 	if dir == "" || dir == "." {
@@ -204,6 +241,13 @@ func (i *customImporter) Import(pathStr string) ([]byte, error) {
 	return i.readFn(pathStr)
 }
 
+func (i *customImporter) resolvePath(pathStr string) string {
+	if !filepath.IsAbs(pathStr) {
+		pathStr = filepath.Join(i.relativePath, pathStr)
+	}
+	return pathStr
+}
+
 func (i *customImporter) RelativeToFile(filePath string) Importer {
 	dir := filepath.Dir(filePath)
 	if dir == "" || dir == "." {
@@ -0,0 +1,36 @@
+package parser
+
+// withCoverageSource returns a version of the parser context where the
+// statements and branches of parsed mappings are registered to a coverage
+// source for the provided input, if coverage is enabled.
+func (pCtx Context) withCoverageSource(path string, input []rune) Context {
+	if pCtx.coverage != nil {
+		pCtx.coverageSource = pCtx.coverage.Source(path, input)
+	}
+	return pCtx
+}
+
+// importPath attempts to resolve the full path of an imported file for the
+// purpose of coverage reports.
+func (pCtx Context) importPath(path string) string {
+	if r, ok := pCtx.importer.(interface{ resolvePath(string) string }); ok {
+		return r.resolvePath(path)
+	}
+	return path
+}
+
+// coverStatement registers a statement beginning at a clip of the input in
+// order for it to be reported even when it is never executed.
+func (pCtx Context) coverStatement(clip []rune) {
+	if pCtx.coverageSource != nil {
+		pCtx.coverageSource.AddStatement(clip)
+	}
+}
+
+// coverBranch registers an arm of a branching expression in order for it to be
+// reported even when it is never taken.
+func (pCtx Context) coverBranch(block, clip []rune, arm int) {
+	if pCtx.coverageSource != nil {
+		pCtx.coverageSource.AddBranch(block, clip, arm)
+	}
+}
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func coverageSummary(s *query.CoverageSource) (summary []string) {
+	for _, c := range s.Counters() {
+		if c.IsBranch() {
+			summary = append(summary, fmt.Sprintf("branch %v:%v of %v:%v arm %v: %v", c.Line, c.Column, c.BlockLine, c.BlockColumn, c.Arm, c.Hits()))
+		} else {
+			summary = append(summary, fmt.Sprintf("statement %v:%v: %v", c.Line, c.Column, c.Hits()))
+		}
+	}
+	return
+}
+
+func TestMappingCoverage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "maps.blobl"), []byte(`map double {
+  root = this * 2
+}`), 0o644))
+
+	mapping := `import "./maps.blobl"
+root.a = this.a.apply("double")
+if this.a > 10 {
+  root.big = true
+} else if this.a > 5 {
+  root.medium = true
+}
+root.b = match this.b {
+  "foo" => "was foo"
+  "bar" => "was bar"
+}
+root.c = if this.c { "yes" } else { "no" }`
+
+	cov := query.NewCoverage()
+	pCtx := GlobalContext().WithImporterRelativeToFile(filepath.Join(dir, "mapping.blobl")).WithCoverage(cov, "mapping.blobl")
+
+	exec, err := ParseMapping(pCtx, mapping)
+	require.Nil(t, err)
+
+	for _, input := range []string{
+		`{"a":20,"b":"foo","c":true}`,
+		`{"a":2,"b":"foo","c":true}`,
+	} {
+		_, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(input)}))
+		require.NoError(t, err)
+	}
+
+	sources := cov.Sources()
+	require.Len(t, sources, 2)
+
+	assert.Equal(t, "mapping.blobl", sources[0].Path)
+	assert.Equal(t, []string{
+		"statement 2:1: 2",
+		"statement 3:1: 2",
+		"branch 3:1 of 3:1 arm 0: 1",
+		"branch 3:1 of 3:1 arm 2: 1",
+		"statement 4:3: 1",
+		"branch 5:3 of 3:1 arm 1: 0",
+		"statement 6:3: 0",
+		"statement 8:1: 2",
+		"branch 8:10 of 8:10 arm 2: 0",
+		"branch 9:3 of 8:10 arm 0: 2",
+		"branch 10:3 of 8:10 arm 1: 0",
+		"statement 12:1: 2",
+		"branch 12:10 of 12:10 arm 0: 2",
+		"branch 12:30 of 12:10 arm 1: 0",
+	}, coverageSummary(sources[0]))
+
+	assert.Equal(t, filepath.Join(dir, "maps.blobl"), sources[1].Path)
+	assert.Equal(t, []string{
+		"statement 2:3: 2",
+	}, coverageSummary(sources[1]))
+}
+
+func TestMappingCoverageReparsed(t *testing.T) {
+	cov := query.NewCoverage()
+	pCtx := GlobalContext().WithCoverage(cov, "")
+
+	for i := 0; i < 2; i++ {
+		exec, err := ParseMapping(pCtx, `root = this.uppercase()`)
+		require.Nil(t, err)
+
+		_, mErr := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`"foo"`)}))
+		require.NoError(t, mErr)
+	}
+
+	sources := cov.Sources()
+	require.Len(t, sources, 1)
+	assert.Equal(t, []string{"statement 1:1: 2"}, coverageSummary(sources[0]))
+}
+
+func TestMappingCoverageSameResults(t *testing.T) {
+	mapping := `root.a = match this.a {
+  "foo" => "was foo"
+}
+root.b = if this.b { "yes" }
+if this.c {
+  root.c = true
+}`
+
+	exec, err := ParseMapping(GlobalContext(), mapping)
+	require.Nil(t, err)
+
+	cov := query.NewCoverage()
+	covExec, err := ParseMapping(GlobalContext().WithCoverage(cov, ""), mapping)
+	require.Nil(t, err)
+
+	for _, input := range []string{
+		`{"a":"foo","b":true,"c":true}`,
+		`{"a":"bar","b":false,"c":false}`,
+	} {
+		exp, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(input)}))
+		require.NoError(t, err)
+
+		act, err := covExec.MapPart(0, message.QuickBatch([][]byte{[]byte(input)}))
+		require.NoError(t, err)
+
+		assert.Equal(t, string(exp.AsBytes()), string(act.AsBytes()), input)
+	}
+
+	sources := cov.Sources()
+	require.Len(t, sources, 1)
+	assert.Equal(t, []string{
+		"statement 1:1: 2",
+		"branch 1:10 of 1:10 arm 1: 1",
+		"branch 2:3 of 1:10 arm 0: 1",
+		"statement 4:1: 2",
+		"branch 4:10 of 4:10 arm 0: 1",
+		"branch 4:10 of 4:10 arm 1: 1",
+		"statement 5:1: 2",
+		"branch 5:1 of 5:1 arm 0: 1",
+		"branch 5:1 of 5:1 arm 1: 1",
+		"statement 6:3: 1",
+	}, coverageSummary(sources[0]))
+}
@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+// importCache stores the parsed executors of imported files such that mappings
+// sharing a parser context do not need to parse the same file more than once.
+// Files are still read for each import, and a cached executor is only reused
+// when the contents of the file are unchanged.
+type importCache struct {
+	mut     sync.Mutex
+	entries map[string]importCacheEntry
+}
+
+type importCacheEntry struct {
+	content string
+	exec    *mapping.Executor
+}
+
+func (c *importCache) get(path, content string) *mapping.Executor {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if e, exists := c.entries[path]; exists && e.content == content {
+		return e.exec
+	}
+	return nil
+}
+
+func (c *importCache) set(path, content string, exec *mapping.Executor) {
+	c.mut.Lock()
+	c.entries[path] = importCacheEntry{content: content, exec: exec}
+	c.mut.Unlock()
+}
+
+// WithImportCache returns a version of the parser context where the parsed
+// contents of imported files are cached and reused by subsequent parses that
+// import the same file, as long as the contents of the file have not changed.
+func (pCtx Context) WithImportCache() Context {
+	pCtx.imports = &importCache{entries: map[string]importCacheEntry{}}
+	return pCtx
+}
+
+// resetImportCache returns a version of the parser context with an empty
+// import cache if caching is enabled, this is necessary when the context is
+// modified in a way that changes how imported files would be parsed.
+func (pCtx Context) resetImportCache() Context {
+	if pCtx.imports != nil {
+		return pCtx.WithImportCache()
+	}
+	return pCtx
+}
+
+// parseImport reads and parses a file imported by a mapping, detecting
+// circular imports and reusing a cached result when possible.
+func (pCtx Context) parseImport(input []rune, fpath string) (*mapping.Executor, *Error) {
+	contents, err := pCtx.importer.Import(fpath)
+	if err != nil {
+		return nil, NewFatalError(input, fmt.Errorf("failed to read import: %w", err))
+	}
+
+	resolvedPath := pCtx.importPath(fpath)
+	for i, p := range pCtx.importChain {
+		if p == resolvedPath {
+			chain := append(append([]string{}, pCtx.importChain[i:]...), resolvedPath)
+			return nil, NewFatalError(input, fmt.Errorf("circular import: %v", strings.Join(chain, " -> ")))
+		}
+	}
+
+	// Cached executors are only used when the importer is able to resolve
+	// absolute paths, and coverage isn't being recorded as the statements of a
+	// file are only registered for coverage when it is parsed.
+	_, canResolve := pCtx.importer.(interface{ resolvePath(string) string })
+	useCache := pCtx.imports != nil && canResolve && pCtx.coverage == nil
+	if useCache {
+		if exec := pCtx.imports.get(resolvedPath, string(contents)); exec != nil {
+			return exec, nil
+		}
+	}
+
+	importContent := []rune(string(contents))
+	nextCtx := pCtx.WithImporterRelativeToFile(fpath).
+		withCoverageSource(resolvedPath, importContent)
+	nextCtx.importChain = append(append([]string{}, pCtx.importChain...), resolvedPath)
+
+	execRes := parseExecutor(nextCtx)(importContent)
+	if execRes.Err != nil {
+		return nil, NewFatalError(input, NewImportError(fpath, importContent, execRes.Err))
+	}
+	if useCache {
+		pCtx.imports.set(resolvedPath, string(contents), execRes.Payload)
+	}
+	return execRes.Payload, nil
+}
+
+// withMapsScope wraps a map or function body imported into a namespace such
+// that any maps it applies are resolved from the file it was defined in rather
+// than the mapping that imported it.
+func withMapsScope(fn query.Function, maps map[string]query.Function) query.Function {
+	return query.ClosureFunction(fn.Annotation(), func(ctx query.FunctionContext) (any, error) {
+		ctx.Maps = maps
+		return fn.Exec(ctx)
+	}, func(ctx query.TargetsContext) (query.TargetsContext, []query.TargetPath) {
+		callerMaps := ctx.Maps
+		ctx.Maps = maps
+		ctx, paths := fn.QueryTargets(ctx)
+		ctx.Maps = callerMaps
+		return ctx, paths
+	})
+}
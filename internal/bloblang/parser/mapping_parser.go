@@ -3,6 +3,7 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/Jeffail/gabs/v2"
@@ -17,13 +18,20 @@ import (
 // The filepath is optional and used for relative file imports and error
 // messages.
 func ParseMapping(pCtx Context, expr string) (*mapping.Executor, *Error) {
-	in := []rune(expr)
+	return parseMapping(pCtx, []rune(expr))
+}
+
+func parseMapping(pCtx Context, in []rune) (*mapping.Executor, *Error) {
+	pCtx = pCtx.withCoverageSource(pCtx.coveragePath, in)
 
 	resDirectImport := singleRootImport(pCtx)(in)
 	if resDirectImport.Err != nil && resDirectImport.Err.IsFatal() {
 		return nil, resDirectImport.Err
 	}
 	if resDirectImport.Err == nil && len(resDirectImport.Remaining) == 0 {
+		if pCtx.coverage != nil {
+			resDirectImport.Payload.SetCoverage(pCtx.coverage)
+		}
 		return resDirectImport.Payload, nil
 	}
 
@@ -37,6 +45,9 @@ func ParseMapping(pCtx Context, expr string) (*mapping.Executor, *Error) {
 	if res.Err != nil {
 		return nil, res.Err
 	}
+	if pCtx.coverage != nil {
+		res.Payload.SetCoverage(pCtx.coverage)
+	}
 	return res.Payload, nil
 }
 
@@ -50,6 +61,7 @@ func mappingStatement(pCtx Context, enableMeta bool, maps map[string]query.Funct
 		enabledStatements = []Func[mapping.Statement]{
 			toNilStatement(importParser(pCtx, maps)),
 			toNilStatement(mapParser(pCtx, maps)),
+			toNilStatement(funcParser(pCtx, maps)),
 		}
 	}
 	enabledStatements = append(enabledStatements,
@@ -59,15 +71,26 @@ func mappingStatement(pCtx Context, enableMeta bool, maps map[string]query.Funct
 		rootLevelIfExpressionParser(pCtx),
 	)
 
-	return OneOf(enabledStatements...)
+	p := OneOf(enabledStatements...)
+	if pCtx.coverageSource == nil {
+		return p
+	}
+	return func(input []rune) Result[mapping.Statement] {
+		res := p(input)
+		if res.Err == nil && res.Payload != nil {
+			pCtx.coverStatement(res.Payload.Input())
+		}
+		return res
+	}
 }
 
 func parseExecutor(pCtx Context) Func[*mapping.Executor] {
 	return func(input []rune) Result[*mapping.Executor] {
 		maps := map[string]query.Function{}
+		functions := map[string]*query.UserFunction{}
 		statements := []mapping.Statement{}
 
-		statementPattern := mappingStatement(pCtx, true, maps)
+		statementPattern := mappingStatement(pCtx.withUserFunctions(functions), true, maps)
 
 		res := statementPattern(DiscardedWhitespaceNewlineComments(input).Remaining)
 		if res.Err != nil {
@@ -100,7 +123,9 @@ func parseExecutor(pCtx Context) Func[*mapping.Executor] {
 				statements = append(statements, res.Payload)
 			}
 		}
-		return Success(mapping.NewExecutor("", input, maps, statements...), res.Remaining)
+		exec := mapping.NewExecutor("", input, maps, statements...)
+		exec.SetFunctions(functions)
+		return Success(exec, res.Remaining)
 	}
 }
 
@@ -120,22 +145,14 @@ func singleRootImport(pCtx Context) Func[*mapping.Executor] {
 		}
 
 		fpath := res.Payload
-		contents, err := pCtx.importer.Import(fpath)
-		if err != nil {
-			return Fail[*mapping.Executor](NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
-		}
-
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
-
-		importContent := []rune(string(contents))
-		execRes := parseExecutor(nextCtx)(importContent)
-		if execRes.Err != nil {
-			return Fail[*mapping.Executor](NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
+		exec, pErr := pCtx.parseImport(input, fpath)
+		if pErr != nil {
+			return Fail[*mapping.Executor](pErr, input)
 		}
 		if len(res.Remaining) > 0 {
 			return Fail[*mapping.Executor](NewFatalError(input, fmt.Errorf("unexpected content after single root import: %s", string(res.Remaining))), input)
 		}
-		return Success(execRes.Payload, res.Remaining)
+		return Success(exec, res.Remaining)
 	}
 }
 
@@ -165,7 +182,10 @@ func singleRootMapping(pCtx Context) Func[*mapping.Executor] {
 			return Fail[*mapping.Executor](NewError(testRes.Remaining, expStr), input)
 		}
 
-		stmt := mapping.NewSingleStatement(input, mapping.NewJSONAssignment(), fn)
+		stmtInput := DiscardedWhitespaceNewlineComments(input).Remaining
+		pCtx.coverStatement(stmtInput)
+
+		stmt := mapping.NewSingleStatement(stmtInput, mapping.NewJSONAssignment(), fn)
 		return Success(mapping.NewExecutor("", input, map[string]query.Function{}, stmt), nil)
 	}
 }
@@ -183,22 +203,33 @@ var varNameParser = JoinStringPayloads(
 	),
 )
 
-var importParserComb = TakeOnly(2, Sequence(
-	Term("import"),
-	SpacesAndTabs,
-	MustBe(
+var importParserComb = Sequence(
+	FuncAsAny(Term("import")),
+	FuncAsAny(SpacesAndTabs),
+	FuncAsAny(MustBe(
 		Expect(
 			QuotedString,
 			"filepath",
 		),
-	),
-))
+	)),
+	FuncAsAny(OptionalPtr(TakeOnly(3, Sequence(
+		SpacesAndTabs,
+		Term("as"),
+		SpacesAndTabs,
+		MustBe(
+			Expect(
+				varNameParser,
+				"namespace",
+			),
+		),
+	)))),
+)
 
 func importParser(pCtx Context, maps map[string]query.Function) Func[string] {
 	return func(input []rune) Result[string] {
 		res := importParserComb(input)
 		if res.Err != nil {
-			return res
+			return Fail[string](res.Err, input)
 		}
 
 		if maps == nil {
@@ -208,39 +239,61 @@ func importParser(pCtx Context, maps map[string]query.Function) Func[string] {
 			)
 		}
 
-		fpath := res.Payload
-		contents, err := pCtx.importer.Import(fpath)
-		if err != nil {
-			return Fail[string](NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
+		fpath := res.Payload[2].(string)
+		exec, pErr := pCtx.parseImport(input, fpath)
+		if pErr != nil {
+			return Fail[string](pErr, input)
 		}
 
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
-
-		importContent := []rune(string(contents))
-		execRes := parseExecutor(nextCtx)(importContent)
-		if execRes.Err != nil {
-			return Fail[string](NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
+		if len(exec.Maps()) == 0 && len(exec.Functions()) == 0 {
+			err := fmt.Errorf("no maps or functions to import from '%v'", fpath)
+			return Fail[string](NewFatalError(input, err), input)
 		}
 
-		exec := execRes.Payload
-		if len(exec.Maps()) == 0 {
-			err := fmt.Errorf("no maps to import from '%v'", fpath)
-			return Fail[string](NewFatalError(input, err), input)
+		// When imported with a namespace the maps and functions of the file
+		// are prefixed with it, and maps applied within them are resolved
+		// from the imported file.
+		var prefix string
+		namespace, _ := res.Payload[3].(*string)
+		if namespace != nil {
+			prefix = *namespace + "."
 		}
 
 		collisions := []string{}
 		for k, v := range exec.Maps() {
-			if _, exists := maps[k]; exists {
-				collisions = append(collisions, k)
-			} else {
-				maps[k] = v
+			if _, exists := maps[prefix+k]; exists {
+				collisions = append(collisions, prefix+k)
+				continue
+			}
+			if namespace != nil {
+				v = withMapsScope(v, exec.Maps())
 			}
+			maps[prefix+k] = v
 		}
 		if len(collisions) > 0 {
+			sort.Strings(collisions)
 			err := fmt.Errorf("map name collisions from import '%v': %v", fpath, collisions)
 			return Fail[string](NewFatalError(input, err), input)
 		}
 
+		for k, v := range exec.Functions() {
+			if _, exists := pCtx.userFunctions[prefix+k]; exists {
+				collisions = append(collisions, prefix+k)
+				continue
+			}
+			if namespace != nil {
+				nsFn := query.NewUserFunction(prefix+k, v.Params())
+				nsFn.SetBody(withMapsScope(v.Body(), exec.Maps()))
+				v = nsFn
+			}
+			pCtx.userFunctions[prefix+k] = v
+		}
+		if len(collisions) > 0 {
+			sort.Strings(collisions)
+			err := fmt.Errorf("function name collisions from import '%v': %v", fpath, collisions)
+			return Fail[string](NewFatalError(input, err), input)
+		}
+
 		return Success(fpath, res.Remaining)
 	}
 }
@@ -305,6 +358,122 @@ func mapParser(pCtx Context, maps map[string]query.Function) Func[string] {
 	}
 }
 
+type funcParam struct {
+	name       string
+	defaultVal query.Function
+}
+
+func funcParamParser(pCtx Context) Func[funcParam] {
+	p := Sequence(
+		FuncAsAny(Expect(varNameParser, "parameter name")),
+		FuncAsAny(Optional(TakeOnly(3, Sequence(
+			FuncAsAny(Discard(SpacesAndTabs)),
+			FuncAsAny(charEquals),
+			FuncAsAny(Discard(SpacesAndTabs)),
+			FuncAsAny(MustBe(Expect(queryParser(pCtx), "default value"))),
+		)))),
+	)
+
+	return func(input []rune) Result[funcParam] {
+		res := p(input)
+		if res.Err != nil {
+			return Fail[funcParam](res.Err, input)
+		}
+		param := funcParam{name: res.Payload[0].(string)}
+		if res.Payload[1] != nil {
+			param.defaultVal = res.Payload[1].(query.Function)
+		}
+		return Success(param, res.Remaining)
+	}
+}
+
+func funcParser(pCtx Context, maps map[string]query.Function) Func[string] {
+	header := Sequence(
+		FuncAsAny(Term("func")),
+		FuncAsAny(SpacesAndTabs),
+		FuncAsAny(Expect(varNameParser, "function name")),
+		FuncAsAny(MustBe(DelimitedPattern(
+			Expect(Sequence(charBracketOpen, DiscardedWhitespaceNewlineComments), "function parameters"),
+			MustBe(Expect(funcParamParser(pCtx), "function parameter")),
+			MustBe(Expect(Sequence(Discard(SpacesAndTabs), charComma, DiscardedWhitespaceNewlineComments), "comma")),
+			MustBe(Expect(Sequence(DiscardedWhitespaceNewlineComments, charBracketClose), "closing bracket")),
+		))),
+		FuncAsAny(SpacesAndTabs),
+	)
+
+	body := MustBe(DelimitedPattern(
+		Sequence(
+			charSquigOpen,
+			DiscardedWhitespaceNewlineComments,
+		),
+		// Prevent imports, maps, functions and metadata assignments.
+		mappingStatement(pCtx, false, nil),
+		Sequence(
+			Discard(SpacesAndTabs),
+			NewlineAllowComment,
+			DiscardedWhitespaceNewlineComments,
+		),
+		Sequence(
+			DiscardedWhitespaceNewlineComments,
+			charSquigClose,
+		),
+	))
+
+	return func(input []rune) Result[string] {
+		res := header(input)
+		if res.Err != nil {
+			return Fail[string](res.Err, input)
+		}
+
+		if pCtx.userFunctions == nil {
+			return Fail[string](
+				NewFatalError(input, errors.New("defining functions is not allowed within this block")),
+				input,
+			)
+		}
+
+		ident := res.Payload[2].(string)
+		if _, exists := pCtx.userFunctions[ident]; exists {
+			return Fail[string](NewFatalError(input, fmt.Errorf("function name collision: %v", ident)), input)
+		}
+		if _, err := pCtx.Functions.Params(ident); err == nil {
+			return Fail[string](NewFatalError(input, fmt.Errorf("function name collides with a built-in function: %v", ident)), input)
+		}
+
+		params := query.NewParams()
+		for _, p := range res.Payload[3].([]funcParam) {
+			for _, d := range params.Definitions {
+				if d.Name == p.name {
+					return Fail[string](NewFatalError(input, fmt.Errorf("duplicate parameter name: %v", p.name)), input)
+				}
+			}
+			def := query.ParamAny(p.name, "")
+			if p.defaultVal != nil {
+				lit, isLit := p.defaultVal.(*query.Literal)
+				if !isLit {
+					return Fail[string](NewFatalError(input, fmt.Errorf("default value of parameter %v must be a literal", p.name)), input)
+				}
+				def = def.Default(lit.Value)
+			}
+			params = params.Add(def)
+		}
+
+		// The function is added before its body is parsed in order to allow
+		// recursive calls.
+		uFn := query.NewUserFunction(ident, params)
+		pCtx.userFunctions[ident] = uFn
+
+		bodyRes := body(res.Remaining)
+		if bodyRes.Err != nil {
+			delete(pCtx.userFunctions, ident)
+			return Fail[string](bodyRes.Err, input)
+		}
+
+		uFn.SetBody(mapping.NewExecutor("func "+ident, input, maps, bodyRes.Payload...))
+		return Success(ident, bodyRes.Remaining)
+	}
+}
+
 func letStatementParser(pCtx Context) Func[mapping.Statement] {
 	p := Sequence(
 		FuncAsAny(Expect(Term("let"), "assignment")),
@@ -23,6 +23,19 @@ func TestMappingErrors(t *testing.T) {
 	require.NoError(t, os.WriteFile(noMapsFile, []byte(`foo = "this is valid but has no maps"`), 0o777))
 	require.NoError(t, os.WriteFile(goodMapFile, []byte(`map foo { foo = "this is valid" }`), 0o777))
 
+	goodFuncFile := filepath.Join(dir, "good_func.blobl")
+	require.NoError(t, os.WriteFile(goodFuncFile, []byte(`func bar() { root = "this is valid" }`), 0o777))
+
+	cycleAFile := filepath.Join(dir, "cycle_a.blobl")
+	cycleBFile := filepath.Join(dir, "cycle_b.blobl")
+	selfFile := filepath.Join(dir, "self.blobl")
+	require.NoError(t, os.WriteFile(cycleAFile, []byte(`import "./cycle_b.blobl"
+map a { root = this }`), 0o777))
+	require.NoError(t, os.WriteFile(cycleBFile, []byte(`import "./cycle_a.blobl"
+map b { root = this }`), 0o777))
+	require.NoError(t, os.WriteFile(selfFile, []byte(`import "./self.blobl"
+map self { root = this }`), 0o777))
+
 	tests := map[string]struct {
 		mapping     string
 		errContains string
@@ -37,16 +50,16 @@ func TestMappingErrors(t *testing.T) {
 		},
 		"no mappings": {
 			mapping:     ``,
-			errContains: `line 1 char 1: expected import, map, or assignment`,
+			errContains: `line 1 char 1: expected import, map, func, or assignment`,
 		},
 		"no mappings 2": {
 			mapping: `
    `,
-			errContains: `line 2 char 4: expected import, map, or assignment`,
+			errContains: `line 2 char 4: expected import, map, func, or assignment`,
 		},
 		"comment with no mapping": {
 			mapping:     `# foobar`,
-			errContains: `line 1 char 1: expected import, map, or assignment`,
+			errContains: `line 1 char 1: expected import, map, func, or assignment`,
 		},
 		"double mapping": {
 			mapping:     `foo = bar bar = baz`,
@@ -87,13 +100,13 @@ meta foo = "bar"`,
 		"bad char 2": {
 			mapping: `let foo = bar
 !foo = bar`,
-			errContains: `line 2 char 1: expected import, map, or assignment`,
+			errContains: `line 2 char 1: expected import, map, func, or assignment`,
 		},
 		"bad char 3": {
 			mapping: `let foo = bar
 !foo = bar
 this = that`,
-			errContains: `line 2 char 1: expected import, map, or assignment`,
+			errContains: `line 2 char 1: expected import, map, func, or assignment`,
 		},
 		"bad query": {
 			mapping:     `foo = blah.`,
@@ -156,7 +169,7 @@ root.foo = "not allowed"`, goodMapFile),
 			mapping: fmt.Sprintf(`import "%v"
 
 foo = bar.apply("from_import")`, noMapsFile),
-			errContains: fmt.Sprintf(`line 1 char 1: no maps to import from '%v'`, noMapsFile),
+			errContains: fmt.Sprintf(`line 1 char 1: no maps or functions to import from '%v'`, noMapsFile),
 		},
 		"colliding maps file import": {
 			mapping: fmt.Sprintf(`map "foo" { this = that }
@@ -166,6 +179,82 @@ import "%v"
 foo = bar.apply("foo")`, goodMapFile),
 			errContains: fmt.Sprintf(`line 3 char 1: map name collisions from import '%v': [foo]`, goodMapFile),
 		},
+		"double function definition": {
+			mapping: `func foo(a) {
+  root = $a
+}
+func foo(b) {
+  root = $b
+}
+root = foo(5)`,
+			errContains: `line 4 char 1: function name collision: foo`,
+		},
+		"function collides with built-in": {
+			mapping: `func uuid_v4() {
+  root = "nope"
+}`,
+			errContains: `line 1 char 1: function name collides with a built-in function: uuid_v4`,
+		},
+		"function duplicate parameters": {
+			mapping:     `func foo(a, a) { root = $a }`,
+			errContains: `line 1 char 1: duplicate parameter name: a`,
+		},
+		"function dynamic default": {
+			mapping:     `func foo(a = this.bar) { root = $a }`,
+			errContains: `line 1 char 1: default value of parameter a must be a literal`,
+		},
+		"function contains meta assignment": {
+			mapping: `func foo() {
+  meta foo = "bar"
+}`,
+			errContains: `line 2 char 3: setting meta fields is not allowed within this block`,
+		},
+		"function called before definition": {
+			mapping: `root = foo(5)
+func foo(a) {
+  root = $a
+}`,
+			errContains: `line 1 char 14: unrecognised function 'foo'`,
+		},
+		"function called with too many args": {
+			mapping: `func foo(a) {
+  root = $a
+}
+root = foo(5, 6)`,
+			errContains: `line 4 char 17: wrong number of arguments, expected 1, got 2`,
+		},
+		"function called with missing args": {
+			mapping: `func foo(a, b) {
+  root = $a + $b
+}
+root = foo(b: 5)`,
+			errContains: `line 4 char 17: missing parameter: a`,
+		},
+		"colliding functions file import": {
+			mapping: fmt.Sprintf(`func bar() { root = "bar" }
+
+import "%v"
+
+root = bar()`, goodFuncFile),
+			errContains: fmt.Sprintf(`line 3 char 1: function name collisions from import '%v': [bar]`, goodFuncFile),
+		},
+		"circular imports": {
+			mapping:     fmt.Sprintf(`import "%v"`, cycleAFile),
+			errContains: fmt.Sprintf(`circular import: %v -> %v -> %v`, cycleAFile, cycleBFile, cycleAFile),
+		},
+		"circular self import": {
+			mapping:     fmt.Sprintf(`from "%v"`, selfFile),
+			errContains: fmt.Sprintf(`circular import: %v -> %v`, selfFile, selfFile),
+		},
+		"colliding namespaced file import": {
+			mapping: fmt.Sprintf(`import "%v" as lib
+import "%v" as lib`, goodMapFile, goodMapFile),
+			errContains: fmt.Sprintf(`line 2 char 1: map name collisions from import '%v': [lib.foo]`, goodMapFile),
+		},
+		"import with no namespace": {
+			mapping:     fmt.Sprintf(`import "%v" as `, goodMapFile),
+			errContains: `required: expected namespace`,
+		},
 		"quotes at root": {
 			mapping: `
 "root.something" = 5 + 2`,
@@ -196,6 +285,25 @@ func TestMappings(t *testing.T) {
 	directMapFile := filepath.Join(dir, "direct_map.blobl")
 	require.NoError(t, os.WriteFile(directMapFile, []byte(`root.nested = this`), 0o777))
 
+	goodFuncFile := filepath.Join(dir, "add_func.blobl")
+	require.NoError(t, os.WriteFile(goodFuncFile, []byte(`func add(a, b = 0) {
+  root = $a + $b
+}`), 0o777))
+
+	libFile := filepath.Join(dir, "lib.blobl")
+	require.NoError(t, os.WriteFile(libFile, []byte(`map normalize {
+  root.name = this.name.apply("clean")
+}
+map clean {
+  root = this.trim().lowercase()
+}
+func exclaim() {
+  root = "!"
+}
+func shout(v) {
+  root = $v.apply("clean").uppercase() + exclaim()
+}`), 0o777))
+
 	type part struct {
 		Content string
 		Meta    map[string]any
@@ -530,6 +638,109 @@ root = this.apply("foo")`, goodMapFile),
 				Content: `{"foo":"this is valid","nested":{"outer":{"inner":"hello world"}}}`,
 			},
 		},
+		"test function positional and named args": {
+			mapping: `func greet(name, greeting = "hello") {
+  root = $greeting + " " + $name
+}
+root.a = greet(this.name)
+root.b = greet(this.name, "hey")
+root.c = greet(greeting: "hi", name: this.name.uppercase())`,
+			input: []part{
+				{Content: `{"name":"bob"}`},
+			},
+			output: part{
+				Content: `{"a":"hello bob","b":"hey bob","c":"hi BOB"}`,
+			},
+		},
+		"test function recursion": {
+			mapping: `func fact(n) {
+  root = if $n <= 1 { 1 } else { $n * fact($n - 1) }
+}
+root = fact(this.n)`,
+			input: []part{
+				{Content: `{"n":5}`},
+			},
+			output: part{
+				Content: `120`,
+			},
+		},
+		"test function variables are isolated": {
+			mapping: `func foo(a) {
+  let b = $a + 1
+  root.a = $a
+  root.b = $b
+  root.this = this.value
+}
+let a = "outer"
+root.result = foo(10)
+root.a = $a`,
+			input: []part{
+				{Content: `{"value":"context"}`},
+			},
+			output: part{
+				Content: `{"a":"outer","result":{"a":10,"b":11,"this":"context"}}`,
+			},
+		},
+		"test function calls from maps": {
+			mapping: `func double(v) {
+  root = $v * 2
+}
+map thing {
+  root.doubled = double(this.value)
+  root.wrapped = this.apply("wrap")
+}
+map wrap {
+  root = [ this.value ]
+}
+root = this.apply("thing")`,
+			input: []part{
+				{Content: `{"value":3}`},
+			},
+			output: part{
+				Content: `{"doubled":6,"wrapped":[3]}`,
+			},
+		},
+		"test imported function": {
+			mapping: fmt.Sprintf(`import "%v"
+
+root = add(this.a, this.b)`, goodFuncFile),
+			input: []part{
+				{Content: `{"a":3,"b":4}`},
+			},
+			output: part{
+				Content: `7`,
+			},
+		},
+		"test namespaced import": {
+			mapping: fmt.Sprintf(`import "%v" as lib
+
+map clean {
+  root = "not this one"
+}
+
+root.normalized = this.apply("lib.normalize")
+root.shouted = lib.shout(this.name)
+root.method = this.lib.uppercase()`, libFile),
+			input: []part{
+				{Content: `{"name":"  Bob ","lib":"field"}`},
+			},
+			output: part{
+				Content: `{"method":"FIELD","normalized":{"name":"bob"},"shouted":"BOB!"}`,
+			},
+		},
+		"test import with multiple namespaces": {
+			mapping: fmt.Sprintf(`import "%v" as a
+import "%v" as b
+
+root.a = a.add(this.n, 1)
+root.b = b.add(a: this.n, b: 2)`, goodFuncFile, goodFuncFile),
+			input: []part{
+				{Content: `{"n":1}`},
+			},
+			output: part{
+				Content: `{"a":2,"b":3}`,
+			},
+		},
 		"test directly imported mapping": {
 			mapping: fmt.Sprintf(`from "%v"`, directMapFile),
 			input: []part{
@@ -599,3 +810,15 @@ root.baz = this.baz.slice(0, 10)
 		}
 	}
 }
+
+func TestMappingFunctionRecursionLimit(t *testing.T) {
+	exec, perr := ParseMapping(GlobalContext(), `func forever(n) {
+  root = forever($n + 1)
+}
+root = forever(0)`)
+	require.Nil(t, perr)
+
+	_, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "entering func forever exceeded maximum allowed stacks of 5000")
+}
@@ -1,13 +1,22 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/value"
 )
 
-func matchCaseParser(pCtx Context) Func[query.MatchCase] {
+type parsedMatchCase struct {
+	input    []rune
+	catchAll bool
+	literal  *query.Literal
+	caseFn   query.Function
+	queryFn  query.Function
+}
+
+func matchCaseParser(pCtx Context) Func[parsedMatchCase] {
 	ignoreToFn := ZeroedFuncAs[string, query.Function]
 
 	p := Sequence(
@@ -33,17 +42,21 @@ func matchCaseParser(pCtx Context) Func[query.MatchCase] {
 		queryParser(pCtx),
 	)
 
-	return func(input []rune) Result[query.MatchCase] {
+	return func(input []rune) Result[parsedMatchCase] {
 		res := p(input)
 		if res.Err != nil {
-			return Fail[query.MatchCase](res.Err, input)
+			return Fail[parsedMatchCase](res.Err, input)
 		}
 
 		var caseFn query.Function
+		var caseLit *query.Literal
 
+		catchAll := false
 		if p := res.Payload[0]; p == nil {
+			catchAll = true
 			caseFn = query.NewLiteralFunction("", true)
 		} else if lit, isLiteral := p.(*query.Literal); isLiteral {
+			caseLit = lit
 			caseFn = query.ClosureFunction("case statement", func(ctx query.FunctionContext) (any, error) {
 				v := ctx.Value()
 				if v == nil {
@@ -55,10 +68,13 @@ func matchCaseParser(pCtx Context) Func[query.MatchCase] {
 			caseFn = p
 		}
 
-		return Success(
-			query.NewMatchCase(caseFn, res.Payload[2]),
-			res.Remaining,
-		)
+		return Success(parsedMatchCase{
+			input:    input,
+			catchAll: catchAll,
+			literal:  caseLit,
+			caseFn:   caseFn,
+			queryFn:  res.Payload[2],
+		}, res.Remaining)
 	}
 }
 
@@ -98,9 +114,24 @@ func matchExpressionParser(pCtx Context) Func[query.Function] {
 		seqSlice := res.Payload
 		contextFn, _ := seqSlice[2].(query.Function)
 
-		cases := seqSlice[4].([]query.MatchCase)
+		parsedCases := seqSlice[4].([]parsedMatchCase)
+		if pCtx.typeChecker != nil {
+			pCtx.checkMatchCases(contextFn, parsedCases)
+		}
+		cases := make([]query.MatchCase, 0, len(parsedCases))
+		catchAll := false
+		for i, c := range parsedCases {
+			pCtx.coverBranch(input, c.input, i)
+			cases = append(cases, query.NewMatchCase(c.caseFn, c.queryFn))
+			catchAll = catchAll || c.catchAll
+		}
+		if !catchAll {
+			// Executions where none of the cases match are attributed to the
+			// expression itself.
+			pCtx.coverBranch(input, input, len(parsedCases))
+		}
 
-		return Success(query.NewMatchFunction(contextFn, cases...), res.Remaining)
+		return Success(query.NewMatchFunctionAt(input, contextFn, cases...), res.Remaining)
 	}
 }
 
@@ -152,9 +183,11 @@ func ifExpressionParser(pCtx Context) Func[query.Function] {
 		seqSlice := res.Payload
 		queryFn := seqSlice[2]
 		ifFn := seqSlice[6]
+		pCtx.coverBranch(input, input, 0)
 
 		var elseIfs []query.ElseIf
 		for {
+			armInput := DiscardedWhitespaceNewlineComments(res.Remaining).Remaining
 			res = elseIfParser(res.Remaining)
 			if res.Err != nil {
 				return Fail[query.Function](res.Err, input)
@@ -163,6 +196,7 @@ func ifExpressionParser(pCtx Context) Func[query.Function] {
 				break
 			}
 			seqSlice = res.Payload
+			pCtx.coverBranch(input, armInput, len(elseIfs)+1)
 			elseIfs = append(elseIfs, query.ElseIf{
 				QueryFn: seqSlice[3],
 				MapFn:   seqSlice[7],
@@ -171,15 +205,21 @@ func ifExpressionParser(pCtx Context) Func[query.Function] {
 
 		var elseFn query.Function
 
+		armInput := DiscardedWhitespaceNewlineComments(res.Remaining).Remaining
 		res = elseParser(res.Remaining)
 		if res.Err != nil {
 			return Fail[query.Function](res.Err, input)
 		}
 		if res.Payload != nil {
 			elseFn = res.Payload[5]
+		} else {
+			// Executions where none of the branches are taken are attributed
+			// to the expression itself.
+			armInput = input
 		}
+		pCtx.coverBranch(input, armInput, len(elseIfs)+1)
 
-		return Success(query.NewIfFunction(queryFn, ifFn, elseIfs, elseFn), res.Remaining)
+		return Success(query.NewIfFunctionAt(input, queryFn, ifFn, elseIfs, elseFn), res.Remaining)
 	}
 }
 
@@ -263,3 +303,57 @@ func lambdaExpressionParser(pCtx Context) Func[query.Function] {
 		return Success(query.NewNamedContextFunction(name, queryFn), queryRes.Remaining)
 	}
 }
+
+// checkMatchCases records type errors for match cases that can never be
+// reached, either because a previous case matches all values, a previous case
+// matches the same literal value, or because the literal value of the case can
+// never equal the value being matched.
+func (pCtx Context) checkMatchCases(contextFn query.Function, cases []parsedMatchCase) {
+	contextType := value.TUnknown
+	if contextFn != nil {
+		contextType = query.StaticType(contextFn)
+	}
+
+	var seenLiterals []any
+	catchAll := false
+	for _, c := range cases {
+		if catchAll {
+			pCtx.addMatchCaseErrors(c.input, errors.New("unreachable match case, a previous case matches all values"))
+			continue
+		}
+		catchAll = c.catchAll
+		if c.literal == nil {
+			continue
+		}
+
+		duplicate := false
+		for _, v := range seenLiterals {
+			if value.ICompare(v, c.literal.Value) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			pCtx.addMatchCaseErrors(c.input, errors.New("unreachable match case, a previous case matches the same value"))
+			continue
+		}
+		seenLiterals = append(seenLiterals, c.literal.Value)
+
+		if litType := query.StaticType(c.literal); !matchTypesComparable(contextType, litType) {
+			pCtx.addMatchCaseErrors(c.input, fmt.Errorf("unreachable match case, a %v value can never match a %v value", litType, contextType))
+		}
+	}
+}
+
+func matchTypesComparable(a, b value.Type) bool {
+	if a == value.TUnknown || b == value.TUnknown || a == b {
+		return true
+	}
+	isStr := func(t value.Type) bool {
+		return t == value.TString || t == value.TBytes || t == value.TTimestamp
+	}
+	isBoolOrNum := func(t value.Type) bool {
+		return t == value.TBool || t == value.TNumber
+	}
+	return (isStr(a) && isStr(b)) || (isBoolOrNum(a) && isBoolOrNum(b))
+}
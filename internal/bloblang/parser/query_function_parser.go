@@ -127,6 +127,9 @@ func parseWithTails(fnParser Func[query.Function], pCtx Context) Func[query.Func
 			if res := MustBe(parseFunctionTail(fn, pCtx))(remaining); res.Err != nil {
 				return Fail[query.Function](res.Err, input)
 			} else {
+				if isErrorGuard(remaining) {
+					pCtx.addTypeGuard(input, remaining)
+				}
 				fn = res.Payload
 				remaining = res.Remaining
 			}
@@ -134,6 +137,19 @@ func parseWithTails(fnParser Func[query.Function], pCtx Context) Func[query.Func
 	}
 }
 
+var errorGuardPattern = Sequence(SnakeCase, charBracketOpen)
+
+// isErrorGuard returns true if the input begins with a call to a method that
+// recovers from errors of its target, i.e. catch or or.
+func isErrorGuard(input []rune) bool {
+	res := errorGuardPattern(input)
+	if res.Err != nil {
+		return false
+	}
+	name := res.Payload[0]
+	return name == "catch" || name == "or"
+}
+
 func quotedPathSegmentParser(input []rune) Result[string] {
 	res := QuotedString(input)
 	if res.Err != nil {
@@ -337,6 +353,9 @@ func methodParser(fn query.Function, pCtx Context) Func[query.Function] {
 		if err != nil {
 			return Fail[query.Function](NewFatalError(res.Remaining, err), input)
 		}
+		if pCtx.typeChecker != nil {
+			pCtx.addTypeErrors(input, pCtx.Methods.CheckTypes(targetMethod, fn, parsedParams)...)
+		}
 		return Success(method, res.Remaining)
 	}
 }
@@ -353,6 +372,14 @@ func functionParser(pCtx Context) Func[query.Function] {
 		seqSlice := res.Payload
 
 		targetFunc := seqSlice[0].(string)
+		if uFn, exists := pCtx.userFunctions[targetFunc]; exists {
+			parsedParams, err := extractArgsParserResult(uFn.Params(), seqSlice[1].([]any))
+			if err != nil {
+				return Fail[query.Function](NewFatalError(res.Remaining, err), input)
+			}
+			return Success(uFn.Init(parsedParams), res.Remaining)
+		}
+
 		params, err := pCtx.Functions.Params(targetFunc)
 		if err != nil {
 			return Fail[query.Function](NewFatalError(res.Remaining, err), input)
@@ -367,6 +394,43 @@ func functionParser(pCtx Context) Func[query.Function] {
 		if err != nil {
 			return Fail[query.Function](NewFatalError(res.Remaining, err), input)
 		}
+		if pCtx.typeChecker != nil {
+			pCtx.addTypeErrors(input, pCtx.Functions.CheckTypes(targetFunc, parsedParams)...)
+		}
 		return Success(fn, res.Remaining)
 	}
 }
+
+var namespacedFunctionNamePattern = Sequence(SnakeCase, charDot, SnakeCase)
+
+// namespacedFunctionParser parses calls to user defined functions that were
+// imported with a namespace, e.g. `foo.bar(baz)`. In order to avoid ambiguity
+// with method calls on field references the parser only succeeds when the
+// namespaced function exists.
+func namespacedFunctionParser(pCtx Context) Func[query.Function] {
+	argsParser := functionArgsParser(pCtx)
+
+	return func(input []rune) Result[query.Function] {
+		res := namespacedFunctionNamePattern(input)
+		if res.Err != nil {
+			return Fail[query.Function](NewError(input, "function"), input)
+		}
+
+		targetFunc := res.Payload[0] + "." + res.Payload[2]
+		uFn, exists := pCtx.userFunctions[targetFunc]
+		if !exists {
+			return Fail[query.Function](NewError(input, "function"), input)
+		}
+
+		argsRes := argsParser(res.Remaining)
+		if argsRes.Err != nil {
+			return Fail[query.Function](argsRes.Err, input)
+		}
+
+		parsedParams, err := extractArgsParserResult(uFn.Params(), argsRes.Payload)
+		if err != nil {
+			return Fail[query.Function](NewFatalError(argsRes.Remaining, err), input)
+		}
+		return Success(uFn.Init(parsedParams), argsRes.Remaining)
+	}
+}
@@ -84,8 +84,11 @@ func rootLevelIfExpressionParser(pCtx Context) Func[mapping.Statement] {
 		seqSlice := res.Payload
 		stmt := mapping.NewRootLevelIfStatement(input)
 		stmt.Add(seqSlice[2].(query.Function), seqSlice[4].([]mapping.Statement)...)
+		pCtx.coverBranch(input, input, 0)
 
+		arms := 1
 		for {
+			armInput := DiscardedWhitespaceNewlineComments(res.Remaining).Remaining
 			res = elseIfParser(res.Remaining)
 			if res.Err != nil {
 				return Fail[mapping.Statement](res.Err, input)
@@ -94,16 +97,24 @@ func rootLevelIfExpressionParser(pCtx Context) Func[mapping.Statement] {
 				break
 			}
 			seqSlice = res.Payload
+			pCtx.coverBranch(input, armInput, arms)
 			stmt.Add(seqSlice[3].(query.Function), seqSlice[5].([]mapping.Statement)...)
+			arms++
 		}
 
+		armInput := DiscardedWhitespaceNewlineComments(res.Remaining).Remaining
 		res = elseParser(res.Remaining)
 		if res.Err != nil {
 			return Fail[mapping.Statement](res.Err, input)
 		}
 		if seqSlice = res.Payload; seqSlice != nil {
 			stmt.Add(nil, seqSlice[3].([]mapping.Statement)...)
+		} else {
+			// Executions where none of the branches are taken are attributed
+			// to the statement itself.
+			armInput = input
 		}
+		pCtx.coverBranch(input, armInput, arms)
 		return Success[mapping.Statement](stmt, res.Remaining)
 	}
 }
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/value"
+)
+
+func TestMappingTrace(t *testing.T) {
+	mappingStr := `map describe {
+  root.kind = match this {
+    this > 10 => "big"
+    _ => "small"
+  }
+}
+let doubled = this.a * 2
+meta foo = "bar"
+root.a = $doubled.apply("describe")
+root.b = match this.b {
+  "foo" => "was foo"
+  "bar" => "was bar"
+}
+root.c = deleted()`
+
+	exec, err := ParseMapping(GlobalContext(), mappingStr)
+	require.Nil(t, err)
+
+	trace := query.NewTrace()
+	part := message.NewPart([]byte(`{"a":3,"b":"bar","c":true}`))
+	msg := message.Batch{part}
+
+	var result any = value.Nothing(nil)
+	vars := map[string]any{}
+	require.NoError(t, exec.ExecOnto(query.FunctionContext{
+		Maps:     exec.Maps(),
+		Vars:     vars,
+		MsgBatch: msg,
+		NewMeta:  part,
+		NewValue: &result,
+	}.WithValueFunc(func() *any {
+		v, err := part.AsStructured()
+		require.NoError(t, err)
+		return &v
+	}).WithTrace(trace), mapping.AssignmentContext{
+		Vars:  vars,
+		Meta:  part,
+		Value: &result,
+	}))
+
+	assert.Equal(t, []query.TraceEvent{
+		{Type: query.TraceVariable, Line: 7, Column: 1, Target: "let doubled", Value: int64(6)},
+		{Type: query.TraceAssignment, Line: 8, Column: 1, Target: "meta foo", Value: "bar"},
+		{Type: query.TraceMatch, Line: 2, Column: 15, Value: int64(6), Case: 1},
+		{Type: query.TraceAssignment, Line: 2, Column: 3, Target: "root.kind", Value: "small"},
+		{Type: query.TraceAssignment, Line: 9, Column: 1, Target: "root.a", Value: map[string]any{"kind": "small"}},
+		{Type: query.TraceMatch, Line: 10, Column: 10, Value: "bar", Case: 1},
+		{Type: query.TraceAssignment, Line: 10, Column: 1, Target: "root.b", Value: "was bar"},
+		{Type: query.TraceAssignment, Line: 14, Column: 1, Target: "root.c", Value: value.Delete(nil)},
+	}, trace.Events())
+}
+
+func TestMappingTraceRootLevelIf(t *testing.T) {
+	mappingStr := `if this.a > 5 {
+  root.size = "big"
+} else if this.a > 2 {
+  root.size = "medium"
+}
+if this.b == "foo" {
+  root.foo = true
+}`
+
+	exec, err := ParseMapping(GlobalContext(), mappingStr)
+	require.Nil(t, err)
+
+	trace := query.NewTrace()
+	part := message.NewPart([]byte(`{"a":3,"b":"bar"}`))
+
+	var result any = value.Nothing(nil)
+	vars := map[string]any{}
+	require.NoError(t, exec.ExecOnto(query.FunctionContext{
+		Maps:     exec.Maps(),
+		Vars:     vars,
+		MsgBatch: message.Batch{part},
+		NewMeta:  part,
+		NewValue: &result,
+	}.WithValueFunc(func() *any {
+		v, err := part.AsStructured()
+		require.NoError(t, err)
+		return &v
+	}).WithTrace(trace), mapping.AssignmentContext{
+		Vars:  vars,
+		Meta:  part,
+		Value: &result,
+	}))
+
+	assert.Equal(t, []query.TraceEvent{
+		{Type: query.TraceIf, Line: 1, Column: 1, Case: 1},
+		{Type: query.TraceAssignment, Line: 4, Column: 3, Target: "root.size", Value: "medium"},
+		{Type: query.TraceIf, Line: 6, Column: 1, Case: -1},
+	}, trace.Events())
+}
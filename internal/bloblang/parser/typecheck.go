@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+)
+
+// typeChecker collects the type errors found whilst parsing a mapping. Parsers
+// may attempt to parse the same input more than once and therefore errors are
+// deduplicated by their position and message.
+type typeChecker struct {
+	mut    sync.Mutex
+	seen   map[typeErrorKey]struct{}
+	errs   []typeError
+	guards []typeGuard
+}
+
+type typeErrorKey struct {
+	remaining int
+	msg       string
+}
+
+type typeError struct {
+	err *Error
+
+	// Whether the error would occur at runtime and can therefore be caught by
+	// a catch or or method further along the query.
+	guardable bool
+}
+
+// typeGuard is a clip of a query that precedes a catch or or method, where
+// errors that occur at runtime are recovered from.
+type typeGuard struct {
+	from []rune
+	to   []rune
+}
+
+func (g typeGuard) covers(clip []rune) bool {
+	return len(clip) > len(g.to) && isClipOf(g.from, clip)
+}
+
+func (t *typeChecker) add(input []rune, err error, guardable bool) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	key := typeErrorKey{remaining: len(input), msg: err.Error()}
+	if _, exists := t.seen[key]; exists {
+		return
+	}
+	t.seen[key] = struct{}{}
+	t.errs = append(t.errs, typeError{err: NewFatalError(input, err), guardable: guardable})
+}
+
+func (t *typeChecker) guard(from, to []rune) {
+	t.mut.Lock()
+	t.guards = append(t.guards, typeGuard{from: from, to: to})
+	t.mut.Unlock()
+}
+
+func (t *typeChecker) guarded(clip []rune) bool {
+	for _, g := range t.guards {
+		if g.covers(clip) {
+			return true
+		}
+	}
+	return false
+}
+
+// withTypeChecker returns a version of the parser context where type errors
+// found whilst parsing are recorded by a type checker.
+func (pCtx Context) withTypeChecker(t *typeChecker) Context {
+	pCtx.typeChecker = t
+	return pCtx
+}
+
+// addTypeErrors records type errors found at a clip of the input, if type
+// checking is enabled. The errors are ignored if the clip is later found to be
+// guarded by a catch or or method, as the error would be recovered from.
+func (pCtx Context) addTypeErrors(input []rune, errs ...error) {
+	if pCtx.typeChecker == nil {
+		return
+	}
+	for _, err := range errs {
+		pCtx.typeChecker.add(input, err, true)
+	}
+}
+
+// addMatchCaseErrors records errors for match cases found at a clip of the
+// input, if type checking is enabled. Unlike type errors these do not occur at
+// runtime and are therefore reported even when guarded.
+func (pCtx Context) addMatchCaseErrors(input []rune, errs ...error) {
+	if pCtx.typeChecker == nil {
+		return
+	}
+	for _, err := range errs {
+		pCtx.typeChecker.add(input, err, false)
+	}
+}
+
+// addTypeGuard records that errors within a query, from the start of the query
+// up until the start of a method that recovers from errors, are guarded, if
+// type checking is enabled.
+func (pCtx Context) addTypeGuard(query, method []rune) {
+	if pCtx.typeChecker == nil {
+		return
+	}
+	pCtx.typeChecker.guard(query, method)
+}
+
+// CheckMapping parses a bloblang mapping and performs a static type check over
+// it, returning an executor to run it along with a list of errors describing
+// definite type mismatches, such as methods being called on values they do not
+// support, and unreachable match cases. Type mismatches within a query that is
+// followed by a catch or or method are not reported, as the resulting error is
+// recovered from at runtime. A parse error is returned if the
+// mapping cannot be parsed at all.
+//
+// Type errors found within imported files are not included, and the input of
+// each error refers to a clip of the provided mapping.
+func CheckMapping(pCtx Context, expr string) (*mapping.Executor, []*Error, *Error) {
+	checker := &typeChecker{seen: map[typeErrorKey]struct{}{}}
+	in := []rune(expr)
+
+	exec, err := parseMapping(pCtx.withTypeChecker(checker), in)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	checker.mut.Lock()
+	defer checker.mut.Unlock()
+
+	var typeErrs []*Error
+	for _, e := range checker.errs {
+		if !isClipOf(in, e.err.Input) {
+			continue
+		}
+		if e.guardable && checker.guarded(e.err.Input) {
+			continue
+		}
+		typeErrs = append(typeErrs, e.err)
+	}
+	sort.SliceStable(typeErrs, func(i, j int) bool {
+		return len(typeErrs[i].Input) > len(typeErrs[j].Input)
+	})
+	return exec, typeErrs, nil
+}
+
+// isClipOf returns true if the clip is a tailing part of the input that shares
+// the same underlying memory.
+func isClipOf(input, clip []rune) bool {
+	if len(clip) == 0 {
+		return len(input) == 0
+	}
+	if len(clip) > len(input) {
+		return false
+	}
+	return &input[len(input)-len(clip)] == &clip[0]
+}
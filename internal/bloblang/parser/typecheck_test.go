@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMappingTypeCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping string
+		errs    []string
+	}{
+		{
+			name:    "no errors",
+			mapping: `root.foo = this.foo.uppercase().trim()`,
+		},
+		{
+			name:    "method on literal of the wrong type",
+			mapping: `root.foo = (5).uppercase()`,
+			errs: []string{
+				"1:16: method uppercase expects a string or bytes value, got number",
+			},
+		},
+		{
+			name: "method on method result of the wrong type",
+			mapping: `root.foo = this.foo
+root.bar = this.bar.length().uppercase()
+root.baz = this.baz.string().round()`,
+			errs: []string{
+				"2:30: method uppercase expects a string or bytes value, got number",
+				"3:30: method round expects a number value, got string",
+			},
+		},
+		{
+			name:    "dynamic argument of the wrong type",
+			mapping: `root.foo = this.foo.has_prefix(this.bar.keys())`,
+			errs: []string{
+				"1:21: method has_prefix: parameter value expects a string value, got array",
+			},
+		},
+		{
+			name:    "function argument of the wrong type",
+			mapping: `root.foo = range(0, this.foo.string())`,
+			errs: []string{
+				"1:12: function range: parameter stop expects a integer value, got string",
+			},
+		},
+		{
+			name: "unreachable match cases",
+			mapping: `root.foo = match this.foo {
+  "a" => 1
+  "a" => 2
+  _ => 3
+  "b" => 4
+}
+root.bar = match this.bar.length() {
+  5 => "five"
+  "five" => "five"
+}`,
+			errs: []string{
+				"3:3: unreachable match case, a previous case matches the same value",
+				"5:3: unreachable match case, a previous case matches all values",
+				"9:3: unreachable match case, a string value can never match a number value",
+			},
+		},
+		{
+			name: "errors guarded by catch or or",
+			mapping: `root.foo = (5).uppercase().catch("nope")
+root.bar = this.bar.length().uppercase().or("nope")
+root.baz = (this.baz.keys().uppercase() + "suffix").catch("")`,
+		},
+		{
+			name:    "errors within the fallback of a catch",
+			mapping: `root.foo = this.foo.catch((5).uppercase())`,
+			errs: []string{
+				"1:31: method uppercase expects a string or bytes value, got number",
+			},
+		},
+		{
+			name:    "errors after a catch",
+			mapping: `root.foo = this.foo.catch("").length().uppercase()`,
+			errs: []string{
+				"1:40: method uppercase expects a string or bytes value, got number",
+			},
+		},
+		{
+			name:    "field named catch is not a guard",
+			mapping: `root.foo = this.foo.length().uppercase().catch`,
+			errs: []string{
+				"1:30: method uppercase expects a string or bytes value, got number",
+			},
+		},
+		{
+			name: "unreachable match cases are reported when guarded",
+			mapping: `root.foo = (match this.foo {
+  _ => 1
+  "a" => 2
+}).catch(0)`,
+			errs: []string{
+				"3:3: unreachable match case, a previous case matches all values",
+			},
+		},
+		{
+			name:    "errors within maps",
+			mapping: "map foo {\n  root = this.keys().uppercase()\n}\nroot = this.apply(\"foo\")",
+			errs: []string{
+				"2:22: method uppercase expects a string or bytes value, got array",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, typeErrs, err := CheckMapping(GlobalContext(), test.mapping)
+			require.Nil(t, err)
+
+			var errStrs []string
+			for _, e := range typeErrs {
+				line, col := LineAndColOf([]rune(test.mapping), e.Input)
+				errStrs = append(errStrs, fmt.Sprintf("%v:%v: %v", line, col, e.Err))
+			}
+			assert.Equal(t, test.errs, errStrs)
+		})
+	}
+}
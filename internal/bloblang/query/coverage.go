@@ -0,0 +1,228 @@
+package query
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Coverage records the number of times that the statements and branches of
+// parsed mappings are executed, which is used in order to determine which parts
+// of a mapping are exercised by tests.
+type Coverage struct {
+	mut     sync.Mutex
+	sources []*CoverageSource
+}
+
+// NewCoverage creates an empty coverage recorder.
+func NewCoverage() *Coverage {
+	return &Coverage{}
+}
+
+// Source returns a coverage source for a parsed input. The path is the file
+// that the input was read from and is empty when the origin of the input is
+// unknown. Inputs that are parsed more than once share the same source and
+// therefore the same counters.
+func (c *Coverage) Source(path string, input []rune) *CoverageSource {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	inputStr := string(input)
+	for _, s := range c.sources {
+		if s.Path == path && string(s.Input) == inputStr {
+			s.addInput(input)
+			return s
+		}
+	}
+
+	s := &CoverageSource{
+		Path:     path,
+		Input:    input,
+		inputs:   [][]rune{input},
+		counters: map[coverageKey]*CoverageCounter{},
+	}
+	c.sources = append(c.sources, s)
+	return s
+}
+
+// Sources returns all coverage sources that have been registered.
+func (c *Coverage) Sources() []*CoverageSource {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	sources := make([]*CoverageSource, len(c.sources))
+	copy(sources, c.sources)
+	return sources
+}
+
+// hit records an execution at a clip of a parsed input, the source of which is
+// found by the memory that the clip shares with it.
+func (c *Coverage) hit(clip []rune, arm int) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	for _, s := range c.sources {
+		if offset, ok := s.offsetOf(clip); ok {
+			s.counter(coverageKey{offset: offset, arm: arm}, offset).hits.Add(1)
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// coverageKey identifies a counter by the offset of a statement or branching
+// expression within the input, and the index of an arm of a branching
+// expression, which is -1 for statements.
+type coverageKey struct {
+	offset int
+	arm    int
+}
+
+// CoverageSource contains the coverage counters of a single parsed input.
+type CoverageSource struct {
+	Path  string
+	Input []rune
+
+	mut      sync.Mutex
+	inputs   [][]rune
+	counters map[coverageKey]*CoverageCounter
+}
+
+func (s *CoverageSource) addInput(input []rune) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	for _, in := range s.inputs {
+		if isSubClip(in, input) {
+			return
+		}
+	}
+	s.inputs = append(s.inputs, input)
+}
+
+func (s *CoverageSource) offsetOf(clip []rune) (int, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	for _, in := range s.inputs {
+		if isSubClip(in, clip) {
+			return len(in) - len(clip), true
+		}
+	}
+	return 0, false
+}
+
+func (s *CoverageSource) counter(key coverageKey, offset int) *CoverageCounter {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if c, exists := s.counters[key]; exists {
+		return c
+	}
+
+	c := &CoverageCounter{Arm: key.arm}
+	c.Line, c.Column = lineAndColOf(s.Input, offset)
+	if key.arm >= 0 {
+		c.BlockLine, c.BlockColumn = lineAndColOf(s.Input, key.offset)
+	}
+	s.counters[key] = c
+	return c
+}
+
+// AddStatement registers a statement that begins at a clip of the input so
+// that it is reported even when it is never executed.
+func (s *CoverageSource) AddStatement(clip []rune) {
+	offset := len(s.Input) - len(clip)
+	s.counter(coverageKey{offset: offset, arm: -1}, offset)
+}
+
+// AddBranch registers an arm of a branching expression so that it is reported
+// even when it is never taken, where block is a clip of the input beginning at
+// the expression, clip begins at the arm, and arm is the index of the arm
+// within the expression.
+func (s *CoverageSource) AddBranch(block, clip []rune, arm int) {
+	s.counter(coverageKey{
+		offset: len(s.Input) - len(block),
+		arm:    arm,
+	}, len(s.Input)-len(clip))
+}
+
+// Counters returns all counters of the source sorted by their position.
+func (s *CoverageSource) Counters() []*CoverageCounter {
+	s.mut.Lock()
+	counters := make([]*CoverageCounter, 0, len(s.counters))
+	for _, c := range s.counters {
+		counters = append(counters, c)
+	}
+	s.mut.Unlock()
+
+	sort.Slice(counters, func(i, j int) bool {
+		if counters[i].Line != counters[j].Line {
+			return counters[i].Line < counters[j].Line
+		}
+		if counters[i].Column != counters[j].Column {
+			return counters[i].Column < counters[j].Column
+		}
+		return counters[i].Arm < counters[j].Arm
+	})
+	return counters
+}
+
+func lineAndColOf(input []rune, offset int) (line, col int) {
+	lines := strings.Split(string(input[:offset]), "\n")
+	return len(lines), len([]rune(lines[len(lines)-1])) + 1
+}
+
+//------------------------------------------------------------------------------
+
+// CoverageCounter counts the executions of either a statement or an arm of a
+// branching expression.
+type CoverageCounter struct {
+	// The position of the statement or branch arm within the input.
+	Line, Column int
+
+	// The position of the branching expression that an arm belongs to, and
+	// the index of the arm within it. Arm is -1 for statements.
+	BlockLine, BlockColumn int
+	Arm                    int
+
+	hits atomic.Int64
+}
+
+// IsBranch returns true if the counter belongs to an arm of a branching
+// expression rather than a statement.
+func (c *CoverageCounter) IsBranch() bool {
+	return c.Arm >= 0
+}
+
+// Hits returns the number of executions recorded.
+func (c *CoverageCounter) Hits() int64 {
+	return c.hits.Load()
+}
+
+//------------------------------------------------------------------------------
+
+// WithCoverage returns a function context where the executions of statements
+// and branches of mappings executed with the context are recorded.
+func (ctx FunctionContext) WithCoverage(c *Coverage) FunctionContext {
+	ctx.coverage = c
+	return ctx
+}
+
+// CoverStatement records an execution of a statement that begins at a clip of
+// a parsed mapping, if coverage is being recorded.
+func (ctx FunctionContext) CoverStatement(clip []rune) {
+	if ctx.coverage != nil {
+		ctx.coverage.hit(clip, -1)
+	}
+}
+
+// CoverBranch records that an arm of a branching expression beginning at a
+// clip of a parsed mapping was taken, if coverage is being recorded.
+func (ctx FunctionContext) CoverBranch(clip []rune, arm int) {
+	if ctx.coverage != nil {
+		ctx.coverage.hit(clip, arm)
+	}
+}
@@ -1,5 +1,9 @@
 package query
 
+import (
+	"github.com/benthosdev/benthos/v4/internal/value"
+)
+
 // ExampleSpec provides a mapping example and some input/output results to
 // display.
 type ExampleSpec struct {
@@ -92,6 +96,10 @@ type FunctionSpec struct {
 
 	// Version is the Benthos version this component was introduced.
 	Version string `json:"version,omitempty"`
+
+	// ReturnType is the type of value that the function always returns, this
+	// is empty when the type varies or is unknown.
+	ReturnType value.Type `json:"return_type,omitempty"`
 }
 
 // NewFunctionSpec creates a new function spec.
@@ -131,6 +139,13 @@ func (s FunctionSpec) MarkImpure() FunctionSpec {
 	return s
 }
 
+// Returns sets the type of value that the function always returns, which is
+// used in order to detect type mismatches before a mapping is executed.
+func (s FunctionSpec) Returns(t value.Type) FunctionSpec {
+	s.ReturnType = t
+	return s
+}
+
 // Param adds a parameter to the function.
 func (s FunctionSpec) Param(def ParamDefinition) FunctionSpec {
 	s.Params = s.Params.Add(def)
@@ -210,6 +225,14 @@ type MethodSpec struct {
 
 	// Version is the Benthos version this component was introduced.
 	Version string `json:"version,omitempty"`
+
+	// InputTypes lists the types of value that the method can be executed
+	// upon, this is empty when the method accepts any type.
+	InputTypes []value.Type `json:"input_types,omitempty"`
+
+	// ReturnType is the type of value that the method always returns, this is
+	// empty when the type varies or is unknown.
+	ReturnType value.Type `json:"return_type,omitempty"`
 }
 
 // NewMethodSpec creates a new method spec.
@@ -269,6 +292,20 @@ func (m MethodSpec) MarkImpure() MethodSpec {
 	return m
 }
 
+// Accepts sets the types of value that the method can be executed upon, which
+// is used in order to detect type mismatches before a mapping is executed.
+func (m MethodSpec) Accepts(types ...value.Type) MethodSpec {
+	m.InputTypes = types
+	return m
+}
+
+// Returns sets the type of value that the method always returns, which is
+// used in order to detect type mismatches before a mapping is executed.
+func (m MethodSpec) Returns(t value.Type) MethodSpec {
+	m.ReturnType = t
+	return m
+}
+
 // Param adds a parameter to the function.
 func (m MethodSpec) Param(def ParamDefinition) MethodSpec {
 	m.Params = m.Params.Add(def)
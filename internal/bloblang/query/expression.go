@@ -25,6 +25,14 @@ func NewMatchCase(caseFn, queryFn Function) MatchCase {
 // NewMatchFunction takes a contextual mapping and a list of MatchCases, when
 // the function is executed.
 func NewMatchFunction(contextFn Function, cases ...MatchCase) Function {
+	return NewMatchFunctionAt(nil, contextFn, cases...)
+}
+
+// NewMatchFunctionAt takes a contextual mapping and a list of MatchCases, when
+// the function is executed. The input is a clip of the parsed mapping beginning
+// at the match expression, and is used in order to report the position of the
+// expression within traces and coverage.
+func NewMatchFunctionAt(input []rune, contextFn Function, cases ...MatchCase) Function {
 	if contextFn == nil {
 		contextFn = ClosureFunction("this", func(ctx FunctionContext) (any, error) {
 			var value any
@@ -46,9 +54,25 @@ func NewMatchFunction(contextFn Function, cases ...MatchCase) Function {
 				return nil, fmt.Errorf("failed to check match case %v: %w", i, err)
 			}
 			if matched, _ := caseVal.(bool); matched {
+				ctx.CoverBranch(input, i)
+				if ctx.Tracing() {
+					ctx.AddTraceEvent(input, TraceEvent{
+						Type:  TraceMatch,
+						Value: value.IClone(ctxVal),
+						Case:  i,
+					})
+				}
 				return c.queryFn.Exec(caseCtx)
 			}
 		}
+		ctx.CoverBranch(input, len(cases))
+		if ctx.Tracing() {
+			ctx.AddTraceEvent(input, TraceEvent{
+				Type:  TraceMatch,
+				Value: value.IClone(ctxVal),
+				Case:  -1,
+			})
+		}
 		return value.Nothing(nil), nil
 	}, func(ctx TargetsContext) (TargetsContext, []TargetPath) {
 		contextCtx, contextTargets := contextFn.QueryTargets(ctx)
@@ -79,6 +103,13 @@ type ElseIf struct {
 // return a boolean value. If the returned boolean is true then the ifFn is
 // executed and returned, otherwise elseFn is executed and returned.
 func NewIfFunction(queryFn, ifFn Function, elseIfs []ElseIf, elseFn Function) Function {
+	return NewIfFunctionAt(nil, queryFn, ifFn, elseIfs, elseFn)
+}
+
+// NewIfFunctionAt creates a logical if expression where the input is a clip of
+// the parsed mapping beginning at the expression, and is used in order to
+// report the position of the expression within coverage.
+func NewIfFunctionAt(input []rune, queryFn, ifFn Function, elseIfs []ElseIf, elseFn Function) Function {
 	allFns := []Function{
 		queryFn, ifFn, elseFn,
 	}
@@ -106,6 +137,7 @@ func NewIfFunction(queryFn, ifFn Function, elseIfs []ElseIf, elseFn Function) Fu
 			}
 		}
 		if queryRes {
+			ctx.CoverBranch(input, 0)
 			return ifFn.Exec(ctx)
 		}
 
@@ -124,10 +156,12 @@ func NewIfFunction(queryFn, ifFn Function, elseIfs []ElseIf, elseFn Function) Fu
 				}
 			}
 			if queryRes {
+				ctx.CoverBranch(input, i+1)
 				return eFn.MapFn.Exec(ctx)
 			}
 		}
 
+		ctx.CoverBranch(input, len(elseIfs)+1)
 		if elseFn != nil {
 			return elseFn.Exec(ctx)
 		}
@@ -1,5 +1,9 @@
 package query
 
+import (
+	"github.com/benthosdev/benthos/v4/internal/value"
+)
+
 // Function takes a set of contextual arguments and returns the result of the
 // query.
 type Function interface {
@@ -50,6 +54,7 @@ type closureFunction struct {
 	annotation   string
 	exec         func(ctx FunctionContext) (any, error)
 	queryTargets func(ctx TargetsContext) (TargetsContext, []TargetPath)
+	returnType   value.Type
 }
 
 func (f closureFunction) Annotation() string {
@@ -74,9 +74,13 @@ func (f *FunctionSet) Init(name string, args *ParsedParams) (Function, error) {
 		return nil, badFunctionErr(name)
 	}
 	if f.disableCtors {
-		return disabledFunction(name), nil
+		return withReturnType(disabledFunction(name), details.spec.ReturnType), nil
 	}
-	return wrapCtorWithDynamicArgs(name, args, details.ctor)
+	fn, err := wrapCtorWithDynamicArgs(name, args, details.ctor)
+	if err != nil {
+		return nil, err
+	}
+	return withReturnType(fn, details.spec.ReturnType), nil
 }
 
 // Without creates a clone of the function set that can be mutated in isolation,
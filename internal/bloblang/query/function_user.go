@@ -0,0 +1,71 @@
+package query
+
+import (
+	"fmt"
+)
+
+// UserFunction is a function defined within a mapping, which executes a body
+// where each parameter is accessible as a variable.
+type UserFunction struct {
+	name   string
+	params Params
+	body   Function
+}
+
+// NewUserFunction creates a user defined function with a name and a set of
+// parameters. The body of the function must be set with SetBody before it can
+// be executed, which allows the function to be referenced recursively whilst
+// its body is being parsed.
+func NewUserFunction(name string, params Params) *UserFunction {
+	return &UserFunction{name: name, params: params}
+}
+
+// Name returns the name of the function.
+func (u *UserFunction) Name() string {
+	return u.name
+}
+
+// Params returns the parameters of the function.
+func (u *UserFunction) Params() Params {
+	return u.params
+}
+
+// Body returns the body of the function, which is nil until it has been set.
+func (u *UserFunction) Body() Function {
+	return u.body
+}
+
+// SetBody sets the body of the function that is executed when it is called.
+func (u *UserFunction) SetBody(body Function) {
+	u.body = body
+}
+
+// Init returns a query function that calls the user defined function with a
+// set of parsed arguments.
+//
+// The body of the function is executed with the same context value as the
+// call, but with variables isolated such that only the arguments of the call
+// are accessible.
+func (u *UserFunction) Init(args *ParsedParams) Function {
+	return ClosureFunction("function "+u.name, func(ctx FunctionContext) (any, error) {
+		if u.body == nil {
+			return nil, fmt.Errorf("function %v has no body", u.name)
+		}
+
+		resolved, err := args.ResolveDynamic(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// The body only has access to the arguments of the call, so variables of
+		// the calling mapping are replaced rather than extended.
+		vars := make(map[string]any, len(u.params.Definitions))
+		for i, def := range u.params.Definitions {
+			if i < len(resolved.values) {
+				vars[def.Name] = resolved.values[i]
+			}
+		}
+		ctx.Vars = vars
+		return u.body.Exec(ctx)
+	}, aggregateTargetPaths(args.dynamic()...))
+}
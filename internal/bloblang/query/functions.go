@@ -188,7 +188,7 @@ var _ = registerSimpleFunction(
 		NewExampleSpec("",
 			`root = if batch_index() > 0 { deleted() }`,
 		),
-	),
+	).Returns(value.TNumber),
 	func(ctx FunctionContext) (any, error) {
 		return int64(ctx.Index), nil
 	},
@@ -203,7 +203,7 @@ var _ = registerSimpleFunction(
 		NewExampleSpec("",
 			`root.foo = batch_size()`,
 		),
-	),
+	).Returns(value.TNumber),
 	func(ctx FunctionContext) (any, error) {
 		return int64(ctx.MsgBatch.Len()), nil
 	},
@@ -220,7 +220,7 @@ var _ = registerSimpleFunction(
 			`{"foo":"bar"}`,
 			`{"doc":"{\"foo\":\"bar\"}"}`,
 		),
-	),
+	).Returns(value.TBytes),
 	func(ctx FunctionContext) (any, error) {
 		return ctx.MsgBatch.Get(ctx.Index).AsBytes(), nil
 	},
@@ -277,7 +277,7 @@ root.id = count("bloblang_function_example")`,
 			`{"message":"bar"}`,
 			`{"id":2,"message":"bar"}`,
 		),
-	).Param(ParamString("name", "An identifier for the counter.")).MarkImpure(),
+	).Returns(value.TNumber).Param(ParamString("name", "An identifier for the counter.")).MarkImpure(),
 	countFunction,
 )
 
@@ -359,7 +359,7 @@ var _ = registerSimpleFunction(
 		NewExampleSpec("",
 			`root.doc.status = if errored() { 400 } else { 200 }`,
 		),
-	),
+	).Returns(value.TBool),
 	func(ctx FunctionContext) (any, error) {
 		return ctx.MsgBatch.Get(ctx.Index).ErrorGet() != nil, nil
 	},
@@ -378,7 +378,7 @@ root.c = range(0, -this.max, -2)`,
 			`{"max":10}`,
 			`{"a":[0,1,2,3,4,5,6,7,8,9],"b":[0,2,4,6,8],"c":[0,-2,-4,-6,-8]}`,
 		),
-	).
+	).Returns(value.TArray).
 		Param(ParamInt64("start", "The start value.")).
 		Param(ParamInt64("stop", "The stop value.")).
 		Param(ParamInt64("step", "The step value.").Default(1)),
@@ -690,7 +690,7 @@ root.sixth = random_int(seed:timestamp_unix_nano(), max:20)
 			`root.first = random_int(timestamp_unix_nano())`,
 			`root.second = random_int(timestamp_unix_nano(), 5, 20)`,
 		),
-	).
+	).Returns(value.TNumber).
 		Param(ParamQuery(
 			"seed",
 			"A seed to use, if a query is provided it will only be resolved once during the lifetime of the mapping.",
@@ -776,7 +776,7 @@ var _ = registerSimpleFunction(
 		NewExampleSpec("",
 			`root.received_at = timestamp_unix()`,
 		),
-	),
+	).Returns(value.TNumber),
 	func(_ FunctionContext) (any, error) {
 		return time.Now().Unix(), nil
 	},
@@ -789,7 +789,7 @@ var _ = registerSimpleFunction(
 		NewExampleSpec("",
 			`root.received_at = timestamp_unix_milli()`,
 		),
-	),
+	).Returns(value.TNumber),
 	func(_ FunctionContext) (any, error) {
 		return time.Now().UnixMilli(), nil
 	},
@@ -802,7 +802,7 @@ var _ = registerSimpleFunction(
 		NewExampleSpec("",
 			`root.received_at = timestamp_unix_micro()`,
 		),
-	),
+	).Returns(value.TNumber),
 	func(_ FunctionContext) (any, error) {
 		return time.Now().UnixMicro(), nil
 	},
@@ -815,7 +815,7 @@ var _ = registerSimpleFunction(
 		NewExampleSpec("",
 			`root.received_at = timestamp_unix_nano()`,
 		),
-	),
+	).Returns(value.TNumber),
 	func(_ FunctionContext) (any, error) {
 		return time.Now().UnixNano(), nil
 	},
@@ -858,7 +858,7 @@ var _ = registerSimpleFunction(
 		FunctionCategoryGeneral, "uuid_v4",
 		"Generates a new RFC-4122 UUID each time it is invoked and prints a string representation.",
 		NewExampleSpec("", `root.id = uuid_v4()`),
-	),
+	).Returns(value.TString),
 	func(_ FunctionContext) (any, error) {
 		u4, err := uuid.NewV4()
 		if err != nil {
@@ -877,7 +877,7 @@ var _ = registerFunction(
 		NewExampleSpec("", `root.id = nanoid()`),
 		NewExampleSpec("It is possible to specify an optional length parameter.", `root.id = nanoid(54)`),
 		NewExampleSpec("It is also possible to specify an optional custom alphabet after the length parameter.", `root.id = nanoid(54, "abcde")`),
-	).
+	).Returns(value.TString).
 		Param(ParamInt64("length", "An optional length.").Optional()).
 		Param(ParamString("alphabet", "An optional custom alphabet to use for generating IDs. When specified the field `length` must also be present.").Optional()),
 	nanoidFunction,
@@ -913,7 +913,7 @@ var _ = registerSimpleFunction(
 		FunctionCategoryGeneral, "ksuid",
 		"Generates a new ksuid each time it is invoked and prints a string representation.",
 		NewExampleSpec("", `root.id = ksuid()`),
-	),
+	).Returns(value.TString),
 	func(_ FunctionContext) (any, error) {
 		return ksuid.New().String(), nil
 	},
@@ -68,9 +68,13 @@ func (m *MethodSet) Init(name string, target Function, args *ParsedParams) (Func
 		return nil, badMethodErr(name)
 	}
 	if m.disableCtors {
-		return disabledMethod(name), nil
+		return withReturnType(disabledMethod(name), details.spec.ReturnType), nil
 	}
-	return wrapMethodCtorWithDynamicArgs(name, target, args, details.ctor)
+	fn, err := wrapMethodCtorWithDynamicArgs(name, target, args, details.ctor)
+	if err != nil {
+		return nil, err
+	}
+	return withReturnType(fn, details.spec.ReturnType), nil
 }
 
 // Without creates a clone of the method set that can be mutated in isolation,
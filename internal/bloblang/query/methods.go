@@ -469,7 +469,7 @@ func orMethod(fn Function, args *ParsedParams) (Function, error) {
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"type", "",
-	).InCategory(
+	).Returns(value.TString).InCategory(
 		MethodCategoryCoercion,
 		"Returns the type of a value as a string, providing one of the following values: `string`, `bytes`, `number`, `bool`, `timestamp`, `array`, `object` or `null`.",
 		NewExampleSpec("",
@@ -9,7 +9,7 @@ import (
 )
 
 var _ = registerSimpleMethod(
-	NewMethodSpec("ceil", "Returns the least integer value greater than or equal to a number. If the resulting value fits within a 64-bit integer then that is returned, otherwise a new floating point number is returned.").InCategory(
+	NewMethodSpec("ceil", "Returns the least integer value greater than or equal to a number. If the resulting value fits within a 64-bit integer then that is returned, otherwise a new floating point number is returned.").Accepts(value.TNumber).Returns(value.TNumber).InCategory(
 		MethodCategoryNumbers, "",
 		NewExampleSpec("",
 			`root.new_value = this.value.ceil()`,
@@ -39,7 +39,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"floor", "Returns the greatest integer value less than or equal to the target number. If the resulting value fits within a 64-bit integer then that is returned, otherwise a new floating point number is returned.",
-	).InCategory(
+	).Accepts(value.TNumber).Returns(value.TNumber).InCategory(
 		MethodCategoryNumbers,
 		"",
 		NewExampleSpec("",
@@ -66,7 +66,7 @@ var _ = registerSimpleMethod(
 )
 
 var _ = registerSimpleMethod(
-	NewMethodSpec("log", "Returns the natural logarithm of a number.").InCategory(
+	NewMethodSpec("log", "Returns the natural logarithm of a number.").Accepts(value.TNumber).Returns(value.TNumber).InCategory(
 		MethodCategoryNumbers, "",
 		NewExampleSpec("",
 			`root.new_value = this.value.log().round()`,
@@ -92,7 +92,7 @@ var _ = registerSimpleMethod(
 )
 
 var _ = registerSimpleMethod(
-	NewMethodSpec("log10", "Returns the decimal logarithm of a number.").InCategory(
+	NewMethodSpec("log10", "Returns the decimal logarithm of a number.").Accepts(value.TNumber).Returns(value.TNumber).InCategory(
 		MethodCategoryNumbers, "",
 		NewExampleSpec("",
 			`root.new_value = this.value.log10()`,
@@ -206,7 +206,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"round", "Rounds numbers to the nearest integer, rounding half away from zero. If the resulting value fits within a 64-bit integer then that is returned, otherwise a new floating point number is returned.",
-	).InCategory(
+	).Accepts(value.TNumber).Returns(value.TNumber).InCategory(
 		MethodCategoryNumbers,
 		"",
 		NewExampleSpec("",
@@ -60,7 +60,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"capitalize", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).InCategory(
 		MethodCategoryStrings,
 		"Takes a string value and returns a copy with all Unicode letters that begin words mapped to their Unicode title case.",
 		NewExampleSpec("",
@@ -454,7 +454,7 @@ root.decrypted = this.value.decode("hex").decrypt_aes("ctr", $key, $vector).stri
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"escape_html", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes, value.TTimestamp).Returns(value.TString).InCategory(
 		MethodCategoryStrings,
 		"Escapes a string so that special characters like `<` to become `&lt;`. It escapes only five such characters: `<`, `>`, `&`, `'` and `\"` so that it can be safely placed within an HTML entity.",
 		NewExampleSpec("",
@@ -511,7 +511,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"unescape_html", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes, value.TTimestamp).Returns(value.TString).InCategory(
 		MethodCategoryStrings,
 		"Unescapes a string so that entities like `&lt;` become `<`. It unescapes a larger range of entities than `escape_html` escapes. For example, `&aacute;` unescapes to `á`, as does `&#225;` and `&xE1;`.",
 		NewExampleSpec("",
@@ -532,7 +532,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"escape_url_query", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes, value.TTimestamp).Returns(value.TString).InCategory(
 		MethodCategoryStrings,
 		"Escapes a string so that it can be safely placed within a URL query.",
 		NewExampleSpec("",
@@ -553,7 +553,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"unescape_url_query", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes, value.TTimestamp).Returns(value.TString).InCategory(
 		MethodCategoryStrings,
 		"Expands escape sequences from a URL query string.",
 		NewExampleSpec("",
@@ -629,7 +629,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"format", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes, value.TTimestamp).Returns(value.TString).InCategory(
 		MethodCategoryStrings,
 		"Use a value string as a format specifier in order to produce a new string, using any number of provided arguments. Please refer to the Go [`fmt` package documentation](https://pkg.go.dev/fmt) for the list of valid format verbs.",
 		NewExampleSpec("",
@@ -650,7 +650,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"has_prefix", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).Returns(value.TBool).InCategory(
 		MethodCategoryStrings,
 		"Checks whether a string has a prefix argument and returns a bool.",
 		NewExampleSpec("",
@@ -683,7 +683,7 @@ root.t2 = this.v2.has_prefix("foo")`,
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"has_suffix", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).Returns(value.TBool).InCategory(
 		MethodCategoryStrings,
 		"Checks whether a string has a suffix argument and returns a bool.",
 		NewExampleSpec("",
@@ -856,7 +856,7 @@ root.h2 = this.value.hash(algorithm: "crc32", polynomial: "Koopman").encode("hex
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"join", "",
-	).InCategory(
+	).Accepts(value.TArray).Returns(value.TString).InCategory(
 		MethodCategoryObjectAndArray,
 		"Join an array of strings with an optional delimiter into a single string.",
 		NewExampleSpec("",
@@ -905,7 +905,7 @@ root.joined_numbers = this.numbers.map_each(this.string()).join(",")`,
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"uppercase", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).InCategory(
 		MethodCategoryStrings,
 		"Convert a string value into uppercase.",
 		NewExampleSpec("",
@@ -933,7 +933,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"lowercase", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).InCategory(
 		MethodCategoryStrings,
 		"Convert a string value into lowercase.",
 		NewExampleSpec("",
@@ -961,7 +961,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"parse_csv", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).Returns(value.TArray).InCategory(
 		MethodCategoryParsing,
 		"Attempts to parse a string into an array of objects by following the CSV format described in RFC 4180.",
 		NewExampleSpec("Parses CSV data with a header row",
@@ -1075,7 +1075,7 @@ func parseCSVMethod(args *ParsedParams) (simpleMethod, error) {
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"parse_json", "",
-	).Param(
+	).Accepts(value.TString, value.TBytes).Param(
 		ParamBool("use_number", "An optional flag that when set makes parsing numbers as json.Number instead of the default float64.").Optional(),
 	).InCategory(
 		MethodCategoryParsing,
@@ -1334,7 +1334,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"quote", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes, value.TTimestamp).Returns(value.TString).InCategory(
 		MethodCategoryStrings,
 		"Quotes a target string using escape sequences (`\\t`, `\\n`, `\\xFF`, `\\u0100`) for control characters and non-printable characters.",
 		NewExampleSpec("",
@@ -1355,7 +1355,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"unquote", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes, value.TTimestamp).Returns(value.TString).InCategory(
 		MethodCategoryStrings,
 		"Unquotes a target string, expanding any escape sequences (`\\t`, `\\n`, `\\xFF`, `\\u0100`) for control characters and non-printable characters.",
 		NewExampleSpec("",
@@ -1383,7 +1383,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"replace_all", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).InCategory(
 		MethodCategoryStrings,
 		"Replaces all occurrences of the first argument in a target string with the second argument.",
 		NewExampleSpec("",
@@ -1493,7 +1493,7 @@ func replaceAllManyImpl(args *ParsedParams) (simpleMethod, error) {
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"re_find_all", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).Returns(value.TArray).InCategory(
 		MethodCategoryRegexp,
 		"Returns an array containing all successive matches of a regular expression in a string.",
 		NewExampleSpec("",
@@ -1717,7 +1717,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"re_match", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).Returns(value.TBool).InCategory(
 		MethodCategoryRegexp,
 		"Checks whether a regular expression matches against any part of a string and returns a boolean.",
 		NewExampleSpec("",
@@ -1764,7 +1764,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"re_replace_all", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).InCategory(
 		MethodCategoryRegexp,
 		"Replaces all occurrences of the argument regular expression in a string with a value. Inside the value $ signs are interpreted as submatch expansions, e.g. `$1` represents the text of the first submatch.",
 		NewExampleSpec("",
@@ -1811,7 +1811,7 @@ func reReplaceAllImpl(args *ParsedParams) (simpleMethod, error) {
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"split", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).Returns(value.TArray).InCategory(
 		MethodCategoryStrings,
 		"Split a string value into an array of strings by splitting it on a string separator.",
 		NewExampleSpec("",
@@ -1853,7 +1853,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"string", "",
-	).InCategory(
+	).Returns(value.TString).InCategory(
 		MethodCategoryCoercion,
 		"Marshal a value into a string. If the value is already a string it is unchanged.",
 		NewExampleSpec("",
@@ -1927,7 +1927,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"trim", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).InCategory(
 		MethodCategoryStrings,
 		"Remove all leading and trailing characters from a string that are contained within an argument cutset. If no arguments are provided then whitespace is removed.",
 		NewExampleSpec("",
@@ -1963,7 +1963,7 @@ root.description = this.description.trim()`,
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"trim_prefix", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).InCategory(
 		MethodCategoryStrings,
 		"Remove the provided leading prefix substring from a string. If the string does not have the prefix substring, it is returned unchanged.",
 		NewExampleSpec("",
@@ -1995,7 +1995,7 @@ root.description = this.description.trim_prefix("foobar_")`,
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"trim_suffix", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes).InCategory(
 		MethodCategoryStrings,
 		"Remove the provided trailing suffix substring from a string. If the string does not have the suffix substring, it is returned unchanged.",
 		NewExampleSpec("",
@@ -18,7 +18,7 @@ var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"all",
 		"Checks each element of an array against a query and returns true if all elements passed. An error occurs if the target is not an array, or if any element results in the provided query returning a non-boolean result. Returns false if the target array is empty.",
-	).InCategory(
+	).Accepts(value.TArray).Returns(value.TBool).InCategory(
 		MethodCategoryObjectAndArray,
 		"",
 		NewExampleSpec("",
@@ -64,7 +64,7 @@ var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"any",
 		"Checks the elements of an array against a query and returns true if any element passes. An error occurs if the target is not an array, or if an element results in the provided query returning a non-boolean result. Returns false if the target array is empty.",
-	).InCategory(
+	).Accepts(value.TArray).Returns(value.TBool).InCategory(
 		MethodCategoryObjectAndArray,
 		"",
 		NewExampleSpec("",
@@ -178,7 +178,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"contains", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes, value.TArray, value.TObject).Returns(value.TBool).InCategory(
 		MethodCategoryObjectAndArray,
 		"Checks whether an array contains an element matching the argument, or an object contains a value matching the argument, and returns a boolean result. Numerical comparisons are made irrespective of the representation type (float versus integer).",
 		NewExampleSpec("",
@@ -245,7 +245,7 @@ var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"enumerated",
 		"Converts an array into a new array of objects, where each object has a field index containing the `index` of the element and a field `value` containing the original value of the element.",
-	).InCategory(
+	).Accepts(value.TArray).Returns(value.TArray).InCategory(
 		MethodCategoryObjectAndArray, "",
 		NewExampleSpec("",
 			`root.foo = this.foo.enumerated()`,
@@ -304,7 +304,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"explode", "",
-	).InCategory(
+	).Accepts(value.TObject).InCategory(
 		MethodCategoryObjectAndArray,
 		"Explodes an array or object at a [field path][field_paths].",
 		NewExampleSpec(`##### On arrays
@@ -473,7 +473,7 @@ var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"find_all",
 		"Returns an array containing the indexes of all occurrences of a value in an array. An empty array is returned if there are no matches. Numerical comparisons are made irrespective of the representation type (float versus integer).",
-	).InCategory(
+	).Accepts(value.TArray).Returns(value.TArray).InCategory(
 		MethodCategoryObjectAndArray, "",
 		NewExampleSpec("",
 			`root.index = this.find_all("bar")`,
@@ -560,7 +560,7 @@ var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"find_all_by",
 		"Returns an array containing the indexes of all occurrences of an array where the provided query resolves to a boolean `true`. An empty array is returned if there are no matches. Numerical comparisons are made irrespective of the representation type (float versus integer).",
-	).InCategory(
+	).Accepts(value.TArray).Returns(value.TArray).InCategory(
 		MethodCategoryObjectAndArray, "",
 		NewExampleSpec("",
 			`root.index = this.find_all_by(v -> v != "bar")`,
@@ -606,7 +606,7 @@ var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"flatten",
 		"Iterates an array and any element that is itself an array is removed and has its elements inserted directly in the resulting array.",
-	).InCategory(
+	).Accepts(value.TArray).Returns(value.TArray).InCategory(
 		MethodCategoryObjectAndArray, "",
 		NewExampleSpec(``,
 			`root.result = this.flatten()`,
@@ -809,7 +809,7 @@ var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"keys",
 		"Returns the keys of an object as an array.",
-	).InCategory(
+	).Accepts(value.TObject).Returns(value.TArray).InCategory(
 		MethodCategoryObjectAndArray, "",
 		NewExampleSpec("",
 			`root.foo_keys = this.foo.keys()`,
@@ -838,7 +838,7 @@ var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"key_values",
 		"Returns the key/value pairs of an object as an array, where each element is an object with a `key` field and a `value` field. The order of the resulting array will be random.",
-	).InCategory(
+	).Accepts(value.TObject).Returns(value.TArray).InCategory(
 		MethodCategoryObjectAndArray, "",
 		NewExampleSpec("",
 			`root.foo_key_values = this.foo.key_values().sort_by(pair -> pair.key)`,
@@ -869,7 +869,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"length", "",
-	).InCategory(
+	).Accepts(value.TString, value.TBytes, value.TArray, value.TObject).Returns(value.TNumber).InCategory(
 		MethodCategoryStrings, "Returns the length of a string.",
 		NewExampleSpec("",
 			`root.foo_len = this.foo.length()`,
@@ -1202,7 +1202,7 @@ var _ = registerSimpleMethod(
 var _ = registerMethod(
 	NewMethodSpec(
 		"sort", "",
-	).InCategory(
+	).Accepts(value.TArray).Returns(value.TArray).InCategory(
 		MethodCategoryObjectAndArray,
 		"Attempts to sort the values of an array in increasing order. The type of all values must match in order for the ordering to succeed. Supports string and number values.",
 		NewExampleSpec("",
@@ -1308,7 +1308,7 @@ func sortMethod(target Function, args *ParsedParams) (Function, error) {
 var _ = registerMethod(
 	NewMethodSpec(
 		"sort_by", "",
-	).InCategory(
+	).Accepts(value.TArray).Returns(value.TArray).InCategory(
 		MethodCategoryObjectAndArray,
 		"Attempts to sort the elements of an array, in increasing order, by a value emitted by an argument query applied to each element. The type of all values must match in order for the ordering to succeed. Supports string and number values.",
 		NewExampleSpec("",
@@ -1544,7 +1544,7 @@ func sumMethod(target Function, _ *ParsedParams) (Function, error) {
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"unique", "",
-	).InCategory(
+	).Accepts(value.TArray).Returns(value.TArray).InCategory(
 		MethodCategoryObjectAndArray,
 		"Attempts to remove duplicate values from an array. The array may contain a combination of different value types, but numbers and strings are checked separately (`\"5\"` is a different element to `5`).",
 		NewExampleSpec("",
@@ -1646,7 +1646,7 @@ func uniqueMethod(args *ParsedParams) (simpleMethod, error) {
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"values", "",
-	).InCategory(
+	).Accepts(value.TObject).Returns(value.TArray).InCategory(
 		MethodCategoryObjectAndArray,
 		"Returns the values of an object as an array. The order of the resulting array will be random.",
 		NewExampleSpec("",
@@ -1674,7 +1674,7 @@ var _ = registerSimpleMethod(
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"without", "",
-	).InCategory(
+	).Accepts(value.TObject).Returns(value.TObject).InCategory(
 		MethodCategoryObjectAndArray,
 		`Returns an object where one or more [field path][field_paths] arguments are removed. Each path specifies a specific field to be deleted from the input object, allowing for nested fields.
 
@@ -60,6 +60,13 @@ type FunctionContext struct {
 
 	// Used to track how many maps we've entered.
 	stackCount int
+
+	// Used to record the steps taken by mappings for debugging.
+	trace       *Trace
+	traceSource []rune
+
+	// Used to record the statements and branches executed by mappings.
+	coverage *Coverage
 }
 
 type namedContextValue struct {
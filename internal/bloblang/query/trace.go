@@ -0,0 +1,132 @@
+package query
+
+import (
+	"sync"
+)
+
+// TraceEventType describes the kind of decision recorded by a trace event.
+type TraceEventType string
+
+// TraceEventTypes.
+const (
+	// TraceAssignment is recorded when a value is assigned to the new message
+	// or its metadata.
+	TraceAssignment TraceEventType = "assignment"
+
+	// TraceVariable is recorded when a value is assigned to a variable with a
+	// let statement.
+	TraceVariable TraceEventType = "variable"
+
+	// TraceMatch is recorded when a match expression has selected a case.
+	TraceMatch TraceEventType = "match"
+
+	// TraceIf is recorded when an if statement has selected a branch.
+	TraceIf TraceEventType = "if"
+)
+
+// TraceEvent describes a single step taken during the execution of a mapping.
+type TraceEvent struct {
+	Type TraceEventType
+
+	// The position of the statement or expression within the mapping, these
+	// are zero when the position is unknown.
+	Line, Column int
+
+	// The target of an assignment, e.g. `root.foo`, `meta bar` or `let baz`.
+	Target string
+
+	// The value assigned for assignments, or the context value being matched
+	// against for match expressions. Values are deep copies taken at the time
+	// of the event, and are nil for if statements.
+	Value any
+
+	// The index of the case selected by a match expression, or the branch
+	// selected by an if statement, where -1 means none were selected.
+	Case int
+}
+
+// Trace records the steps taken during the executions of a mapping, which is
+// used in order to debug the behaviour of a mapping with a given input.
+type Trace struct {
+	mut    sync.Mutex
+	events []TraceEvent
+}
+
+// NewTrace creates an empty trace recorder.
+func NewTrace() *Trace {
+	return &Trace{}
+}
+
+// Add a trace event.
+func (t *Trace) Add(e TraceEvent) {
+	t.mut.Lock()
+	t.events = append(t.events, e)
+	t.mut.Unlock()
+}
+
+// Events returns all events recorded by the trace in the order that they
+// occurred.
+func (t *Trace) Events() []TraceEvent {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	events := make([]TraceEvent, len(t.events))
+	copy(events, t.events)
+	return events
+}
+
+// Reset removes all events recorded by the trace.
+func (t *Trace) Reset() {
+	t.mut.Lock()
+	t.events = nil
+	t.mut.Unlock()
+}
+
+//------------------------------------------------------------------------------
+
+// WithTrace returns a function context where the steps taken by mappings
+// executed with the context are recorded by a trace.
+func (ctx FunctionContext) WithTrace(t *Trace) FunctionContext {
+	ctx.trace = t
+	return ctx
+}
+
+// Tracing returns true if the function context has a trace attached.
+func (ctx FunctionContext) Tracing() bool {
+	return ctx.trace != nil
+}
+
+// WithTraceSource returns a function context where the positions of traced
+// events are calculated relative to the provided input. If the input is a
+// part of the current source, such as the body of a map definition, then the
+// current source is kept so that positions remain relative to the whole
+// mapping.
+func (ctx FunctionContext) WithTraceSource(input []rune) FunctionContext {
+	if ctx.trace == nil || isSubClip(ctx.traceSource, input) {
+		return ctx
+	}
+	ctx.traceSource = input
+	return ctx
+}
+
+// AddTraceEvent records an event to the trace of the context, if one is
+// attached, where the position of the event is calculated from a clip of the
+// current trace source.
+func (ctx FunctionContext) AddTraceEvent(clip []rune, e TraceEvent) {
+	if ctx.trace == nil {
+		return
+	}
+	if isSubClip(ctx.traceSource, clip) {
+		e.Line, e.Column = lineAndColOf(ctx.traceSource, len(ctx.traceSource)-len(clip))
+	}
+	ctx.trace.Add(e)
+}
+
+// isSubClip returns true if the clip is a tailing part of the input that
+// shares the same underlying memory.
+func isSubClip(input, clip []rune) bool {
+	if len(input) == 0 || len(clip) == 0 || len(clip) > len(input) {
+		return false
+	}
+	return &input[len(input)-len(clip)] == &clip[0]
+}
@@ -0,0 +1,135 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/value"
+)
+
+// StaticType attempts to determine the type of value that a function returns
+// without executing it. Returns value.TUnknown when the type cannot be
+// determined, which is the case for most queries as the types of values
+// within messages are only known at runtime.
+func StaticType(fn Function) value.Type {
+	switch t := fn.(type) {
+	case *Literal:
+		switch vt := value.ITypeOf(t.Value); vt {
+		case value.TDelete, value.TNothing, value.TQuery:
+			return value.TUnknown
+		default:
+			return vt
+		}
+	case *arrayLiteral:
+		return value.TArray
+	case *mapLiteral:
+		return value.TObject
+	case closureFunction:
+		if t.returnType != "" {
+			return t.returnType
+		}
+	}
+	return value.TUnknown
+}
+
+func withReturnType(fn Function, t value.Type) Function {
+	if t == "" {
+		return fn
+	}
+	if cFn, ok := fn.(closureFunction); ok {
+		cFn.returnType = t
+		return cFn
+	}
+	return fn
+}
+
+// typesCompatible returns false if a value of type actual can never be used
+// where a value of type expected is required.
+func typesCompatible(expected, actual value.Type) bool {
+	if actual == value.TUnknown || expected == value.TUnknown || expected == actual {
+		return true
+	}
+	switch expected {
+	case value.TInt, value.TFloat, value.TNumber:
+		return actual == value.TNumber
+	case value.TString:
+		return actual == value.TBytes
+	case value.TBytes:
+		return actual == value.TString
+	case value.TTimestamp:
+		return actual == value.TString || actual == value.TBytes || actual == value.TNumber
+	case value.TBool:
+		return actual == value.TNumber
+	case value.TQuery:
+		return true
+	}
+	return false
+}
+
+func typesStr(types []value.Type) string {
+	strs := make([]string, len(types))
+	for i, t := range types {
+		strs[i] = string(t)
+	}
+	if len(strs) == 1 {
+		return strs[0]
+	}
+	return strings.Join(strs[:len(strs)-1], ", ") + " or " + strs[len(strs)-1]
+}
+
+// checkArgTypes returns errors for each dynamic argument of a parsed set of
+// parameters that resolves to a type that can never be accepted by the
+// parameter.
+func checkArgTypes(args *ParsedParams) (errs []error) {
+	if args == nil || args.source.Variadic {
+		return nil
+	}
+	for _, dArg := range args.dynArgs {
+		if dArg.index >= len(args.source.Definitions) {
+			continue
+		}
+		def := args.source.Definitions[dArg.index]
+		if t := StaticType(dArg.fn); !typesCompatible(def.ValueType, t) {
+			errs = append(errs, fmt.Errorf("parameter %v expects a %v value, got %v", def.Name, def.ValueType, t))
+		}
+	}
+	return
+}
+
+// CheckTypes returns errors describing definite type mismatches between the
+// arguments provided to a function and the types of its parameters.
+func (f *FunctionSet) CheckTypes(name string, args *ParsedParams) (errs []error) {
+	if _, exists := f.functions[name]; !exists {
+		return nil
+	}
+	for _, err := range checkArgTypes(args) {
+		errs = append(errs, fmt.Errorf("function %v: %w", name, err))
+	}
+	return
+}
+
+// CheckTypes returns errors describing definite type mismatches between the
+// target and arguments provided to a method and the types it accepts.
+func (m *MethodSet) CheckTypes(name string, target Function, args *ParsedParams) (errs []error) {
+	details, exists := m.methods[name]
+	if !exists {
+		return nil
+	}
+	if inputTypes := details.spec.InputTypes; len(inputTypes) > 0 {
+		t := StaticType(target)
+		compatible := false
+		for _, it := range inputTypes {
+			if typesCompatible(it, t) {
+				compatible = true
+				break
+			}
+		}
+		if !compatible {
+			errs = append(errs, fmt.Errorf("method %v expects a %v value, got %v", name, typesStr(inputTypes), t))
+		}
+	}
+	for _, err := range checkArgTypes(args) {
+		errs = append(errs, fmt.Errorf("method %v: %w", name, err))
+	}
+	return
+}
@@ -18,6 +18,8 @@ type Environment struct {
 	tracers    *TracerSet
 
 	scanners *ScannerSet
+
+	templates *TemplateSet
 }
 
 // NewEnvironment creates an empty environment.
@@ -32,6 +34,7 @@ func NewEnvironment() *Environment {
 		metrics:    &MetricsSet{},
 		tracers:    &TracerSet{},
 		scanners:   &ScannerSet{},
+		templates:  &TemplateSet{},
 	}
 }
 
@@ -66,6 +69,11 @@ func (e *Environment) Clone() *Environment {
 	for _, v := range e.scanners.specs {
 		_ = newEnv.scanners.Add(v.constructor, v.spec)
 	}
+	for cType, byName := range e.templates.specs {
+		for name, render := range byName {
+			newEnv.templates.Add(cType, name, render)
+		}
+	}
 	return newEnv
 }
 
@@ -109,4 +117,5 @@ var GlobalEnvironment = &Environment{
 	metrics:    AllMetrics,
 	tracers:    AllTracers,
 	scanners:   AllScanners,
+	templates:  AllTemplates,
 }
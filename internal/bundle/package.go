@@ -16,6 +16,7 @@ import (
 
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/component/buffer"
@@ -55,7 +56,7 @@ type NewManagement interface {
 	Environment() *Environment
 	BloblEnvironment() *bloblang.Environment
 
-	RegisterEndpoint(path, desc string, h http.HandlerFunc)
+	RegisterEndpoint(path, desc string, h http.HandlerFunc, ops ...api.OperationSpec)
 
 	NewBuffer(conf buffer.Config) (buffer.Streamed, error)
 	NewCache(conf cache.Config) (cache.V1, error)
@@ -0,0 +1,59 @@
+package bundle
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// AllTemplates is a set containing every template component that has been
+// registered to the global environment.
+var AllTemplates = &TemplateSet{
+	specs: map[docs.Type]map[string]TemplateRenderFunc{},
+}
+
+//------------------------------------------------------------------------------
+
+// TemplateAdd records that a component of this environment was registered from
+// a template, allowing its configs to be expanded with TemplateRender.
+func (e *Environment) TemplateAdd(cType docs.Type, name string, render TemplateRenderFunc) {
+	e.templates.Add(cType, name, render)
+}
+
+// TemplateRender attempts to render the config of a template component into
+// the config of the component that the template is built from. Returns false
+// if the component was not registered from a template.
+func (e *Environment) TemplateRender(cType docs.Type, name string, conf any) (any, bool, error) {
+	return e.templates.Render(cType, name, conf)
+}
+
+//------------------------------------------------------------------------------
+
+// TemplateRenderFunc renders the config of a template component into the
+// config of the component that the template is built from.
+type TemplateRenderFunc func(conf any) (any, error)
+
+// TemplateSet contains the template components of an environment.
+type TemplateSet struct {
+	specs map[docs.Type]map[string]TemplateRenderFunc
+}
+
+// Add a template component to this set.
+func (s *TemplateSet) Add(cType docs.Type, name string, render TemplateRenderFunc) {
+	if s.specs == nil {
+		s.specs = map[docs.Type]map[string]TemplateRenderFunc{}
+	}
+	if s.specs[cType] == nil {
+		s.specs[cType] = map[string]TemplateRenderFunc{}
+	}
+	s.specs[cType][name] = render
+}
+
+// Render the config of a template component, returns false if the set does not
+// contain a template of the given type and name.
+func (s *TemplateSet) Render(cType docs.Type, name string, conf any) (any, bool, error) {
+	render, exists := s.specs[cType][name]
+	if !exists {
+		return nil, false, nil
+	}
+	res, err := render(conf)
+	return res, true, err
+}
@@ -2,9 +2,12 @@ package blobl
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/Jeffail/gabs/v2"
@@ -62,6 +65,10 @@ Find out more about Bloblang at: https://benthos.dev/docs/guides/bloblang/about`
 				Usage: "Set the buffer size for document lines.",
 				Value: bufio.MaxScanTokenSize,
 			},
+			&cli.BoolFlag{
+				Name:  "trace",
+				Usage: "print a trace of each assignment, variable and match decision made by the mapping as JSON lines before the result of each document.",
+			},
 		},
 		Action: run,
 		Subcommands: []*cli.Command{
@@ -113,8 +120,9 @@ Find out more about Bloblang at: https://benthos.dev/docs/guides/bloblang/about`
 }
 
 type execCache struct {
-	msg  message.Batch
-	vars map[string]any
+	msg   message.Batch
+	vars  map[string]any
+	trace *query.Trace
 }
 
 func newExecCache() *execCache {
@@ -155,13 +163,19 @@ func (e *execCache) executeMapping(exec *mapping.Executor, rawInput, prettyOutpu
 	}
 
 	var result any = value.Nothing(nil)
-	err := exec.ExecOnto(query.FunctionContext{
+	fnCtx := query.FunctionContext{
 		Maps:     exec.Maps(),
 		Vars:     e.vars,
 		MsgBatch: e.msg,
 		NewMeta:  e.msg.Get(0),
 		NewValue: &result,
-	}.WithValueFunc(lazyValue), mapping.AssignmentContext{
+	}.WithValueFunc(lazyValue)
+	if e.trace != nil {
+		e.trace.Reset()
+		fnCtx = fnCtx.WithTrace(e.trace)
+	}
+
+	err := exec.ExecOnto(fnCtx, mapping.AssignmentContext{
 		Vars:  e.vars,
 		Meta:  e.msg.Get(0),
 		Value: &result,
@@ -211,6 +225,42 @@ func (e *execCache) executeMapping(exec *mapping.Executor, rawInput, prettyOutpu
 	return resultStr, nil
 }
 
+// traceEvents returns the events recorded by the trace of the last execution
+// in a form that can be marshalled as JSON.
+func (e *execCache) traceEvents() []map[string]any {
+	if e.trace == nil {
+		return nil
+	}
+	events := e.trace.Events()
+	objs := make([]map[string]any, 0, len(events))
+	for _, event := range events {
+		obj := map[string]any{
+			"type":   string(event.Type),
+			"line":   event.Line,
+			"column": event.Column,
+		}
+		if event.Type == query.TraceMatch || event.Type == query.TraceIf {
+			obj["case"] = event.Case
+		} else {
+			obj["target"] = event.Target
+		}
+		if event.Type == query.TraceIf {
+			objs = append(objs, obj)
+			continue
+		}
+		switch t := event.Value.(type) {
+		case value.Delete:
+			obj["deleted"] = true
+		case []byte:
+			obj["value"] = string(t)
+		default:
+			obj["value"] = t
+		}
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
 func run(c *cli.Context) error {
 	t := c.Int("threads")
 	if t < 1 {
@@ -218,6 +268,7 @@ func run(c *cli.Context) error {
 	}
 	raw := c.Bool("raw")
 	pretty := c.Bool("pretty")
+	trace := c.Bool("trace")
 	file := c.String("file")
 	m := c.Args().First()
 
@@ -275,6 +326,9 @@ func run(c *cli.Context) error {
 			defer wg.Done()
 
 			execCache := newExecCache()
+			if trace {
+				execCache.trace = query.NewTrace()
+			}
 			for {
 				input, open := <-inputsChan
 				if !open {
@@ -282,6 +336,19 @@ func run(c *cli.Context) error {
 				}
 
 				resultStr, err := execCache.executeMapping(exec, raw, pretty, input)
+				if trace {
+					var traceBuf bytes.Buffer
+					enc := json.NewEncoder(&traceBuf)
+					enc.SetEscapeHTML(false)
+					for _, event := range execCache.traceEvents() {
+						_ = enc.Encode(event)
+					}
+					if err == nil {
+						resultStr = traceBuf.String() + resultStr
+					} else if traceBuf.Len() > 0 {
+						resultsChan <- strings.TrimSuffix(traceBuf.String(), "\n")
+					}
+				}
 				if err != nil {
 					fmt.Fprintln(os.Stderr, red(fmt.Sprintf("failed to execute map: %v", err)))
 					continue
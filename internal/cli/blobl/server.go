@@ -22,6 +22,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
 
 	_ "embed"
@@ -150,6 +151,7 @@ func runServer(c *cli.Context) error {
 		req := struct {
 			Mapping string `json:"mapping"`
 			Input   string `json:"input"`
+			Trace   bool   `json:"trace"`
 		}{}
 		dec := json.NewDecoder(r.Body)
 		if err := dec.Decode(&req); err != nil {
@@ -160,9 +162,10 @@ func runServer(c *cli.Context) error {
 		fSync.update(req.Input, req.Mapping)
 
 		res := struct {
-			ParseError   string `json:"parse_error"`
-			MappingError string `json:"mapping_error"`
-			Result       string `json:"result"`
+			ParseError   string           `json:"parse_error"`
+			MappingError string           `json:"mapping_error"`
+			Result       string           `json:"result"`
+			Trace        []map[string]any `json:"trace,omitempty"`
 		}{}
 		defer func() {
 			resBytes, err := json.Marshal(res)
@@ -184,12 +187,16 @@ func runServer(c *cli.Context) error {
 		}
 
 		execCache := newExecCache()
+		if req.Trace {
+			execCache.trace = query.NewTrace()
+		}
 		output, err := execCache.executeMapping(exec, false, true, []byte(req.Input))
 		if err != nil {
 			res.MappingError = err.Error()
 		} else {
 			res.Result = output
 		}
+		res.Trace = execCache.traceEvents()
 	})
 
 	indexTemplate := template.Must(template.New("index").Parse(bloblangEditorPage))
@@ -16,7 +16,9 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/health"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/tap"
 	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -111,6 +113,11 @@ func CreateManager(
 		return
 	}
 
+	httpServer.RegisterEndpoint("/health", health.HandlerDescription, health.Handler(mgr.Health()), health.HandlerOperations()...)
+	if conf.HTTP.DebugEndpoints {
+		httpServer.RegisterEndpoint("/debug/tap", tap.HandlerDescription, tap.Handler(mgr.Taps()))
+	}
+
 	stoppableMgr = newStoppableManager(httpServer, mgr)
 	return
 }
@@ -73,7 +73,8 @@ func RunService(c *cli.Context, version, dateBuilt string, streamsMode bool) int
 	watching := c.Bool("watcher")
 	if streamsMode {
 		enableStreamsAPI := !c.Bool("no-api")
-		stoppableStream = initStreamsMode(strict, watching, enableStreamsAPI, confReader, stoppableManager.Manager())
+		persistDir := c.String("persist-dir")
+		stoppableStream = initStreamsMode(strict, watching, enableStreamsAPI, persistDir, confReader, stoppableManager.Manager())
 	} else {
 		stoppableStream, dataStreamClosedChan = initNormalMode(conf, strict, watching, confReader, stoppableManager.Manager())
 	}
@@ -107,11 +108,24 @@ func DelayShutdown(ctx context.Context, duration time.Duration) error {
 
 func initStreamsMode(
 	strict, watching, enableAPI bool,
+	persistDir string,
 	confReader *config.Reader,
 	mgr *manager.Type,
 ) Stoppable {
 	logger := mgr.Logger()
-	streamMgr := strmmgr.New(mgr, strmmgr.OptAPIEnabled(enableAPI))
+
+	streamMgrOpts := []func(*strmmgr.Type){
+		strmmgr.OptAPIEnabled(enableAPI),
+	}
+	if persistDir != "" {
+		store, err := strmmgr.NewDirectoryStore(persistDir)
+		if err != nil {
+			logger.Error("Failed to create stream persistence directory: %v", err)
+			os.Exit(1)
+		}
+		streamMgrOpts = append(streamMgrOpts, strmmgr.OptSetStore(store))
+	}
+	streamMgr := strmmgr.New(mgr, streamMgrOpts...)
 
 	streamConfs := map[string]stream.Config{}
 	lints, err := confReader.ReadStreams(streamConfs)
@@ -132,6 +146,13 @@ func initStreamsMode(
 		os.Exit(1)
 	}
 
+	// Streams persisted via the API reflect the most recent changes and are
+	// therefore applied over the streams of config files.
+	if err := streamMgr.ApplyStored(streamConfs, confReader.StreamFileDigests()); err != nil {
+		logger.Error("Failed to load persisted streams: %v", err)
+		os.Exit(1)
+	}
+
 	for id, conf := range streamConfs {
 		if err := streamMgr.Create(id, conf); err != nil {
 			logger.Error("Failed to create stream (%v): %v\n", id, err)
@@ -144,6 +165,14 @@ func initStreamsMode(
 		ctx, done := context.WithTimeout(context.Background(), time.Second*30)
 		defer done()
 
+		var digest string
+		if newStreamConf != nil {
+			digest = confReader.StreamFileDigests()[id]
+		}
+		if err := streamMgr.FileChanged(id, digest); err != nil {
+			logger.Error("Failed to update persisted streams: %v", err)
+		}
+
 		var updateErr error
 		if newStreamConf != nil {
 			if updateErr = streamMgr.Update(ctx, id, *newStreamConf); updateErr != nil && errors.Is(updateErr, strmmgr.ErrStreamDoesNotExist) {
@@ -180,13 +209,19 @@ func initNormalMode(
 	stoppedChan = make(chan struct{})
 	var closeOnce sync.Once
 	streamInit := func() (Stoppable, error) {
-		return stream.New(conf.Config, mgr, stream.OptOnClose(func() {
-			if !watching {
-				closeOnce.Do(func() {
-					close(stoppedChan)
-				})
-			}
-		}))
+		opts := []func(*stream.Type){
+			stream.OptOnClose(func() {
+				if !watching {
+					closeOnce.Do(func() {
+						close(stoppedChan)
+					})
+				}
+			}),
+		}
+		if watching {
+			opts = append(opts, stream.OptIncrementalUpdates())
+		}
+		return stream.New(conf.Config, mgr, opts...)
 	}
 
 	initStream, err := streamInit()
@@ -203,7 +238,15 @@ func initNormalMode(
 		ctx, done := context.WithTimeout(context.Background(), 30*time.Second)
 		defer done()
 		// NOTE: We're ignoring observability field changes for now.
-		return stoppableStream.Replace(ctx, func() (Stoppable, error) {
+		return stoppableStream.Update(ctx, func(current Stoppable) (bool, error) {
+			// Only the components of the stream that have changed are
+			// replaced, unless the changes cannot be applied in place.
+			strm, ok := current.(*stream.Type)
+			if !ok {
+				return false, nil
+			}
+			return strm.Update(ctx, newStreamConf.Config)
+		}, func() (Stoppable, error) {
 			conf.Config = newStreamConf.Config
 			return streamInit()
 		})
@@ -74,7 +74,28 @@ func (s *SwappableStopper) Replace(ctx context.Context, fn func() (Stoppable, er
 		// If the outer stream has been stopped then do not create a new one.
 		return nil
 	}
+	return s.replace(ctx, fn)
+}
+
+// Update attempts to apply changes to the resource in place with a closure
+// that returns false if the resource cannot be updated in place, in which case
+// the resource is replaced as it would be with Replace.
+func (s *SwappableStopper) Update(ctx context.Context, updateFn func(Stoppable) (bool, error), fn func() (Stoppable, error)) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.stopped {
+		return nil
+	}
+
+	updated, err := updateFn(s.current)
+	if updated {
+		return err
+	}
+	return s.replace(ctx, fn)
+}
 
+func (s *SwappableStopper) replace(ctx context.Context, fn func() (Stoppable, error)) error {
 	// The underlying implementation is expected to continue shutting resources
 	// down in the background. An error here indicates that it hasn't managed to
 	// fully clean up before reaching a context deadline.
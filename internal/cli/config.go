@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/cli/common"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+func configCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Inspect Benthos configs",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "explain",
+				Usage: "Print the fully resolved effective config along with the origin of each value",
+				Description: `
+Reads a config along with any resources, templates and --set overrides, and
+prints the config that would actually run. Resources are merged into the
+config, template components are expanded into the components they render,
+and any fields that are not set are populated with their default values.
+
+Each value is annotated with a comment describing where it came from, which is
+either a file and line, an environment variable, a --set override, a template
+or a default:
+
+  benthos -c ./config.yaml -r "./resources/*.yaml" config explain
+  benthos -t "./templates/*.yaml" -c ./config.yaml config explain`[1:],
+				Action: func(c *cli.Context) error {
+					if code := ExplainAction(c, os.Stdout, os.Stderr); code != 0 {
+						os.Exit(code)
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// ExplainAction performs the benthos config explain subcommand and returns the
+// appropriate exit code. This function is exported for testing purposes only.
+func ExplainAction(c *cli.Context, stdout, stderr io.Writer) int {
+	_, _, confReader := common.ReadConfig(c, false)
+
+	node, err := confReader.Explain()
+	if err != nil {
+		fmt.Fprintf(stderr, "Configuration file read error: %v\n", err)
+		return 1
+	}
+
+	sanitConf := docs.NewSanitiseConfig(bundle.GlobalEnvironment)
+	sanitConf.RemoveTypeField = true
+	sanitConf.ScrubSecrets = true
+	if err := config.Spec().SanitiseYAML(node, sanitConf); err != nil {
+		fmt.Fprintf(stderr, "Explain error: %v\n", err)
+		return 1
+	}
+
+	configYAML, err := docs.MarshalYAML(*node)
+	if err != nil {
+		fmt.Fprintf(stderr, "Explain error: %v\n", err)
+		return 1
+	}
+	fmt.Fprint(stdout, string(configYAML))
+	return 0
+}
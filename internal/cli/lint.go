@@ -8,10 +8,12 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"sort"
 	"sync"
 
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/config"
@@ -23,6 +25,7 @@ import (
 var (
 	red    = color.New(color.FgRed).SprintFunc()
 	yellow = color.New(color.FgYellow).SprintFunc()
+	green  = color.New(color.FgGreen).SprintFunc()
 )
 
 type pathLint struct {
@@ -48,6 +51,57 @@ func lintFile(path string, skipEnvVarCheck bool, lConf docs.LintConfig) (pathLin
 	return
 }
 
+// fixFile attempts to resolve linting errors within a config file by rewriting
+// it in place, and returns the linting errors that were fixed. The raw contents
+// of the file are modified rather than the contents after environment variable
+// interpolations have been resolved, and comments and the ordering of fields
+// are preserved.
+func fixFile(path string, lConf docs.LintConfig) (pathFixes []pathLint, err error) {
+	rawBytes, err := ifs.ReadFile(ifs.OS(), path)
+	if err != nil {
+		// Read errors are reported when linting.
+		return nil, nil
+	}
+	if bytes.HasPrefix(rawBytes, []byte("# BENTHOS LINT DISABLE")) {
+		return nil, nil
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(rawBytes, &root); err != nil || len(root.Content) == 0 {
+		// Parse errors are reported when linting.
+		return nil, nil
+	}
+
+	fixes, err := config.Spec().FixYAML(docs.NewLintContext(lConf), root.Content[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to fix config: %w", err)
+	}
+	if len(fixes) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(fixes, func(i, j int) bool {
+		return fixes[i].Line < fixes[j].Line
+	})
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return nil, fmt.Errorf("failed to marshal fixed config: %w", err)
+	}
+	if err := ifs.WriteFile(ifs.OS(), path, buf.Bytes(), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write fixed config: %w", err)
+	}
+
+	for _, l := range fixes {
+		pathFixes = append(pathFixes, pathLint{
+			source: path,
+			lint:   l,
+		})
+	}
+	return
+}
+
 func lintMDSnippets(path string, lConf docs.LintConfig) (pathLints []pathLint) {
 	rawBytes, err := ifs.ReadFile(ifs.OS(), path)
 	if err != nil {
@@ -146,9 +200,15 @@ Exits with a status code 1 if any linting errors are detected:
   benthos lint ./configs/*.yaml
   benthos lint ./foo.yaml ./bar.yaml
   benthos lint ./configs/...
+  benthos lint --fix ./configs/...
 
 If a path ends with '...' then Benthos will walk the target and lint any
-files with the .yaml or .yml extension.`[1:],
+files with the .yaml or .yml extension.
+
+When the --fix flag is set linting errors that have a deterministic
+correction, such as deprecated fields with a direct replacement and
+misspelled field names, are fixed by rewriting the config files in place,
+and only the errors that remain are reported.`[1:],
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:  "deprecated",
@@ -165,6 +225,11 @@ files with the .yaml or .yml extension.`[1:],
 				Value: false,
 				Usage: "Do not produce lint errors when environment interpolations exist without defaults within configs but aren't defined.",
 			},
+			&cli.BoolFlag{
+				Name:  "fix",
+				Value: false,
+				Usage: "Rewrite config files in place in order to fix linting errors that have a deterministic correction.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if code := LintAction(c, os.Stderr); code != 0 {
@@ -192,9 +257,10 @@ func LintAction(c *cli.Context, stderr io.Writer) int {
 	lConf.RejectDeprecated = c.Bool("deprecated")
 	lConf.RequireLabels = c.Bool("labels")
 	skipEnvVarCheck := c.Bool("skip-env-var-check")
+	fix := c.Bool("fix")
 
 	var pathLintMut sync.Mutex
-	var pathLints []pathLint
+	var pathLints, pathFixes []pathLint
 	threads := runtime.NumCPU()
 	var wg sync.WaitGroup
 	wg.Add(threads)
@@ -208,15 +274,25 @@ func LintAction(c *cli.Context, stderr io.Writer) int {
 				if target == "" {
 					continue
 				}
-				var lints []pathLint
+				var lints, fixes []pathLint
 				if path.Ext(target) == ".md" {
 					lints = lintMDSnippets(target, lConf)
 				} else {
-					lints = lintFile(target, skipEnvVarCheck, lConf)
+					if fix {
+						var err error
+						if fixes, err = fixFile(target, lConf); err != nil {
+							lints = append(lints, pathLint{
+								source: target,
+								lint:   docs.NewLintError(1, docs.LintFailedRead, err),
+							})
+						}
+					}
+					lints = append(lints, lintFile(target, skipEnvVarCheck, lConf)...)
 				}
-				if len(lints) > 0 {
+				if len(lints) > 0 || len(fixes) > 0 {
 					pathLintMut.Lock()
 					pathLints = append(pathLints, lints...)
+					pathFixes = append(pathFixes, fixes...)
 					pathLintMut.Unlock()
 				}
 			}
@@ -224,6 +300,10 @@ func LintAction(c *cli.Context, stderr io.Writer) int {
 	}
 	wg.Wait()
 
+	for _, fix := range pathFixes {
+		fmt.Fprintf(stderr, "%v: %v %v\n", fix.source, green("fixed"), fix.lint.Error())
+	}
+
 	if len(pathLints) == 0 {
 		return 0
 	}
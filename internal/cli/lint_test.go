@@ -170,3 +170,53 @@ output:
 		})
 	}
 }
+
+func TestLintFix(t *testing.T) {
+	tmpDir := t.TempDir()
+	fooPath := filepath.Join(tmpDir, "foo.yaml")
+
+	require.NoError(t, os.WriteFile(fooPath, []byte(`
+# Reads lines from a file
+input:
+  file:
+    paths: ./data.txt # only one for now
+    codec: lines
+    max_buffer: 2000
+pipeline:
+  procesors:
+    - mapping: 'root = content().uppercase()'
+output:
+  drop: {}
+  dorp_on: nope
+`), 0o644))
+
+	code, outStr := executeLintSubcmd(t, []string{"benthos", "lint", "--fix", fooPath})
+	assert.Equal(t, 1, code)
+	assert.Contains(t, outStr, fooPath+": fixed (5,1) expected array value, wrapped value in an array")
+	assert.Contains(t, outStr, fooPath+": fixed (6,1) field codec is deprecated, replaced codec lines with an equivalent scanner")
+	assert.Contains(t, outStr, fooPath+": fixed (9,1) field procesors not recognised, renamed to processors")
+	assert.Contains(t, outStr, "(14,1) field dorp_on is invalid")
+
+	fixedBytes, err := os.ReadFile(fooPath)
+	require.NoError(t, err)
+	assert.Equal(t, `# Reads lines from a file
+input:
+  file:
+    paths:
+      - ./data.txt # only one for now
+    scanner:
+      lines:
+        max_buffer_size: 2000
+pipeline:
+  processors:
+    - mapping: 'root = content().uppercase()'
+output:
+  drop: {}
+  dorp_on: nope
+`, string(fixedBytes))
+
+	code, outStr = executeLintSubcmd(t, []string{"benthos", "lint", "--fix", fooPath})
+	assert.Equal(t, 1, code)
+	assert.NotContains(t, outStr, "fixed")
+	assert.Contains(t, outStr, "(14,1) field dorp_on is invalid")
+}
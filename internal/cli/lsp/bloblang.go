@@ -0,0 +1,425 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	ibloblang "github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+var (
+	importRegexp  = regexp.MustCompile(`^\s*import\s+"((?:[^"\\]|\\.)*)"(?:\s+as\s+([a-zA-Z0-9_]+))?`)
+	mapDefRegexp  = regexp.MustCompile(`^\s*map\s+"?([a-zA-Z0-9_]+)"?\s*\{`)
+	funcDefRegexp = regexp.MustCompile(`^\s*func\s+([a-zA-Z0-9_]+)\s*\(([^)]*)\)?`)
+	applyRegexp   = regexp.MustCompile(`apply\(\s*"([^"]*)"\s*\)`)
+)
+
+var bloblangKeywords = []string{
+	"root", "this", "meta", "let", "if", "else", "match", "map", "func", "import", "from", "deleted", "nothing",
+}
+
+func bloblangEnvFor(path string) *ibloblang.Environment {
+	env := ibloblang.GlobalEnvironment()
+	if path != "" {
+		env = env.WithImporterRelativeToFile(path)
+	}
+	return env.Deactivated()
+}
+
+func bloblangDiagnostics(d *document) []diagnostic {
+	input := []rune(d.text)
+	diagAt := func(clip []rune, err error) diagnostic {
+		line, col := parser.LineAndColOf(input, clip)
+		return diagnostic{
+			Range:    lineRange(d, line-1, col-1),
+			Severity: severityError,
+			Source:   "bloblang",
+			Message:  err.Error(),
+		}
+	}
+
+	typeErrs, err := bloblangEnvFor(uriToPath(d.uri)).CheckMapping(d.text)
+	if err != nil {
+		var pErr *parser.Error
+		if errors.As(err, &pErr) {
+			return []diagnostic{diagAt(pErr.Input, pErr)}
+		}
+		return []diagnostic{{
+			Range:    lineRange(d, 0, 0),
+			Severity: severityError,
+			Source:   "bloblang",
+			Message:  err.Error(),
+		}}
+	}
+
+	var diags []diagnostic
+	for _, tErr := range typeErrs {
+		diags = append(diags, diagAt(tErr.Input, tErr.Err))
+	}
+	return diags
+}
+
+//------------------------------------------------------------------------------
+
+func isIdentRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// identAt returns the rune span of the identifier surrounding a rune offset of
+// a line.
+func identAt(line []rune, i int) (start, end int) {
+	start, end = i, i
+	for start > 0 && isIdentRune(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isIdentRune(line[end]) {
+		end++
+	}
+	return
+}
+
+// namespaceBefore returns the identifier that precedes a dot immediately
+// before a rune offset, e.g. `lib` for the offset of `foo` in `lib.foo`.
+func namespaceBefore(line []rune, i int) (string, bool) {
+	if i == 0 || line[i-1] != '.' {
+		return "", false
+	}
+	start, _ := identAt(line, i-1)
+	return string(line[start : i-1]), true
+}
+
+type bloblangImport struct {
+	path      string
+	namespace string
+	line      int
+	start     int
+	end       int
+}
+
+func bloblangImports(lines []string) []bloblangImport {
+	var imports []bloblangImport
+	for i, l := range lines {
+		m := importRegexp.FindStringSubmatchIndex(l)
+		if m == nil {
+			continue
+		}
+		imp := bloblangImport{
+			path:  l[m[2]:m[3]],
+			line:  i,
+			start: len([]rune(l[:m[2]])),
+			end:   len([]rune(l[:m[3]])),
+		}
+		if m[4] >= 0 {
+			imp.namespace = l[m[4]:m[5]]
+		}
+		imports = append(imports, imp)
+	}
+	return imports
+}
+
+func signature(name string, params query.Params) string {
+	var args []string
+	for _, p := range params.Definitions {
+		args = append(args, p.Name)
+	}
+	if params.Variadic {
+		args = append(args, "...")
+	}
+	return fmt.Sprintf("%v(%v)", name, strings.Join(args, ", "))
+}
+
+func functionDocs(spec query.FunctionSpec) string {
+	return fmt.Sprintf("```coffee\n%v\n```\n\n%v", signature(spec.Name, spec.Params), strings.TrimSpace(spec.Description))
+}
+
+func methodDocs(spec query.MethodSpec) string {
+	return fmt.Sprintf("```coffee\n.%v\n```\n\n%v", signature(spec.Name, spec.Params), strings.TrimSpace(spec.Description))
+}
+
+// bloblangCompletionAt provides completion items for a Bloblang mapping at a
+// line and rune offset, where functions defined within the mapping and any of
+// its namespaced imports are also suggested.
+func (s *Server) bloblangCompletionAt(src *bloblangSource, lineIdx, char int) []completionItem {
+	line := []rune(src.lines[lineIdx])
+	start, _ := identAt(line, min(char, len(line)))
+
+	env := ibloblang.GlobalEnvironment()
+
+	var items []completionItem
+	if ns, ok := namespaceBefore(line, start); ok {
+		for _, imp := range bloblangImports(src.lines) {
+			if imp.namespace != ns {
+				continue
+			}
+			if nsSrc := s.loadBloblangSource(src.resolveImport(imp.path)); nsSrc != nil {
+				for _, def := range nsSrc.definitions(funcDefRegexp) {
+					items = append(items, completionItem{
+						Label:  def.name,
+						Kind:   completionKindFunction,
+						Detail: fmt.Sprintf("%v(%v)", def.name, def.params),
+					})
+				}
+			}
+			return items
+		}
+
+		env.WalkMethods(func(name string, spec query.MethodSpec) {
+			if spec.Status == query.StatusHidden {
+				return
+			}
+			items = append(items, completionItem{
+				Label:         name,
+				Kind:          completionKindMethod,
+				Detail:        signature(name, spec.Params),
+				Documentation: markdown(strings.TrimSpace(spec.Description)),
+				Deprecated:    spec.Status == query.StatusDeprecated,
+			})
+		})
+		return items
+	}
+
+	env.WalkFunctions(func(name string, spec query.FunctionSpec) {
+		if spec.Status == query.StatusHidden {
+			return
+		}
+		items = append(items, completionItem{
+			Label:         name,
+			Kind:          completionKindFunction,
+			Detail:        signature(name, spec.Params),
+			Documentation: markdown(strings.TrimSpace(spec.Description)),
+			Deprecated:    spec.Status == query.StatusDeprecated,
+		})
+	})
+	for _, def := range src.definitions(funcDefRegexp) {
+		items = append(items, completionItem{
+			Label:  def.name,
+			Kind:   completionKindFunction,
+			Detail: fmt.Sprintf("%v(%v)", def.name, def.params),
+		})
+	}
+	for _, k := range bloblangKeywords {
+		items = append(items, completionItem{Label: k, Kind: completionKindKeyword})
+	}
+	return items
+}
+
+// bloblangHoverAt provides documentation for the function or method at a line
+// and rune offset of a Bloblang mapping.
+func bloblangHoverAt(d *document, lineIdx, char int) *hover {
+	line := []rune(d.line(lineIdx))
+	if char >= len(line) {
+		return nil
+	}
+	start, end := identAt(line, char)
+	if start == end {
+		return nil
+	}
+	name := string(line[start:end])
+
+	env := ibloblang.GlobalEnvironment()
+	var contents string
+	if _, isMethod := namespaceBefore(line, start); isMethod {
+		env.WalkMethods(func(n string, spec query.MethodSpec) {
+			if n == name {
+				contents = methodDocs(spec)
+			}
+		})
+	} else if end < len(line) && line[end] == '(' {
+		env.WalkFunctions(func(n string, spec query.FunctionSpec) {
+			if n == name {
+				contents = functionDocs(spec)
+			}
+		})
+	}
+	if contents == "" {
+		return nil
+	}
+
+	r := wordRange(d, lineIdx, start, end)
+	return &hover{Contents: *markdown(contents), Range: &r}
+}
+
+// bloblangDefinitionAt finds the definitions of the map applied, function
+// called or file imported at a line and rune offset of a Bloblang mapping.
+func (s *Server) bloblangDefinitionAt(src *bloblangSource, lineIdx, char int) []location {
+	lineStr := src.lines[lineIdx]
+	line := []rune(lineStr)
+
+	for _, imp := range bloblangImports(src.lines) {
+		if imp.line == lineIdx && char >= imp.start && char <= imp.end {
+			path := src.resolveImport(imp.path)
+			if _, err := os.Stat(path); err != nil && s.getDocument(pathToURI(path)) == nil {
+				return nil
+			}
+			return []location{{URI: pathToURI(path)}}
+		}
+	}
+
+	for _, m := range applyRegexp.FindAllStringSubmatchIndex(lineStr, -1) {
+		start, end := len([]rune(lineStr[:m[2]])), len([]rune(lineStr[:m[3]]))
+		if char < start || char > end {
+			continue
+		}
+		name := lineStr[m[2]:m[3]]
+		ns := ""
+		if i := strings.Index(name, "."); i >= 0 {
+			ns, name = name[:i], name[i+1:]
+		}
+		return s.findBloblangDefinitions(src, mapDefRegexp, ns, name, map[string]struct{}{})
+	}
+
+	if char >= len(line) {
+		return nil
+	}
+	start, end := identAt(line, char)
+	if start == end {
+		return nil
+	}
+	rest := strings.TrimLeft(string(line[end:]), " \t")
+	if !strings.HasPrefix(rest, "(") {
+		return nil
+	}
+
+	// Methods are also preceded by a dot, but will not match the namespace of
+	// an import and therefore yield no definitions.
+	ns, _ := namespaceBefore(line, start)
+	return s.findBloblangDefinitions(src, funcDefRegexp, ns, string(line[start:end]), map[string]struct{}{})
+}
+
+//------------------------------------------------------------------------------
+
+// bloblangSource is the text of a Bloblang mapping along with the directory
+// that relative imports are resolved from.
+type bloblangSource struct {
+	uri   string
+	lines []string
+	dir   string
+}
+
+type bloblangDef struct {
+	name   string
+	params string
+	line   int
+	start  int
+}
+
+func (b *bloblangSource) resolveImport(path string) string {
+	if filepath.IsAbs(path) || b.dir == "" {
+		return path
+	}
+	return filepath.Join(b.dir, path)
+}
+
+func (b *bloblangSource) definitions(re *regexp.Regexp) []bloblangDef {
+	var defs []bloblangDef
+	for i, l := range b.lines {
+		m := re.FindStringSubmatchIndex(l)
+		if m == nil {
+			continue
+		}
+		def := bloblangDef{
+			name:  l[m[2]:m[3]],
+			line:  i,
+			start: len([]rune(l[:m[2]])),
+		}
+		if len(m) > 5 && m[4] >= 0 {
+			def.params = strings.TrimSpace(l[m[4]:m[5]])
+		}
+		defs = append(defs, def)
+	}
+	sort.SliceStable(defs, func(i, j int) bool {
+		return defs[i].line < defs[j].line
+	})
+	return defs
+}
+
+func (s *Server) loadBloblangSource(path string) *bloblangSource {
+	uri := pathToURI(path)
+	if d := s.getDocument(uri); d != nil {
+		return &bloblangSource{uri: uri, lines: d.lines, dir: filepath.Dir(path)}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return &bloblangSource{
+		uri:   uri,
+		lines: strings.Split(string(content), "\n"),
+		dir:   filepath.Dir(path),
+	}
+}
+
+// findBloblangDefinitions searches for the definitions of a map or function
+// within a mapping and the files it imports. When a namespace is provided only
+// the file imported under that namespace is searched.
+func (s *Server) findBloblangDefinitions(src *bloblangSource, re *regexp.Regexp, ns, name string, visited map[string]struct{}) []location {
+	if _, seen := visited[src.uri]; seen {
+		return nil
+	}
+	visited[src.uri] = struct{}{}
+
+	if ns == "" {
+		for _, def := range src.definitions(re) {
+			if def.name == name {
+				l := strings.TrimSuffix(src.lines[def.line], "\r")
+				start := utf16Offset(l, def.start)
+				return []location{{
+					URI: src.uri,
+					Range: lspRange{
+						Start: position{Line: def.line, Character: start},
+						End:   position{Line: def.line, Character: start + len(name)},
+					},
+				}}
+			}
+		}
+	}
+
+	for _, imp := range bloblangImports(src.lines) {
+		if imp.namespace != ns {
+			continue
+		}
+		impSrc := s.loadBloblangSource(src.resolveImport(imp.path))
+		if impSrc == nil {
+			continue
+		}
+		if locs := s.findBloblangDefinitions(impSrc, re, "", name, visited); len(locs) > 0 {
+			return locs
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Server) bloblangSourceOf(d *document) *bloblangSource {
+	src := &bloblangSource{uri: d.uri, lines: d.lines}
+	if path := uriToPath(d.uri); path != "" {
+		src.dir = filepath.Dir(path)
+	}
+	return src
+}
+
+func (s *Server) bloblangCompletion(d *document, pos position) []completionItem {
+	if pos.Line >= len(d.lines) {
+		return nil
+	}
+	return s.bloblangCompletionAt(s.bloblangSourceOf(d), pos.Line, runeOffset(d.line(pos.Line), pos.Character))
+}
+
+func (s *Server) bloblangHover(d *document, pos position) *hover {
+	return bloblangHoverAt(d, pos.Line, runeOffset(d.line(pos.Line), pos.Character))
+}
+
+func (s *Server) bloblangDefinition(d *document, pos position) []location {
+	if pos.Line >= len(d.lines) {
+		return nil
+	}
+	return s.bloblangDefinitionAt(s.bloblangSourceOf(d), pos.Line, runeOffset(d.line(pos.Line), pos.Character))
+}
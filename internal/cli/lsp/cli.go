@@ -0,0 +1,30 @@
+package lsp
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+// CliCommand is a cli.Command definition for running a language server.
+func CliCommand(version string) *cli.Command {
+	return &cli.Command{
+		Name:  "lsp",
+		Usage: "Run a language server for Benthos configs and Bloblang mappings",
+		Description: `
+Runs a Language Server Protocol server over stdin and stdout, which provides
+editors with completion, hover documentation, diagnostics and go-to-definition
+for YAML config files and Bloblang (.blobl) files:
+
+  benthos lsp
+
+Diagnostics are provided by the same linting rules as the lint subcommand. The
+server is intended to be launched by an editor rather than run directly.
+
+EXPERIMENTAL: This subcommand is experimental and therefore is subject to
+change outside of major version releases.`[1:],
+		Action: func(c *cli.Context) error {
+			return NewServer(os.Stdin, os.Stdout, version).Serve()
+		},
+	}
+}
@@ -0,0 +1,283 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// Server is a language server for Benthos config files and Bloblang mappings.
+type Server struct {
+	conn     *conn
+	lintConf docs.LintConfig
+	version  string
+
+	docsMut sync.Mutex
+	docs    map[string]*document
+
+	shutdown bool
+}
+
+// NewServer creates a language server that reads requests from r and writes
+// responses and notifications to w.
+func NewServer(r io.Reader, w io.Writer, version string) *Server {
+	return &Server{
+		conn:     newConn(r, w),
+		lintConf: docs.NewLintConfig(bundle.GlobalEnvironment),
+		version:  version,
+		docs:     map[string]*document{},
+	}
+}
+
+// errExit is returned by a handler when the client has requested that the
+// server exits.
+var errExit = errors.New("exit requested")
+
+// Serve handles messages until the client requests an exit or the input is
+// closed.
+func (s *Server) Serve() error {
+	for {
+		msg, err := s.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			var rErr *rpcError
+			if errors.As(err, &rErr) {
+				_ = s.conn.write(&rpcMessage{ID: nullID(), Error: rErr})
+				continue
+			}
+			return err
+		}
+
+		result, err := s.handle(msg)
+		if errors.Is(err, errExit) {
+			if !s.shutdown {
+				return errors.New("exit requested before shutdown")
+			}
+			return nil
+		}
+
+		// Notifications do not receive a response.
+		if msg.ID == nil {
+			continue
+		}
+
+		resp := &rpcMessage{ID: msg.ID, Result: result}
+		if err != nil {
+			var rErr *rpcError
+			if !errors.As(err, &rErr) {
+				rErr = &rpcError{Code: codeInternalError, Message: err.Error()}
+			}
+			resp.Result, resp.Error = nil, rErr
+		} else if result == nil {
+			resp.Result = json.RawMessage("null")
+		}
+		if err := s.conn.write(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func nullID() *json.RawMessage {
+	id := json.RawMessage("null")
+	return &id
+}
+
+func (s *Server) handle(msg *rpcMessage) (any, error) {
+	if msg.Method == "" {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "missing method"}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return s.initialize(), nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "exit":
+		return nil, errExit
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := decodeParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.setDocument(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := decodeParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// Documents are synchronised in full, and therefore the last change
+		// contains the entire document.
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return nil, s.setDocument(params.TextDocument.URI, text)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := decodeParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		s.docsMut.Lock()
+		delete(s.docs, params.TextDocument.URI)
+		s.docsMut.Unlock()
+		return nil, s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []diagnostic{},
+		})
+	case "textDocument/completion":
+		return s.withDocument(msg.Params, func(d *document, pos position) (any, error) {
+			items := []completionItem{}
+			if isBloblang(d.uri) {
+				items = append(items, s.bloblangCompletion(d, pos)...)
+			} else {
+				items = append(items, s.yamlCompletion(d, pos)...)
+			}
+			return completionList{Items: items}, nil
+		})
+	case "textDocument/hover":
+		return s.withDocument(msg.Params, func(d *document, pos position) (any, error) {
+			var h *hover
+			if isBloblang(d.uri) {
+				h = s.bloblangHover(d, pos)
+			} else {
+				h = s.yamlHover(d, pos)
+			}
+			if h == nil {
+				return nil, nil
+			}
+			return h, nil
+		})
+	case "textDocument/definition":
+		return s.withDocument(msg.Params, func(d *document, pos position) (any, error) {
+			var locs []location
+			if isBloblang(d.uri) {
+				locs = s.bloblangDefinition(d, pos)
+			} else {
+				locs = s.yamlDefinition(d, pos)
+			}
+			if len(locs) == 0 {
+				return nil, nil
+			}
+			return locs, nil
+		})
+	}
+
+	if strings.HasPrefix(msg.Method, "$/") || msg.ID == nil {
+		// Notifications that we do not support can be safely ignored.
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %v", msg.Method)}
+}
+
+func decodeParams(raw json.RawMessage, v any) error {
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) initialize() any {
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync": map[string]any{
+				"openClose": true,
+				"change":    1, // Full
+			},
+			"completionProvider": map[string]any{
+				"triggerCharacters": []string{".", ":", " "},
+			},
+			"hoverProvider":      true,
+			"definitionProvider": true,
+		},
+		"serverInfo": map[string]any{
+			"name":    "benthos",
+			"version": s.version,
+		},
+	}
+}
+
+func (s *Server) withDocument(raw json.RawMessage, fn func(d *document, pos position) (any, error)) (any, error) {
+	var params textDocumentPositionParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	d := s.getDocument(params.TextDocument.URI)
+	if d == nil {
+		return nil, nil
+	}
+	return fn(d, params.Position)
+}
+
+func (s *Server) getDocument(uri string) *document {
+	s.docsMut.Lock()
+	defer s.docsMut.Unlock()
+	return s.docs[uri]
+}
+
+func (s *Server) openDocuments() []*document {
+	s.docsMut.Lock()
+	defer s.docsMut.Unlock()
+
+	docs := make([]*document, 0, len(s.docs))
+	for _, d := range s.docs {
+		docs = append(docs, d)
+	}
+	return docs
+}
+
+func (s *Server) setDocument(uri, text string) error {
+	d := newDocument(uri, text)
+
+	s.docsMut.Lock()
+	s.docs[uri] = d
+	s.docsMut.Unlock()
+
+	var diags []diagnostic
+	if isBloblang(uri) {
+		diags = bloblangDiagnostics(d)
+	} else {
+		diags = s.yamlDiagnostics(d)
+	}
+	if diags == nil {
+		diags = []diagnostic{}
+	}
+	return s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diags,
+	})
+}
+
+//------------------------------------------------------------------------------
+
+func isBloblang(uri string) bool {
+	return strings.HasSuffix(uri, ".blobl")
+}
+
+// uriToPath converts a file URI into a file path, an empty string is returned
+// for URIs of other schemes.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// pathToURI converts a file path into a file URI.
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
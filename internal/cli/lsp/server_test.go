@@ -0,0 +1,421 @@
+package lsp_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/lsp"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/pure"
+)
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+type hover struct {
+	Contents struct {
+		Value string `json:"value"`
+	} `json:"contents"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  any              `json:"params,omitempty"`
+}
+
+type response struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+	Result json.RawMessage  `json:"result"`
+	Error  *rpcError        `json:"error"`
+}
+
+type testClient struct {
+	t      *testing.T
+	r      *textproto.Reader
+	w      io.Writer
+	nextID int
+}
+
+func startServer(t *testing.T) (*testClient, <-chan error) {
+	t.Helper()
+
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- lsp.NewServer(serverR, serverW, "test").Serve()
+		_ = serverW.Close()
+	}()
+	t.Cleanup(func() {
+		_ = clientW.Close()
+	})
+
+	return &testClient{
+		t: t,
+		r: textproto.NewReader(bufio.NewReader(clientR)),
+		w: clientW,
+	}, done
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+
+	c, _ := startServer(t)
+	c.request("initialize", map[string]any{})
+	c.notify("initialized", map[string]any{})
+	return c
+}
+
+func (c *testClient) write(msg message) {
+	c.t.Helper()
+
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	require.NoError(c.t, err)
+
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	require.NoError(c.t, err)
+}
+
+func (c *testClient) read() *response {
+	c.t.Helper()
+
+	header, err := c.r.ReadMIMEHeader()
+	require.NoError(c.t, err)
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	require.NoError(c.t, err)
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(c.r.R, body)
+	require.NoError(c.t, err)
+
+	var res response
+	require.NoError(c.t, json.Unmarshal(body, &res))
+	return &res
+}
+
+func (c *testClient) notify(method string, params any) {
+	c.t.Helper()
+	c.write(message{Method: method, Params: params})
+}
+
+func (c *testClient) requestRaw(method string, params any) *response {
+	c.t.Helper()
+
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	c.write(message{ID: &id, Method: method, Params: params})
+
+	for {
+		res := c.read()
+		if res.ID == nil {
+			continue
+		}
+		require.Equal(c.t, string(id), string(*res.ID))
+		return res
+	}
+}
+
+func (c *testClient) request(method string, params any) json.RawMessage {
+	c.t.Helper()
+
+	res := c.requestRaw(method, params)
+	require.Nil(c.t, res.Error)
+	return res.Result
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+func (c *testClient) open(uri, text string) []diagnostic {
+	c.t.Helper()
+	c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": "", "version": 1, "text": text},
+	})
+
+	msg := c.read()
+	require.Equal(c.t, "textDocument/publishDiagnostics", msg.Method)
+
+	var params struct {
+		URI         string       `json:"uri"`
+		Diagnostics []diagnostic `json:"diagnostics"`
+	}
+	require.NoError(c.t, json.Unmarshal(msg.Params, &params))
+	assert.Equal(c.t, uri, params.URI)
+	return params.Diagnostics
+}
+
+func (c *testClient) positionRequest(method, uri string, line, char int, v any) {
+	c.t.Helper()
+	res := c.request(method, map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     position{Line: line, Character: char},
+	})
+	require.NoError(c.t, json.Unmarshal(res, v))
+}
+
+func (c *testClient) completionLabels(uri string, line, char int) []string {
+	c.t.Helper()
+	var list struct {
+		Items []struct {
+			Label string `json:"label"`
+		} `json:"items"`
+	}
+	c.positionRequest("textDocument/completion", uri, line, char, &list)
+	labels := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		labels = append(labels, item.Label)
+	}
+	return labels
+}
+
+func TestServerLifecycle(t *testing.T) {
+	c, done := startServer(t)
+
+	var res map[string]any
+	require.NoError(t, json.Unmarshal(c.request("initialize", map[string]any{}), &res))
+	assert.Contains(t, res, "capabilities")
+
+	_ = c.request("shutdown", nil)
+	c.notify("exit", nil)
+	require.NoError(t, <-done)
+}
+
+func TestServerUnknownMethod(t *testing.T) {
+	c := newTestClient(t)
+
+	res := c.requestRaw("workspace/nope", nil)
+	require.NotNil(t, res.Error)
+	assert.Equal(t, -32601, res.Error.Code)
+}
+
+func TestServerYAMLDiagnostics(t *testing.T) {
+	c := newTestClient(t)
+
+	diags := c.open("file:///tmp/config.yaml", `
+input:
+  generate:
+    mapping: 'root = this.foo.length().uppercase()'
+    nope: true
+output:
+  drop: {}
+`[1:])
+	require.Len(t, diags, 2)
+
+	assert.Equal(t, 2, diags[0].Range.Start.Line)
+	assert.Equal(t, "method uppercase expects a string or bytes value, got number", diags[0].Message)
+
+	assert.Equal(t, 3, diags[1].Range.Start.Line)
+	assert.Equal(t, 4, diags[1].Range.Start.Character)
+	assert.Equal(t, "field nope not recognised", diags[1].Message)
+
+	diags = c.open("file:///tmp/broken.yaml", "input:\n  generate: {\n")
+	require.Len(t, diags, 1)
+	assert.Equal(t, 1, diags[0].Severity)
+}
+
+func TestServerBloblangDiagnostics(t *testing.T) {
+	c := newTestClient(t)
+
+	diags := c.open("file:///tmp/mapping.blobl", "root.a = this.a\nroot.b = this.b.length().uppercase()\n")
+	require.Len(t, diags, 1)
+	assert.Equal(t, 1, diags[0].Range.Start.Line)
+	assert.Equal(t, "method uppercase expects a string or bytes value, got number", diags[0].Message)
+
+	diags = c.open("file:///tmp/mapping.blobl", "root.a = this.a\nroot.b = #\n")
+	require.Len(t, diags, 1)
+	assert.Equal(t, 1, diags[0].Range.Start.Line)
+	assert.Equal(t, 9, diags[0].Range.Start.Character)
+
+	diags = c.open("file:///tmp/mapping.blobl", "root = this\n")
+	assert.Empty(t, diags)
+}
+
+func TestServerYAMLCompletion(t *testing.T) {
+	c := newTestClient(t)
+
+	uri := "file:///tmp/config.yaml"
+	c.open(uri, "input:\n  gen\n")
+	labels := c.completionLabels(uri, 1, 5)
+	assert.Contains(t, labels, "generate")
+	assert.Contains(t, labels, "processors")
+	assert.NotContains(t, labels, "mapping")
+
+	c.open(uri, `
+input:
+  generate:
+    mapping: 'root = this'
+pipeline:
+  processors:
+    - mapping: 'root = this'
+      
+http:
+  enabled: 
+`[1:])
+
+	labels = c.completionLabels(uri, 6, 6)
+	assert.Contains(t, labels, "label")
+	assert.Contains(t, labels, "mapping")
+	assert.NotContains(t, labels, "generate")
+
+	labels = c.completionLabels(uri, 4, 2)
+	assert.Contains(t, labels, "processors")
+	assert.Contains(t, labels, "threads")
+
+	labels = c.completionLabels(uri, 2, 4)
+	assert.Contains(t, labels, "mapping")
+	assert.Contains(t, labels, "interval")
+
+	labels = c.completionLabels(uri, 8, 11)
+	assert.Equal(t, []string{"true", "false"}, labels)
+
+	labels = c.completionLabels(uri, 2, 20)
+	assert.Contains(t, labels, "now")
+}
+
+func TestServerYAMLHover(t *testing.T) {
+	c := newTestClient(t)
+
+	uri := "file:///tmp/config.yaml"
+	c.open(uri, `
+input:
+  generate:
+    mapping: 'root = this.uppercase()'
+`[1:])
+
+	var h hover
+	c.positionRequest("textDocument/hover", uri, 1, 4, &h)
+	assert.Contains(t, h.Contents.Value, "**generate** input")
+
+	h = hover{}
+	c.positionRequest("textDocument/hover", uri, 2, 6, &h)
+	assert.Contains(t, h.Contents.Value, "**mapping** `string`")
+
+	h = hover{}
+	c.positionRequest("textDocument/hover", uri, 2, 30, &h)
+	assert.Contains(t, h.Contents.Value, ".uppercase()")
+}
+
+func TestServerYAMLResourceDefinition(t *testing.T) {
+	c := newTestClient(t)
+
+	uri := "file:///tmp/config.yaml"
+	c.open(uri, `
+pipeline:
+  processors:
+    - resource: foo
+processor_resources:
+  - label: foo
+    mapping: 'root = this'
+`[1:])
+
+	var locs []location
+	c.positionRequest("textDocument/definition", uri, 2, 17, &locs)
+	require.Len(t, locs, 1)
+	assert.Equal(t, uri, locs[0].URI)
+	assert.Equal(t, lspRange{
+		Start: position{Line: 4, Character: 11},
+		End:   position{Line: 4, Character: 14},
+	}, locs[0].Range)
+}
+
+func TestServerBloblang(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.blobl"), []byte(`
+map thing {
+  root = this
+}
+
+func shout(v) {
+  root = v.uppercase()
+}
+`[1:]), 0o644))
+
+	mainPath := filepath.Join(dir, "main.blobl")
+	mainURI := pathToURI(mainPath)
+	libURI := pathToURI(filepath.Join(dir, "lib.blobl"))
+
+	c := newTestClient(t)
+	diags := c.open(mainURI, `
+import "./lib.blobl" as lib
+root.a = this.apply("lib.thing")
+root.b = lib.shout(this.b)
+root.c = this.c.
+`[1:])
+	require.Len(t, diags, 1)
+
+	labels := c.completionLabels(mainURI, 3, 16)
+	assert.Contains(t, labels, "uppercase")
+	assert.NotContains(t, labels, "now")
+
+	labels = c.completionLabels(mainURI, 2, 13)
+	assert.Equal(t, []string{"shout"}, labels)
+
+	labels = c.completionLabels(mainURI, 2, 9)
+	assert.Contains(t, labels, "now")
+	assert.Contains(t, labels, "root")
+
+	var h hover
+	c.positionRequest("textDocument/hover", mainURI, 1, 15, &h)
+	assert.Contains(t, h.Contents.Value, ".apply(mapping)")
+
+	var locs []location
+	c.positionRequest("textDocument/definition", mainURI, 1, 24, &locs)
+	require.Len(t, locs, 1)
+	assert.Equal(t, libURI, locs[0].URI)
+	assert.Equal(t, 0, locs[0].Range.Start.Line)
+	assert.Equal(t, 4, locs[0].Range.Start.Character)
+
+	locs = nil
+	c.positionRequest("textDocument/definition", mainURI, 2, 15, &locs)
+	require.Len(t, locs, 1)
+	assert.Equal(t, libURI, locs[0].URI)
+	assert.Equal(t, 4, locs[0].Range.Start.Line)
+	assert.Equal(t, 5, locs[0].Range.Start.Character)
+
+	locs = nil
+	c.positionRequest("textDocument/definition", mainURI, 0, 12, &locs)
+	require.Len(t, locs, 1)
+	assert.Equal(t, libURI, locs[0].URI)
+}
@@ -0,0 +1,604 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// nodeCtx describes what the keys and values of a YAML node are expected to
+// be according to the config spec.
+type nodeCtx struct {
+	fields  docs.FieldSpecs // An object with known fields
+	cType   docs.Type       // A component of a given type
+	mapOf   *docs.FieldSpec // An object of arbitrary keys
+	arrayOf *docs.FieldSpec // An array
+}
+
+func fieldCtx(f docs.FieldSpec) nodeCtx {
+	switch f.Kind {
+	case docs.Kind2DArray:
+		inner := f.Array()
+		return nodeCtx{arrayOf: &inner}
+	case docs.KindArray:
+		inner := f.Scalar()
+		return nodeCtx{arrayOf: &inner}
+	case docs.KindMap:
+		inner := f.Scalar()
+		return nodeCtx{mapOf: &inner}
+	}
+	if t, isCore := f.Type.IsCoreComponent(); isCore {
+		return nodeCtx{cType: t}
+	}
+	if len(f.Children) > 0 {
+		return nodeCtx{fields: f.Children}
+	}
+	return nodeCtx{}
+}
+
+// keyInfo describes a key of a YAML mapping.
+type keyInfo struct {
+	node      *yaml.Node
+	value     *yaml.Node
+	parent    nodeCtx
+	field     *docs.FieldSpec
+	component *docs.ComponentSpec
+	valueCtx  nodeCtx
+}
+
+// textSpan is an inclusive range of zero based lines, where the first line
+// begins at a rune offset.
+type textSpan struct {
+	start, startChar, end int
+}
+
+// yamlAnalysis is the result of walking a YAML config alongside the config
+// spec in order to determine the documentation of each key.
+type yamlAnalysis struct {
+	prov docs.Provider
+	keys []*keyInfo
+
+	// The lines of scalar values that are Bloblang mappings or interpolated
+	// strings.
+	bloblangSpans []textSpan
+}
+
+func analyseYAML(prov docs.Provider, text string) (*yamlAnalysis, error) {
+	node, err := docs.UnmarshalYAML([]byte(text))
+	if err != nil {
+		return nil, err
+	}
+	a := &yamlAnalysis{prov: prov}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	a.walk(nodeCtx{fields: config.Spec()}, nil, node)
+	sort.SliceStable(a.keys, func(i, j int) bool {
+		if a.keys[i].node.Line == a.keys[j].node.Line {
+			return a.keys[i].node.Column < a.keys[j].node.Column
+		}
+		return a.keys[i].node.Line < a.keys[j].node.Line
+	})
+	return a, nil
+}
+
+func (a *yamlAnalysis) walk(ctx nodeCtx, field *docs.FieldSpec, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		if ctx.arrayOf != nil {
+			for _, n := range node.Content {
+				a.walk(fieldCtx(*ctx.arrayOf), ctx.arrayOf, n)
+			}
+		}
+	case yaml.ScalarNode:
+		if field != nil && (field.Bloblang || field.Interpolated) {
+			span := textSpan{start: node.Line - 1, startChar: node.Column - 1}
+			if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+				span.start, span.startChar = span.start+1, 0
+			}
+			span.end = span.start + strings.Count(strings.TrimRight(node.Value, "\n"), "\n")
+			a.bloblangSpans = append(a.bloblangSpans, span)
+		}
+	case yaml.MappingNode:
+		var inferred *docs.ComponentSpec
+		var reserved map[string]docs.FieldSpec
+		if ctx.cType != "" {
+			reserved = docs.ReservedFieldsByType(ctx.cType)
+			if _, spec, err := docs.GetInferenceCandidateFromYAML(a.prov, ctx.cType, node); err == nil {
+				inferred = &spec
+			}
+		}
+
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			info := &keyInfo{node: key, value: value, parent: ctx}
+
+			switch {
+			case ctx.fields != nil:
+				for _, f := range ctx.fields {
+					if f.Name == key.Value {
+						f := f
+						info.field = &f
+						info.valueCtx = fieldCtx(f)
+						break
+					}
+				}
+			case ctx.cType != "":
+				if f, exists := reserved[key.Value]; exists && key.Value != "plugin" {
+					info.field = &f
+					info.valueCtx = fieldCtx(f)
+				} else if inferred != nil && (key.Value == inferred.Name || key.Value == "plugin") {
+					info.component = inferred
+					info.valueCtx = fieldCtx(inferred.Config)
+				} else if spec, exists := a.prov.GetDocs(key.Value, ctx.cType); exists {
+					info.component = &spec
+					info.valueCtx = fieldCtx(spec.Config)
+				}
+			case ctx.mapOf != nil:
+				info.field = ctx.mapOf
+				info.valueCtx = fieldCtx(*ctx.mapOf)
+			}
+
+			a.keys = append(a.keys, info)
+
+			valueField := info.field
+			if info.component != nil {
+				valueField = &info.component.Config
+			}
+			a.walk(info.valueCtx, valueField, value)
+		}
+	}
+}
+
+// keyAt returns the key that covers a zero based line and rune offset.
+func (a *yamlAnalysis) keyAt(line, char int) *keyInfo {
+	for _, k := range a.keys {
+		if k.node.Line-1 != line {
+			continue
+		}
+		start := k.node.Column - 1
+		if char >= start && char <= start+len([]rune(k.node.Value)) {
+			return k
+		}
+	}
+	return nil
+}
+
+// valueAt returns the key of a single line scalar value that covers a zero
+// based line and rune offset.
+func (a *yamlAnalysis) valueAt(line, char int) *keyInfo {
+	for _, k := range a.keys {
+		v := k.value
+		if v.Kind != yaml.ScalarNode || v.Line-1 != line {
+			continue
+		}
+		start := v.Column - 1
+		if char >= start && char <= start+len([]rune(v.Value))+2 {
+			return k
+		}
+	}
+	return nil
+}
+
+// contextAt determines the context of a key that would be written at a zero
+// based line and rune offset, based on the keys that precede it.
+func (a *yamlAnalysis) contextAt(line, indent int) nodeCtx {
+	for i := len(a.keys) - 1; i >= 0; i-- {
+		k := a.keys[i]
+		if k.node.Line-1 >= line {
+			continue
+		}
+		switch col := k.node.Column - 1; {
+		case col == indent:
+			return k.parent
+		case col < indent:
+			ctx := k.valueCtx
+			if ctx.arrayOf != nil {
+				ctx = fieldCtx(*ctx.arrayOf)
+			}
+			return ctx
+		}
+	}
+	return nodeCtx{fields: config.Spec()}
+}
+
+func (a *yamlAnalysis) inBloblang(line, char int) bool {
+	for _, s := range a.bloblangSpans {
+		if (line > s.start || (line == s.start && char >= s.startChar)) && line <= s.end {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+func componentDocs(t docs.Type) []docs.ComponentSpec {
+	switch t {
+	case docs.TypeBuffer:
+		return bundle.AllBuffers.Docs()
+	case docs.TypeCache:
+		return bundle.AllCaches.Docs()
+	case docs.TypeInput:
+		return bundle.AllInputs.Docs()
+	case docs.TypeMetrics:
+		return bundle.AllMetrics.Docs()
+	case docs.TypeOutput:
+		return bundle.AllOutputs.Docs()
+	case docs.TypeProcessor:
+		return bundle.AllProcessors.Docs()
+	case docs.TypeRateLimit:
+		return bundle.AllRateLimits.Docs()
+	case docs.TypeTracer:
+		return bundle.AllTracers.Docs()
+	case docs.TypeScanner:
+		return bundle.AllScanners.Docs()
+	}
+	return nil
+}
+
+func fieldDocs(f docs.FieldSpec) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%v** `%v`", f.Name, fieldTypeString(f))
+	if desc := strings.TrimSpace(f.Description); desc != "" {
+		fmt.Fprintf(&b, "\n\n%v", desc)
+	}
+	if len(f.AnnotatedOptions) > 0 {
+		b.WriteString("\n\nOptions:\n")
+		for _, o := range f.AnnotatedOptions {
+			fmt.Fprintf(&b, "\n- `%v`: %v", o[0], o[1])
+		}
+	} else if len(f.Options) > 0 {
+		fmt.Fprintf(&b, "\n\nOptions: `%v`", strings.Join(f.Options, "`, `"))
+	}
+	if f.Default != nil {
+		if d, err := yaml.Marshal(*f.Default); err == nil {
+			fmt.Fprintf(&b, "\n\nDefault: `%v`", strings.TrimSpace(string(d)))
+		}
+	}
+	return b.String()
+}
+
+func fieldTypeString(f docs.FieldSpec) string {
+	switch f.Kind {
+	case docs.KindArray:
+		return "array of " + string(f.Type)
+	case docs.Kind2DArray:
+		return "two-dimensional array of " + string(f.Type)
+	case docs.KindMap:
+		return "map of " + string(f.Type)
+	}
+	return string(f.Type)
+}
+
+func componentSpecDocs(c docs.ComponentSpec) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%v** %v (%v)", c.Name, c.Type, c.Status)
+	if summary := strings.TrimSpace(c.Summary); summary != "" {
+		fmt.Fprintf(&b, "\n\n%v", summary)
+	}
+	if desc := strings.TrimSpace(c.Description); desc != "" {
+		fmt.Fprintf(&b, "\n\n%v", desc)
+	}
+	return b.String()
+}
+
+//------------------------------------------------------------------------------
+
+var yamlErrLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+func (s *Server) yamlDiagnostics(d *document) []diagnostic {
+	rawBytes, err := config.ReplaceEnvVariables([]byte(d.text), os.LookupEnv)
+	if err != nil {
+		var errEnvMissing *config.ErrMissingEnvVars
+		if !errors.As(err, &errEnvMissing) {
+			rawBytes = []byte(d.text)
+		} else {
+			rawBytes = errEnvMissing.BestAttempt
+		}
+	}
+
+	lints, err := config.LintYAMLBytes(s.lintConf, rawBytes)
+	if err != nil {
+		line := 0
+		if m := yamlErrLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			line--
+		}
+		return []diagnostic{{
+			Range:    lineRange(d, line, 0),
+			Severity: severityError,
+			Source:   "benthos",
+			Message:  err.Error(),
+		}}
+	}
+
+	var diags []diagnostic
+	for _, l := range lints {
+		line := max(l.Line-1, 0)
+		// Lints without a specific column are reported from the beginning of
+		// the content of the line.
+		lineStr := d.line(line)
+		col := max(l.Column-1, len([]rune(lineStr))-len([]rune(strings.TrimLeft(lineStr, " \t"))))
+		severity := severityWarning
+		if l.Level == docs.LintError {
+			severity = severityError
+		}
+		diags = append(diags, diagnostic{
+			Range:    lineRange(d, line, col),
+			Severity: severity,
+			Source:   "benthos",
+			Message:  l.What,
+		})
+	}
+	return diags
+}
+
+var (
+	yamlKeyPrefixRegexp   = regexp.MustCompile(`^((?:\s*-)*\s*)([a-zA-Z0-9_]*)$`)
+	yamlValuePrefixRegexp = regexp.MustCompile(`^((?:\s*-)*\s*)([a-zA-Z0-9_]+):\s*([^\s"']*)$`)
+)
+
+// yamlCompletion suggests keys according to the context of the indentation at
+// the cursor, or values for fields with a known set of options.
+func (s *Server) yamlCompletion(d *document, pos position) []completionItem {
+	if pos.Line >= len(d.lines) {
+		return nil
+	}
+	lineStr := d.line(pos.Line)
+	lineRunes := []rune(lineStr)
+	char := min(runeOffset(lineStr, pos.Character), len(lineRunes))
+	prefix := string(lineRunes[:char])
+
+	if a, err := analyseYAML(s.lintConf.DocsProvider, d.text); err == nil && a.inBloblang(pos.Line, char) {
+		return s.bloblangCompletionAt(&bloblangSource{uri: d.uri, lines: d.lines}, pos.Line, char)
+	}
+
+	// The line being written is replaced so that the rest of the document can
+	// be parsed, even when the line itself is incomplete.
+	withLine := func(replacement string) *yamlAnalysis {
+		lines := append([]string{}, d.lines...)
+		lines[pos.Line] = replacement
+		a, err := analyseYAML(s.lintConf.DocsProvider, strings.Join(lines, "\n"))
+		if err != nil {
+			return nil
+		}
+		return a
+	}
+
+	if m := yamlValuePrefixRegexp.FindStringSubmatch(prefix); m != nil {
+		a := withLine(m[1] + m[2] + ":")
+		if a == nil {
+			return nil
+		}
+		k := a.keyAt(pos.Line, len([]rune(m[1])))
+		if k == nil {
+			return nil
+		}
+		return valueCompletion(k)
+	}
+
+	m := yamlKeyPrefixRegexp.FindStringSubmatch(prefix)
+	if m == nil {
+		return nil
+	}
+	a := withLine(m[1])
+	if a == nil {
+		return nil
+	}
+	return keyCompletion(a.contextAt(pos.Line, len([]rune(m[1]))))
+}
+
+func keyCompletion(ctx nodeCtx) []completionItem {
+	var items []completionItem
+	fieldItem := func(f docs.FieldSpec) completionItem {
+		return completionItem{
+			Label:         f.Name,
+			Kind:          completionKindField,
+			Detail:        fieldTypeString(f),
+			Documentation: markdown(fieldDocs(f)),
+			InsertText:    f.Name + ": ",
+			Deprecated:    f.IsDeprecated,
+		}
+	}
+
+	switch {
+	case ctx.fields != nil:
+		for _, f := range ctx.fields {
+			if f.IsDeprecated {
+				continue
+			}
+			items = append(items, fieldItem(f))
+		}
+	case ctx.cType != "":
+		for _, c := range componentDocs(ctx.cType) {
+			if c.Status == docs.StatusDeprecated {
+				continue
+			}
+			items = append(items, completionItem{
+				Label:         c.Name,
+				Kind:          completionKindClass,
+				Detail:        strings.TrimSpace(c.Summary),
+				Documentation: markdown(componentSpecDocs(c)),
+				InsertText:    c.Name + ":",
+			})
+		}
+		reserved := docs.ReservedFieldsByType(ctx.cType)
+		names := make([]string, 0, len(reserved))
+		for k := range reserved {
+			if k != "type" && k != "plugin" {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			f := reserved[k]
+			f.Name = k
+			items = append(items, fieldItem(f))
+		}
+	}
+	return items
+}
+
+func valueCompletion(k *keyInfo) []completionItem {
+	var items []completionItem
+	if k.field == nil {
+		return nil
+	}
+	if k.field.Name == "type" && k.parent.cType != "" {
+		for _, c := range componentDocs(k.parent.cType) {
+			items = append(items, completionItem{
+				Label:         c.Name,
+				Kind:          completionKindValue,
+				Detail:        strings.TrimSpace(c.Summary),
+				Documentation: markdown(componentSpecDocs(c)),
+			})
+		}
+		return items
+	}
+	for _, o := range k.field.AnnotatedOptions {
+		items = append(items, completionItem{
+			Label:         o[0],
+			Kind:          completionKindValue,
+			Documentation: markdown(o[1]),
+		})
+	}
+	for _, o := range k.field.Options {
+		items = append(items, completionItem{Label: o, Kind: completionKindValue})
+	}
+	if len(items) == 0 && k.field.Type == docs.FieldTypeBool {
+		for _, o := range []string{"true", "false"} {
+			items = append(items, completionItem{Label: o, Kind: completionKindValue})
+		}
+	}
+	return items
+}
+
+// yamlHover provides documentation for the field or component key at the
+// cursor, or the functions and methods of Bloblang fields.
+func (s *Server) yamlHover(d *document, pos position) *hover {
+	a, err := analyseYAML(s.lintConf.DocsProvider, d.text)
+	if err != nil {
+		return nil
+	}
+	char := runeOffset(d.line(pos.Line), pos.Character)
+
+	if a.inBloblang(pos.Line, char) {
+		return bloblangHoverAt(d, pos.Line, char)
+	}
+
+	var contents string
+	var r lspRange
+	if k := a.keyAt(pos.Line, char); k != nil {
+		switch {
+		case k.component != nil:
+			contents = componentSpecDocs(*k.component)
+		case k.field != nil:
+			contents = fieldDocs(*k.field)
+		}
+		start := k.node.Column - 1
+		r = wordRange(d, pos.Line, start, start+len([]rune(k.node.Value)))
+	} else if k := a.valueAt(pos.Line, char); k != nil && k.field != nil && k.field.Name == "type" && k.parent.cType != "" {
+		if spec, exists := a.prov.GetDocs(k.value.Value, k.parent.cType); exists {
+			contents = componentSpecDocs(spec)
+		}
+		start := k.value.Column - 1
+		r = wordRange(d, pos.Line, start, start+len([]rune(k.value.Value)))
+	}
+	if contents == "" {
+		return nil
+	}
+	return &hover{Contents: *markdown(contents), Range: &r}
+}
+
+// resourceReferenceFields are the names of fields that reference a resource
+// by its label.
+var resourceReferenceFields = map[string]struct{}{
+	"resource":   {},
+	"cache":      {},
+	"rate_limit": {},
+}
+
+// yamlDefinition finds the resource definitions of a label referenced at the
+// cursor, or the definitions of maps and functions within Bloblang fields.
+func (s *Server) yamlDefinition(d *document, pos position) []location {
+	a, err := analyseYAML(s.lintConf.DocsProvider, d.text)
+	if err != nil || pos.Line >= len(d.lines) {
+		return nil
+	}
+	char := runeOffset(d.line(pos.Line), pos.Character)
+
+	if a.inBloblang(pos.Line, char) {
+		return s.bloblangDefinitionAt(&bloblangSource{uri: d.uri, lines: d.lines}, pos.Line, char)
+	}
+
+	k := a.valueAt(pos.Line, char)
+	if k == nil || k.value.Value == "" {
+		return nil
+	}
+	if _, isRef := resourceReferenceFields[k.node.Value]; !isRef {
+		return nil
+	}
+
+	// Resources can be defined within any of the open config files.
+	docs := []*document{d}
+	for _, od := range s.openDocuments() {
+		if od.uri != d.uri && !isBloblang(od.uri) {
+			docs = append(docs, od)
+		}
+	}
+
+	var locs []location
+	for _, od := range docs {
+		oa := a
+		if od != d {
+			if oa, err = analyseYAML(s.lintConf.DocsProvider, od.text); err != nil {
+				continue
+			}
+		}
+		for _, label := range oa.resourceLabels(k.value.Value) {
+			start := label.Column - 1
+			locs = append(locs, location{
+				URI:   od.uri,
+				Range: wordRange(od, label.Line-1, start, start+len([]rune(label.Value))),
+			})
+		}
+	}
+	return locs
+}
+
+// resourceLabels returns the label values of resources with a given label.
+func (a *yamlAnalysis) resourceLabels(label string) []*yaml.Node {
+	var nodes []*yaml.Node
+	for _, k := range a.keys {
+		if k.node.Value != "label" || k.value.Value != label || k.parent.cType == "" {
+			continue
+		}
+		for _, r := range a.keys {
+			if !strings.HasSuffix(r.node.Value, "_resources") || r.node.Column != 1 {
+				continue
+			}
+			for _, item := range r.value.Content {
+				if item.Kind != yaml.MappingNode {
+					continue
+				}
+				for i := 0; i < len(item.Content)-1; i += 2 {
+					if item.Content[i] == k.node {
+						nodes = append(nodes, k.value)
+					}
+				}
+			}
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/component/health"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	smanager "github.com/benthosdev/benthos/v4/internal/stream/manager"
+)
+
+// openAPIDocument generates an OpenAPI document from a HTTP server with all
+// endpoints that can be exposed registered with it.
+func openAPIDocument() ([]byte, error) {
+	httpServer, err := api.New(Version, "", api.NewConfig(), nil, log.Noop(), metrics.NewLocal())
+	if err != nil {
+		return nil, err
+	}
+
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(httpServer))
+	if err != nil {
+		return nil, err
+	}
+	httpServer.RegisterEndpoint("/health", health.HandlerDescription, health.Handler(mgr.Health()), health.HandlerOperations()...)
+
+	// Registers the endpoints of streams mode.
+	streamMgr := smanager.New(mgr, smanager.OptAPIEnabled(true))
+	if err := streamMgr.Stop(context.Background()); err != nil {
+		return nil, err
+	}
+
+	noopHandler := func(w http.ResponseWriter, r *http.Request) {}
+	for _, kind := range []string{"inputs", "outputs"} {
+		httpServer.RegisterEndpoint(
+			"/"+kind, "Get a map of running "+kind+" with their current uptimes.",
+			noopHandler, api.DynamicListOperations()...,
+		)
+		httpServer.RegisterEndpoint(
+			"/"+kind+"/{id}", "Perform CRUD operations on the configuration of dynamic "+kind+".",
+			noopHandler, api.DynamicCRUDOperations()...,
+		)
+		httpServer.RegisterEndpoint(
+			"/"+kind+"/{id}/uptime", "Returns the uptime of a specific component.",
+			noopHandler, api.DynamicUptimeOperations()...,
+		)
+	}
+	return httpServer.OpenAPI()
+}
+
+func openAPICliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "openapi",
+		Usage: "Print an OpenAPI document of the Benthos HTTP API",
+		Description: `
+Prints an OpenAPI 3 document in JSON format describing all endpoints that can
+be exposed by the Benthos HTTP server, including those of streams mode and
+dynamic components. A running instance also serves a document describing only
+the endpoints it has registered at the path /openapi.json.
+
+  benthos openapi > openapi.json`[1:],
+		Action: func(c *cli.Context) error {
+			docBytes, err := openAPIDocument()
+			if err != nil {
+				return err
+			}
+
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, docBytes, "", "  "); err != nil {
+				return err
+			}
+			fmt.Fprintln(c.App.Writer, buf.String())
+			return nil
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package cli_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Jeffail/gabs/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	icli "github.com/benthosdev/benthos/v4/internal/cli"
+)
+
+func TestOpenAPICommand(t *testing.T) {
+	var buf bytes.Buffer
+	cliApp := icli.App()
+	cliApp.Writer = &buf
+	require.NoError(t, cliApp.Run([]string{"benthos", "openapi"}))
+
+	doc, err := gabs.ParseJSON(buf.Bytes())
+	require.NoError(t, err)
+
+	assert.Equal(t, "3.0.3", doc.S("openapi").Data())
+	for _, p := range []string{
+		"/ping",
+		"/ready",
+		"/streams",
+		"/streams/{id}",
+		"/streams/{id}/pause",
+		"/inputs/{id}",
+		"/outputs/{id}/uptime",
+	} {
+		assert.NotNil(t, doc.S("paths", p).Data(), p)
+	}
+	assert.NotNil(t, doc.S("paths", "/streams/{id}", "patch", "requestBody").Data())
+	assert.NotNil(t, doc.S("paths", "/streams/{id}", "post", "responses", "400").Data())
+}
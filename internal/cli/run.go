@@ -13,6 +13,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/cli/blobl"
 	"github.com/benthosdev/benthos/v4/internal/cli/common"
+	"github.com/benthosdev/benthos/v4/internal/cli/lsp"
 	"github.com/benthosdev/benthos/v4/internal/cli/studio"
 	clitemplate "github.com/benthosdev/benthos/v4/internal/cli/template"
 	"github.com/benthosdev/benthos/v4/internal/cli/test"
@@ -224,6 +225,7 @@ variables have been resolved:
 				},
 			},
 			lintCliCommand(),
+			configCliCommand(),
 			{
 				Name:  "streams",
 				Usage: "Run Benthos in streams mode",
@@ -254,6 +256,11 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 						Value: true,
 						Usage: "Whether HTTP endpoints registered by stream configs should be prefixed with the stream ID",
 					},
+					&cli.StringFlag{
+						Name:  "persist-dir",
+						Value: "",
+						Usage: "A directory to persist streams created, updated and deleted via the HTTP API, which are restored when Benthos is restarted",
+					},
 				},
 				Action: func(c *cli.Context) error {
 					os.Exit(common.RunService(c, Version, DateBuilt, true))
@@ -261,10 +268,12 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 				},
 			},
 			listCliCommand(),
+			openAPICliCommand(),
 			createCliCommand(),
 			test.CliCommand(),
 			clitemplate.CliCommand(),
 			blobl.CliCommand(),
+			lsp.CliCommand(Version),
 			studio.CliCommand(Version, DateBuilt),
 		},
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
+	"strconv"
 
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/config/test"
@@ -32,9 +33,27 @@ type ProcProvider interface {
 	ProvideBloblang(path string) ([]iprocessor.V1, error)
 }
 
+// StreamProvider returns a running stream extracted from a Benthos config,
+// where targeted inputs and outputs are replaced with in-memory fixtures.
+type StreamProvider interface {
+	ProvideStream(path string, environment map[string]string, mocks map[string]any, inputs, outputs []string) (*FixtureStream, error)
+}
+
 // ExecuteFrom executes a test case from the perspective of a given directory,
 // which is used for obtaining relative condition file imports.
 func ExecuteFrom(fs fs.FS, dir string, c test.Case, provider ProcProvider) (failures []CaseFailure, err error) {
+	return executeFrom(fs, dir, c, provider, nil)
+}
+
+func executeFrom(fs fs.FS, dir string, c test.Case, provider ProcProvider, snapshot snapshotFunc) (failures []CaseFailure, err error) {
+	if c.TargetStream != "" {
+		sProvider, ok := provider.(StreamProvider)
+		if !ok {
+			return nil, fmt.Errorf("failed to initialise stream '%v': provider does not support stream targets", c.TargetStream)
+		}
+		return executeStreamFrom(fs, dir, c, sProvider, snapshot)
+	}
+
 	var procSet []iprocessor.V1
 	if c.TargetMapping != "" {
 		if procSet, err = provider.ProvideBloblang(c.TargetMapping); err != nil {
@@ -55,36 +74,49 @@ func ExecuteFrom(fs fs.FS, dir string, c test.Case, provider ProcProvider) (fail
 	}
 
 	var inputMsg []message.Batch
+	if inputMsg, err = inputBatchesToMessages(fs, dir, c.InputBatches); err != nil {
+		return
+	}
 
-	for _, inputBatch := range c.InputBatches {
+	outputBatches, result := iprocessor.ExecuteAll(context.Background(), procSet, inputMsg...)
+	if result != nil {
+		reportFailure(fmt.Sprintf("processors resulted in error: %v", result))
+	}
+
+	checkOutputBatches(fs, dir, []string{"output_batches"}, c.OutputBatches, outputBatches, reportFailure, snapshot)
+	return
+}
+
+func inputBatchesToMessages(fs fs.FS, dir string, inputBatches [][]test.InputConfig) ([]message.Batch, error) {
+	var inputMsg []message.Batch
+	for _, inputBatch := range inputBatches {
 		parts := make([]*message.Part, len(inputBatch))
 		for i, v := range inputBatch {
+			var err error
 			if parts[i], err = v.ToMessage(fs, dir); err != nil {
-				err = fmt.Errorf("failed to create test input %v: %w", i, err)
-				return
+				return nil, fmt.Errorf("failed to create test input %v: %w", i, err)
 			}
 		}
-
-		currentBatch := message.Batch(parts)
-		inputMsg = append(inputMsg, currentBatch)
-
-	}
-
-	outputBatches, result := iprocessor.ExecuteAll(context.Background(), procSet, inputMsg...)
-	if result != nil {
-		reportFailure(fmt.Sprintf("processors resulted in error: %v", result))
+		inputMsg = append(inputMsg, message.Batch(parts))
 	}
+	return inputMsg, nil
+}
 
-	if lExp, lAct := len(c.OutputBatches), len(outputBatches); lAct < lExp {
+// checkOutputBatches compares a series of batches against their expected
+// conditions, where path is the location of the expected batches within the
+// test case. When a snapshot func is provided it is given the opportunity to
+// update any failed conditions instead of reporting them.
+func checkOutputBatches(fs fs.FS, dir string, path []string, expected [][]test.OutputConditionsMap, actual []message.Batch, reportFailure func(reason string), snapshot snapshotFunc) {
+	if lExp, lAct := len(expected), len(actual); lAct < lExp {
 		reportFailure(fmt.Sprintf("wrong batch count, expected %v, got %v", lExp, lAct))
 	}
 
-	for i, v := range outputBatches {
-		if len(c.OutputBatches) <= i {
+	for i, v := range actual {
+		if len(expected) <= i {
 			reportFailure(fmt.Sprintf("unexpected batch: %s", message.GetAllBytes(v)))
 			continue
 		}
-		expectedBatch := c.OutputBatches[i]
+		expectedBatch := expected[i]
 		if lExp, lAct := len(expectedBatch), v.Len(); lExp != lAct {
 			reportFailure(fmt.Sprintf("mismatch of output batch %v message counts, expected %v, got %v", i, lExp, lAct))
 		}
@@ -94,6 +126,10 @@ func ExecuteFrom(fs fs.FS, dir string, c test.Case, provider ProcProvider) (fail
 				return nil
 			}
 			condErrs := expectedBatch[i2].CheckAll(fs, dir, part)
+			if snapshot != nil && len(condErrs) > 0 {
+				condPath := append(append([]string{}, path...), strconv.Itoa(i), strconv.Itoa(i2))
+				condErrs = snapshotConditions(fs, dir, condPath, expectedBatch[i2], part, snapshot)
+			}
 			for _, condErr := range condErrs {
 				reportFailure(fmt.Sprintf("batch %v message %v: %v", i, i2, condErr))
 			}
@@ -103,5 +139,4 @@ func ExecuteFrom(fs fs.FS, dir string, c test.Case, provider ProcProvider) (fail
 			return nil
 		})
 	}
-	return
 }
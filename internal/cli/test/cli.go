@@ -23,6 +23,8 @@ fail the process will report the errors and exit with a status code 1.
   benthos test ./path/to/configs/...
   benthos test ./foo_configs/*.yaml ./bar_configs/*.yaml
   benthos test ./foo.yaml
+  benthos test --update-snapshots ./path/to/configs/...
+  benthos test --coverage --coverage-out ./lcov.info ./path/to/configs/...
 
 For more information check out the docs at:
 https://benthos.dev/docs/configuration/unit_testing`[1:],
@@ -32,6 +34,21 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 				Value: "",
 				Usage: "allow components to write logs at a provided level to stdout.",
 			},
+			&cli.BoolFlag{
+				Name:  "update-snapshots",
+				Value: false,
+				Usage: "rewrite failed content_equals, json_equals, file_equals and file_json_equals conditions to match the actual output, printing a diff of each change.",
+			},
+			&cli.BoolFlag{
+				Name:  "coverage",
+				Value: false,
+				Usage: "print a summary of the line and branch coverage of Bloblang mappings exercised by the tests.",
+			},
+			&cli.StringFlag{
+				Name:  "coverage-out",
+				Value: "",
+				Usage: "write the line and branch coverage of Bloblang mappings exercised by the tests to a file in LCOV format.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if len(c.StringSlice("set")) > 0 {
@@ -44,6 +61,11 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 				fmt.Printf("Failed to resolve resource glob pattern: %v\n", err)
 				os.Exit(1)
 			}
+			runOpts := []RunOptFunc{
+				OptUpdateSnapshots(c.Bool("update-snapshots")),
+				OptCoverage(c.Bool("coverage")),
+				OptCoverageOutput(c.String("coverage-out")),
+			}
 			if logLevel := c.String("log"); logLevel != "" {
 				logConf := log.NewConfig()
 				logConf.LogLevel = logLevel
@@ -52,10 +74,10 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 					fmt.Printf("Failed to init logger: %v\n", err)
 					os.Exit(1)
 				}
-				if RunAll(c.Args().Slice(), "_benthos_test", true, logger, resourcesPaths) {
+				if RunAll(c.Args().Slice(), "_benthos_test", true, logger, resourcesPaths, runOpts...) {
 					os.Exit(0)
 				}
-			} else if RunAll(c.Args().Slice(), "_benthos_test", true, log.Noop(), resourcesPaths) {
+			} else if RunAll(c.Args().Slice(), "_benthos_test", true, log.Noop(), resourcesPaths, runOpts...) {
 				os.Exit(0)
 			}
 			os.Exit(1)
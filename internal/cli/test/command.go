@@ -1,6 +1,7 @@
 package test
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/fatih/color"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/config/test"
@@ -44,57 +46,67 @@ func GetPathPair(fullPath, testSuffix string) (configPath, definitionPath string
 	return
 }
 
-func getDefinition(targetPath, definitionPath string) ([]test.Case, error) {
+// getDefinition reads the test cases of a config, returning them along with
+// the path of the file that they were defined within, which is either the
+// definition path or the config itself.
+func getDefinition(targetPath, definitionPath string) ([]test.Case, string, error) {
 	if _, err := ifs.OS().Stat(targetPath); err != nil {
-		return nil, fmt.Errorf("unable to access target config file '%v': %v", targetPath, err)
+		return nil, "", fmt.Errorf("unable to access target config file '%v': %v", targetPath, err)
 	}
 	if _, err := ifs.OS().Stat(definitionPath); err != nil {
 		if !errors.Is(err, fs.ErrNotExist) {
-			return nil, fmt.Errorf("unable to access test definition file '%v': %v", definitionPath, err)
+			return nil, "", fmt.Errorf("unable to access test definition file '%v': %v", definitionPath, err)
 		}
 		if !strings.HasSuffix(targetPath, ".yaml") && !strings.HasSuffix(targetPath, ".yml") {
-			return nil, nil
+			return nil, "", nil
 		}
 		definitionPath = targetPath
 	}
 	defBytes, err := ifs.ReadFile(ifs.OS(), definitionPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read test definition from '%v': %v", definitionPath, err)
+		return nil, "", fmt.Errorf("failed to read test definition from '%v': %v", definitionPath, err)
 	}
 
 	node, err := docs.UnmarshalYAML(defBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse test definition from '%v': %v", definitionPath, err)
+		return nil, "", fmt.Errorf("failed to parse test definition from '%v': %v", definitionPath, err)
 	}
 
 	cases, err := test.FromAny(node)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse test definition from '%v': %v", definitionPath, err)
+		return nil, "", fmt.Errorf("failed to parse test definition from '%v': %v", definitionPath, err)
 	}
-	return cases, nil
+	return cases, definitionPath, nil
 }
 
 // GetTestTargets searches for test definition targets in a path with a given
 // test suffix.
 func GetTestTargets(targetPaths []string, testSuffix string) (map[string][]test.Case, error) {
-	targetPaths, err := ifilepath.GlobsAndSuperPaths(ifs.OS(), targetPaths, "yaml", "yml")
-	if err != nil {
-		return nil, err
+	targetDefinitions, _, err := getTestTargets(targetPaths, testSuffix)
+	return targetDefinitions, err
+}
+
+func getTestTargets(targetPaths []string, testSuffix string) (targetDefinitions map[string][]test.Case, definitionPaths map[string]string, err error) {
+	if targetPaths, err = ifilepath.GlobsAndSuperPaths(ifs.OS(), targetPaths, "yaml", "yml"); err != nil {
+		return
 	}
 
-	targetDefinitions := map[string][]test.Case{}
+	targetDefinitions = map[string][]test.Case{}
+	definitionPaths = map[string]string{}
 	for _, tPath := range targetPaths {
 		configPath, definitionPath := GetPathPair(tPath, testSuffix)
-		def, err := getDefinition(configPath, definitionPath)
-		if err != nil {
-			return nil, err
+
+		var def []test.Case
+		if def, definitionPath, err = getDefinition(configPath, definitionPath); err != nil {
+			return
 		}
 		if len(def) == 0 {
 			continue
 		}
 		targetDefinitions[filepath.Clean(configPath)] = def
+		definitionPaths[filepath.Clean(configPath)] = definitionPath
 	}
-	return targetDefinitions, nil
+	return
 }
 
 // Lints the config target of a test definition and either returns linting
@@ -115,11 +127,54 @@ func lintTarget(path, testSuffix string) ([]docs.Lint, error) {
 
 //------------------------------------------------------------------------------
 
+type runConfig struct {
+	updateSnapshots bool
+	coverageSummary bool
+	coverageOutput  string
+
+	coverage *query.Coverage
+}
+
+// RunOptFunc is an optional setting for RunAll.
+type RunOptFunc func(*runConfig)
+
+// OptUpdateSnapshots sets whether failed conditions that describe the exact
+// contents of a message should instead be updated to match the message.
+func OptUpdateSnapshots(b bool) RunOptFunc {
+	return func(c *runConfig) {
+		c.updateSnapshots = b
+	}
+}
+
+// OptCoverage sets whether a summary of the line and branch coverage of
+// mappings exercised by tests should be printed.
+func OptCoverage(b bool) RunOptFunc {
+	return func(c *runConfig) {
+		c.coverageSummary = b
+	}
+}
+
+// OptCoverageOutput sets a file path where the line and branch coverage of
+// mappings exercised by tests is written in LCOV format.
+func OptCoverageOutput(path string) RunOptFunc {
+	return func(c *runConfig) {
+		c.coverageOutput = path
+	}
+}
+
 // RunAll executes the test command for a slice of paths. The path can either be
 // a config file, a config files test definition file, a directory, or the
 // wildcard pattern './...'.
-func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, resourcesPaths []string) bool {
-	targets, err := GetTestTargets(paths, testSuffix)
+func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, resourcesPaths []string, opts ...RunOptFunc) bool {
+	var conf runConfig
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	if conf.coverageSummary || conf.coverageOutput != "" {
+		conf.coverage = query.NewCoverage()
+	}
+
+	targets, definitionPaths, err := getTestTargets(paths, testSuffix)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to obtain test targets: %v\n", err)
 		return false
@@ -142,6 +197,8 @@ func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, re
 	}
 	sort.Strings(targetPaths)
 
+	snapshots := newSnapshotSet()
+	coveragePaths := append([]string{}, resourcesPaths...)
 	for _, target := range targetPaths {
 		var lints []docs.Lint
 		var failCases []CaseFailure
@@ -151,10 +208,16 @@ func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, re
 				return false
 			}
 		}
-		if failCases, err = Execute(targets[target], target, resourcesPaths, logger); err != nil {
+		var updates []snapshotUpdate
+		if failCases, updates, err = execute(targets[target], target, resourcesPaths, logger, conf); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to execute test target '%v': %v\n", target, err)
 			return false
 		}
+		coveragePaths = append(coveragePaths, coverageFiles(target, targets[target])...)
+		if err := snapshots.add(definitionPaths[target], updates); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to update snapshots of test target '%v': %v\n", target, err)
+			return false
+		}
 		if len(lints) > 0 || len(failCases) > 0 {
 			fails = append(fails, failedTarget{
 				target: target,
@@ -166,6 +229,33 @@ func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, re
 			fmt.Printf("Test '%v' %v\n", target, green("succeeded"))
 		}
 	}
+	if snapshots.count > 0 {
+		diffs, err := snapshots.apply()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to update snapshots: %v\n", err)
+			return false
+		}
+		fmt.Println("")
+		for _, d := range diffs {
+			fmt.Println(colorDiff(d))
+		}
+		fmt.Printf("%v\n", yellow(fmt.Sprintf("Updated %v snapshots", snapshots.count)))
+	}
+	if conf.coverage != nil {
+		report := newCoverageReport(conf.coverage, coveragePaths)
+		if conf.coverageSummary {
+			fmt.Println("")
+			report.writeSummary(os.Stdout)
+		}
+		if conf.coverageOutput != "" {
+			var buf bytes.Buffer
+			_ = report.writeLCOV(&buf)
+			if err := ifs.WriteFile(ifs.OS(), conf.coverageOutput, buf.Bytes(), 0o644); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write coverage report: %v\n", err)
+				return false
+			}
+		}
+	}
 	if len(fails) > 0 {
 		fmt.Printf("\nFailures:\n\n")
 		for i, fail := range fails {
@@ -0,0 +1,296 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/config/test"
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+)
+
+type coverageBranchKey struct {
+	line      int
+	blockLine int
+	blockCol  int
+	arm       int
+}
+
+// fileCoverage is the aggregated coverage of all mappings found within a
+// single file.
+type fileCoverage struct {
+	path     string
+	lines    map[int]int64
+	branches map[coverageBranchKey]int64
+}
+
+func (f *fileCoverage) add(lineOffset int, s *query.CoverageSource) {
+	for _, c := range s.Counters() {
+		line := c.Line + lineOffset
+		if !c.IsBranch() {
+			f.lines[line] += c.Hits()
+			continue
+		}
+		f.branches[coverageBranchKey{
+			line:      line,
+			blockLine: c.BlockLine + lineOffset,
+			blockCol:  c.BlockColumn,
+			arm:       c.Arm,
+		}] += c.Hits()
+	}
+}
+
+func (f *fileCoverage) lineStats() (hit, total int) {
+	for _, hits := range f.lines {
+		if hits > 0 {
+			hit++
+		}
+	}
+	return hit, len(f.lines)
+}
+
+func (f *fileCoverage) branchStats() (hit, total int) {
+	for _, hits := range f.branches {
+		if hits > 0 {
+			hit++
+		}
+	}
+	return hit, len(f.branches)
+}
+
+func (f *fileCoverage) sortedLines() []int {
+	lines := make([]int, 0, len(f.lines))
+	for l := range f.lines {
+		lines = append(lines, l)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+func (f *fileCoverage) sortedBranches() []coverageBranchKey {
+	keys := make([]coverageBranchKey, 0, len(f.branches))
+	for k := range f.branches {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].blockLine != keys[j].blockLine {
+			return keys[i].blockLine < keys[j].blockLine
+		}
+		if keys[i].blockCol != keys[j].blockCol {
+			return keys[i].blockCol < keys[j].blockCol
+		}
+		return keys[i].arm < keys[j].arm
+	})
+	return keys
+}
+
+//------------------------------------------------------------------------------
+
+// coverageReport attributes the coverage recorded for parsed mappings to the
+// files that they were written within.
+type coverageReport struct {
+	files []*fileCoverage
+}
+
+// coverageFiles returns the paths of all config files that are exercised by a
+// test definition and may therefore contain mappings.
+func coverageFiles(targetPath string, cases []test.Case) []string {
+	files := []string{targetPath}
+	for _, c := range cases {
+		if c.TargetStream != "" {
+			if filepath.IsAbs(c.TargetStream) {
+				files = append(files, c.TargetStream)
+			} else {
+				files = append(files, filepath.Join(filepath.Dir(targetPath), c.TargetStream))
+			}
+		}
+		if c.TargetProcessors != "" {
+			if filePath, _, err := resolveProcessorsPointer(targetPath, c.TargetProcessors); err == nil {
+				files = append(files, filePath)
+			}
+		}
+	}
+	return files
+}
+
+// yamlMappingOffsets walks a YAML document and returns the line offsets of all
+// string values that exactly match a mapping.
+func yamlMappingOffsets(node *yaml.Node, mapping string) (offsets []int) {
+	if node.Kind == yaml.ScalarNode && node.Value == mapping {
+		offset := node.Line - 1
+		if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			// The contents of block scalars begin on the following line.
+			offset++
+		}
+		return []int{offset}
+	}
+	for _, n := range node.Content {
+		offsets = append(offsets, yamlMappingOffsets(n, mapping)...)
+	}
+	return
+}
+
+func newCoverageReport(cov *query.Coverage, configPaths []string) *coverageReport {
+	seenPaths := map[string]struct{}{}
+	var configs []string
+	var configNodes []*yaml.Node
+	for _, p := range configPaths {
+		p = filepath.Clean(p)
+		if _, exists := seenPaths[p]; exists {
+			continue
+		}
+		seenPaths[p] = struct{}{}
+
+		confBytes, err := ifs.ReadFile(ifs.OS(), p)
+		if err != nil {
+			continue
+		}
+		var node yaml.Node
+		if err := yaml.Unmarshal(confBytes, &node); err != nil {
+			continue
+		}
+		configs = append(configs, p)
+		configNodes = append(configNodes, &node)
+	}
+
+	files := map[string]*fileCoverage{}
+	addTo := func(path string, lineOffset int, s *query.CoverageSource) {
+		f, exists := files[path]
+		if !exists {
+			f = &fileCoverage{
+				path:     path,
+				lines:    map[int]int64{},
+				branches: map[coverageBranchKey]int64{},
+			}
+			files[path] = f
+		}
+		f.add(lineOffset, s)
+	}
+
+	for _, s := range cov.Sources() {
+		if s.Path != "" {
+			addTo(filepath.Clean(s.Path), 0, s)
+			continue
+		}
+
+		// Mappings without a known origin were read from config fields, and so
+		// we attempt to find them within the tested config files.
+		mapping := string(s.Input)
+		for i, node := range configNodes {
+			for _, offset := range yamlMappingOffsets(node, mapping) {
+				addTo(configs[i], offset, s)
+			}
+		}
+	}
+
+	r := &coverageReport{}
+	for _, f := range files {
+		r.files = append(r.files, f)
+	}
+	sort.Slice(r.files, func(i, j int) bool {
+		return r.files[i].path < r.files[j].path
+	})
+	return r
+}
+
+func coveragePercent(hit, total int) string {
+	if total == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%%", float64(hit)/float64(total)*100)
+}
+
+// writeSummary writes a human readable summary of the line and branch coverage
+// of each file.
+func (r *coverageReport) writeSummary(w io.Writer) {
+	fmt.Fprintln(w, "Mapping coverage:")
+	if len(r.files) == 0 {
+		fmt.Fprintf(w, "  %v\n", yellow("No mappings were executed"))
+		return
+	}
+
+	var totalLinesHit, totalLines, totalBranchesHit, totalBranches int
+	for _, f := range r.files {
+		linesHit, lines := f.lineStats()
+		branchesHit, branches := f.branchStats()
+		totalLinesHit += linesHit
+		totalLines += lines
+		totalBranchesHit += branchesHit
+		totalBranches += branches
+
+		fmt.Fprintf(w, "  %v: %v of lines (%v/%v), %v of branches (%v/%v)\n",
+			f.path,
+			coveragePercent(linesHit, lines), linesHit, lines,
+			coveragePercent(branchesHit, branches), branchesHit, branches,
+		)
+
+		var uncoveredLines, uncoveredBranches []int
+		for _, l := range f.sortedLines() {
+			if f.lines[l] == 0 {
+				uncoveredLines = append(uncoveredLines, l)
+			}
+		}
+		for _, k := range f.sortedBranches() {
+			if f.branches[k] == 0 {
+				uncoveredBranches = append(uncoveredBranches, k.line)
+			}
+		}
+		if len(uncoveredLines) > 0 {
+			fmt.Fprintf(w, "    %v %v\n", red("uncovered lines:"), uncoveredLines)
+		}
+		if len(uncoveredBranches) > 0 {
+			sort.Ints(uncoveredBranches)
+			fmt.Fprintf(w, "    %v %v\n", red("uncovered branches on lines:"), dedupeInts(uncoveredBranches))
+		}
+	}
+	fmt.Fprintf(w, "  total: %v of lines (%v/%v), %v of branches (%v/%v)\n",
+		coveragePercent(totalLinesHit, totalLines), totalLinesHit, totalLines,
+		coveragePercent(totalBranchesHit, totalBranches), totalBranchesHit, totalBranches,
+	)
+}
+
+func dedupeInts(s []int) []int {
+	var res []int
+	for i, v := range s {
+		if i == 0 || s[i-1] != v {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// writeLCOV writes the coverage report in LCOV tracefile format.
+func (r *coverageReport) writeLCOV(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString("TN:\n")
+	for _, f := range r.files {
+		fmt.Fprintf(&buf, "SF:%v\n", f.path)
+
+		blockIDs := map[[2]int]int{}
+		for _, k := range f.sortedBranches() {
+			blockKey := [2]int{k.blockLine, k.blockCol}
+			id, exists := blockIDs[blockKey]
+			if !exists {
+				id = len(blockIDs)
+				blockIDs[blockKey] = id
+			}
+			fmt.Fprintf(&buf, "BRDA:%v,%v,%v,%v\n", k.line, id, k.arm, f.branches[k])
+		}
+		branchesHit, branches := f.branchStats()
+		fmt.Fprintf(&buf, "BRF:%v\nBRH:%v\n", branches, branchesHit)
+
+		for _, l := range f.sortedLines() {
+			fmt.Fprintf(&buf, "DA:%v,%v\n", l, f.lines[l])
+		}
+		linesHit, lines := f.lineStats()
+		fmt.Fprintf(&buf, "LF:%v\nLH:%v\n", lines, linesHit)
+		buf.WriteString("end_of_record\n")
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
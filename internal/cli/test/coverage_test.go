@@ -0,0 +1,88 @@
+package test_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/test"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestCommandCoverage(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"foo.yaml": `
+pipeline:
+  processors:
+    - mapping: |
+        root.kind = match this.kind {
+          "a" => "first"
+          "b" => "second"
+        }
+        if this.big {
+          root.size = "big"
+        }
+    - mapping: 'root = content().uppercase()'
+`,
+		"foo_benthos_test.yaml": `
+tests:
+  - name: kind a
+    target_processors: '/pipeline/processors'
+    input_batch:
+      - content: '{"kind":"a","big":false}'
+    output_batches:
+      - - content_equals: '{"KIND":"FIRST"}'
+  - name: bar mapping
+    target_mapping: './bar.blobl'
+    input_batch:
+      - content: '{}'
+    output_batches:
+      - - json_equals: { "bar": "default" }
+`,
+		"bar.blobl": `root = this
+root.bar = if this.bar == null { "default" }
+`,
+	})
+	require.NoError(t, err)
+
+	lcovPath := filepath.Join(testDir, "lcov.info")
+	assert.True(t, test.RunAll(
+		[]string{filepath.Join(testDir, "foo.yaml")},
+		"_benthos_test", false, log.Noop(), nil,
+		test.OptCoverageOutput(lcovPath),
+	))
+
+	lcovBytes, err := os.ReadFile(lcovPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, `TN:
+SF:`+filepath.Join(testDir, "bar.blobl")+`
+BRDA:2,0,0,1
+BRDA:2,0,1,0
+BRF:2
+BRH:1
+DA:1,1
+DA:2,1
+LF:2
+LH:2
+end_of_record
+SF:`+filepath.Join(testDir, "foo.yaml")+`
+BRDA:6,0,0,1
+BRDA:7,0,1,0
+BRDA:5,0,2,0
+BRDA:9,1,0,0
+BRDA:9,1,1,1
+BRF:5
+BRH:2
+DA:5,1
+DA:9,1
+DA:10,0
+DA:12,1
+LF:4
+LH:3
+end_of_record
+`, string(lcovBytes))
+}
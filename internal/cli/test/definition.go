@@ -11,25 +11,37 @@ import (
 
 // Execute the test definition.
 func Execute(cases []test.Case, testFilePath string, resourcesPaths []string, logger log.Modular) ([]CaseFailure, error) {
+	failures, _, err := execute(cases, testFilePath, resourcesPaths, logger, runConfig{})
+	return failures, err
+}
+
+func execute(cases []test.Case, testFilePath string, resourcesPaths []string, logger log.Modular, conf runConfig) ([]CaseFailure, []snapshotUpdate, error) {
 	procsProvider := NewProcessorsProvider(
 		testFilePath,
 		OptAddResourcesPaths(resourcesPaths),
 		OptProcessorsProviderSetLogger(logger),
+		OptProcessorsProviderSetCoverage(conf.coverage),
 	)
 
 	dir := filepath.Dir(testFilePath)
 
 	var totalFailures []CaseFailure
+	var updates []snapshotUpdate
 	for i, c := range cases {
+		var snapshot snapshotFunc
+		if conf.updateSnapshots {
+			snapshot = snapshotCollector(dir, i, &updates)
+		}
+
 		cleanupEnv := setEnvironment(c.Environment)
-		failures, err := ExecuteFrom(ifs.OS(), dir, c, procsProvider)
+		failures, err := executeFrom(ifs.OS(), dir, c, procsProvider, snapshot)
 		if err != nil {
 			cleanupEnv()
-			return nil, fmt.Errorf("test case %v failed: %v", i, err)
+			return nil, nil, fmt.Errorf("test case %v failed: %v", i, err)
 		}
 		totalFailures = append(totalFailures, failures...)
 		cleanupEnv()
 	}
 
-	return totalFailures, nil
+	return totalFailures, updates, nil
 }
@@ -12,8 +12,10 @@ import (
 	"github.com/Jeffail/gabs/v2"
 	yaml "gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/config"
@@ -37,7 +39,8 @@ type ProcessorsProvider struct {
 	resourcesPaths []string
 	cachedConfigs  map[string]cachedConfig
 
-	logger log.Modular
+	logger   log.Modular
+	coverage *query.Coverage
 }
 
 // NewProcessorsProvider returns a new processors provider aimed at a filepath.
@@ -67,6 +70,22 @@ func OptProcessorsProviderSetLogger(logger log.Modular) func(*ProcessorsProvider
 	}
 }
 
+// OptProcessorsProviderSetCoverage sets a coverage recorder that tracks the
+// executions of statements and branches of mappings within tested components.
+func OptProcessorsProviderSetCoverage(c *query.Coverage) func(*ProcessorsProvider) {
+	return func(p *ProcessorsProvider) {
+		p.coverage = c
+	}
+}
+
+func (p *ProcessorsProvider) managerOpts() []manager.OptFunc {
+	opts := []manager.OptFunc{manager.OptSetLogger(p.logger)}
+	if p.coverage != nil {
+		opts = append(opts, manager.OptSetBloblangEnvironment(bloblang.GlobalEnvironment().WithCoverage(p.coverage)))
+	}
+	return opts
+}
+
 //------------------------------------------------------------------------------
 
 // Provide attempts to extract an array of processors from a Benthos config.
@@ -94,6 +113,9 @@ func (p *ProcessorsProvider) ProvideBloblang(pathStr string) ([]processor.V1, er
 	}
 
 	pCtx := parser.GlobalContext().WithImporterRelativeToFile(pathStr)
+	if p.coverage != nil {
+		pCtx = pCtx.WithCoverage(p.coverage, pathStr)
+	}
 	exec, mapErr := parser.ParseMapping(pCtx, string(mappingBytes))
 	if mapErr != nil {
 		return nil, mapErr
@@ -145,7 +167,7 @@ func (b *bloblangProc) Close(context.Context) error {
 //------------------------------------------------------------------------------
 
 func (p *ProcessorsProvider) initProcs(confs cachedConfig) ([]processor.V1, error) {
-	mgr, err := manager.New(confs.mgr, manager.OptSetLogger(p.logger))
+	mgr, err := manager.New(confs.mgr, p.managerOpts()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialise resources: %v", err)
 	}
@@ -248,39 +270,10 @@ func setMock(confSpec docs.FieldSpecs, root *yaml.Node, mock any, pathSlice ...s
 	return nil
 }
 
-func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]string, mocks map[string]any) (cachedConfig, error) {
-	cacheKey := confTargetID(jsonPtr, environment, mocks)
-
-	confs, exists := p.cachedConfigs[cacheKey]
-	if exists {
-		return confs, nil
-	}
-
-	targetPath, procPath, err := resolveProcessorsPointer(p.targetPath, jsonPtr)
-	if err != nil {
-		return confs, err
-	}
-	if targetPath == "" {
-		targetPath = p.targetPath
-	}
-
-	// Set custom environment vars.
-	ogEnvVars := map[string]string{}
-	for k, v := range environment {
-		ogEnvVars[k] = os.Getenv(k)
-		os.Setenv(k, v)
-	}
-
-	cleanupEnv := setEnvironment(environment)
-	defer cleanupEnv()
-
-	envVarLookup := func(name string) (string, bool) {
-		if s, ok := environment[name]; ok {
-			return s, true
-		}
-		return os.LookupEnv(name)
-	}
-
+// readMockedConfig reads a config file with environment variables replaced and
+// mocked components injected, returning the root YAML node along with a map of
+// component labels to their paths within the config.
+func (p *ProcessorsProvider) readMockedConfig(targetPath string, envVarLookup func(string) (string, bool), mocks map[string]any) (*yaml.Node, map[string][]string, error) {
 	remainingMocks := map[string]any{}
 	for k, v := range mocks {
 		remainingMocks[k] = v
@@ -288,12 +281,12 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 
 	configBytes, _, _, err := config.ReadFileEnvSwap(ifs.OS(), targetPath, envVarLookup)
 	if err != nil {
-		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+		return nil, nil, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
 	}
 
 	root, err := docs.UnmarshalYAML(configBytes)
 	if err != nil {
-		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+		return nil, nil, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
 	}
 
 	// Replace mock components, starting with all absolute paths in JSON pointer
@@ -305,57 +298,112 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 		}
 		mockPathSlice, err := gabs.JSONPointerToSlice(k)
 		if err != nil {
-			return confs, fmt.Errorf("failed to parse mock path '%v': %w", k, err)
+			return nil, nil, fmt.Errorf("failed to parse mock path '%v': %w", k, err)
 		}
 		if err = setMock(confSpec, root, &v, mockPathSlice...); err != nil {
-			return confs, fmt.Errorf("failed to set mock '%v': %w", k, err)
+			return nil, nil, fmt.Errorf("failed to set mock '%v': %w", k, err)
 		}
 		delete(remainingMocks, k)
 	}
 
 	labelsToPaths := map[string][]string{}
+	confSpec.YAMLLabelsToPaths(bundle.GlobalEnvironment, root, labelsToPaths, nil)
 	if len(remainingMocks) > 0 {
-		confSpec.YAMLLabelsToPaths(bundle.GlobalEnvironment, root, labelsToPaths, nil)
 		for k, v := range remainingMocks {
 			mockPathSlice, exists := labelsToPaths[k]
 			if !exists {
-				return confs, fmt.Errorf("mock for label '%v' could not be applied as the label was not found in the test target file, it is not currently possible to mock resources imported separate to the test file", k)
+				return nil, nil, fmt.Errorf("mock for label '%v' could not be applied as the label was not found in the test target file, it is not currently possible to mock resources imported separate to the test file", k)
 			}
 			if err = setMock(confSpec, root, &v, mockPathSlice...); err != nil {
-				return confs, fmt.Errorf("failed to set mock '%v': %w", k, err)
+				return nil, nil, fmt.Errorf("failed to set mock '%v': %w", k, err)
 			}
 			delete(remainingMocks, k)
 		}
-	}
 
-	pConf, err := confSpec.ParsedConfigFromAny(root)
-	if err != nil {
-		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+		// Mocks may have introduced or removed labels.
+		labelsToPaths = map[string][]string{}
+		confSpec.YAMLLabelsToPaths(bundle.GlobalEnvironment, root, labelsToPaths, nil)
 	}
+	return root, labelsToPaths, nil
+}
 
+// resourcesFromParsed extracts the resources of a parsed config and merges them
+// with resources parsed from any additional resource files.
+func (p *ProcessorsProvider) resourcesFromParsed(targetPath string, pConf *docs.ParsedConfig, envVarLookup func(string) (string, bool)) (manager.ResourceConfig, error) {
 	mgrWrapper, err := manager.FromParsed(bundle.GlobalEnvironment, pConf)
 	if err != nil {
-		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+		return mgrWrapper, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
 	}
 
 	for _, path := range p.resourcesPaths {
 		resourceBytes, _, _, err := config.ReadFileEnvSwap(ifs.OS(), path, envVarLookup)
 		if err != nil {
-			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+			return mgrWrapper, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
 		}
 
 		confNode, err := docs.UnmarshalYAML(resourceBytes)
 		if err != nil {
-			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+			return mgrWrapper, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
 		}
 
 		extraMgrWrapper, err := manager.FromAny(bundle.GlobalEnvironment, confNode)
 		if err != nil {
-			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+			return mgrWrapper, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
 		}
 		if err = mgrWrapper.AddFrom(&extraMgrWrapper); err != nil {
-			return confs, fmt.Errorf("failed to merge resources from '%v': %v", path, err)
+			return mgrWrapper, fmt.Errorf("failed to merge resources from '%v': %v", path, err)
+		}
+	}
+	return mgrWrapper, nil
+}
+
+func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]string, mocks map[string]any) (cachedConfig, error) {
+	cacheKey := confTargetID(jsonPtr, environment, mocks)
+
+	confs, exists := p.cachedConfigs[cacheKey]
+	if exists {
+		return confs, nil
+	}
+
+	targetPath, procPath, err := resolveProcessorsPointer(p.targetPath, jsonPtr)
+	if err != nil {
+		return confs, err
+	}
+	if targetPath == "" {
+		targetPath = p.targetPath
+	}
+
+	// Set custom environment vars.
+	ogEnvVars := map[string]string{}
+	for k, v := range environment {
+		ogEnvVars[k] = os.Getenv(k)
+		os.Setenv(k, v)
+	}
+
+	cleanupEnv := setEnvironment(environment)
+	defer cleanupEnv()
+
+	envVarLookup := func(name string) (string, bool) {
+		if s, ok := environment[name]; ok {
+			return s, true
 		}
+		return os.LookupEnv(name)
+	}
+
+	root, labelsToPaths, err := p.readMockedConfig(targetPath, envVarLookup, mocks)
+	if err != nil {
+		return confs, err
+	}
+
+	confSpec := config.Spec()
+	pConf, err := confSpec.ParsedConfigFromAny(root)
+	if err != nil {
+		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+	}
+
+	mgrWrapper, err := p.resourcesFromParsed(targetPath, pConf, envVarLookup)
+	if err != nil {
+		return confs, err
 	}
 
 	// We can clear all input and output resources as they're not used by procs
@@ -371,9 +419,6 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 			return confs, fmt.Errorf("failed to parse case processors path '%v': %w", procPath, err)
 		}
 	} else {
-		if len(labelsToPaths) == 0 {
-			confSpec.YAMLLabelsToPaths(bundle.GlobalEnvironment, root, labelsToPaths, nil)
-		}
 		if pathSlice, exists = labelsToPaths[procPath]; !exists {
 			return confs, fmt.Errorf("target for label '%v' failed as the label was not found in the test target file, it is not currently possible to target resources imported separate to the test file", procPath)
 		}
@@ -0,0 +1,418 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config/test"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// snapshotFunc is called with each failed condition of an output message along
+// with the path of the condition within its test case. Returns true when the
+// condition has been scheduled to be updated to match the message, in which
+// case the failure should not be reported.
+type snapshotFunc func(path []string, cond test.OutputCondition, part *message.Part) bool
+
+// snapshotConditions checks each condition of a map individually and offers
+// any that fail to a snapshot func, returning the errors of the conditions
+// that could not be snapshotted.
+func snapshotConditions(fs fs.FS, dir string, path []string, conds test.OutputConditionsMap, part *message.Part, snapshot snapshotFunc) (errs []error) {
+	condTypes := make([]string, 0, len(conds))
+	for k := range conds {
+		condTypes = append(condTypes, k)
+	}
+	sort.Strings(condTypes)
+	for _, k := range condTypes {
+		err := conds[k].Check(fs, dir, part)
+		if err == nil {
+			continue
+		}
+		if snapshot(append(append([]string{}, path...), k), conds[k], part) {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%v: %v", k, err))
+	}
+	return
+}
+
+// snapshotUpdate describes an expectation of a test definition that should be
+// replaced with the actual contents of a message. Conditions that reference a
+// file result in the file being rewritten, otherwise the condition is rewritten
+// within the test definition.
+type snapshotUpdate struct {
+	// The path to the condition within the test definition.
+	path  []string
+	value any
+
+	// The path and new contents of a file referenced by the condition.
+	filePath    string
+	fileContent []byte
+}
+
+func newSnapshotUpdate(dir string, path []string, cond test.OutputCondition, part *message.Part) (snapshotUpdate, bool) {
+	switch c := cond.(type) {
+	case test.ContentEqualsCondition:
+		return snapshotUpdate{path: path, value: string(part.AsBytes())}, true
+	case test.ContentJSONEqualsCondition:
+		var v any
+		if err := json.Unmarshal(part.AsBytes(), &v); err != nil {
+			return snapshotUpdate{}, false
+		}
+		return snapshotUpdate{path: path, value: v}, true
+	case test.FileEqualsCondition:
+		return snapshotUpdate{
+			filePath:    filepath.Join(dir, string(c)),
+			fileContent: part.AsBytes(),
+		}, true
+	case test.FileJSONEqualsCondition:
+		var v any
+		if err := json.Unmarshal(part.AsBytes(), &v); err != nil {
+			return snapshotUpdate{}, false
+		}
+		jBytes, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return snapshotUpdate{}, false
+		}
+		return snapshotUpdate{
+			filePath:    filepath.Join(dir, string(c)),
+			fileContent: append(jBytes, '\n'),
+		}, true
+	}
+	return snapshotUpdate{}, false
+}
+
+// newSnapshotNode returns a YAML node of a snapshot value that replaces an
+// existing node, where the style of the original node is respected when
+// possible, i.e. a JSON document that was originally expressed as a string
+// remains a string.
+func newSnapshotNode(node *yaml.Node, v any) (*yaml.Node, error) {
+	if _, isStr := v.(string); !isStr && node.Kind == yaml.ScalarNode {
+		jBytes, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		// Retain the trailing line break of block scalars.
+		if strings.HasSuffix(node.Value, "\n") {
+			jBytes = append(jBytes, '\n')
+		}
+		v = string(jBytes)
+	}
+
+	var newNode yaml.Node
+	if err := newNode.Encode(v); err != nil {
+		return nil, err
+	}
+	if node.Kind == newNode.Kind {
+		newNode.Style = node.Style
+	}
+	return &newNode, nil
+}
+
+// snapshotSet accumulates the snapshot updates of test targets such that each
+// modified file is written once, regardless of how many conditions reference
+// it.
+type snapshotSet struct {
+	count       int
+	files       map[string][]byte
+	definitions map[string][]snapshotUpdate
+}
+
+func newSnapshotSet() *snapshotSet {
+	return &snapshotSet{
+		files:       map[string][]byte{},
+		definitions: map[string][]snapshotUpdate{},
+	}
+}
+
+// add the snapshot updates of a test definition to the set. Returns an error if
+// a file is updated with contents that differ from a previous update of it.
+func (s *snapshotSet) add(definitionPath string, updates []snapshotUpdate) error {
+	for _, u := range updates {
+		if u.filePath == "" {
+			s.definitions[definitionPath] = append(s.definitions[definitionPath], u)
+			s.count++
+			continue
+		}
+		if existing, exists := s.files[u.filePath]; exists && !bytes.Equal(existing, u.fileContent) {
+			return fmt.Errorf("conflicting snapshots for file '%v', which is referenced by conditions with different outputs", u.filePath)
+		}
+		s.files[u.filePath] = u.fileContent
+		s.count++
+	}
+	return nil
+}
+
+// apply writes the snapshot updates to the test definitions and any referenced
+// condition files, returning a diff for each modified file.
+func (s *snapshotSet) apply() (diffs []string, err error) {
+	contents := make(map[string][]byte, len(s.files)+len(s.definitions))
+	for path, content := range s.files {
+		contents[path] = content
+	}
+	for path, updates := range s.definitions {
+		if _, exists := contents[path]; exists {
+			return nil, fmt.Errorf("conflicting snapshots for file '%v', which is both a test definition and referenced by a condition", path)
+		}
+		before, err := ifs.ReadFile(ifs.OS(), path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read test definition '%v': %w", path, err)
+		}
+		if contents[path], err = patchSnapshots(before, updates); err != nil {
+			return nil, fmt.Errorf("failed to update test definition '%v': %w", path, err)
+		}
+	}
+
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		before, err := ifs.ReadFile(ifs.OS(), path)
+		if err != nil {
+			before = nil
+		}
+		after := contents[path]
+		if bytes.Equal(before, after) {
+			continue
+		}
+		if err := ifs.WriteFile(ifs.OS(), path, after, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write snapshot '%v': %w", path, err)
+		}
+		diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(before)),
+			B:        difflib.SplitLines(string(after)),
+			FromFile: path,
+			ToFile:   path,
+			Context:  3,
+		})
+		diffs = append(diffs, diff)
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// patchSnapshots replaces the values of conditions within the source of a test
+// definition with snapshot values. Only the text of each replaced value is
+// modified, leaving the formatting and comments of the rest of the definition
+// untouched.
+func patchSnapshots(src []byte, updates []snapshotUpdate) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(src, &root); err != nil {
+		return nil, err
+	}
+
+	var nodes []*yaml.Node
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		nodes = append(nodes, n)
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	walk(&root)
+
+	var lineStarts []int
+	lineStarts = append(lineStarts, 0)
+	for i, b := range src {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	offsetOf := func(n *yaml.Node) int {
+		if n.Line < 1 || n.Line > len(lineStarts) {
+			return len(src)
+		}
+		offset := lineStarts[n.Line-1]
+		for col := 1; col < n.Column && offset < len(src); col++ {
+			_, size := utf8.DecodeRune(src[offset:])
+			offset += size
+		}
+		return offset
+	}
+
+	type patch struct {
+		start, end int
+		text       string
+	}
+	patches := make([]patch, 0, len(updates))
+	for _, u := range updates {
+		pathStr := strings.Join(u.path, ".")
+
+		condsNode, err := docs.GetYAMLPath(&root, u.path[:len(u.path)-1]...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate snapshot %v: %w", pathStr, err)
+		}
+		if condsNode.Kind != yaml.MappingNode || condsNode.Style&yaml.FlowStyle != 0 {
+			return nil, fmt.Errorf("failed to update snapshot %v: conditions must be a block style mapping", pathStr)
+		}
+
+		var keyNode, valueNode *yaml.Node
+		for i := 0; i < len(condsNode.Content)-1; i += 2 {
+			if condsNode.Content[i].Value == u.path[len(u.path)-1] {
+				keyNode, valueNode = condsNode.Content[i], condsNode.Content[i+1]
+				break
+			}
+		}
+		if keyNode == nil {
+			return nil, fmt.Errorf("failed to locate snapshot %v", pathStr)
+		}
+
+		newNode, err := newSnapshotNode(valueNode, u.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set snapshot %v: %w", pathStr, err)
+		}
+		text, err := snapshotValueText(newNode, keyNode.Column-1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set snapshot %v: %w", pathStr, err)
+		}
+
+		keyStart := offsetOf(keyNode)
+		colon := bytes.IndexByte(src[keyStart:], ':')
+		if colon < 0 {
+			return nil, fmt.Errorf("failed to locate snapshot %v", pathStr)
+		}
+		start := keyStart + colon + 1
+
+		// The value ends where the next node that is not a descendant of it
+		// begins, excluding any whitespace and comments in between.
+		end, hasNext := len(src), false
+		for i, n := range nodes {
+			if n == valueNode {
+				if next := i + snapshotNodeCount(valueNode); next < len(nodes) {
+					end, hasNext = offsetOf(nodes[next]), true
+				}
+				break
+			}
+		}
+		end = snapshotValueEnd(src[:end], start, hasNext, valueNode, keyNode)
+
+		patches = append(patches, patch{start: start, end: end, text: text})
+	}
+
+	sort.Slice(patches, func(i, j int) bool {
+		return patches[i].start > patches[j].start
+	})
+	patched := append([]byte{}, src...)
+	for _, p := range patches {
+		patched = append(patched[:p.start], append([]byte(p.text), patched[p.end:]...)...)
+	}
+	return patched, nil
+}
+
+func snapshotNodeCount(n *yaml.Node) int {
+	count := 1
+	for _, c := range n.Content {
+		count += snapshotNodeCount(c)
+	}
+	return count
+}
+
+// snapshotValueEnd trims the source following the value of a mapping key up to
+// the next node (or the end of the source), such that it ends with the last
+// line of the value, excluding any trailing comment.
+func snapshotValueEnd(src []byte, start int, hasNext bool, valueNode, keyNode *yaml.Node) int {
+	end := len(src)
+
+	// Remove the indentation (and sequence indicators) preceding the next
+	// node.
+	if i := bytes.LastIndexByte(src[start:end], '\n'); hasNext && i >= 0 {
+		end = start + i
+	}
+
+	// Remove blank lines and comments following the value, unless the value
+	// is a block scalar where indented lines are part of its contents.
+	isBlockScalar := valueNode.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0
+	for {
+		lineStart := bytes.LastIndexByte(src[start:end], '\n')
+		if lineStart < 0 {
+			break
+		}
+		lineStart += start + 1
+
+		line := src[lineStart:end]
+		trimmed := bytes.TrimLeft(line, " \t")
+		if len(bytes.TrimSpace(trimmed)) > 0 {
+			if trimmed[0] != '#' || (isBlockScalar && len(line)-len(trimmed) >= keyNode.Column) {
+				break
+			}
+		}
+		end = lineStart - 1
+	}
+
+	// Remove a comment that trails the value on its last line.
+	for _, comment := range []string{valueNode.LineComment, keyNode.LineComment} {
+		if comment == "" {
+			continue
+		}
+		trimmed := bytes.TrimRight(src[start:end], " \t")
+		if bytes.HasSuffix(trimmed, []byte(comment)) {
+			end = start + len(trimmed) - len(comment)
+		}
+	}
+	return start + len(bytes.TrimRight(src[start:end], " \t"))
+}
+
+// snapshotValueText returns the YAML text of a value that follows the colon of
+// a mapping key at a given indentation.
+func snapshotValueText(value *yaml.Node, indent int) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&yaml.Node{
+		Kind:    yaml.MappingNode,
+		Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "k"}, value},
+	}); err != nil {
+		return "", err
+	}
+
+	text := strings.TrimSuffix(strings.TrimPrefix(buf.String(), "k:"), "\n")
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = strings.Repeat(" ", indent) + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// snapshotCollector returns a snapshot func that collects updates for a test
+// case of a given index.
+func snapshotCollector(dir string, caseIndex int, updates *[]snapshotUpdate) snapshotFunc {
+	return func(path []string, cond test.OutputCondition, part *message.Part) bool {
+		u, ok := newSnapshotUpdate(dir, append([]string{"tests", strconv.Itoa(caseIndex)}, path...), cond, part)
+		if ok {
+			*updates = append(*updates, u)
+		}
+		return ok
+	}
+}
+
+func colorDiff(diff string) string {
+	lines := strings.Split(diff, "\n")
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "+++"), strings.HasPrefix(l, "---"):
+		case strings.HasPrefix(l, "+"):
+			lines[i] = green(l)
+		case strings.HasPrefix(l, "-"):
+			lines[i] = red(l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,139 @@
+package test_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/test"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestCommandUpdateSnapshots(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"foo.yaml": `
+pipeline:
+  processors:
+  - mapping: 'root = this.map_each(kv -> kv.value.uppercase())'
+`,
+		"foo_benthos_test.yaml": `tests:
+- name: first test
+  target_processors:    "/pipeline/processors"
+  input_batch:
+  - content: '{"a":"foo","b":"bar"}'
+  - content: '{"a":"baz"}'
+  - content: '{"a":"buz"}'
+  - content: '{"a":"bev"}'
+  output_batches:
+  - - json_equals: '{"a":"nope"}' # This comment remains
+    - json_equals:
+          a: nope
+          b: nope
+
+      # As does this one
+    - file_json_equals: ./expected.json
+      metadata_equals: {}
+    - json_equals: |
+        {"a":"nope"}
+- name: second test
+  target_processors:    "/pipeline/processors"
+  input_batch:
+  - content: '{"a":"buz"}'
+  output_batches:
+  - - content_equals: '{"a":"BUZ"}'
+      file_json_equals: expected.json
+`,
+		"expected.json": `{"a":"nope"}`,
+	})
+	require.NoError(t, err)
+
+	assert.False(t, test.RunAll([]string{filepath.Join(testDir, "foo.yaml")}, "_benthos_test", false, log.Noop(), nil))
+	assert.True(t, test.RunAll([]string{filepath.Join(testDir, "foo.yaml")}, "_benthos_test", false, log.Noop(), nil, test.OptUpdateSnapshots(true)))
+	assert.True(t, test.RunAll([]string{filepath.Join(testDir, "foo.yaml")}, "_benthos_test", false, log.Noop(), nil))
+
+	// Only the replaced values are modified.
+	defBytes, err := os.ReadFile(filepath.Join(testDir, "foo_benthos_test.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `tests:
+- name: first test
+  target_processors:    "/pipeline/processors"
+  input_batch:
+  - content: '{"a":"foo","b":"bar"}'
+  - content: '{"a":"baz"}'
+  - content: '{"a":"buz"}'
+  - content: '{"a":"bev"}'
+  output_batches:
+  - - json_equals: '{"a":"FOO","b":"BAR"}' # This comment remains
+    - json_equals:
+        a: BAZ
+
+      # As does this one
+    - file_json_equals: ./expected.json
+      metadata_equals: {}
+    - json_equals: |
+        {"a":"BEV"}
+- name: second test
+  target_processors:    "/pipeline/processors"
+  input_batch:
+  - content: '{"a":"buz"}'
+  output_batches:
+  - - content_equals: '{"a":"BUZ"}'
+      file_json_equals: expected.json
+`, string(defBytes))
+
+	expBytes, err := os.ReadFile(filepath.Join(testDir, "expected.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": \"BUZ\"\n}\n", string(expBytes))
+}
+
+func TestCommandUpdateSnapshotsConflict(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"foo.yaml": `
+pipeline:
+  processors:
+  - mapping: 'root = content().uppercase()'
+`,
+		"foo_benthos_test.yaml": `tests:
+  - name: first test
+    target_processors: '/pipeline/processors'
+    input_batch:
+      - content: 'foo'
+      - content: 'bar'
+    output_batches:
+      - - file_equals: ./expected.txt
+        - file_equals: ./expected.txt
+`,
+		"expected.txt": `nope`,
+	})
+	require.NoError(t, err)
+
+	assert.False(t, test.RunAll([]string{filepath.Join(testDir, "foo.yaml")}, "_benthos_test", false, log.Noop(), nil, test.OptUpdateSnapshots(true)))
+
+	expBytes, err := os.ReadFile(filepath.Join(testDir, "expected.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "nope", string(expBytes))
+}
+
+func TestCommandUpdateSnapshotsUnsupported(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"foo.yaml": `
+pipeline:
+  processors:
+  - mapping: 'root = content().uppercase()'
+`,
+		"foo_benthos_test.yaml": `tests:
+  - name: first test
+    target_processors: '/pipeline/processors'
+    input_batch:
+      - content: 'foo'
+    output_batches:
+      - - content_matches: '^bar$'
+`,
+	})
+	require.NoError(t, err)
+
+	assert.False(t, test.RunAll([]string{filepath.Join(testDir, "foo.yaml")}, "_benthos_test", false, log.Noop(), nil, test.OptUpdateSnapshots(true)))
+}
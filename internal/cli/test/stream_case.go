@@ -0,0 +1,176 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/config/test"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// The maximum period of time to wait for all input fixture batches to be
+// acknowledged before the stream under test is shut down.
+var streamCaseAckTimeout = time.Second * 10
+
+// The maximum period of time to wait for the stream under test to shut down.
+var streamCaseStopTimeout = time.Second * 10
+
+type fixtureAck struct {
+	resolved bool
+	err      error
+}
+
+func executeStreamFrom(fs fs.FS, dir string, c test.Case, provider StreamProvider, snapshot snapshotFunc) (failures []CaseFailure, err error) {
+	if len(c.InputBatches) > 0 || len(c.OutputBatches) > 0 {
+		return nil, errors.New("input and output batches cannot be used with a target stream, use input_fixtures and output_fixtures instead")
+	}
+
+	reportFailure := func(reason string) {
+		failures = append(failures, CaseFailure{
+			Name:     c.Name,
+			TestLine: c.Line(),
+			Reason:   reason,
+		})
+	}
+
+	inputKeys := make([]string, 0, len(c.InputFixtures))
+	for k := range c.InputFixtures {
+		inputKeys = append(inputKeys, k)
+	}
+	sort.Strings(inputKeys)
+
+	outputKeys := make([]string, 0, len(c.OutputFixtures))
+	for k := range c.OutputFixtures {
+		outputKeys = append(outputKeys, k)
+	}
+	sort.Strings(outputKeys)
+
+	inputMsgs := make([][]message.Batch, len(inputKeys))
+	for i, k := range inputKeys {
+		if inputMsgs[i], err = inputBatchesToMessages(fs, dir, c.InputFixtures[k].InputBatches); err != nil {
+			return nil, fmt.Errorf("input fixture '%v': %w", k, err)
+		}
+	}
+
+	strm, err := provider.ProvideStream(c.TargetStream, c.Environment, c.Mocks, inputKeys, outputKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise stream '%v': %v", c.TargetStream, err)
+	}
+
+	// Consume from all output fixtures until they are closed during shutdown.
+	var outputWG sync.WaitGroup
+	outputMsgs := make([][]message.Batch, len(outputKeys))
+	for i, k := range outputKeys {
+		pipe, _ := strm.OutputPipe(k)
+
+		var rejectErr error
+		if r := c.OutputFixtures[k].Reject; r != "" {
+			rejectErr = errors.New(r)
+		}
+
+		outputWG.Add(1)
+		go func(i int) {
+			defer outputWG.Done()
+			for tran := range pipe {
+				outputMsgs[i] = append(outputMsgs[i], tran.Payload.ShallowCopy())
+				_ = tran.Ack(context.Background(), rejectErr)
+			}
+		}(i)
+	}
+
+	var ackMut sync.Mutex
+	acks := make([][]fixtureAck, len(inputKeys))
+	pending, feeding := 0, true
+	allAcked := make(chan struct{})
+
+	ackCtx, ackDone := context.WithTimeout(context.Background(), streamCaseAckTimeout)
+	defer ackDone()
+
+feedLoop:
+	for i, k := range inputKeys {
+		pipe, _ := strm.InputPipe(k)
+		acks[i] = make([]fixtureAck, len(inputMsgs[i]))
+		for j, b := range inputMsgs[i] {
+			ackMut.Lock()
+			pending++
+			ackMut.Unlock()
+
+			i, j := i, j
+			tran := message.NewTransactionFunc(b, func(ctx context.Context, err error) error {
+				ackMut.Lock()
+				defer ackMut.Unlock()
+				if acks[i][j].resolved {
+					return nil
+				}
+				acks[i][j] = fixtureAck{resolved: true, err: err}
+				if pending--; pending == 0 && !feeding {
+					close(allAcked)
+				}
+				return nil
+			})
+
+			select {
+			case pipe <- tran:
+			case <-ackCtx.Done():
+				ackMut.Lock()
+				pending--
+				ackMut.Unlock()
+				reportFailure(fmt.Sprintf("input fixture '%v': timed out delivering batch %v", k, j))
+				break feedLoop
+			}
+		}
+	}
+
+	ackMut.Lock()
+	if feeding = false; pending == 0 {
+		close(allAcked)
+	}
+	ackMut.Unlock()
+
+	select {
+	case <-allAcked:
+	case <-ackCtx.Done():
+	}
+
+	stopCtx, stopDone := context.WithTimeout(context.Background(), streamCaseStopTimeout)
+	defer stopDone()
+	if err := strm.Stop(stopCtx); err != nil {
+		reportFailure(fmt.Sprintf("failed to cleanly shut down stream: %v", err))
+	}
+	outputWG.Wait()
+
+	ackMut.Lock()
+	defer ackMut.Unlock()
+
+	for i, k := range inputKeys {
+		expAcks := c.InputFixtures[k].Acks
+		for j, a := range acks[i] {
+			if !a.resolved {
+				reportFailure(fmt.Sprintf("input fixture '%v': batch %v was not acknowledged", k, j))
+				continue
+			}
+			if len(expAcks) <= j {
+				continue
+			}
+			switch {
+			case expAcks[j] == test.AckResultAck && a.err != nil:
+				reportFailure(fmt.Sprintf("input fixture '%v': batch %v expected %v, got %v: %v", k, j, test.AckResultAck, test.AckResultNack, red(a.err)))
+			case expAcks[j] == test.AckResultNack && a.err == nil:
+				reportFailure(fmt.Sprintf("input fixture '%v': batch %v expected %v, got %v", k, j, test.AckResultNack, test.AckResultAck))
+			}
+		}
+	}
+
+	for i, k := range outputKeys {
+		path := []string{"output_fixtures", k, "output_batches"}
+		checkOutputBatches(fs, dir, path, c.OutputFixtures[k].OutputBatches, outputMsgs[i], func(reason string) {
+			reportFailure(fmt.Sprintf("output fixture '%v': %v", k, reason))
+		}, snapshot)
+	}
+	return
+}
@@ -0,0 +1,155 @@
+package test_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/test"
+	dtest "github.com/benthosdev/benthos/v4/internal/config/test"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestStreamCase(t *testing.T) {
+	color.NoColor = true
+
+	testDir, err := initTestFiles(t, map[string]string{
+		"config1.yaml": `
+input:
+  label: foo_in
+  generate:
+    mapping: 'root = "not used"'
+  processors:
+    - mapping: 'root = content().uppercase()'
+
+pipeline:
+  processors:
+    - label: fetch_stuff
+      http:
+        url: http://example.com/nope
+
+output:
+  switch:
+    cases:
+      - check: content().contains("BAR")
+        output:
+          label: bar_out
+          drop: {}
+      - output:
+          fallback:
+            - label: primary_out
+              drop: {}
+            - label: secondary_out
+              drop: {}
+`,
+	})
+	require.NoError(t, err)
+
+	defYAML := `
+tests:
+  - name: routing
+    target_stream: ./config1.yaml
+    mocks:
+      fetch_stuff:
+        mapping: 'root = content().string() + " MOCKED"'
+    input_fixtures:
+      foo_in:
+        input_batches:
+          - - content: foo
+          - - content: bar
+        acks: [ ack, ack ]
+    output_fixtures:
+      bar_out:
+        output_batches:
+          - - content_equals: BAR MOCKED
+      primary_out:
+        reject: simulated outage
+        output_batches:
+          - - content_equals: FOO MOCKED
+      secondary_out:
+        output_batches:
+          - - content_equals: FOO MOCKED
+
+  - name: all rejected
+    target_stream: ./config1.yaml
+    mocks:
+      fetch_stuff:
+        mapping: 'root = content()'
+    input_fixtures:
+      /input:
+        input_batches:
+          - - content: foo
+        acks: [ ack ]
+    output_fixtures:
+      /output/switch/cases/1/output/fallback/0:
+        reject: nope
+      /output/switch/cases/1/output/fallback/1:
+        reject: nope again
+        output_batches:
+          - - content_equals: nah
+`
+
+	node, err := docs.UnmarshalYAML([]byte(defYAML))
+	require.NoError(t, err)
+
+	cases, err := dtest.FromAny(node)
+	require.NoError(t, err)
+	require.Len(t, cases, 2)
+
+	failures, err := test.Execute(cases, filepath.Join(testDir, "config1.yaml"), nil, log.Noop())
+	require.NoError(t, err)
+
+	var failStrs []string
+	for _, f := range failures {
+		failStrs = append(failStrs, f.Reason)
+	}
+	assert.Equal(t, []string{
+		"input fixture '/input': batch 0 expected ack, got nack: nope again",
+		"output fixture '/output/switch/cases/1/output/fallback/0': unexpected batch: [FOO]",
+		"output fixture '/output/switch/cases/1/output/fallback/1': batch 0 message 0: content_equals: content mismatch\n  expected: nah\n  received: FOO",
+	}, failStrs)
+	for _, f := range failures {
+		assert.Equal(t, "all rejected", f.Name)
+	}
+}
+
+func TestStreamCaseErrors(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"config1.yaml": `
+input:
+  generate:
+    mapping: 'root = "not used"'
+
+output:
+  label: foo_out
+  drop: {}
+`,
+	})
+	require.NoError(t, err)
+
+	_, err = test.Execute([]dtest.Case{
+		{
+			Name:         "bad label",
+			TargetStream: "./config1.yaml",
+			InputFixtures: map[string]dtest.InputFixture{
+				"nope": {},
+			},
+		},
+	}, filepath.Join(testDir, "config1.yaml"), nil, log.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "label 'nope' was not found")
+
+	_, err = test.Execute([]dtest.Case{
+		{
+			Name:         "mixed modes",
+			TargetStream: "./config1.yaml",
+			InputBatches: [][]dtest.InputConfig{{{Content: "foo"}}},
+		},
+	}, filepath.Join(testDir, "config1.yaml"), nil, log.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be used with a target stream")
+}
@@ -0,0 +1,199 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jeffail/gabs/v2"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+// FixtureStream is a running stream constructed from a Benthos config where
+// targeted inputs and outputs have been replaced with in-memory fixtures.
+type FixtureStream struct {
+	strm *stream.Type
+	mgr  *manager.Type
+
+	inputs  map[string]chan message.Transaction
+	outputs map[string]<-chan message.Transaction
+}
+
+// InputPipe returns a channel that feeds transactions into an input fixture
+// identified by the key used to target it.
+func (f *FixtureStream) InputPipe(key string) (chan<- message.Transaction, bool) {
+	c, exists := f.inputs[key]
+	return c, exists
+}
+
+// OutputPipe returns a channel that yields transactions from an output fixture
+// identified by the key used to target it. The channel is closed once the
+// output has shut down.
+func (f *FixtureStream) OutputPipe(key string) (<-chan message.Transaction, bool) {
+	c, exists := f.outputs[key]
+	return c, exists
+}
+
+// Stop the stream and all of its resources, giving in-flight messages the
+// opportunity to complete.
+func (f *FixtureStream) Stop(ctx context.Context) error {
+	if err := f.strm.StopGracefully(ctx); err != nil {
+		_ = f.strm.StopUnordered(ctx)
+	}
+	f.mgr.TriggerStopConsuming()
+	f.mgr.TriggerCloseNow()
+	return f.mgr.WaitForClose(ctx)
+}
+
+//------------------------------------------------------------------------------
+
+func fixturePipeName(cType docs.Type, i int) string {
+	return fmt.Sprintf("_benthos_test_%v_fixture_%v", cType, i)
+}
+
+// setFixture replaces the plugin of an input or output with an inproc plugin
+// connected to a named pipe, retaining the label and processors of the
+// component.
+func setFixture(root *yaml.Node, pipe string, pathSlice ...string) error {
+	target, err := docs.GetYAMLPath(root, pathSlice...)
+	if err != nil {
+		return err
+	}
+	if target.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %v: expected a component object", target.Line)
+	}
+
+	var newContent []*yaml.Node
+	for i := 0; i < len(target.Content)-1; i += 2 {
+		switch target.Content[i].Value {
+		case "label", "processors":
+			newContent = append(newContent, target.Content[i], target.Content[i+1])
+		}
+	}
+
+	var keyNode, valueNode yaml.Node
+	if err := keyNode.Encode("inproc"); err != nil {
+		return err
+	}
+	if err := valueNode.Encode(pipe); err != nil {
+		return err
+	}
+	target.Content = append(newContent, &keyNode, &valueNode)
+	return nil
+}
+
+func fixturePath(labelsToPaths map[string][]string, key string) ([]string, error) {
+	if strings.HasPrefix(key, "/") {
+		return gabs.JSONPointerToSlice(key)
+	}
+	pathSlice, exists := labelsToPaths[key]
+	if !exists {
+		return nil, fmt.Errorf("label '%v' was not found in the test target file", key)
+	}
+	return pathSlice, nil
+}
+
+// ProvideStream attempts to construct and run an entire stream from a Benthos
+// config file, where the inputs and outputs identified by a list of labels or
+// JSON pointers are replaced with in-memory fixtures. Supports injected mocked
+// components in the parsed config.
+func (p *ProcessorsProvider) ProvideStream(path string, environment map[string]string, mocks map[string]any, inputs, outputs []string) (*FixtureStream, error) {
+	targetPath := path
+	if !filepath.IsAbs(targetPath) {
+		targetPath = filepath.Join(filepath.Dir(p.targetPath), targetPath)
+	}
+
+	cleanupEnv := setEnvironment(environment)
+	defer cleanupEnv()
+
+	envVarLookup := func(name string) (string, bool) {
+		if s, ok := environment[name]; ok {
+			return s, true
+		}
+		return os.LookupEnv(name)
+	}
+
+	root, labelsToPaths, err := p.readMockedConfig(targetPath, envVarLookup, mocks)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve all paths before modifying the config as labels are removed
+	// along with the components they belong to.
+	inputPaths := make([][]string, len(inputs))
+	for i, k := range inputs {
+		if inputPaths[i], err = fixturePath(labelsToPaths, k); err != nil {
+			return nil, fmt.Errorf("failed to resolve input fixture '%v': %w", k, err)
+		}
+	}
+	outputPaths := make([][]string, len(outputs))
+	for i, k := range outputs {
+		if outputPaths[i], err = fixturePath(labelsToPaths, k); err != nil {
+			return nil, fmt.Errorf("failed to resolve output fixture '%v': %w", k, err)
+		}
+	}
+
+	for i, k := range inputs {
+		if err := setFixture(root, fixturePipeName(docs.TypeInput, i), inputPaths[i]...); err != nil {
+			return nil, fmt.Errorf("failed to set input fixture '%v': %w", k, err)
+		}
+	}
+	for i, k := range outputs {
+		if err := setFixture(root, fixturePipeName(docs.TypeOutput, i), outputPaths[i]...); err != nil {
+			return nil, fmt.Errorf("failed to set output fixture '%v': %w", k, err)
+		}
+	}
+
+	pConf, err := config.Spec().ParsedConfigFromAny(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+	}
+
+	streamConf, err := stream.FromParsed(bundle.GlobalEnvironment, pConf, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+	}
+
+	mgrConf, err := p.resourcesFromParsed(targetPath, pConf, envVarLookup)
+	if err != nil {
+		return nil, err
+	}
+
+	mgr, err := manager.New(mgrConf, p.managerOpts()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise resources: %v", err)
+	}
+
+	f := &FixtureStream{
+		mgr:     mgr,
+		inputs:  map[string]chan message.Transaction{},
+		outputs: map[string]<-chan message.Transaction{},
+	}
+	for i, k := range inputs {
+		tChan := make(chan message.Transaction)
+		mgr.SetPipe(fixturePipeName(docs.TypeInput, i), tChan)
+		f.inputs[k] = tChan
+	}
+
+	if f.strm, err = stream.New(streamConf, mgr); err != nil {
+		mgr.TriggerCloseNow()
+		return nil, fmt.Errorf("failed to initialise stream: %v", err)
+	}
+
+	for i, k := range outputs {
+		if f.outputs[k], err = mgr.GetPipe(fixturePipeName(docs.TypeOutput, i)); err != nil {
+			_ = f.Stop(context.Background())
+			return nil, fmt.Errorf("output fixture '%v' was not initialised, the output may not be reachable within the stream: %v", k, err)
+		}
+	}
+	return f, nil
+}
@@ -2,7 +2,12 @@ package interop
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/codec"
 	"github.com/benthosdev/benthos/v4/internal/component/scanner"
@@ -17,7 +22,7 @@ const (
 
 func OldReaderCodecFields(defaultScanner string) []*service.ConfigField {
 	return []*service.ConfigField{
-		service.NewInternalField(codec.NewReaderDocs(fieldCodecFromString)).Deprecated().Optional(),
+		service.NewInternalField(codec.NewReaderDocs(fieldCodecFromString).FixerFunc(fixOldReaderCodec)).Deprecated().Optional(),
 		service.NewIntField(crFieldMaxBuffer).Deprecated().Default(1000000),
 		service.NewScannerField(crFieldCodec).
 			Description("The [scanner](/docs/components/scanners/about) by which the stream of bytes consumed will be broken out into individual messages. Scanners are useful for processing large sources of data without holding the entirety of it within memory. For example, the `csv` scanner allows you to process individual CSV rows without loading the entire CSV file in memory at once.").
@@ -27,6 +32,117 @@ func OldReaderCodecFields(defaultScanner string) []*service.ConfigField {
 	}
 }
 
+// oldCodecToScanner converts the name of a deprecated codec into an equivalent
+// scanner config, returns false if there is no direct equivalent.
+func oldCodecToScanner(codecName string, maxBuffer int) (any, bool) {
+	switch codecName {
+	case "csv-gzip":
+		codecName = "gzip/csv"
+	case "tar-gzip":
+		codecName = "gzip/tar"
+	}
+
+	parts := strings.Split(codecName, "/")
+
+	var scanner any
+	switch base := parts[len(parts)-1]; {
+	case base == "all-bytes":
+		scanner = map[string]any{"to_the_end": map[string]any{}}
+	case base == "lines":
+		scanner = map[string]any{"lines": map[string]any{"max_buffer_size": maxBuffer}}
+	case strings.HasPrefix(base, "delim:") && len(base) > 6:
+		scanner = map[string]any{"lines": map[string]any{
+			"custom_delimiter": strings.TrimPrefix(base, "delim:"),
+			"max_buffer_size":  maxBuffer,
+		}}
+	case base == "csv":
+		scanner = map[string]any{"csv": map[string]any{}}
+	case strings.HasPrefix(base, "csv:") && len([]rune(base)) == 5:
+		scanner = map[string]any{"csv": map[string]any{"custom_delimiter": strings.TrimPrefix(base, "csv:")}}
+	case strings.HasPrefix(base, "chunker:"):
+		size, err := strconv.Atoi(strings.TrimPrefix(base, "chunker:"))
+		if err != nil {
+			return nil, false
+		}
+		scanner = map[string]any{"chunker": map[string]any{"size": size}}
+	case strings.HasPrefix(base, "regex:") && len(base) > 6:
+		scanner = map[string]any{"re_match": map[string]any{
+			"pattern":         strings.TrimPrefix(base, "regex:"),
+			"max_buffer_size": maxBuffer,
+		}}
+	case base == "tar":
+		scanner = map[string]any{"tar": map[string]any{}}
+	default:
+		return nil, false
+	}
+
+	for i := len(parts) - 2; i >= 0; i-- {
+		switch parts[i] {
+		case "gzip", "pgzip":
+			scanner = map[string]any{"decompress": map[string]any{
+				"algorithm": parts[i],
+				"into":      scanner,
+			}}
+		case "skipbom":
+			scanner = map[string]any{"skip_bom": map[string]any{"into": scanner}}
+		default:
+			return nil, false
+		}
+	}
+	return scanner, true
+}
+
+// fixOldReaderCodec rewrites a deprecated codec field, along with max_buffer,
+// into the equivalent scanner field.
+func fixOldReaderCodec(parent *yaml.Node) (string, error) {
+	codecIndex, maxBuffer := -1, 1000000
+	for i := 0; i < len(parent.Content)-1; i += 2 {
+		switch parent.Content[i].Value {
+		case fieldCodecFromString:
+			codecIndex = i
+		case crFieldMaxBuffer:
+			var err error
+			if maxBuffer, err = strconv.Atoi(parent.Content[i+1].Value); err != nil {
+				return "", nil
+			}
+		}
+	}
+	if codecIndex == -1 {
+		return "", nil
+	}
+
+	codecName := parent.Content[codecIndex+1].Value
+	scanner, ok := oldCodecToScanner(codecName, maxBuffer)
+	if !ok {
+		return "", nil
+	}
+
+	var scannerNode yaml.Node
+	if err := scannerNode.Encode(scanner); err != nil {
+		return "", err
+	}
+
+	var newContent []*yaml.Node
+	for i := 0; i < len(parent.Content)-1; i += 2 {
+		switch parent.Content[i].Value {
+		case fieldCodecFromString:
+			keyNode := parent.Content[i]
+			keyNode.Value = crFieldCodec
+			if keyNode.LineComment == "" {
+				keyNode.LineComment = parent.Content[i+1].LineComment
+			}
+			newContent = append(newContent, keyNode, &scannerNode)
+		case crFieldMaxBuffer, crFieldCodec:
+			// The scanner field is ignored when a codec is set, and max_buffer
+			// is now a field of the scanner.
+		default:
+			newContent = append(newContent, parent.Content[i], parent.Content[i+1])
+		}
+	}
+	parent.Content = newContent
+	return fmt.Sprintf("field %v is deprecated, replaced codec %v with an equivalent %v", fieldCodecFromString, codecName, crFieldCodec), nil
+}
+
 type FallbackReaderCodec interface {
 	Create(rdr io.ReadCloser, aFn service.AckFunc, details scanner.SourceDetails) (FallbackReaderStream, error)
 	Close(context.Context) error
@@ -8,9 +8,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/codec/interop"
 	"github.com/benthosdev/benthos/v4/internal/component/scanner"
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/public/service"
 
 	_ "github.com/benthosdev/benthos/v4/public/components/pure"
@@ -133,3 +136,88 @@ func TestInteropCodecDefault(t *testing.T) {
 	require.NoError(t, strm.Close(context.Background()))
 	assert.True(t, acked)
 }
+
+func TestInteropCodecFix(t *testing.T) {
+	var fields docs.FieldSpecs
+	for _, f := range interop.OldReaderCodecFields("lines") {
+		fields = append(fields, f.XUnwrapper().(interface {
+			Unwrap() docs.FieldSpec
+		}).Unwrap())
+	}
+
+	for _, test := range []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{
+			name: "lines with max buffer",
+			input: `codec: lines # split lines
+max_buffer: 500
+`,
+			output: `scanner: # split lines
+  lines:
+    max_buffer_size: 500
+`,
+		},
+		{
+			name: "custom delimiter",
+			input: `codec: delim:foo
+`,
+			output: `scanner:
+  lines:
+    custom_delimiter: foo
+    max_buffer_size: 1000000
+`,
+		},
+		{
+			name: "decompressed csv",
+			input: `codec: csv-gzip
+scanner:
+  to_the_end: {}
+`,
+			output: `scanner:
+  decompress:
+    algorithm: gzip
+    into:
+      csv: {}
+`,
+		},
+		{
+			name: "nested decompress and skip bom",
+			input: `codec: pgzip/skipbom/chunker:100
+`,
+			output: `scanner:
+  decompress:
+    algorithm: pgzip
+    into:
+      skip_bom:
+        into:
+          chunker:
+            size: 100
+`,
+		},
+		{
+			name: "no equivalent scanner",
+			input: `codec: auto
+`,
+			output: `codec: auto
+`,
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.input), &node))
+
+			_, err := fields.FixYAML(docs.NewLintContext(docs.NewLintConfig(bundle.GlobalEnvironment)), node.Content[0])
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			enc := yaml.NewEncoder(&buf)
+			enc.SetIndent(2)
+			require.NoError(t, enc.Encode(&node))
+			assert.Equal(t, test.output, buf.String())
+		})
+	}
+}
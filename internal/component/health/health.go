@@ -0,0 +1,308 @@
+// Package health provides a mechanism for tracking the state of the components
+// of a running service, such as whether they are connected and when they last
+// succeeded or failed, in order to report on them in detail.
+package health
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+// State describes the health of a component.
+type State string
+
+// The states that a component can be in.
+const (
+	// StateConnecting means the component has not yet established a
+	// connection, or has lost it and is attempting to reconnect.
+	StateConnecting State = "connecting"
+
+	// StateConnected means the component is connected and its most recent
+	// operation succeeded.
+	StateConnected State = "connected"
+
+	// StateDegraded means the component is connected but its most recent
+	// operation failed.
+	StateDegraded State = "degraded"
+
+	// StateFailed means the most recent attempt of the component to connect
+	// failed.
+	StateFailed State = "failed"
+)
+
+// Healthy returns whether the state describes a component that is able to
+// operate, which is the case for connected and degraded components.
+func (s State) Healthy() bool {
+	return s == StateConnected || s == StateDegraded
+}
+
+// Kind describes the kind of a component.
+type Kind string
+
+// The kinds of component that are tracked.
+const (
+	KindInput     Kind = "input"
+	KindOutput    Kind = "output"
+	KindProcessor Kind = "processor"
+	KindCache     Kind = "cache"
+	KindRateLimit Kind = "rate_limit"
+)
+
+// Status is a snapshot of the health of a component.
+type Status struct {
+	Stream            string     `json:"stream,omitempty"`
+	Kind              Kind       `json:"kind"`
+	Name              string     `json:"name"`
+	Type              string     `json:"type,omitempty"`
+	State             State      `json:"state"`
+	LastError         string     `json:"last_error,omitempty"`
+	LastErrorAt       *time.Time `json:"last_error_at,omitempty"`
+	LastSuccessAt     *time.Time `json:"last_success_at,omitempty"`
+	ReconnectAttempts int64      `json:"reconnect_attempts"`
+
+	// Whether the component was selected as critical to the health of the
+	// service when the status was reported.
+	Critical bool `json:"critical"`
+}
+
+//------------------------------------------------------------------------------
+
+// Tracker records the health of a single component. All methods are safe to
+// call on a nil tracker, in which case they do nothing.
+type Tracker struct {
+	reg     *registryState
+	stream  string
+	kind    Kind
+	name    string
+	typeStr string
+
+	lastSuccess atomic.Int64
+
+	mut         sync.Mutex
+	connected   bool
+	attempts    int64
+	lastErr     error
+	lastErrTime time.Time
+}
+
+// Open adds the tracker to its registry, after which the component it tracks
+// is reported until the tracker is closed.
+func (t *Tracker) Open() {
+	if t == nil {
+		return
+	}
+	t.reg.add(t)
+}
+
+// Close removes the tracker from its registry.
+func (t *Tracker) Close() {
+	if t == nil {
+		return
+	}
+	t.reg.remove(t)
+}
+
+// SetConnected records that the component has established a connection,
+// resetting the count of reconnect attempts.
+func (t *Tracker) SetConnected() {
+	if t == nil {
+		return
+	}
+	t.mut.Lock()
+	t.connected = true
+	t.attempts = 0
+	t.mut.Unlock()
+}
+
+// SetDisconnected records that the component has lost its connection.
+func (t *Tracker) SetDisconnected() {
+	if t == nil {
+		return
+	}
+	t.mut.Lock()
+	t.connected = false
+	t.mut.Unlock()
+}
+
+// ConnectionFailed records a failed attempt of the component to connect.
+func (t *Tracker) ConnectionFailed(err error) {
+	if t == nil {
+		return
+	}
+	t.mut.Lock()
+	t.connected = false
+	t.attempts++
+	t.lastErr = err
+	t.lastErrTime = time.Now()
+	t.mut.Unlock()
+}
+
+// Succeeded records a successful operation of the component.
+func (t *Tracker) Succeeded() {
+	if t == nil {
+		return
+	}
+	t.lastSuccess.Store(time.Now().UnixNano())
+}
+
+// Failed records a failed operation of the component.
+func (t *Tracker) Failed(err error) {
+	if t == nil {
+		return
+	}
+	t.mut.Lock()
+	t.lastErr = err
+	t.lastErrTime = time.Now()
+	t.mut.Unlock()
+}
+
+// Status returns a snapshot of the health of the component.
+func (t *Tracker) Status() Status {
+	s := Status{
+		Stream: t.stream,
+		Kind:   t.kind,
+		Name:   t.name,
+		Type:   t.typeStr,
+	}
+
+	var lastSuccess time.Time
+	if n := t.lastSuccess.Load(); n > 0 {
+		lastSuccess = time.Unix(0, n)
+		s.LastSuccessAt = &lastSuccess
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	s.ReconnectAttempts = t.attempts
+	if t.lastErr != nil {
+		lastErrTime := t.lastErrTime
+		s.LastError = t.lastErr.Error()
+		s.LastErrorAt = &lastErrTime
+	}
+
+	switch {
+	case !t.connected && t.attempts > 0:
+		s.State = StateFailed
+	case !t.connected:
+		s.State = StateConnecting
+	case t.lastErr != nil && t.lastErrTime.After(lastSuccess):
+		s.State = StateDegraded
+	default:
+		s.State = StateConnected
+	}
+	return s
+}
+
+//------------------------------------------------------------------------------
+
+type registryState struct {
+	mut      sync.Mutex
+	trackers map[*Tracker]struct{}
+}
+
+func (r *registryState) add(t *Tracker) {
+	r.mut.Lock()
+	r.trackers[t] = struct{}{}
+	r.mut.Unlock()
+}
+
+func (r *registryState) remove(t *Tracker) {
+	r.mut.Lock()
+	delete(r.trackers, t)
+	r.mut.Unlock()
+}
+
+// Registry tracks the health of the components of a service. A registry can be
+// scoped to a stream with ForStream, where all scopes share the same
+// components.
+type Registry struct {
+	stream string
+	state  *registryState
+}
+
+// NewRegistry creates an empty registry of component health.
+func NewRegistry() *Registry {
+	return &Registry{
+		state: &registryState{
+			trackers: map[*Tracker]struct{}{},
+		},
+	}
+}
+
+// ForStream returns a variant of the registry where components are tracked as
+// belonging to a stream.
+func (r *Registry) ForStream(id string) *Registry {
+	return &Registry{stream: id, state: r.state}
+}
+
+// NewTracker creates a tracker for a component of the registry, which is
+// reported once opened.
+func (r *Registry) NewTracker(kind Kind, name, typeStr string) *Tracker {
+	return &Tracker{
+		reg:     r.state,
+		stream:  r.stream,
+		kind:    kind,
+		name:    name,
+		typeStr: typeStr,
+	}
+}
+
+// Statuses returns a snapshot of the health of all open components, sorted by
+// stream, kind and name.
+func (r *Registry) Statuses() []Status {
+	r.state.mut.Lock()
+	trackers := make([]*Tracker, 0, len(r.state.trackers))
+	for t := range r.state.trackers {
+		trackers = append(trackers, t)
+	}
+	r.state.mut.Unlock()
+
+	statuses := make([]Status, 0, len(trackers))
+	for _, t := range trackers {
+		statuses = append(statuses, t.Status())
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if statuses[i].Stream != statuses[j].Stream {
+			return statuses[i].Stream < statuses[j].Stream
+		}
+		if statuses[i].Kind != statuses[j].Kind {
+			return statuses[i].Kind < statuses[j].Kind
+		}
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+//------------------------------------------------------------------------------
+
+// Provider is implemented by component managers that provide a registry for
+// tracking the health of the component they belong to.
+type Provider interface {
+	Label() string
+	Path() []string
+	Health() *Registry
+}
+
+// NewTracker returns a tracker for the component that owns the provided
+// manager, which is identified by its label or otherwise its path. Returns nil
+// if the manager does not provide a registry or the component cannot be
+// identified.
+func NewTracker(mgr any, kind Kind, typeStr string) *Tracker {
+	p, ok := mgr.(Provider)
+	if !ok || p.Health() == nil {
+		return nil
+	}
+	name := p.Label()
+	if name == "" {
+		if len(p.Path()) == 0 {
+			return nil
+		}
+		name = "root." + query.SliceToDotPath(p.Path()...)
+	}
+	return p.Health().NewTracker(kind, name, typeStr)
+}
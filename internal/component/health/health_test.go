@@ -0,0 +1,100 @@
+package health
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	label string
+	path  []string
+	reg   *Registry
+}
+
+func (f fakeProvider) Label() string     { return f.label }
+func (f fakeProvider) Path() []string    { return f.path }
+func (f fakeProvider) Health() *Registry { return f.reg }
+
+func TestTrackerNil(t *testing.T) {
+	assert.Nil(t, NewTracker(struct{}{}, KindInput, "foo"))
+	assert.Nil(t, NewTracker(fakeProvider{label: "foo"}, KindInput, "foo"))
+	assert.Nil(t, NewTracker(fakeProvider{reg: NewRegistry()}, KindInput, "foo"))
+
+	var tr *Tracker
+	tr.Open()
+	tr.SetConnected()
+	tr.ConnectionFailed(errors.New("nope"))
+	tr.SetDisconnected()
+	tr.Succeeded()
+	tr.Failed(errors.New("nope"))
+	tr.Close()
+}
+
+func TestTrackerNames(t *testing.T) {
+	reg := NewRegistry()
+
+	NewTracker(fakeProvider{label: "foo", path: []string{"input"}, reg: reg}, KindInput, "kafka").Open()
+	NewTracker(fakeProvider{path: []string{"output"}, reg: reg}, KindOutput, "amqp_1").Open()
+	NewTracker(fakeProvider{path: []string{"output"}, reg: reg.ForStream("bar")}, KindOutput, "nats").Open()
+
+	statuses := reg.Statuses()
+	require.Len(t, statuses, 3)
+
+	assert.Equal(t, "", statuses[0].Stream)
+	assert.Equal(t, KindInput, statuses[0].Kind)
+	assert.Equal(t, "foo", statuses[0].Name)
+	assert.Equal(t, "kafka", statuses[0].Type)
+
+	assert.Equal(t, "", statuses[1].Stream)
+	assert.Equal(t, KindOutput, statuses[1].Kind)
+	assert.Equal(t, "root.output", statuses[1].Name)
+
+	assert.Equal(t, "bar", statuses[2].Stream)
+	assert.Equal(t, "root.output", statuses[2].Name)
+}
+
+func TestTrackerStates(t *testing.T) {
+	reg := NewRegistry()
+	tr := reg.NewTracker(KindInput, "foo", "kafka")
+	assert.Empty(t, reg.Statuses())
+
+	tr.Open()
+
+	s := tr.Status()
+	assert.Equal(t, StateConnecting, s.State)
+	assert.Nil(t, s.LastSuccessAt)
+	assert.Nil(t, s.LastErrorAt)
+
+	tr.ConnectionFailed(errors.New("connection refused"))
+	tr.ConnectionFailed(errors.New("connection refused again"))
+	s = tr.Status()
+	assert.Equal(t, StateFailed, s.State)
+	assert.Equal(t, int64(2), s.ReconnectAttempts)
+	assert.Equal(t, "connection refused again", s.LastError)
+	assert.NotNil(t, s.LastErrorAt)
+
+	tr.SetConnected()
+	s = tr.Status()
+	assert.Equal(t, StateDegraded, s.State)
+	assert.Equal(t, int64(0), s.ReconnectAttempts)
+
+	tr.Succeeded()
+	s = tr.Status()
+	assert.Equal(t, StateConnected, s.State)
+	assert.NotNil(t, s.LastSuccessAt)
+	assert.Equal(t, "connection refused again", s.LastError)
+
+	tr.Failed(errors.New("bad read"))
+	s = tr.Status()
+	assert.Equal(t, StateDegraded, s.State)
+	assert.Equal(t, "bad read", s.LastError)
+
+	tr.SetDisconnected()
+	assert.Equal(t, StateConnecting, tr.Status().State)
+
+	tr.Close()
+	assert.Empty(t, reg.Statuses())
+}
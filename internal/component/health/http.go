@@ -0,0 +1,98 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// HandlerDescription describes the endpoint served by Handler.
+const HandlerDescription = "Returns a JSON document describing the health of each input, output, processor resource, cache and rate limit. The query parameter critical is a comma separated list of the names or kinds of components that are critical, all components are critical by default. Returns a 503 if any critical component is not connected or degraded, or if a critical component name is not found."
+
+// Document is the health report of all components of a service.
+type Document struct {
+	Healthy    bool     `json:"healthy"`
+	Missing    []string `json:"missing,omitempty"`
+	Components []Status `json:"components"`
+}
+
+// NewDocument creates a health report of the components of a registry, where
+// components that match any of the provided selectors are critical. A selector
+// matches components by either their name or their kind, and when no selectors
+// are provided all components are critical. Selectors of a name that do not
+// match any components are reported as missing.
+func NewDocument(reg *Registry, selectors ...string) Document {
+	doc := Document{
+		Healthy:    true,
+		Components: reg.Statuses(),
+	}
+
+	matched := map[string]bool{}
+	for _, s := range selectors {
+		matched[s] = false
+	}
+
+	for i, c := range doc.Components {
+		if len(selectors) == 0 {
+			doc.Components[i].Critical = true
+		} else {
+			if _, exists := matched[c.Name]; exists {
+				matched[c.Name] = true
+				doc.Components[i].Critical = true
+			}
+			if _, exists := matched[string(c.Kind)]; exists {
+				matched[string(c.Kind)] = true
+				doc.Components[i].Critical = true
+			}
+		}
+		if doc.Components[i].Critical && !c.State.Healthy() {
+			doc.Healthy = false
+		}
+	}
+
+	for _, s := range selectors {
+		if matched[s] || isKind(s) {
+			continue
+		}
+		doc.Missing = append(doc.Missing, s)
+		doc.Healthy = false
+	}
+	return doc
+}
+
+func isKind(s string) bool {
+	switch Kind(s) {
+	case KindInput, KindOutput, KindProcessor, KindCache, KindRateLimit:
+		return true
+	}
+	return false
+}
+
+// Handler returns an http.HandlerFunc that responds with a health report of
+// the components of a registry, where the critical components are selected by
+// the query parameter critical.
+func Handler(reg *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var selectors []string
+		for _, v := range r.URL.Query()["critical"] {
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					selectors = append(selectors, s)
+				}
+			}
+		}
+
+		doc := NewDocument(reg, selectors...)
+		docBytes, err := json.Marshal(doc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !doc.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = w.Write(docBytes)
+	}
+}
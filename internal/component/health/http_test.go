@@ -0,0 +1,124 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRegistry() *Registry {
+	reg := NewRegistry()
+
+	in := reg.NewTracker(KindInput, "foo", "kafka")
+	in.Open()
+	in.SetConnected()
+	in.Succeeded()
+
+	out := reg.NewTracker(KindOutput, "bar", "http_client")
+	out.Open()
+	out.ConnectionFailed(errors.New("nope"))
+
+	c := reg.NewTracker(KindCache, "baz", "redis")
+	c.Open()
+	c.SetConnected()
+	c.Failed(errors.New("timed out"))
+
+	return reg
+}
+
+func TestDocumentCritical(t *testing.T) {
+	reg := testRegistry()
+
+	tests := []struct {
+		name      string
+		selectors []string
+		healthy   bool
+		critical  []string
+		missing   []string
+	}{
+		{
+			name:     "all critical by default",
+			healthy:  false,
+			critical: []string{"foo", "bar", "baz"},
+		},
+		{
+			name:      "by name",
+			selectors: []string{"foo", "baz"},
+			healthy:   true,
+			critical:  []string{"foo", "baz"},
+		},
+		{
+			name:      "by kind",
+			selectors: []string{"input", "cache", "rate_limit"},
+			healthy:   true,
+			critical:  []string{"foo", "baz"},
+		},
+		{
+			name:      "failed output",
+			selectors: []string{"output"},
+			healthy:   false,
+			critical:  []string{"bar"},
+		},
+		{
+			name:      "missing name",
+			selectors: []string{"foo", "nope"},
+			healthy:   false,
+			critical:  []string{"foo"},
+			missing:   []string{"nope"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			doc := NewDocument(reg, test.selectors...)
+			assert.Equal(t, test.healthy, doc.Healthy)
+			assert.Equal(t, test.missing, doc.Missing)
+
+			var critical []string
+			for _, c := range doc.Components {
+				if c.Critical {
+					critical = append(critical, c.Name)
+				}
+			}
+			assert.ElementsMatch(t, test.critical, critical)
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	handler := Handler(testRegistry())
+
+	req := httptest.NewRequest("GET", "/health", http.NoBody)
+	res := httptest.NewRecorder()
+	handler(res, req)
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+
+	var doc Document
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &doc))
+	assert.False(t, doc.Healthy)
+	require.Len(t, doc.Components, 3)
+
+	assert.Equal(t, "baz", doc.Components[0].Name)
+	assert.Equal(t, StateDegraded, doc.Components[0].State)
+	assert.Equal(t, "timed out", doc.Components[0].LastError)
+
+	assert.Equal(t, "foo", doc.Components[1].Name)
+	assert.Equal(t, StateConnected, doc.Components[1].State)
+	assert.NotNil(t, doc.Components[1].LastSuccessAt)
+
+	assert.Equal(t, "bar", doc.Components[2].Name)
+	assert.Equal(t, StateFailed, doc.Components[2].State)
+	assert.Equal(t, int64(1), doc.Components[2].ReconnectAttempts)
+
+	req = httptest.NewRequest("GET", "/health?critical=foo,baz&critical=input", http.NoBody)
+	res = httptest.NewRecorder()
+	handler(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/benthosdev/benthos/v4/internal/api"
+)
+
+// HandlerOperations returns the operations of the endpoint served by Handler,
+// describing them within the OpenAPI document of the HTTP API.
+func HandlerOperations() []api.OperationSpec {
+	timeProp := map[string]any{"type": "string", "format": "date-time"}
+	docBody := &api.BodySpec{
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"healthy": map[string]any{"type": "boolean"},
+				"missing": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				"components": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"stream": map[string]any{"type": "string"},
+							"kind": map[string]any{
+								"type": "string",
+								"enum": []any{KindInput, KindOutput, KindProcessor, KindCache, KindRateLimit},
+							},
+							"name": map[string]any{"type": "string"},
+							"type": map[string]any{"type": "string"},
+							"state": map[string]any{
+								"type": "string",
+								"enum": []any{StateConnecting, StateConnected, StateDegraded, StateFailed},
+							},
+							"last_error":         map[string]any{"type": "string"},
+							"last_error_at":      timeProp,
+							"last_success_at":    timeProp,
+							"reconnect_attempts": map[string]any{"type": "integer"},
+							"critical":           map[string]any{"type": "boolean"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return []api.OperationSpec{{
+		Method: "GET",
+		Responses: []api.ResponseSpec{
+			{Status: http.StatusOK, Description: "All critical components are connected or degraded.", Body: docBody},
+			{Status: http.StatusServiceUnavailable, Description: "One or more critical components are connecting, failed or missing.", Body: docBody},
+		},
+	}}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/Jeffail/shutdown"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/health"
+	"github.com/benthosdev/benthos/v4/internal/component/tap"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
@@ -26,7 +28,9 @@ type AsyncReader struct {
 	typeStr string
 	reader  Async
 
-	mgr component.Observability
+	mgr    component.Observability
+	health *health.Tracker
+	tap    *tap.Point
 
 	transactions chan message.Transaction
 	shutSig      *shutdown.Signaller
@@ -55,6 +59,8 @@ func NewAsyncReader(
 		typeStr:      typeStr,
 		reader:       r,
 		mgr:          mgr,
+		health:       health.NewTracker(mgr, health.KindInput, typeStr),
+		tap:          tap.NewPoint(mgr),
 		transactions: make(chan message.Transaction),
 		shutSig:      shutdown.NewSignaller(),
 	}
@@ -95,10 +101,13 @@ func (r *AsyncReader) loop() {
 	closeNowCtx, cnDone := r.shutSig.HardStopCtx(context.Background())
 	defer cnDone()
 
+	r.health.Open()
 	defer func() {
 		_ = r.reader.Close(context.Background())
 
 		atomic.StoreInt32(&r.connected, 0)
+		r.health.Close()
+		r.tap.Close()
 
 		close(r.transactions)
 		r.shutSig.TriggerHasStopped()
@@ -122,6 +131,7 @@ func (r *AsyncReader) loop() {
 				}
 				r.mgr.Logger().Error("Failed to connect to %v: %v\n", r.typeStr, err)
 				mFailedConn.Incr(1)
+				r.health.ConnectionFailed(err)
 
 				var nextBoff time.Duration
 
@@ -152,6 +162,7 @@ func (r *AsyncReader) loop() {
 	r.mgr.Logger().Info("Input type %v is now active", r.typeStr)
 	mConn.Incr(1)
 	atomic.StoreInt32(&r.connected, 1)
+	r.health.SetConnected()
 
 	for {
 		msg, ackFn, err := r.reader.ReadBatch(closeAtLeisureCtx)
@@ -160,6 +171,7 @@ func (r *AsyncReader) loop() {
 		if errors.Is(err, component.ErrNotConnected) {
 			mLostConn.Incr(1)
 			atomic.StoreInt32(&r.connected, 0)
+			r.health.SetDisconnected()
 
 			// Continue to try to reconnect while still active.
 			if !initConnection() {
@@ -167,6 +179,7 @@ func (r *AsyncReader) loop() {
 			}
 			mConn.Incr(1)
 			atomic.StoreInt32(&r.connected, 1)
+			r.health.SetConnected()
 			continue
 		}
 
@@ -178,6 +191,7 @@ func (r *AsyncReader) loop() {
 		if err != nil || len(msg) == 0 {
 			if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, component.ErrTimeout) && !errors.Is(err, component.ErrNotConnected) {
 				r.mgr.Logger().Error("Failed to read message: %v\n", err)
+				r.health.Failed(err)
 			}
 
 			nextBoff := r.readBackoff.NextBackOff()
@@ -195,12 +209,14 @@ func (r *AsyncReader) loop() {
 
 		r.readBackoff.Reset()
 		mRcvd.Incr(int64(msg.Len()))
+		r.health.Succeeded()
 		r.mgr.Logger().Trace("Consumed %v messages from '%v'.\n", msg.Len(), r.typeStr)
 
 		startedAt := time.Now()
 
 		resChan := make(chan error, 1)
 		tracing.InitSpans(r.mgr.Tracer(), traceName, msg)
+		r.tap.Emit(msg, nil)
 		select {
 		case r.transactions <- message.NewTransaction(msg, resChan):
 		case <-r.shutSig.SoftStopChan():
@@ -229,6 +245,7 @@ func (r *AsyncReader) loop() {
 
 			if err = aFn(closeNowCtx, res); err != nil {
 				r.mgr.Logger().Error("Failed to acknowledge message: %v\n", err)
+				r.health.Failed(err)
 			}
 		}(msg, ackFn, resChan)
 	}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/tap"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -309,6 +310,62 @@ func TestAsyncReaderCanReconnect(t *testing.T) {
 	require.NoError(t, r.WaitForClose(tCtx))
 }
 
+type tapObservability struct {
+	component.Observability
+	reg *tap.Registry
+}
+
+func (t tapObservability) Label() string       { return "foo" }
+func (t tapObservability) Taps() *tap.Registry { return t.reg }
+
+func TestAsyncReaderTap(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	readerImpl := newMockAsyncReader()
+	readerImpl.msgsToSnd = []message.Batch{message.QuickBatch([][]byte{[]byte("hello world")})}
+
+	reg := tap.NewRegistry()
+	r, err := input.NewAsyncReader("foo", readerImpl, tapObservability{Observability: mock.NewManager(), reg: reg})
+	require.NoError(t, err)
+	assert.True(t, reg.HasPoint("foo"))
+
+	tp := reg.Attach(tap.Config{Label: "foo", Sample: 1, BufferSize: 10})
+	defer reg.Detach(tp)
+
+	go func() {
+		select {
+		case readerImpl.connChan <- nil:
+		case <-time.After(time.Second):
+		}
+		select {
+		case readerImpl.readChan <- nil:
+		case <-time.After(time.Second):
+		}
+		select {
+		case readerImpl.ackChan <- nil:
+		case <-time.After(time.Second):
+		}
+	}()
+
+	var ts message.Transaction
+	select {
+	case ts = <-r.TransactionChan():
+	case <-tCtx.Done():
+		t.Fatal("Timed out")
+	}
+	require.NoError(t, ts.Ack(tCtx, nil))
+
+	require.Len(t, tp.Events(), 1)
+	e := <-tp.Events()
+	assert.Equal(t, "foo", e.Label)
+	assert.Equal(t, "hello world", e.Payload)
+
+	r.TriggerCloseNow()
+	require.NoError(t, r.WaitForClose(tCtx))
+	assert.False(t, reg.HasPoint("foo"))
+}
+
 func TestAsyncReaderFailsReconnect(t *testing.T) {
 	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
 	defer done()
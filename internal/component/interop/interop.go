@@ -9,6 +9,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
@@ -163,3 +164,13 @@ func UnwrapBatcher(b *service.Batcher) *policy.Batcher {
 		Unwrap() *policy.Batcher
 	}).Unwrap()
 }
+
+// WithFixerFunc adds a function to a public config spec that is able to rewrite
+// the config of a component in order to resolve linting errors, which is used
+// by the lint subcommand when fixes are enabled.
+func WithFixerFunc(spec *service.ConfigSpec, fn docs.FixFunc) *service.ConfigSpec {
+	spec.XUnwrapper().(interface {
+		SetFixerFunc(fn docs.FixFunc)
+	}).SetFixerFunc(fn)
+	return spec
+}
@@ -0,0 +1,170 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+// AdaptiveConcurrencyConfig describes how the number of parallel writes of an
+// output is adjusted based on the observed latency and errors of writes.
+type AdaptiveConcurrencyConfig struct {
+	// The lowest number of writes to allow in flight, and the number that the
+	// limit begins at.
+	MinInFlight int
+
+	// Writes with a latency exceeding the baseline latency multiplied by this
+	// factor are treated as a sign of congestion.
+	LatencyTolerance float64
+
+	// The ratio by which the limit is multiplied when congestion is detected.
+	BackoffRatio float64
+}
+
+// NewAdaptiveConcurrencyConfig returns an AdaptiveConcurrencyConfig with
+// default values.
+func NewAdaptiveConcurrencyConfig() AdaptiveConcurrencyConfig {
+	return AdaptiveConcurrencyConfig{
+		MinInFlight:      1,
+		LatencyTolerance: 2,
+		BackoffRatio:     0.9,
+	}
+}
+
+// Validate checks whether the config is valid for an output with a given
+// maximum number of writes in flight.
+func (c AdaptiveConcurrencyConfig) Validate(maxInFlight int) error {
+	if c.MinInFlight < 1 || c.MinInFlight > maxInFlight {
+		return fmt.Errorf("min_in_flight '%v' must be between 1 and the max_in_flight '%v'", c.MinInFlight, maxInFlight)
+	}
+	if c.LatencyTolerance < 1 {
+		return fmt.Errorf("latency_tolerance '%v' must be at least 1", c.LatencyTolerance)
+	}
+	if c.BackoffRatio <= 0 || c.BackoffRatio >= 1 {
+		return fmt.Errorf("backoff_ratio '%v' must be between 0 and 1", c.BackoffRatio)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// baselineDrift is the rate at which the baseline latency creeps towards
+// slower writes, allowing it to recover when a downstream service becomes
+// permanently slower.
+const baselineDrift = 0.01
+
+// adaptiveLimiter limits the number of writes in flight with an additive
+// increase, multiplicative decrease (AIMD) strategy. The limit grows by one for
+// each window of successful writes, and shrinks by the backoff ratio when a
+// write fails or its latency exceeds the tolerance of the baseline latency,
+// which is the lowest recently observed latency.
+type adaptiveLimiter struct {
+	conf        AdaptiveConcurrencyConfig
+	maxInFlight int
+	mLimit      metrics.StatGauge
+
+	mut          sync.Mutex
+	limit        float64
+	inFlight     int
+	baseline     time.Duration
+	lastDecrease time.Time
+	releasedChan chan struct{}
+}
+
+func newAdaptiveLimiter(conf AdaptiveConcurrencyConfig, maxInFlight int, mLimit metrics.StatGauge) *adaptiveLimiter {
+	l := &adaptiveLimiter{
+		conf:         conf,
+		maxInFlight:  maxInFlight,
+		mLimit:       mLimit,
+		limit:        float64(conf.MinInFlight),
+		releasedChan: make(chan struct{}),
+	}
+	mLimit.Set(int64(conf.MinInFlight))
+	return l
+}
+
+// currentLimit returns the number of writes currently allowed in flight.
+func (l *adaptiveLimiter) currentLimit() int {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	return int(l.limit)
+}
+
+// acquire blocks until a write is permitted under the current limit, or the
+// context is cancelled.
+func (l *adaptiveLimiter) acquire(ctx context.Context) error {
+	for {
+		l.mut.Lock()
+		if l.inFlight < int(l.limit) {
+			l.inFlight++
+			l.mut.Unlock()
+			return nil
+		}
+		releasedChan := l.releasedChan
+		l.mut.Unlock()
+
+		select {
+		case <-releasedChan:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release a write that began at a given time and completed with a latency and
+// an error, adjusting the limit accordingly.
+func (l *adaptiveLimiter) release(started time.Time, latency time.Duration, err error) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.inFlight--
+
+	if err == nil {
+		if l.baseline == 0 || latency < l.baseline {
+			l.baseline = latency
+		} else {
+			l.baseline += time.Duration(float64(latency-l.baseline) * baselineDrift)
+		}
+	}
+
+	congested := err != nil || float64(latency) > float64(l.baseline)*l.conf.LatencyTolerance
+	if congested {
+		// Writes that began before the last decrease were sent under the old
+		// limit and have already been accounted for.
+		if started.Before(l.lastDecrease) {
+			l.signalReleased()
+			return
+		}
+		l.limit *= l.conf.BackoffRatio
+		if minLimit := float64(l.conf.MinInFlight); l.limit < minLimit {
+			l.limit = minLimit
+		}
+		l.lastDecrease = time.Now()
+	} else if l.limit < float64(l.maxInFlight) {
+		l.limit += 1 / l.limit
+		if maxLimit := float64(l.maxInFlight); l.limit > maxLimit {
+			l.limit = maxLimit
+		}
+	}
+
+	l.mLimit.Set(int64(l.limit))
+	l.signalReleased()
+}
+
+// cancel releases a write that was never attempted without adjusting the
+// limit.
+func (l *adaptiveLimiter) cancel() {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.inFlight--
+	l.signalReleased()
+}
+
+func (l *adaptiveLimiter) signalReleased() {
+	close(l.releasedChan)
+	l.releasedChan = make(chan struct{})
+}
@@ -0,0 +1,187 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestAdaptiveConcurrencyConfigValidate(t *testing.T) {
+	conf := NewAdaptiveConcurrencyConfig()
+	require.NoError(t, conf.Validate(1))
+
+	conf.MinInFlight = 5
+	assert.ErrorContains(t, conf.Validate(4), "min_in_flight")
+
+	conf = NewAdaptiveConcurrencyConfig()
+	conf.LatencyTolerance = 0.5
+	assert.ErrorContains(t, conf.Validate(4), "latency_tolerance")
+
+	conf = NewAdaptiveConcurrencyConfig()
+	conf.BackoffRatio = 1
+	assert.ErrorContains(t, conf.Validate(4), "backoff_ratio")
+}
+
+func TestAdaptiveLimiterAIMD(t *testing.T) {
+	stats := metrics.NewLocal()
+	l := newAdaptiveLimiter(NewAdaptiveConcurrencyConfig(), 4, stats.GetGauge("limit"))
+	assert.Equal(t, 1, l.currentLimit())
+
+	healthyRound := func() {
+		t.Helper()
+		n := l.currentLimit()
+		for i := 0; i < n; i++ {
+			require.NoError(t, l.acquire(context.Background()))
+		}
+		for i := 0; i < n; i++ {
+			l.release(time.Now(), time.Millisecond, nil)
+		}
+	}
+
+	// The limit grows by roughly one for each round of healthy writes.
+	healthyRound()
+	assert.Equal(t, 2, l.currentLimit())
+	healthyRound()
+	assert.InDelta(t, 2.9, l.limit, 0.001)
+	for i := 0; i < 10; i++ {
+		healthyRound()
+	}
+	assert.Equal(t, 4, l.currentLimit())
+	assert.Equal(t, int64(4), stats.GetCounters()["limit"])
+
+	// Writes that fail shrink the limit, but only once for writes that were
+	// in flight at the same time.
+	started := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, l.acquire(context.Background()))
+	}
+	for i := 0; i < 4; i++ {
+		l.release(started, time.Millisecond, errors.New("nope"))
+	}
+	assert.Equal(t, 3, l.currentLimit())
+
+	// Writes that exceed the latency tolerance shrink the limit.
+	before := l.limit
+	require.NoError(t, l.acquire(context.Background()))
+	l.release(time.Now(), time.Millisecond*3, nil)
+	assert.InDelta(t, before*0.9, l.limit, 0.001)
+
+	// But never beyond the minimum.
+	for i := 0; i < 50; i++ {
+		require.NoError(t, l.acquire(context.Background()))
+		l.release(time.Now(), time.Millisecond, errors.New("nope"))
+	}
+	assert.Equal(t, 1, l.currentLimit())
+	assert.Equal(t, int64(1), stats.GetCounters()["limit"])
+}
+
+func TestAdaptiveLimiterAcquireBlocks(t *testing.T) {
+	l := newAdaptiveLimiter(NewAdaptiveConcurrencyConfig(), 4, metrics.Noop().GetGauge("limit"))
+	require.NoError(t, l.acquire(context.Background()))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer done()
+	require.ErrorIs(t, l.acquire(ctx), context.DeadlineExceeded)
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- l.acquire(context.Background())
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired beyond the limit")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	l.cancel()
+	select {
+	case err := <-acquired:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+type adaptiveMockWriter struct {
+	inFlight, maxSeen int32
+}
+
+func (w *adaptiveMockWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (w *adaptiveMockWriter) WriteBatch(ctx context.Context, msg message.Batch) error {
+	n := atomic.AddInt32(&w.inFlight, 1)
+	defer atomic.AddInt32(&w.inFlight, -1)
+	for {
+		seen := atomic.LoadInt32(&w.maxSeen)
+		if n <= seen || atomic.CompareAndSwapInt32(&w.maxSeen, seen, n) {
+			break
+		}
+	}
+	<-time.After(time.Millisecond)
+	return nil
+}
+
+func (w *adaptiveMockWriter) Close(context.Context) error { return nil }
+
+func TestAsyncWriterAdaptiveConcurrency(t *testing.T) {
+	_, err := NewAsyncWriter("foo", 2, &adaptiveMockWriter{}, component.NoopObservability(), AsyncWriterOptAdaptiveConcurrency(AdaptiveConcurrencyConfig{
+		MinInFlight:      3,
+		LatencyTolerance: 2,
+		BackoffRatio:     0.9,
+	}))
+	require.ErrorContains(t, err, "min_in_flight")
+
+	writer := &adaptiveMockWriter{}
+	w, err := NewAsyncWriter("foo", 8, writer, component.NoopObservability(), AsyncWriterOptAdaptiveConcurrency(NewAdaptiveConcurrencyConfig()))
+	require.NoError(t, err)
+
+	msgChan := make(chan message.Transaction)
+	resChan := make(chan error)
+	require.NoError(t, w.Consume(msgChan))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				select {
+				case msgChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("foo")}), resChan):
+				case <-time.After(time.Second * 5):
+					t.Error("timed out")
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		select {
+		case err := <-resChan:
+			require.NoError(t, err)
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+	wg.Wait()
+
+	// Starting from a single write in flight the limit can grow by at most a
+	// few writes over twenty healthy writes.
+	assert.LessOrEqual(t, atomic.LoadInt32(&writer.maxSeen), int32(6))
+
+	w.TriggerCloseNow()
+	require.NoError(t, w.WaitForClose(context.Background()))
+}
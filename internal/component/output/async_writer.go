@@ -3,6 +3,7 @@ package output
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,7 +15,9 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/health"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/tap"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
@@ -45,19 +48,34 @@ type AsyncWriter struct {
 
 	typeStr     string
 	maxInflight int
+	adaptive    *AdaptiveConcurrencyConfig
 	writer      AsyncSink
 
 	log    log.Modular
 	stats  metrics.Type
 	tracer trace.TracerProvider
+	tap    *tap.Point
+	health *health.Tracker
 
 	transactions <-chan message.Transaction
 
 	shutSig *shutdown.Signaller
 }
 
+// AsyncWriterOpt is a functional option for customising an AsyncWriter.
+type AsyncWriterOpt func(*AsyncWriter)
+
+// AsyncWriterOptAdaptiveConcurrency enables adaptive concurrency, where the
+// number of parallel writes is adjusted between a minimum and the max in flight
+// of the writer based on the observed latency and errors of writes.
+func AsyncWriterOptAdaptiveConcurrency(conf AdaptiveConcurrencyConfig) AsyncWriterOpt {
+	return func(w *AsyncWriter) {
+		w.adaptive = &conf
+	}
+}
+
 // NewAsyncWriter creates a Streamed implementation around an AsyncSink.
-func NewAsyncWriter(typeStr string, maxInflight int, w AsyncSink, mgr component.Observability) (Streamed, error) {
+func NewAsyncWriter(typeStr string, maxInflight int, w AsyncSink, mgr component.Observability, opts ...AsyncWriterOpt) (Streamed, error) {
 	aWriter := &AsyncWriter{
 		typeStr:      typeStr,
 		maxInflight:  maxInflight,
@@ -65,9 +83,19 @@ func NewAsyncWriter(typeStr string, maxInflight int, w AsyncSink, mgr component.
 		log:          mgr.Logger(),
 		stats:        mgr.Metrics(),
 		tracer:       mgr.Tracer(),
+		tap:          tap.NewPoint(mgr),
+		health:       health.NewTracker(mgr, health.KindOutput, typeStr),
 		transactions: nil,
 		shutSig:      shutdown.NewSignaller(),
 	}
+	for _, opt := range opts {
+		opt(aWriter)
+	}
+	if aWriter.adaptive != nil {
+		if err := aWriter.adaptive.Validate(maxInflight); err != nil {
+			return nil, fmt.Errorf("adaptive concurrency: %w", err)
+		}
+	}
 	return aWriter, nil
 }
 
@@ -97,10 +125,18 @@ func (w *AsyncWriter) loop() {
 		traceName = "output_" + w.typeStr
 	)
 
+	var limiter *adaptiveLimiter
+	if w.adaptive != nil {
+		limiter = newAdaptiveLimiter(*w.adaptive, w.maxInflight, w.stats.GetGauge("output_in_flight_limit"))
+	}
+
+	w.health.Open()
 	defer func() {
 		_ = w.writer.Close(context.Background())
 
 		atomic.StoreInt32(&w.isConnected, 0)
+		w.health.Close()
+		w.tap.Close()
 		w.shutSig.TriggerHasStopped()
 	}()
 
@@ -120,6 +156,7 @@ func (w *AsyncWriter) loop() {
 				}
 				w.log.Error("Failed to connect to %v: %v\n", w.typeStr, err)
 				mFailedConn.Incr(1)
+				w.health.ConnectionFailed(err)
 
 				var nextBoff time.Duration
 
@@ -146,6 +183,7 @@ func (w *AsyncWriter) loop() {
 	w.log.Info("Output type %v is now active", w.typeStr)
 	mConn.Incr(1)
 	atomic.StoreInt32(&w.isConnected, 1)
+	w.health.SetConnected()
 
 	wg := sync.WaitGroup{}
 	wg.Add(w.maxInflight)
@@ -167,6 +205,7 @@ func (w *AsyncWriter) loop() {
 			}
 		}
 		mLostConn.Incr(1)
+		w.health.SetDisconnected()
 
 		// Continue to try to reconnect while still active.
 		for {
@@ -177,6 +216,7 @@ func (w *AsyncWriter) loop() {
 			if latency, err = w.latencyMeasuringWrite(closeLeisureCtx, msg); err != component.ErrNotConnected {
 				atomic.StoreInt32(&w.isConnected, 1)
 				mConn.Incr(1)
+				w.health.SetConnected()
 				return
 			} else if err != nil {
 				mError.Incr(1)
@@ -188,20 +228,29 @@ func (w *AsyncWriter) loop() {
 		defer wg.Done()
 
 		for {
+			if limiter != nil {
+				if err := limiter.acquire(closeLeisureCtx); err != nil {
+					return
+				}
+			}
+
 			var ts message.Transaction
 			var open bool
 			select {
 			case ts, open = <-w.transactions:
-				if !open {
-					return
-				}
 			case <-w.shutSig.SoftStopChan():
+			}
+			if !open {
+				if limiter != nil {
+					limiter.cancel()
+				}
 				return
 			}
 
 			w.log.Trace("Attempting to write %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
 			_, spans := tracing.WithChildSpans(w.tracer, traceName, ts.Payload)
 
+			started := time.Now()
 			latency, err := w.latencyMeasuringWrite(closeLeisureCtx, ts.Payload)
 
 			// If our writer says it is not connected.
@@ -210,6 +259,9 @@ func (w *AsyncWriter) loop() {
 			} else if err != nil {
 				mError.Incr(1)
 			}
+			if limiter != nil {
+				limiter.release(started, time.Duration(latency), err)
+			}
 
 			// Close immediately if our writer is closed.
 			if errors.Is(err, component.ErrTypeClosed) {
@@ -221,6 +273,7 @@ func (w *AsyncWriter) loop() {
 					// TODO: Maybe reintroduce a sleep here if we encounter a
 					// busy retry loop.
 					w.log.Error("Failed to send message to %v: %v\n", w.typeStr, err)
+					w.health.Failed(err)
 				} else {
 					w.log.Debug("Rejecting message: %v\n", err)
 				}
@@ -228,6 +281,7 @@ func (w *AsyncWriter) loop() {
 				mBatchSent.Incr(1)
 				mSent.Incr(int64(batch.MessageCollapsedCount(ts.Payload)))
 				mLatency.Timing(latency)
+				w.health.Succeeded()
 				w.log.Trace("Successfully wrote %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
 			}
 
@@ -235,6 +289,7 @@ func (w *AsyncWriter) loop() {
 				s.Finish()
 			}
 
+			w.tap.Emit(ts.Payload, err)
 			_ = ts.Ack(closeLeisureCtx, err)
 		}
 	}
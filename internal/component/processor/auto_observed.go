@@ -6,6 +6,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/tap"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
@@ -60,6 +61,7 @@ type v2ToV1Processor struct {
 	typeStr string
 	p       AutoObserved
 	mgr     component.Observability
+	tap     *tap.Point
 
 	mReceived      metrics.StatCounter
 	mBatchReceived metrics.StatCounter
@@ -73,7 +75,7 @@ type v2ToV1Processor struct {
 // implementation of V1 which handles observability information.
 func NewAutoObservedProcessor(typeStr string, p AutoObserved, mgr component.Observability) V1 {
 	return &v2ToV1Processor{
-		typeStr: typeStr, p: p, mgr: mgr,
+		typeStr: typeStr, p: p, mgr: mgr, tap: tap.NewPoint(mgr),
 
 		mReceived:      mgr.Metrics().GetCounter("processor_received"),
 		mBatchReceived: mgr.Metrics().GetCounter("processor_batch_received"),
@@ -116,10 +118,12 @@ func (a *v2ToV1Processor) ProcessBatch(ctx context.Context, msg message.Batch) (
 
 	a.mSent.Incr(int64(len(newParts)))
 	a.mBatchSent.Incr(1)
+	a.tap.Emit(newParts, nil)
 	return []message.Batch{newParts}, nil
 }
 
 func (a *v2ToV1Processor) Close(ctx context.Context) error {
+	a.tap.Close()
 	return a.p.Close(ctx)
 }
 
@@ -189,6 +193,7 @@ type v2BatchedToV1Processor struct {
 	typeStr string
 	p       AutoObservedBatched
 	mgr     component.Observability
+	tap     *tap.Point
 
 	mReceived      metrics.StatCounter
 	mBatchReceived metrics.StatCounter
@@ -202,7 +207,7 @@ type v2BatchedToV1Processor struct {
 // implementation of V1 which handles observability information.
 func NewAutoObservedBatchedProcessor(typeStr string, p AutoObservedBatched, mgr component.Observability) V1 {
 	return &v2BatchedToV1Processor{
-		typeStr: typeStr, p: p, mgr: mgr,
+		typeStr: typeStr, p: p, mgr: mgr, tap: tap.NewPoint(mgr),
 
 		mReceived:      mgr.Metrics().GetCounter("processor_received"),
 		mBatchReceived: mgr.Metrics().GetCounter("processor_batch_received"),
@@ -248,11 +253,13 @@ func (a *v2BatchedToV1Processor) ProcessBatch(ctx context.Context, msg message.B
 
 	for _, m := range outputBatches {
 		a.mSent.Incr(int64(m.Len()))
+		a.tap.Emit(m, nil)
 	}
 	a.mBatchSent.Incr(int64(len(outputBatches)))
 	return outputBatches, nil
 }
 
 func (a *v2BatchedToV1Processor) Close(ctx context.Context) error {
+	a.tap.Close()
 	return a.p.Close(ctx)
 }
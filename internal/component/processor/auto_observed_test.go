@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/tap"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
@@ -257,3 +258,38 @@ func TestBatchProcessorAirGapIndividualErrors(t *testing.T) {
 	assert.NoError(t, msgs[0][1].ErrorGet())
 	assert.EqualError(t, msgs[0][2].ErrorGet(), "invalid character 'a' looking for beginning of value")
 }
+
+type tapObservability struct {
+	component.Observability
+	reg *tap.Registry
+}
+
+func (t tapObservability) Label() string       { return "foo" }
+func (t tapObservability) Taps() *tap.Registry { return t.reg }
+
+func TestProcessorAirGapTap(t *testing.T) {
+	tCtx := context.Background()
+
+	reg := tap.NewRegistry()
+	agrp := NewAutoObservedProcessor("foo", &fnProcessor{
+		fn: func(c context.Context, m *message.Part) ([]*message.Part, error) {
+			newPart := m.ShallowCopy()
+			newPart.SetBytes([]byte("changed"))
+			return []*message.Part{newPart}, nil
+		},
+	}, tapObservability{Observability: component.NoopObservability(), reg: reg})
+
+	_, res := agrp.ProcessBatch(tCtx, message.QuickBatch([][]byte{[]byte("first")}))
+	require.NoError(t, res)
+
+	tp := reg.Attach(tap.Config{Label: "foo", Sample: 1, BufferSize: 10})
+	defer reg.Detach(tp)
+
+	_, res = agrp.ProcessBatch(tCtx, message.QuickBatch([][]byte{[]byte("second")}))
+	require.NoError(t, res)
+
+	require.Len(t, tp.Events(), 1)
+	e := <-tp.Events()
+	assert.Equal(t, "foo", e.Label)
+	assert.Equal(t, "changed", e.Payload)
+}
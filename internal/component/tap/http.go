@@ -0,0 +1,127 @@
+package tap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRate       = 10
+	defaultDuration   = time.Minute
+	maxDuration       = 10 * time.Minute
+	defaultBufferSize = 100
+	keepAliveInterval = 15 * time.Second
+	endEventName      = "end"
+)
+
+// HandlerDescription describes the endpoint served by Handler.
+const HandlerDescription = "DEBUG: Streams copies of messages leaving a labelled component as server-sent events. Query parameters: label (required), sample (ratio of messages between 0 and 1, default 1), rate (maximum events per second, default 10) and duration (default 1m, maximum 10m)."
+
+func parseQuery(r *http.Request) (conf Config, duration time.Duration, err error) {
+	q := r.URL.Query()
+
+	if conf.Label = q.Get("label"); conf.Label == "" {
+		err = errors.New("query parameter label is required")
+		return
+	}
+
+	conf.Sample = 1
+	if s := q.Get("sample"); s != "" {
+		if conf.Sample, err = strconv.ParseFloat(s, 64); err != nil || conf.Sample <= 0 || conf.Sample > 1 {
+			err = errors.New("query parameter sample must be a number greater than 0 and no more than 1")
+			return
+		}
+	}
+
+	conf.Rate = defaultRate
+	if s := q.Get("rate"); s != "" {
+		if conf.Rate, err = strconv.ParseFloat(s, 64); err != nil || conf.Rate <= 0 {
+			err = errors.New("query parameter rate must be a number greater than 0")
+			return
+		}
+	}
+
+	duration = defaultDuration
+	if s := q.Get("duration"); s != "" {
+		if duration, err = time.ParseDuration(s); err != nil || duration <= 0 {
+			err = errors.New("query parameter duration must be a positive duration string")
+			return
+		}
+		if duration > maxDuration {
+			duration = maxDuration
+		}
+	}
+
+	conf.BufferSize = defaultBufferSize
+	return
+}
+
+// Handler returns an http.HandlerFunc that attaches a tap to the registry
+// according to the query parameters of a request, and streams the events it
+// observes to the client as server-sent events until either the client
+// disconnects or the duration of the tap elapses.
+func Handler(reg *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conf, duration, err := parseQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Components that do not emit to taps, such as brokers, would result
+		// in a tap that never receives anything.
+		if !reg.HasPoint(conf.Label) {
+			http.Error(w, fmt.Sprintf("no running component that can be tapped found with label '%v'", conf.Label), http.StatusNotFound)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Server does not support streaming", http.StatusInternalServerError)
+			return
+		}
+
+		t := reg.Attach(conf)
+		defer reg.Detach(t)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		deadline := time.NewTimer(duration)
+		defer deadline.Stop()
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case e := <-t.Events():
+				eBytes, err := json.Marshal(e)
+				if err != nil {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "data: %s\n\n", eBytes); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case <-deadline.C:
+				_, _ = fmt.Fprintf(w, "event: %v\ndata: {\"dropped\":%v}\n\n", endEventName, t.Dropped())
+				flusher.Flush()
+				return
+			case <-r.Context().Done():
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package tap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestHandlerBadQuery(t *testing.T) {
+	handler := Handler(NewRegistry())
+
+	for _, q := range []string{
+		"",
+		"label=foo&sample=0",
+		"label=foo&sample=2",
+		"label=foo&rate=nope",
+		"label=foo&duration=-1s",
+	} {
+		req := httptest.NewRequest("GET", "/debug/tap?"+q, http.NoBody)
+		res := httptest.NewRecorder()
+		handler(res, req)
+		assert.Equal(t, http.StatusBadRequest, res.Code, q)
+	}
+}
+
+func TestHandlerUnknownLabel(t *testing.T) {
+	reg := NewRegistry()
+	handler := Handler(reg)
+
+	tapRes := func() int {
+		req := httptest.NewRequest("GET", "/debug/tap?label=foo", http.NoBody)
+		res := httptest.NewRecorder()
+		handler(res, req)
+		return res.Code
+	}
+
+	assert.Equal(t, http.StatusNotFound, tapRes())
+
+	// Labels are only tappable while a point of that label is open.
+	point := NewPoint(fakeProvider{label: "foo", reg: reg})
+	assert.True(t, reg.HasPoint("foo"))
+
+	point.Close()
+	point.Close()
+	assert.False(t, reg.HasPoint("foo"))
+	assert.Equal(t, http.StatusNotFound, tapRes())
+}
+
+func TestHandlerStreamsEvents(t *testing.T) {
+	reg := NewRegistry()
+	point := NewPoint(fakeProvider{label: "foo", reg: reg})
+
+	server := httptest.NewServer(Handler(reg))
+	t.Cleanup(server.Close)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"?label=foo&rate=1000&duration=500ms", http.NoBody)
+	require.NoError(t, err)
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	for reg.active.Load() == 0 {
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for tap")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	part := message.NewPart([]byte("hello world"))
+	part.MetaSetMut("foo", "bar")
+	point.Emit(message.Batch{part}, nil)
+
+	var lines []string
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasPrefix(lines[0], "data: "))
+
+	var e Event
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[0], "data: ")), &e))
+	assert.Equal(t, "foo", e.Label)
+	assert.Equal(t, "hello world", e.Payload)
+	assert.Equal(t, map[string]string{"foo": "bar"}, e.Metadata)
+	assert.False(t, e.Errored)
+
+	assert.Equal(t, "event: end", lines[1])
+	assert.Equal(t, `data: {"dropped":0}`, lines[2])
+
+	assert.Equal(t, int64(0), reg.active.Load())
+}
@@ -0,0 +1,268 @@
+// Package tap provides a mechanism for observing copies of the messages that
+// leave labelled components of a running pipeline, which is useful for
+// debugging without modifying and redeploying a config.
+package tap
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// Event is a copy of a message observed leaving a labelled component.
+type Event struct {
+	Label     string            `json:"label"`
+	Timestamp time.Time         `json:"timestamp"`
+	Payload   string            `json:"payload"`
+	Metadata  map[string]string `json:"metadata"`
+	Errored   bool              `json:"errored"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// Config describes which messages a tap receives.
+type Config struct {
+	// The label of the component to observe.
+	Label string
+
+	// The ratio of messages to sample between 0 and 1.
+	Sample float64
+
+	// The maximum number of events per second delivered to the tap, events
+	// that exceed this rate are dropped. Zero means unlimited.
+	Rate float64
+
+	// The number of events that can be buffered before they're dropped.
+	BufferSize int
+}
+
+// Tap receives copies of messages leaving a labelled component.
+type Tap struct {
+	conf     Config
+	interval time.Duration
+
+	mut  sync.Mutex
+	next time.Time
+
+	events  chan Event
+	dropped atomic.Int64
+}
+
+// Events returns a channel of events observed by the tap. Events are dropped
+// rather than blocking the pipeline when this channel is not consumed fast
+// enough.
+func (t *Tap) Events() <-chan Event {
+	return t.events
+}
+
+// Dropped returns the number of events that were dropped due to the rate limit
+// or a full buffer.
+func (t *Tap) Dropped() int64 {
+	return t.dropped.Load()
+}
+
+// allow returns whether an event may be delivered according to the sample
+// ratio and rate limit of the tap.
+func (t *Tap) allow() bool {
+	if t.conf.Sample < 1 && rand.Float64() >= t.conf.Sample {
+		return false
+	}
+	if t.interval <= 0 {
+		return true
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	now := time.Now()
+	if now.Before(t.next) {
+		t.dropped.Add(1)
+		return false
+	}
+	t.next = now.Add(t.interval)
+	return true
+}
+
+func (t *Tap) deliver(e Event) {
+	select {
+	case t.events <- e:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Registry tracks the taps attached to labelled components. The number of
+// attached taps is tracked atomically so that emitting messages to a registry
+// without any taps costs nothing more than an atomic load.
+type Registry struct {
+	active atomic.Int64
+
+	mut    sync.RWMutex
+	taps   map[string]map[*Tap]struct{}
+	points map[string]int
+}
+
+// NewRegistry creates an empty registry of taps.
+func NewRegistry() *Registry {
+	return &Registry{
+		taps:   map[string]map[*Tap]struct{}{},
+		points: map[string]int{},
+	}
+}
+
+// HasPoint returns true if a running component of the given label emits
+// messages to the taps of this registry.
+func (r *Registry) HasPoint(label string) bool {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	return r.points[label] > 0
+}
+
+func (r *Registry) addPoint(label string, delta int) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.points[label] += delta; r.points[label] <= 0 {
+		delete(r.points, label)
+	}
+}
+
+// Attach a new tap to the components of a given label. The tap must be
+// detached once it is no longer consumed.
+func (r *Registry) Attach(conf Config) *Tap {
+	if conf.BufferSize <= 0 {
+		conf.BufferSize = 1
+	}
+	t := &Tap{
+		conf:   conf,
+		events: make(chan Event, conf.BufferSize),
+	}
+	if conf.Rate > 0 {
+		t.interval = time.Duration(float64(time.Second) / conf.Rate)
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	labelTaps, exists := r.taps[conf.Label]
+	if !exists {
+		labelTaps = map[*Tap]struct{}{}
+		r.taps[conf.Label] = labelTaps
+	}
+	labelTaps[t] = struct{}{}
+	r.active.Add(1)
+	return t
+}
+
+// Detach a tap from the registry, after which it receives no more events.
+func (r *Registry) Detach(t *Tap) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	labelTaps, exists := r.taps[t.conf.Label]
+	if !exists {
+		return
+	}
+	if _, exists := labelTaps[t]; !exists {
+		return
+	}
+	delete(labelTaps, t)
+	if len(labelTaps) == 0 {
+		delete(r.taps, t.conf.Label)
+	}
+	r.active.Add(-1)
+}
+
+func (r *Registry) emit(label string, batch message.Batch, err error) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+
+	labelTaps := r.taps[label]
+	if len(labelTaps) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for t := range labelTaps {
+		for _, p := range batch {
+			if !t.allow() {
+				continue
+			}
+			t.deliver(newEvent(label, now, p, err))
+		}
+	}
+}
+
+func newEvent(label string, ts time.Time, p *message.Part, err error) Event {
+	e := Event{
+		Label:     label,
+		Timestamp: ts,
+		Payload:   string(p.AsBytes()),
+		Metadata:  map[string]string{},
+	}
+	_ = p.MetaIterStr(func(k, v string) error {
+		e.Metadata[k] = v
+		return nil
+	})
+	if err == nil {
+		err = p.ErrorGet()
+	}
+	if err != nil {
+		e.Errored = true
+		e.Error = err.Error()
+	}
+	return e
+}
+
+//------------------------------------------------------------------------------
+
+// Provider is implemented by component managers that provide a registry of
+// taps for the labelled component they belong to.
+type Provider interface {
+	Label() string
+	Taps() *Registry
+}
+
+// Point is the position of a labelled component from which messages are
+// emitted to any attached taps.
+type Point struct {
+	reg    *Registry
+	label  string
+	closed atomic.Bool
+}
+
+// NewPoint returns a tap point for the component that owns the provided
+// manager, or nil if the manager does not provide taps or the component has no
+// label. Emitting messages to a nil point does nothing. The point must be
+// closed once the component stops.
+func NewPoint(mgr any) *Point {
+	p, ok := mgr.(Provider)
+	if !ok || p.Label() == "" || p.Taps() == nil {
+		return nil
+	}
+	p.Taps().addPoint(p.Label(), 1)
+	return &Point{reg: p.Taps(), label: p.Label()}
+}
+
+// Emit copies of the messages of a batch leaving the component to any taps
+// attached to its label. An optional error is attached to all messages, in
+// addition to any errors already flagged on them.
+func (p *Point) Emit(batch message.Batch, err error) {
+	if p == nil || p.reg.active.Load() == 0 {
+		return
+	}
+	p.reg.emit(p.label, batch, err)
+}
+
+// Close the point, after which taps can no longer be attached to its label
+// unless another component of the same label is running. Closing a point more
+// than once has no effect.
+func (p *Point) Close() {
+	if p == nil || !p.closed.CompareAndSwap(false, true) {
+		return
+	}
+	p.reg.addPoint(p.label, -1)
+}
@@ -0,0 +1,144 @@
+package tap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type fakeProvider struct {
+	label string
+	reg   *Registry
+}
+
+func (f fakeProvider) Label() string   { return f.label }
+func (f fakeProvider) Taps() *Registry { return f.reg }
+
+func readEvents(t *testing.T, tp *Tap, n int) []Event {
+	t.Helper()
+
+	var events []Event
+	for i := 0; i < n; i++ {
+		select {
+		case e := <-tp.Events():
+			events = append(events, e)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %v", i)
+		}
+	}
+	return events
+}
+
+func TestTapNilPoint(t *testing.T) {
+	assert.Nil(t, NewPoint(struct{}{}))
+	assert.Nil(t, NewPoint(fakeProvider{label: "", reg: NewRegistry()}))
+	assert.Nil(t, NewPoint(fakeProvider{label: "foo"}))
+
+	var p *Point
+	p.Emit(message.QuickBatch([][]byte{[]byte("hello")}), nil)
+}
+
+func TestTapLabels(t *testing.T) {
+	reg := NewRegistry()
+
+	fooPoint := NewPoint(fakeProvider{label: "foo", reg: reg})
+	barPoint := NewPoint(fakeProvider{label: "bar", reg: reg})
+	require.NotNil(t, fooPoint)
+	require.NotNil(t, barPoint)
+
+	fooTap := reg.Attach(Config{Label: "foo", Sample: 1, BufferSize: 10})
+
+	part := message.NewPart([]byte("hello"))
+	part.MetaSetMut("a", "b")
+	fooPoint.Emit(message.Batch{part}, nil)
+	barPoint.Emit(message.QuickBatch([][]byte{[]byte("ignored")}), nil)
+	fooPoint.Emit(message.QuickBatch([][]byte{[]byte("world")}), errors.New("nope"))
+
+	events := readEvents(t, fooTap, 2)
+	assert.Equal(t, "foo", events[0].Label)
+	assert.Equal(t, "hello", events[0].Payload)
+	assert.Equal(t, map[string]string{"a": "b"}, events[0].Metadata)
+	assert.False(t, events[0].Errored)
+
+	assert.Equal(t, "world", events[1].Payload)
+	assert.True(t, events[1].Errored)
+	assert.Equal(t, "nope", events[1].Error)
+
+	select {
+	case e := <-fooTap.Events():
+		t.Fatalf("unexpected event: %v", e)
+	default:
+	}
+
+	reg.Detach(fooTap)
+	assert.Equal(t, int64(0), reg.active.Load())
+	assert.Empty(t, reg.taps)
+
+	fooPoint.Emit(message.QuickBatch([][]byte{[]byte("after")}), nil)
+	select {
+	case e := <-fooTap.Events():
+		t.Fatalf("unexpected event: %v", e)
+	default:
+	}
+}
+
+func TestTapFlaggedErrors(t *testing.T) {
+	reg := NewRegistry()
+	point := NewPoint(fakeProvider{label: "foo", reg: reg})
+
+	tp := reg.Attach(Config{Label: "foo", Sample: 1, BufferSize: 10})
+	defer reg.Detach(tp)
+
+	part := message.NewPart([]byte("hello"))
+	part.ErrorSet(errors.New("flagged"))
+	point.Emit(message.Batch{part}, nil)
+
+	events := readEvents(t, tp, 1)
+	assert.True(t, events[0].Errored)
+	assert.Equal(t, "flagged", events[0].Error)
+}
+
+func TestTapRateAndBuffer(t *testing.T) {
+	reg := NewRegistry()
+	point := NewPoint(fakeProvider{label: "foo", reg: reg})
+
+	rateTap := reg.Attach(Config{Label: "foo", Sample: 1, Rate: 0.001, BufferSize: 10})
+	bufTap := reg.Attach(Config{Label: "foo", Sample: 1, BufferSize: 2})
+
+	point.Emit(message.QuickBatch([][]byte{
+		[]byte("a"), []byte("b"), []byte("c"), []byte("d"),
+	}), nil)
+
+	assert.Len(t, readEvents(t, rateTap, 1), 1)
+	assert.Equal(t, int64(3), rateTap.Dropped())
+
+	assert.Len(t, readEvents(t, bufTap, 2), 2)
+	assert.Equal(t, int64(2), bufTap.Dropped())
+
+	reg.Detach(rateTap)
+	reg.Detach(bufTap)
+	assert.Equal(t, int64(0), reg.active.Load())
+}
+
+func TestTapSampling(t *testing.T) {
+	reg := NewRegistry()
+	point := NewPoint(fakeProvider{label: "foo", reg: reg})
+
+	tp := reg.Attach(Config{Label: "foo", Sample: 0.5, BufferSize: 1000})
+	defer reg.Detach(tp)
+
+	var batch message.Batch
+	for i := 0; i < 1000; i++ {
+		batch = append(batch, message.NewPart([]byte("hello")))
+	}
+	point.Emit(batch, nil)
+
+	received := len(tp.Events())
+	assert.Greater(t, received, 300)
+	assert.Less(t, received, 700)
+}
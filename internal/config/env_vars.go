@@ -73,3 +73,14 @@ func ReplaceEnvVariables(inBytes []byte, lookupFn func(string) (string, bool)) (
 	}
 	return
 }
+
+// EscapeEnvVariables escapes any environment variable interpolations within a
+// blob of data, such that ReplaceEnvVariables results in the original data.
+func EscapeEnvVariables(inBytes []byte) []byte {
+	return envRegex.ReplaceAllFunc(inBytes, func(content []byte) []byte {
+		escaped := make([]byte, 0, len(content)+2)
+		escaped = append(escaped, "${"...)
+		escaped = append(escaped, content[1:]...)
+		return append(escaped, '}')
+	})
+}
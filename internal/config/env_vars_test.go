@@ -63,3 +63,20 @@ func TestEnvSwapping(t *testing.T) {
 		}
 	}
 }
+
+func TestEnvEscaping(t *testing.T) {
+	envFn := func(s string) (string, bool) {
+		return "nope", true
+	}
+
+	for _, in := range []string{
+		"foo: ${BENTHOS_TEST_FOO}",
+		"foo: ${BENTHOS_TEST_FOO:default} and ${BENTHOS.TEST.BAR}",
+		"foo: ${! json(\"bar\") }",
+		"foo: no interpolations",
+	} {
+		out, err := ReplaceEnvVariables(EscapeEnvVariables([]byte(in)), envFn)
+		require.NoError(t, err, in)
+		assert.Equal(t, in, string(out))
+	}
+}
@@ -0,0 +1,403 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Jeffail/gabs/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
+)
+
+// Explain reads a Benthos config from the files and options specified and
+// returns the effective config as a YAML node. Resources from resource files
+// are merged into the config, template components are expanded into the
+// components that they render, and fields that are not set are populated with
+// their default values.
+//
+// Each value of the resulting node is annotated with a line comment describing
+// where the value came from, which is either a file and line, an environment
+// variable, a --set override, a template, or a default.
+func (r *Reader) Explain() (*yaml.Node, error) {
+	root, err := r.explainFile(r.mainPath)
+	if err != nil {
+		return nil, err
+	}
+
+	confSpec := r.specFullConfig
+	if r.streamsMode {
+		confSpec = r.specObservability
+	}
+	for _, override := range r.overrides {
+		if err := applyOverrides(confSpec, root, override); err != nil {
+			return nil, err
+		}
+		path := override[:strings.Index(override, "=")]
+		if n, err := docs.GetYAMLPath(root, gabs.DotPathToSlice(path)...); err == nil {
+			annotateExplained(n, "--set "+override)
+		}
+	}
+
+	resourcesPaths, err := r.resourcePathsExpanded()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range resourcesPaths {
+		rNode, err := r.explainFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range r.specResources {
+			resources := yamlMappingValue(rNode, spec.Name)
+			if resources == nil || resources.Kind != yaml.SequenceNode {
+				continue
+			}
+			existing := yamlMappingValue(root, spec.Name)
+			if existing == nil || existing.Kind != yaml.SequenceNode {
+				existing = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+				yamlSetMappingValue(root, spec.Name, existing)
+			}
+			existing.Content = append(existing.Content, resources.Content...)
+		}
+	}
+
+	e := explainer{prov: r.lintConf.DocsProvider}
+	if err := e.fields(confSpec, root); err != nil {
+		return nil, err
+	}
+	clearExplainedCollections(root)
+	return root, nil
+}
+
+// clearExplainedCollections removes the origin of objects and arrays that were
+// empty when annotated but have since been populated, as their origin would
+// otherwise be printed alongside the key of the first child.
+func clearExplainedCollections(node *yaml.Node) {
+	if (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) && len(node.Content) > 0 {
+		node.LineComment = ""
+	}
+	for _, n := range node.Content {
+		clearExplainedCollections(n)
+	}
+}
+
+// explainFile reads a config file without resolving environment variable
+// interpolations up front, and instead resolves them for each value so that
+// the values can be annotated with their origin.
+func (r *Reader) explainFile(path string) (*yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if path == "" {
+		return root, nil
+	}
+
+	confBytes, err := ifs.ReadFile(r.fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(confBytes, &doc); err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	if len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	if err := explainFileNode(path, root); err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	return root, nil
+}
+
+func explainFileNode(path string, node *yaml.Node) error {
+	node.HeadComment, node.LineComment, node.FootComment = "", "", ""
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			key := node.Content[i]
+			key.HeadComment, key.LineComment, key.FootComment = "", "", ""
+			if err := explainFileNode(path, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		if len(node.Content) == 0 {
+			node.LineComment = fmt.Sprintf("%v:%v", path, node.Line)
+		}
+	case yaml.SequenceNode:
+		for _, n := range node.Content {
+			if err := explainFileNode(path, n); err != nil {
+				return err
+			}
+		}
+		if len(node.Content) == 0 {
+			node.LineComment = fmt.Sprintf("%v:%v", path, node.Line)
+		}
+	case yaml.ScalarNode:
+		origin := fmt.Sprintf("%v:%v", path, node.Line)
+		if !envRegex.MatchString(node.Value) {
+			node.LineComment = origin
+			return nil
+		}
+		return explainEnvNode(origin, node)
+	}
+	return nil
+}
+
+// explainEnvNode resolves the environment variable interpolations of a scalar
+// node and annotates it with the variables that were used.
+func explainEnvNode(origin string, node *yaml.Node) error {
+	var vars []string
+	for _, match := range envRegex.FindAllString(node.Value, -1) {
+		name, defaultValue, hasDefault := strings.Cut(match[2:len(match)-1], ":")
+		if v, _ := os.LookupEnv(name); v != "" {
+			vars = append(vars, name)
+		} else if hasDefault {
+			vars = append(vars, fmt.Sprintf("%v unset, used default %v", name, defaultValue))
+		} else {
+			vars = append(vars, fmt.Sprintf("%v unset", name))
+		}
+	}
+
+	replaced, err := ReplaceEnvVariables([]byte(node.Value), os.LookupEnv)
+	if err != nil {
+		var errEnvMissing *ErrMissingEnvVars
+		if !errors.As(err, &errEnvMissing) {
+			return err
+		}
+		replaced = errEnvMissing.BestAttempt
+	}
+
+	// Interpolations are resolved before a config is parsed, and therefore an
+	// unquoted value could resolve to any type.
+	var resolved yaml.Node
+	if node.Style == 0 && yaml.Unmarshal(replaced, &resolved) == nil && len(resolved.Content) > 0 {
+		line, column := node.Line, node.Column
+		*node = *resolved.Content[0]
+		node.Line, node.Column = line, column
+	} else {
+		node.Value = string(replaced)
+	}
+	annotateExplained(node, fmt.Sprintf("env %v (%v)", strings.Join(vars, ", "), origin))
+	return nil
+}
+
+// annotateExplained sets the origin of all values within a node.
+func annotateExplained(node *yaml.Node, origin string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			annotateExplained(node.Content[i+1], origin)
+		}
+		if len(node.Content) == 0 {
+			node.LineComment = origin
+		}
+	case yaml.SequenceNode:
+		for _, n := range node.Content {
+			annotateExplained(n, origin)
+		}
+		if len(node.Content) == 0 {
+			node.LineComment = origin
+		}
+	default:
+		node.LineComment = origin
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type explainer struct {
+	prov docs.Provider
+}
+
+func (e *explainer) fields(specs docs.FieldSpecs, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for _, spec := range specs {
+		value := yamlMappingValue(node, spec.Name)
+		if value == nil {
+			if spec.IsDeprecated {
+				continue
+			}
+			_, isCore := spec.Type.IsCoreComponent()
+			switch {
+			case spec.Default != nil:
+				value = &yaml.Node{}
+				if err := value.Encode(*spec.Default); err != nil {
+					return fmt.Errorf("field %v: %w", spec.Name, err)
+				}
+			case len(spec.Children) > 0 && spec.Kind == docs.KindScalar && !isCore:
+				// Objects without a default are populated with the defaults
+				// of their children.
+				value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			default:
+				continue
+			}
+			annotateExplained(value, "default")
+			yamlSetMappingValue(node, spec.Name, value)
+		}
+		if err := e.field(spec, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *explainer) field(spec docs.FieldSpec, node *yaml.Node) error {
+	switch spec.Kind {
+	case docs.Kind2DArray:
+		if node.Kind == yaml.SequenceNode {
+			for _, n := range node.Content {
+				if err := e.field(spec.Array(), n); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case docs.KindArray:
+		if node.Kind == yaml.SequenceNode {
+			for _, n := range node.Content {
+				if err := e.field(spec.Scalar(), n); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case docs.KindMap:
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i < len(node.Content)-1; i += 2 {
+				if err := e.field(spec.Scalar(), node.Content[i+1]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if coreType, isCore := spec.Type.IsCoreComponent(); isCore {
+		return e.component(coreType, node)
+	}
+	if len(spec.Children) > 0 {
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			comment := node.LineComment
+			*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", LineComment: comment}
+		}
+		return e.fields(spec.Children, node)
+	}
+	return nil
+}
+
+func (e *explainer) component(cType docs.Type, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		return nil
+	}
+
+	name, cSpec, err := docs.GetInferenceCandidateFromYAML(e.prov, cType, node)
+	if err != nil {
+		// Components that cannot be identified are left as they are, as it's
+		// assumed that linting will capture the problem.
+		return nil
+	}
+
+	confNode := yamlMappingValue(node, name)
+	if confNode == nil && cSpec.Plugin {
+		confNode = yamlMappingValue(node, "plugin")
+	}
+
+	if env, ok := e.prov.(*bundle.Environment); ok {
+		tmplConf := confNode
+		if tmplConf == nil || tmplConf.Kind != yaml.MappingNode {
+			tmplConf = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		rendered, isTemplate, err := env.TemplateRender(cType, name, tmplConf)
+		if err != nil {
+			return fmt.Errorf("line %v: template %v: %w", node.Line, name, err)
+		}
+		if isTemplate {
+			var renderedNode yaml.Node
+			if err := renderedNode.Encode(rendered); err != nil {
+				return fmt.Errorf("line %v: template %v: %w", node.Line, name, err)
+			}
+			annotateExplained(&renderedNode, "template "+name)
+			mergeTemplateReserved(cType, node, &renderedNode)
+			*node = renderedNode
+			return e.component(cType, node)
+		}
+	}
+
+	if confNode == nil {
+		confNode = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		annotateExplained(confNode, "default")
+		yamlSetMappingValue(node, name, confNode)
+	}
+	if err := e.field(cSpec.Config, confNode); err != nil {
+		return err
+	}
+
+	for k, spec := range docs.ReservedFieldsByType(cType) {
+		if value := yamlMappingValue(node, k); value != nil {
+			if err := e.field(spec, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mergeTemplateReserved carries the reserved fields of a template component
+// into the component config it rendered, following the same rules as when the
+// template is constructed.
+func mergeTemplateReserved(cType docs.Type, tmplNode, renderedNode *yaml.Node) {
+	if label := yamlMappingValue(tmplNode, "label"); label != nil && yamlMappingValue(renderedNode, "label") == nil {
+		yamlSetMappingValue(renderedNode, "label", label)
+	}
+
+	procs := yamlMappingValue(tmplNode, "processors")
+	if procs == nil || procs.Kind != yaml.SequenceNode {
+		return
+	}
+	renderedProcs := yamlMappingValue(renderedNode, "processors")
+	if renderedProcs == nil || renderedProcs.Kind != yaml.SequenceNode {
+		yamlSetMappingValue(renderedNode, "processors", procs)
+		return
+	}
+	switch cType {
+	case docs.TypeInput:
+		// Template processors are inserted before configured processors.
+		renderedProcs.Content = append(renderedProcs.Content, procs.Content...)
+	case docs.TypeOutput:
+		// Template processors are inserted after configured processors.
+		renderedProcs.Content = append(append([]*yaml.Node{}, procs.Content...), renderedProcs.Content...)
+	}
+}
+
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func yamlSetMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   "!!str",
+		Value: key,
+	}, value)
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/template"
+)
+
+func TestReaderExplain(t *testing.T) {
+	t.Setenv("EXPLAIN_TEST_PATH", "/tmp/foo.txt")
+
+	env := bundle.GlobalEnvironment.Clone()
+	require.NoError(t, template.RegisterTemplateYAML(env, []byte(`
+name: explain_gen
+type: input
+fields:
+  - name: msg
+    type: string
+mapping: |
+  root.generate.mapping = "root = %q".format(this.msg)
+  root.processors = [ { "log": { "message": "from template" } } ]
+`)))
+
+	testFS := &testFS{m: fstest.MapFS{
+		"main.yaml": &fstest.MapFile{
+			Data: []byte(`# A comment
+input:
+  label: foo
+  explain_gen:
+    msg: hello
+  processors:
+    - mapping: root = this
+pipeline:
+  threads: ${EXPLAIN_TEST_THREADS:4}
+output:
+  file:
+    path: ${EXPLAIN_TEST_PATH}
+    codec: lines
+`),
+		},
+		"res.yaml": &fstest.MapFile{
+			Data: []byte(`cache_resources:
+  - label: foocache
+    memory:
+      default_ttl: 60s
+`),
+		},
+	}}
+
+	lConf := docs.NewLintConfig(env)
+	rdr := newDummyReader("main.yaml", []string{"res.yaml"},
+		OptUseFS(testFS),
+		OptSetLintConfig(lConf),
+		OptAddOverrides("output.file.codec=all-bytes"),
+	)
+
+	node, err := rdr.Explain()
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		path    []string
+		value   string
+		comment string
+	}{
+		{path: []string{"input", "label"}, value: "foo", comment: "main.yaml:3"},
+		{path: []string{"input", "generate", "mapping"}, value: `root = "hello"`, comment: "template explain_gen"},
+		{path: []string{"input", "generate", "interval"}, value: "1s", comment: "default"},
+		{path: []string{"input", "processors", "0", "log", "message"}, value: "from template", comment: "template explain_gen"},
+		{path: []string{"input", "processors", "1", "mapping"}, value: "root = this", comment: "main.yaml:7"},
+		{path: []string{"pipeline", "threads"}, value: "4", comment: "env EXPLAIN_TEST_THREADS unset, used default 4 (main.yaml:9)"},
+		{path: []string{"output", "file", "path"}, value: "/tmp/foo.txt", comment: "env EXPLAIN_TEST_PATH (main.yaml:12)"},
+		{path: []string{"output", "file", "codec"}, value: "all-bytes", comment: "--set output.file.codec=all-bytes"},
+		{path: []string{"cache_resources", "0", "label"}, value: "foocache", comment: "res.yaml:2"},
+		{path: []string{"cache_resources", "0", "memory", "default_ttl"}, value: "60s", comment: "res.yaml:4"},
+		{path: []string{"cache_resources", "0", "memory", "shards"}, value: "1", comment: "default"},
+		{path: []string{"shutdown_timeout"}, value: "20s", comment: "default"},
+	} {
+		n, err := docs.GetYAMLPath(node, test.path...)
+		require.NoError(t, err, test.path)
+		assert.Equal(t, test.value, n.Value, test.path)
+		assert.Equal(t, test.comment, n.LineComment, test.path)
+	}
+
+	_, err = docs.GetYAMLPath(node, "input", "explain_gen")
+	require.Error(t, err)
+
+	inputNode, err := docs.GetYAMLPath(node, "input")
+	require.NoError(t, err)
+	assert.Empty(t, inputNode.LineComment)
+	assert.Empty(t, inputNode.HeadComment)
+}
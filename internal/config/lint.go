@@ -77,6 +77,11 @@ func LintYAMLBytes(lintConf docs.LintConfig, rawBytes []byte) ([]docs.Lint, erro
 //
 // An modTime timestamp is returned if the modtime of the file is available.
 func ReadFileEnvSwap(store ifs.FS, path string, lookupEnvFn func(name string) (string, bool)) (configBytes []byte, lints []docs.Lint, modTime time.Time, err error) {
+	_, configBytes, lints, modTime, err = readFileEnvSwap(store, path, lookupEnvFn)
+	return
+}
+
+func readFileEnvSwap(store ifs.FS, path string, lookupEnvFn func(name string) (string, bool)) (rawBytes, configBytes []byte, lints []docs.Lint, modTime time.Time, err error) {
 	var configFile fs.File
 	if configFile, err = store.Open(path); err != nil {
 		return
@@ -86,18 +91,18 @@ func ReadFileEnvSwap(store ifs.FS, path string, lookupEnvFn func(name string) (s
 		modTime = info.ModTime()
 	}
 
-	if configBytes, err = io.ReadAll(configFile); err != nil {
+	if rawBytes, err = io.ReadAll(configFile); err != nil {
 		return
 	}
 
-	if !utf8.Valid(configBytes) {
+	if !utf8.Valid(rawBytes) {
 		lints = append(lints, docs.NewLintError(
 			1, docs.LintFailedRead,
 			errors.New("detected invalid utf-8 encoding in config, this may result in interpolation functions not working as expected"),
 		))
 	}
 
-	if configBytes, err = ReplaceEnvVariables(configBytes, lookupEnvFn); err != nil {
+	if configBytes, err = ReplaceEnvVariables(rawBytes, lookupEnvFn); err != nil {
 		var errEnvMissing *ErrMissingEnvVars
 		if errors.As(err, &errEnvMissing) {
 			configBytes = errEnvMissing.BestAttempt
@@ -29,6 +29,10 @@ const (
 
 type streamFileInfo struct {
 	id string
+
+	// A digest of the raw contents of the file as of the last time it was
+	// read, prior to the substitution of environment variables.
+	digest string
 }
 
 type fileWatcher interface {
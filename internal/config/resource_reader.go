@@ -294,6 +294,24 @@ func (r *Reader) TriggerResourceDelete(mgr bundle.NewManagement, path string) er
 	return nil
 }
 
+// resourceUnchanged returns true if a resource config read from a file is
+// identical to the config previously read from the same file, in which case the
+// running resource can be left untouched.
+func resourceUnchanged[T any](prev, current *T) bool {
+	if prev == nil {
+		return false
+	}
+	prevBytes, err := yaml.Marshal(prev)
+	if err != nil {
+		return false
+	}
+	currentBytes, err := yaml.Marshal(current)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(prevBytes, currentBytes)
+}
+
 func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, currentInfo, prevInfo resourceFileInfo) error {
 	// Kind of arbitrary, but I feel better about having some sort of timeout.
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
@@ -309,6 +327,9 @@ func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, cur
 	}
 	for k, v := range currentInfo.rateLimits {
 		delete(unaccounted, k)
+		if r.resourceSources.rateLimits[k] == path && resourceUnchanged(prevInfo.rateLimits[k], v) {
+			continue
+		}
 		if err := mgr.StoreRateLimit(ctx, k, *v); err != nil {
 			mgr.Logger().Error("Failed to update resource %v: %v", k, err)
 			return fmt.Errorf("resource %v: %w", k, err)
@@ -325,6 +346,9 @@ func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, cur
 	}
 	for k, v := range currentInfo.caches {
 		delete(unaccounted, k)
+		if r.resourceSources.caches[k] == path && resourceUnchanged(prevInfo.caches[k], v) {
+			continue
+		}
 		if err := mgr.StoreCache(ctx, k, *v); err != nil {
 			mgr.Logger().Error("Failed to update resource %v: %v", k, err)
 			return fmt.Errorf("resource %v: %w", k, err)
@@ -341,6 +365,9 @@ func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, cur
 	}
 	for k, v := range currentInfo.processors {
 		delete(unaccounted, k)
+		if r.resourceSources.processors[k] == path && resourceUnchanged(prevInfo.processors[k], v) {
+			continue
+		}
 		if err := mgr.StoreProcessor(ctx, k, *v); err != nil {
 			mgr.Logger().Error("Failed to update resource %v: %v", k, err)
 			return fmt.Errorf("resource %v: %w", k, err)
@@ -357,6 +384,9 @@ func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, cur
 	}
 	for k, v := range currentInfo.inputs {
 		delete(unaccounted, k)
+		if r.resourceSources.inputs[k] == path && resourceUnchanged(prevInfo.inputs[k], v) {
+			continue
+		}
 		if err := mgr.StoreInput(ctx, k, *v); err != nil {
 			mgr.Logger().Error("Failed to update resource %v: %v", k, err)
 			return fmt.Errorf("resource %v: %w", k, err)
@@ -373,6 +403,9 @@ func (r *Reader) applyResourceChanges(path string, mgr bundle.NewManagement, cur
 	}
 	for k, v := range currentInfo.outputs {
 		delete(unaccounted, k)
+		if r.resourceSources.outputs[k] == path && resourceUnchanged(prevInfo.outputs[k], v) {
+			continue
+		}
 		if err := mgr.StoreOutput(ctx, k, *v); err != nil {
 			mgr.Logger().Error("Failed to update resource %v: %v", k, err)
 			return fmt.Errorf("resource %v: %w", k, err)
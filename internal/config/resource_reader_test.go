@@ -5,11 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -246,3 +248,59 @@ processor_resources:
 	assertProc("barproc", "hello world", "hello world and a replaced bar")
 	assertProc("bazproc", "hello world", "hello world and a new baz")
 }
+
+func TestReaderResourceUnchangedNotReplaced(t *testing.T) {
+	testFS := &testFS{m: fstest.MapFS{
+		"a.yaml": &fstest.MapFile{
+			Data: []byte(`
+cache_resources:
+  - label: foocache
+    memory: {}
+processor_resources:
+  - label: fooproc
+    mapping: 'root = content() + " foo1"'
+`),
+		},
+	}}
+
+	rdr := newDummyReader("", nil, OptUseFS(testFS))
+
+	testMgr, err := manager.New(manager.ResourceConfig{})
+	require.NoError(t, err)
+	require.NoError(t, rdr.TriggerResourceUpdate(testMgr, true, "a.yaml"))
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	require.NoError(t, testMgr.AccessCache(tCtx, "foocache", func(c cache.V1) {
+		require.NoError(t, c.Set(tCtx, "meow", []byte("woof"), nil))
+	}))
+
+	// Update the processor only, the cache should remain untouched.
+	testFS.m["a.yaml"] = &fstest.MapFile{
+		Data: []byte(`
+cache_resources:
+  - label: foocache
+    memory: {}
+processor_resources:
+  - label: fooproc
+    mapping: 'root = content() + " foo2"'
+`),
+	}
+	require.NoError(t, rdr.TriggerResourceUpdate(testMgr, true, "a.yaml"))
+
+	require.NoError(t, testMgr.AccessCache(tCtx, "foocache", func(c cache.V1) {
+		v, err := c.Get(tCtx, "meow")
+		require.NoError(t, err)
+		assert.Equal(t, "woof", string(v))
+	}))
+
+	require.NoError(t, testMgr.AccessProcessor(tCtx, "fooproc", func(p processor.V1) {
+		res, err := p.ProcessBatch(tCtx, message.Batch{
+			message.NewPart([]byte("hello")),
+		})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, "hello foo2", string(res[0][0].AsBytes()))
+	}))
+}
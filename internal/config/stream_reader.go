@@ -2,6 +2,8 @@ package config
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -43,13 +45,15 @@ func inferStreamID(dir, path string) (string, error) {
 	return id, nil
 }
 
-func (r *Reader) readStreamFileConfig(path string) (conf stream.Config, lints []string, err error) {
-	var confBytes []byte
+func (r *Reader) readStreamFileConfig(path string) (conf stream.Config, digest string, lints []string, err error) {
+	var rawBytes, confBytes []byte
 	var dLints []docs.Lint
 	var modTime time.Time
-	if confBytes, dLints, modTime, err = ReadFileEnvSwap(r.fs, path, os.LookupEnv); err != nil {
+	if rawBytes, confBytes, dLints, modTime, err = readFileEnvSwap(r.fs, path, os.LookupEnv); err != nil {
 		return
 	}
+	sum := sha256.Sum256(rawBytes)
+	digest = hex.EncodeToString(sum[:])
 	for _, l := range dLints {
 		lints = append(lints, l.Error())
 	}
@@ -89,15 +93,33 @@ func (r *Reader) readStreamFile(id, path string, confs map[string]stream.Config)
 		return nil, fmt.Errorf("stream id (%v) collision from file: %v", id, path)
 	}
 
-	conf, lints, err := r.readStreamFileConfig(path)
+	conf, digest, lints, err := r.readStreamFileConfig(path)
 	if err != nil {
 		return nil, err
 	}
 
+	info := r.streamFileInfo[path]
+	info.digest = digest
+	r.streamFileInfo[path] = info
+
 	confs[id] = conf
 	return lints, nil
 }
 
+// StreamFileDigests returns a digest of the raw contents of each stream config
+// file as of the last time it was read, keyed by the stream ID. Digests are
+// taken prior to the substitution of environment variables, and therefore only
+// change when the contents of a file change.
+func (r *Reader) StreamFileDigests() map[string]string {
+	digests := map[string]string{}
+	for _, info := range r.streamFileInfo {
+		if info.digest != "" {
+			digests[info.id] = info.digest
+		}
+	}
+	return digests
+}
+
 func (r *Reader) streamPathsExpanded() ([]string, error) {
 	streamsPaths, err := ifilepath.Globs(r.fs, r.streamsPaths)
 	if err != nil {
@@ -189,13 +211,15 @@ func (r *Reader) TriggerStreamUpdate(mgr bundle.NewManagement, strict bool, path
 		return nil
 	}
 
-	conf, lints, err := r.readStreamFileConfig(path)
+	conf, digest, lints, err := r.readStreamFileConfig(path)
 	if errors.Is(err, fs.ErrNotExist) {
 		info, exists := r.streamFileInfo[path]
 		if !exists {
 			return nil
 		}
 		mgr.Logger().Info("Stream %v config deleted, attempting to remove stream.", info.id)
+		info.digest = ""
+		r.streamFileInfo[path] = info
 
 		if err := r.streamUpdateFn(info.id, nil); err != nil {
 			mgr.Logger().Error("Failed to remove deleted stream %v config: %v", info.id, err)
@@ -218,9 +242,10 @@ func (r *Reader) TriggerStreamUpdate(mgr bundle.NewManagement, strict bool, path
 			return err
 		}
 		info = streamFileInfo{id: id}
-		r.streamFileInfo[path] = info
 		mgr.Logger().Info("Stream %v config added, attempting to create stream.", info.id)
 	}
+	info.digest = digest
+	r.streamFileInfo[path] = info
 
 	lintlog := mgr.Logger()
 	for _, lint := range lints {
@@ -116,3 +116,40 @@ pipeline:
 	assert.Equal(t, `root = "second"`, gabs.Wrap(testConfToAny(t, streamConfs["inner_second"])).S("pipeline", "processors", "0", "bloblang").Data())
 	assert.Equal(t, `root = "third"`, gabs.Wrap(testConfToAny(t, streamConfs["inner_third"])).S("pipeline", "processors", "0", "bloblang").Data())
 }
+
+func TestStreamFileDigests(t *testing.T) {
+	t.Setenv("BENTHOS_TEST_DIGEST_MAPPING", `root = "from the env"`)
+
+	dir := t.TempDir()
+
+	streamPath := filepath.Join(dir, "foo.yaml")
+	require.NoError(t, os.WriteFile(streamPath, []byte(`
+pipeline:
+  processors:
+    - bloblang: '${BENTHOS_TEST_DIGEST_MAPPING}'
+`), 0o644))
+
+	readDigests := func() map[string]string {
+		t.Helper()
+
+		rdr := config.NewReader("", nil, config.OptSetStreamPaths(streamPath))
+		_, err := rdr.ReadStreams(map[string]stream.Config{})
+		require.NoError(t, err)
+		return rdr.StreamFileDigests()
+	}
+
+	first := readDigests()
+	require.Contains(t, first, "foo")
+
+	// Digests are taken from the raw file and therefore do not change with
+	// the environment.
+	t.Setenv("BENTHOS_TEST_DIGEST_MAPPING", `root = "from a new env"`)
+	assert.Equal(t, first, readDigests())
+
+	require.NoError(t, os.WriteFile(streamPath, []byte(`
+pipeline:
+  processors:
+    - bloblang: 'root = "changed"'
+`), 0o644))
+	assert.NotEqual(t, first["foo"], readDigests()["foo"])
+}
@@ -1,6 +1,8 @@
 package test
 
 import (
+	"fmt"
+
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
 
@@ -9,10 +11,13 @@ const (
 	fieldCaseEnvironment      = "environment"
 	fieldCaseTargetProcessors = "target_processors"
 	fieldCaseTargetMapping    = "target_mapping"
+	fieldCaseTargetStream     = "target_stream"
 	fieldCaseMocks            = "mocks"
 	fieldCaseInputBatch       = "input_batch"
 	fieldCaseInputBatches     = "input_batches"
 	fieldCaseOutputBatches    = "output_batches"
+	fieldCaseInputFixtures    = "input_fixtures"
+	fieldCaseOutputFixtures   = "output_fixtures"
 )
 
 type Case struct {
@@ -20,9 +25,12 @@ type Case struct {
 	Environment      map[string]string
 	TargetProcessors string
 	TargetMapping    string
+	TargetStream     string
 	Mocks            map[string]any
 	InputBatches     [][]InputConfig
 	OutputBatches    [][]OutputConditionsMap
+	InputFixtures    map[string]InputFixture
+	OutputFixtures   map[string]OutputFixture
 
 	line int
 }
@@ -48,6 +56,10 @@ It is also possible to target processors in a separate file by prefixing the tar
 		docs.FieldString(fieldCaseTargetMapping,
 			"A file path relative to the test definition path of a Bloblang file to execute as an alternative to testing processors with the `target_processors` field. This allows you to define unit tests for Bloblang mappings directly.",
 		).HasDefault(""),
+		docs.FieldString(fieldCaseTargetStream,
+			"A file path relative to the test definition path of a config to execute in its entirety as an alternative to testing processors with the `target_processors` field. When set, messages are fed into the stream with `input_fixtures` and collected from the stream with `output_fixtures`, allowing you to test input processors, output routing and acknowledgements.",
+			"./foo.yaml",
+		).HasDefault(""),
 		docs.FieldAnything(fieldCaseMocks,
 			"An optional map of processors to mock. Keys should contain either a label or a JSON pointer of a processor that should be mocked. Values should contain a processor definition, which will replace the mocked processor. Most of the time you'll want to use a [`mapping` processor][processors.mapping] here, and use it to create a result that emulates the target processor.",
 			map[string]any{
@@ -67,6 +79,10 @@ It is also possible to target processors in a separate file by prefixing the tar
 			ArrayOfArrays().Optional().WithChildren(inputFields()...),
 		docs.FieldObject(fieldCaseOutputBatches, "List of output batches.").
 			ArrayOfArrays().Optional().WithChildren(outputFields()...),
+		docs.FieldObject(fieldCaseInputFixtures, "A map of inputs to replace with in-memory fixtures when testing a `target_stream`. Keys should contain either a label or a JSON pointer of an input, and values define the batches of messages that the input will yield. Processors of the replaced input are retained.").
+			Map().Optional().WithChildren(inputFixtureFields()...),
+		docs.FieldObject(fieldCaseOutputFixtures, "A map of outputs to replace with in-memory fixtures when testing a `target_stream`. Keys should contain either a label or a JSON pointer of an output, and values define the batches of messages that the output is expected to receive. Processors of the replaced output are retained.").
+			Map().Optional().WithChildren(outputFixtureFields()...),
 	}
 }
 
@@ -94,6 +110,9 @@ func CaseFromParsed(pConf *docs.ParsedConfig) (c Case, err error) {
 	if c.TargetMapping, err = pConf.FieldString(fieldCaseTargetMapping); err != nil {
 		return
 	}
+	if c.TargetStream, err = pConf.FieldString(fieldCaseTargetStream); err != nil {
+		return
+	}
 
 	if pConf.Contains(fieldCaseMocks) {
 		var tmpMocksAny map[string]*docs.ParsedConfig
@@ -109,19 +128,9 @@ func CaseFromParsed(pConf *docs.ParsedConfig) (c Case, err error) {
 	}
 
 	if pConf.Contains(fieldCaseInputBatches) {
-		var iBListOfList [][]*docs.ParsedConfig
-		if iBListOfList, err = pConf.FieldObjectListOfLists(fieldCaseInputBatches); err != nil {
+		if c.InputBatches, err = inputBatchesFromParsed(pConf, fieldCaseInputBatches); err != nil {
 			return
 		}
-		for _, ol := range iBListOfList {
-			tmpList := make([]InputConfig, len(ol))
-			for i, il := range ol {
-				if tmpList[i], err = InputFromParsed(il); err != nil {
-					return
-				}
-			}
-			c.InputBatches = append(c.InputBatches, tmpList)
-		}
 	}
 
 	if pConf.Contains(fieldCaseInputBatch) {
@@ -143,18 +152,36 @@ func CaseFromParsed(pConf *docs.ParsedConfig) (c Case, err error) {
 	}
 
 	if pConf.Contains(fieldCaseOutputBatches) {
-		var oBListOfList [][]*docs.ParsedConfig
-		if oBListOfList, err = pConf.FieldObjectListOfLists(fieldCaseOutputBatches); err != nil {
+		if c.OutputBatches, err = outputBatchesFromParsed(pConf, fieldCaseOutputBatches); err != nil {
 			return
 		}
-		for _, ol := range oBListOfList {
-			tmpList := make([]OutputConditionsMap, len(ol))
-			for i, il := range ol {
-				if tmpList[i], err = OutputConditionsFromParsed(il); err != nil {
-					return
-				}
+	}
+
+	if pConf.Contains(fieldCaseInputFixtures) {
+		var tmpMap map[string]*docs.ParsedConfig
+		if tmpMap, err = pConf.FieldObjectMap(fieldCaseInputFixtures); err != nil {
+			return
+		}
+		c.InputFixtures = map[string]InputFixture{}
+		for k, v := range tmpMap {
+			if c.InputFixtures[k], err = InputFixtureFromParsed(v); err != nil {
+				err = fmt.Errorf("%v.%v: %w", fieldCaseInputFixtures, k, err)
+				return
+			}
+		}
+	}
+
+	if pConf.Contains(fieldCaseOutputFixtures) {
+		var tmpMap map[string]*docs.ParsedConfig
+		if tmpMap, err = pConf.FieldObjectMap(fieldCaseOutputFixtures); err != nil {
+			return
+		}
+		c.OutputFixtures = map[string]OutputFixture{}
+		for k, v := range tmpMap {
+			if c.OutputFixtures[k], err = OutputFixtureFromParsed(v); err != nil {
+				err = fmt.Errorf("%v.%v: %w", fieldCaseOutputFixtures, k, err)
+				return
 			}
-			c.OutputBatches = append(c.OutputBatches, tmpList)
 		}
 	}
 	return
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+const (
+	fieldInputFixtureInputBatches = "input_batches"
+	fieldInputFixtureAcks         = "acks"
+
+	fieldOutputFixtureOutputBatches = "output_batches"
+	fieldOutputFixtureReject        = "reject"
+)
+
+// Acknowledgement outcomes that can be expected of input fixture batches.
+const (
+	AckResultAck  = "ack"
+	AckResultNack = "nack"
+)
+
+func inputFixtureFields() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldObject(fieldInputFixtureInputBatches, "A series of batches of messages to feed into the stream from this input.").
+			ArrayOfArrays().WithChildren(inputFields()...),
+		docs.FieldString(fieldInputFixtureAcks, "An optional list of expected acknowledgement outcomes, one for each input batch in order, where each outcome is either `ack` or `nack`. A batch is nacked when the stream was unable to deliver it, e.g. when an output rejected it and no fallback was available.", []string{"ack", "nack"}).
+			Array().Optional(),
+	}
+}
+
+func outputFixtureFields() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldObject(fieldOutputFixtureOutputBatches, "List of batches expected to reach this output, in the order that they are expected to arrive. If the output is expected to receive no messages this can be left empty.").
+			ArrayOfArrays().Optional().WithChildren(outputFields()...),
+		docs.FieldString(fieldOutputFixtureReject, "An optional error message, when set all messages that reach this output are rejected with the error. This is useful for testing `fallback` chains and the acknowledgement behaviour of a stream.", "simulated outage").HasDefault(""),
+	}
+}
+
+// InputFixture describes an in-memory replacement for an input of a stream
+// under test, along with the expected outcomes of the batches it yields.
+type InputFixture struct {
+	InputBatches [][]InputConfig
+	Acks         []string
+}
+
+// InputFixtureFromParsed attempts to parse an input fixture from a parsed
+// config.
+func InputFixtureFromParsed(pConf *docs.ParsedConfig) (f InputFixture, err error) {
+	if f.InputBatches, err = inputBatchesFromParsed(pConf, fieldInputFixtureInputBatches); err != nil {
+		return
+	}
+	if pConf.Contains(fieldInputFixtureAcks) {
+		if f.Acks, err = pConf.FieldStringList(fieldInputFixtureAcks); err != nil {
+			return
+		}
+		for i, a := range f.Acks {
+			if a != AckResultAck && a != AckResultNack {
+				err = fmt.Errorf("%v.%v: unrecognised acknowledgement outcome '%v', expected %v or %v", fieldInputFixtureAcks, i, a, AckResultAck, AckResultNack)
+				return
+			}
+		}
+		if lAcks, lBatches := len(f.Acks), len(f.InputBatches); lAcks != lBatches {
+			err = fmt.Errorf("%v: expected %v acknowledgement outcomes to match the number of input batches, got %v", fieldInputFixtureAcks, lBatches, lAcks)
+			return
+		}
+	}
+	return
+}
+
+// OutputFixture describes an in-memory replacement for an output of a stream
+// under test, along with the batches it is expected to receive.
+type OutputFixture struct {
+	OutputBatches [][]OutputConditionsMap
+	Reject        string
+}
+
+// OutputFixtureFromParsed attempts to parse an output fixture from a parsed
+// config.
+func OutputFixtureFromParsed(pConf *docs.ParsedConfig) (f OutputFixture, err error) {
+	if pConf.Contains(fieldOutputFixtureOutputBatches) {
+		if f.OutputBatches, err = outputBatchesFromParsed(pConf, fieldOutputFixtureOutputBatches); err != nil {
+			return
+		}
+	}
+	if f.Reject, err = pConf.FieldString(fieldOutputFixtureReject); err != nil {
+		return
+	}
+	return
+}
@@ -91,3 +91,20 @@ func InputFromParsed(pConf *docs.ParsedConfig) (conf InputConfig, err error) {
 	}
 	return
 }
+
+func inputBatchesFromParsed(pConf *docs.ParsedConfig, field string) (batches [][]InputConfig, err error) {
+	var iBListOfList [][]*docs.ParsedConfig
+	if iBListOfList, err = pConf.FieldObjectListOfLists(field); err != nil {
+		return
+	}
+	for _, ol := range iBListOfList {
+		tmpList := make([]InputConfig, len(ol))
+		for i, il := range ol {
+			if tmpList[i], err = InputFromParsed(il); err != nil {
+				return
+			}
+		}
+		batches = append(batches, tmpList)
+	}
+	return
+}
@@ -183,6 +183,23 @@ func OutputConditionsFromParsed(pConf *docs.ParsedConfig) (m OutputConditionsMap
 	return
 }
 
+func outputBatchesFromParsed(pConf *docs.ParsedConfig, field string) (batches [][]OutputConditionsMap, err error) {
+	var oBListOfList [][]*docs.ParsedConfig
+	if oBListOfList, err = pConf.FieldObjectListOfLists(field); err != nil {
+		return
+	}
+	for _, ol := range oBListOfList {
+		tmpList := make([]OutputConditionsMap, len(ol))
+		for i, il := range ol {
+			if tmpList[i], err = OutputConditionsFromParsed(il); err != nil {
+				return
+			}
+		}
+		batches = append(batches, tmpList)
+	}
+	return
+}
+
 type BloblangCondition struct {
 	m *mapping.Executor
 }
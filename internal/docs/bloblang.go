@@ -1,6 +1,8 @@
 package docs
 
 import (
+	ibloblang "github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
 )
 
@@ -14,6 +16,11 @@ func LintBloblangMapping(ctx LintContext, line, col int, v any) []Lint {
 	if str == "" {
 		return nil
 	}
+	if unwrapper, ok := ctx.conf.BloblangEnv.XUnwrapper().(interface {
+		Unwrap() *ibloblang.Environment
+	}); ok {
+		return lintBloblangMappingTypes(unwrapper.Unwrap(), line, col, str)
+	}
 	_, err := ctx.conf.BloblangEnv.Parse(str)
 	if err == nil {
 		return nil
@@ -26,6 +33,33 @@ func LintBloblangMapping(ctx LintContext, line, col int, v any) []Lint {
 	return []Lint{NewLintError(line, LintBadBloblang, err)}
 }
 
+// lintBloblangMappingTypes parses a mapping and performs a static type check
+// over it, returning a lint for a parse error, or for each definite type
+// mismatch and unreachable match case.
+func lintBloblangMappingTypes(env *ibloblang.Environment, line, col int, str string) []Lint {
+	input := []rune(str)
+	lintAt := func(clip []rune, err error) Lint {
+		cLine, cCol := parser.LineAndColOf(input, clip)
+		lint := NewLintError(line+cLine-1, LintBadBloblang, err)
+		lint.Column = col + cCol
+		return lint
+	}
+
+	typeErrs, err := env.CheckMapping(str)
+	if err != nil {
+		if pErr, ok := err.(*parser.Error); ok {
+			return []Lint{lintAt(pErr.Input, pErr)}
+		}
+		return []Lint{NewLintError(line, LintBadBloblang, err)}
+	}
+
+	var lints []Lint
+	for _, tErr := range typeErrs {
+		lints = append(lints, lintAt(tErr.Input, tErr.Err))
+	}
+	return lints
+}
+
 // LintBloblangField is function for linting a config field expected to be an
 // interpolation string.
 func LintBloblangField(ctx LintContext, line, col int, v any) []Lint {
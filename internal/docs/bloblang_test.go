@@ -41,6 +41,25 @@ func TestLintBloblangMapping(t *testing.T) {
 				},
 			},
 		},
+		"mapping type mismatch guarded by catch": {
+			mapping: "root.foo = this.foo\nroot.bar = this.bar.length().uppercase().catch(\"\")",
+			line:    2,
+			col:     4,
+		},
+		"mapping type mismatch": {
+			mapping: "root.foo = this.foo\nroot.bar = this.bar.length().uppercase()",
+			line:    2,
+			col:     4,
+			wantLints: []docs.Lint{
+				{
+					Line:   3,
+					Column: 34,
+					Level:  docs.LintError,
+					Type:   docs.LintBadBloblang,
+					What:   `method uppercase expects a string or bytes value, got number`,
+				},
+			},
+		},
 	}
 
 	ctx := docs.NewLintContext(docs.NewLintConfig(bundle.GlobalEnvironment))
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/benthosdev/benthos/v4/internal/value"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
 )
@@ -138,6 +140,7 @@ type FieldSpec struct {
 
 	omitWhenFn   func(field, parent any) (why string, shouldOmit bool)
 	customLintFn LintFunc
+	fixFn        FixFunc
 }
 
 // IsInterpolated indicates that the field supports interpolation functions.
@@ -305,6 +308,20 @@ func (f FieldSpec) LinterFunc(fn LintFunc) FieldSpec {
 	return f
 }
 
+// FixerFunc adds a function to a field that is able to deterministically
+// rewrite a config in order to resolve a linting error, which is usually
+// because the field is deprecated in favour of a newer alternative. When fixes
+// are applied to a config the provided function will be called with the object
+// that contains the field, or with the component itself when the field is the
+// config of a component.
+//
+// Similar to LinterFunc a fixer function defined this way will only be
+// effective in the binary that defines it.
+func (f FieldSpec) FixerFunc(fn FixFunc) FieldSpec {
+	f.fixFn = fn
+	return f
+}
+
 func lintsFromAny(line int, v any) (lints []Lint) {
 	switch t := v.(type) {
 	case []any:
@@ -710,6 +727,11 @@ func NewLintContext(conf LintConfig) LintContext {
 // LintFunc is a common linting function for field values.
 type LintFunc func(ctx LintContext, line, col int, value any) []Lint
 
+// FixFunc is a function that rewrites the parent YAML node of a field in order
+// to resolve a linting error. Returns a description of the fix when a change
+// was made, or an empty string if the node was left unchanged.
+type FixFunc func(parent *yaml.Node) (fixed string, err error)
+
 // LintLevel describes the severity level of a linting error.
 type LintLevel int
 
@@ -0,0 +1,329 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FixYAML takes a yaml.Node and a config spec and attempts to resolve linting
+// errors found within the config by rewriting the node in place. Fixes are only
+// applied when the correction is deterministic, such as rewriting deprecated
+// fields with a declared fixer, removing fields that are ineffective, and
+// renaming unknown fields that closely match exactly one known field. Returns a
+// list of the linting errors that were fixed.
+func FixYAML(ctx LintContext, cType Type, node *yaml.Node) ([]Lint, error) {
+	node = unwrapDocumentNode(node)
+	if node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var name string
+	var keys []string
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == "type" {
+			name = node.Content[i+1].Value
+			break
+		}
+		keys = append(keys, node.Content[i].Value)
+	}
+	if name == "" {
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		var err error
+		if name, _, err = getInferenceCandidateFromList(ctx.conf.DocsProvider, cType, keys); err != nil {
+			return nil, nil
+		}
+	}
+
+	cSpec, exists := ctx.conf.DocsProvider.GetDocs(name, cType)
+	if !exists {
+		return nil, nil
+	}
+
+	var lints []Lint
+	if cSpec.Config.fixFn != nil {
+		what, err := cSpec.Config.fixFn(node)
+		if err != nil {
+			return nil, err
+		}
+		if what != "" {
+			// The fix may have changed the component type, and therefore we
+			// start again from the top.
+			lints = append(lints, NewLintError(node.Line, LintDeprecated, errors.New(what)))
+			moreLints, err := FixYAML(ctx, cType, node)
+			return append(lints, moreLints...), err
+		}
+	}
+
+	reservedFields := ReservedFieldsByType(cType)
+
+	nameFound := false
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == name {
+			nameFound = true
+			break
+		}
+	}
+
+	candidates := []string{name}
+	for k := range reservedFields {
+		candidates = append(candidates, k)
+	}
+
+	var newContent []*yaml.Node
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+		key := keyNode.Value
+		if key == "plugin" && (nameFound || !cSpec.Plugin) {
+			lints = append(lints, NewLintError(keyNode.Line, LintShouldOmit, errors.New("removed ineffective plugin object")))
+			continue
+		}
+
+		if _, exists := reservedFields[key]; !exists && key != name && key != "type" && key != "plugin" {
+			if newKey := closestFieldName(node, key, candidates); newKey != "" {
+				lints = append(lints, NewLintError(keyNode.Line, LintUnknown, fmt.Errorf("field %v is invalid when the component type is %v (%v), renamed to %v", key, name, cType, newKey)))
+				keyNode.Value = newKey
+				key = newKey
+				nameFound = nameFound || key == name
+			}
+		}
+		newContent = append(newContent, keyNode, valueNode)
+
+		var fieldLints []Lint
+		var err error
+		if key == name || (key == "plugin" && cSpec.Plugin) {
+			fieldLints, err = cSpec.Config.FixYAML(ctx, valueNode)
+		} else if spec, exists := reservedFields[key]; exists {
+			fieldLints, err = spec.FixYAML(ctx, valueNode)
+		}
+		if err != nil {
+			return nil, err
+		}
+		lints = append(lints, fieldLints...)
+	}
+	node.Content = newContent
+	return lints, nil
+}
+
+// FixYAML attempts to resolve linting errors found by checking a field
+// definition against a yaml node by rewriting the node in place. Returns a list
+// of the linting errors that were fixed.
+func (f FieldSpec) FixYAML(ctx LintContext, node *yaml.Node) ([]Lint, error) {
+	if node.Kind == yaml.AliasNode || node.Kind == yaml.DocumentNode {
+		return nil, nil
+	}
+
+	var lints []Lint
+	switch f.Kind {
+	case Kind2DArray:
+		if node.Kind != yaml.SequenceNode {
+			return nil, nil
+		}
+		for _, n := range node.Content {
+			fieldLints, err := f.Array().FixYAML(ctx, n)
+			if err != nil {
+				return nil, err
+			}
+			lints = append(lints, fieldLints...)
+		}
+		return lints, nil
+	case KindArray:
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			return nil, nil
+		}
+		if node.Kind != yaml.SequenceNode {
+			// A single value where an array is expected is almost certainly
+			// meant to be an array of one value.
+			lints = append(lints, NewLintError(node.Line, LintExpectedArray, errors.New("expected array value, wrapped value in an array")))
+			elementNode := *node
+			*node = yaml.Node{
+				Kind:    yaml.SequenceNode,
+				Tag:     "!!seq",
+				Content: []*yaml.Node{&elementNode},
+				Line:    elementNode.Line,
+				Column:  elementNode.Column,
+			}
+		}
+		for _, n := range node.Content {
+			fieldLints, err := f.Scalar().FixYAML(ctx, n)
+			if err != nil {
+				return nil, err
+			}
+			lints = append(lints, fieldLints...)
+		}
+		return lints, nil
+	case KindMap:
+		if node.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			fieldLints, err := f.Scalar().FixYAML(ctx, node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			lints = append(lints, fieldLints...)
+		}
+		return lints, nil
+	}
+
+	if coreType, isCore := f.Type.IsCoreComponent(); isCore {
+		return FixYAML(ctx, coreType, node)
+	}
+
+	if len(f.Children) > 0 {
+		return f.Children.FixYAML(ctx, node)
+	}
+	return nil, nil
+}
+
+// FixYAML walks a yaml node and attempts to resolve linting errors found by
+// rewriting the node in place. Returns a list of the linting errors that were
+// fixed.
+func (f FieldSpecs) FixYAML(ctx LintContext, node *yaml.Node) ([]Lint, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var lints []Lint
+
+	// Fixers of fields are executed first as they're likely to add or remove
+	// other fields.
+	for _, spec := range f {
+		if spec.fixFn == nil {
+			continue
+		}
+		keyNode := yamlMappingKey(node, spec.Name)
+		if keyNode == nil {
+			continue
+		}
+		what, err := spec.fixFn(node)
+		if err != nil {
+			return nil, err
+		}
+		if what != "" {
+			lints = append(lints, NewLintError(keyNode.Line, LintDeprecated, errors.New(what)))
+		}
+	}
+
+	specNames := map[string]FieldSpec{}
+	candidates := make([]string, 0, len(f))
+	for _, spec := range f {
+		specNames[spec.Name] = spec
+		candidates = append(candidates, spec.Name)
+	}
+
+	// Determine which fields should be removed before modifying the node, as
+	// whether a field should be omitted depends on the other fields.
+	omit := map[*yaml.Node]string{}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if spec, exists := specNames[node.Content[i].Value]; exists {
+			if why, shouldOmit := spec.shouldOmitYAML(f, node.Content[i+1], node); shouldOmit {
+				omit[node.Content[i]] = why
+			}
+		}
+	}
+
+	var newContent []*yaml.Node
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		if keyNode.Tag == "!!merge" {
+			newContent = append(newContent, keyNode, valueNode)
+			continue
+		}
+
+		if why, exists := omit[keyNode]; exists {
+			lints = append(lints, NewLintError(keyNode.Line, LintShouldOmit, fmt.Errorf("removed field %v: %v", keyNode.Value, why)))
+			continue
+		}
+
+		spec, exists := specNames[keyNode.Value]
+		if !exists && valueNode.Kind != yaml.AliasNode {
+			if newKey := closestFieldName(node, keyNode.Value, candidates); newKey != "" {
+				lints = append(lints, NewLintError(keyNode.Line, LintUnknown, fmt.Errorf("field %v not recognised, renamed to %v", keyNode.Value, newKey)))
+				keyNode.Value = newKey
+				spec, exists = specNames[newKey]
+			}
+		}
+		newContent = append(newContent, keyNode, valueNode)
+		if !exists {
+			continue
+		}
+
+		fieldLints, err := spec.FixYAML(ctx, valueNode)
+		if err != nil {
+			return nil, err
+		}
+		lints = append(lints, fieldLints...)
+	}
+	node.Content = newContent
+	return lints, nil
+}
+
+//------------------------------------------------------------------------------
+
+func yamlMappingKey(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// closestFieldName returns the candidate that resembles an unrecognised field
+// name closely enough to be an obvious typo, or an empty string if there isn't
+// exactly one such candidate, or the candidate is already set within the
+// object. A rename is only safe when it is unambiguous, as renaming a field to
+// the wrong candidate silently changes the meaning of a config.
+func closestFieldName(node *yaml.Node, name string, candidates []string) string {
+	maxDistance := 2
+	if len(name) <= 4 {
+		maxDistance = 1
+	}
+
+	closest := ""
+	for _, c := range candidates {
+		if c == closest || editDistance(name, c) > maxDistance {
+			continue
+		}
+		if closest != "" {
+			return ""
+		}
+		closest = c
+	}
+	if closest == "" || yamlMappingKey(node, closest) != nil {
+		return ""
+	}
+	return closest
+}
+
+// editDistance returns the Damerau-Levenshtein (optimal string alignment)
+// distance between two strings.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	rows := make([][]int, len(ar)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(br)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ar)][len(br)]
+}
@@ -0,0 +1,210 @@
+package docs_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+func TestYAMLComponentFixing(t *testing.T) {
+	prov := docs.NewMappedDocsProvider()
+
+	renameKey := func(node *yaml.Node, from, to string) bool {
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			if node.Content[i].Value == from {
+				node.Content[i].Value = to
+				return true
+			}
+		}
+		return false
+	}
+
+	prov.RegisterDocs(docs.ComponentSpec{
+		Name: "testfixfoo",
+		Type: docs.TypeInput,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("foo1", "").Optional(),
+			docs.FieldString("foo2", "").OmitWhen(func(field, parent any) (string, bool) {
+				if field == "drop me" {
+					return "because foo", true
+				}
+				return "", false
+			}).Optional(),
+			docs.FieldProcessor("foo3", "").Array().Optional(),
+			docs.FieldString("foo4", "").Optional().Deprecated().FixerFunc(func(parent *yaml.Node) (string, error) {
+				if !renameKey(parent, "foo4", "foo1") {
+					return "", nil
+				}
+				return "field foo4 is deprecated, renamed to foo1", nil
+			}),
+			docs.FieldString("foo5", "").Optional(),
+			docs.FieldString("foo6", "").Optional(),
+			docs.FieldString("timeout", "").Optional(),
+		),
+	})
+	prov.RegisterDocs(docs.ComponentSpec{
+		Name:   "testfixbar",
+		Type:   docs.TypeInput,
+		Status: docs.StatusDeprecated,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("bar1", "").Optional(),
+		).FixerFunc(func(parent *yaml.Node) (string, error) {
+			if !renameKey(parent, "testfixbar", "testfixfoo") {
+				return "", nil
+			}
+			for i := 0; i < len(parent.Content)-1; i += 2 {
+				if parent.Content[i].Value == "testfixfoo" {
+					renameKey(parent.Content[i+1], "bar1", "foo1")
+				}
+			}
+			return "component testfixbar is deprecated, replaced with testfixfoo", nil
+		}),
+	})
+	prov.RegisterDocs(docs.ComponentSpec{
+		Name:   "testfixproc",
+		Type:   docs.TypeProcessor,
+		Config: docs.FieldString("", ""),
+	})
+
+	tests := []struct {
+		name   string
+		input  string
+		output string
+		res    []docs.Lint
+	}{
+		{
+			name: "nothing to fix",
+			input: `
+testfixfoo:
+  # comment here
+  foo1: hello world # And what's this?
+`,
+			output: `testfixfoo:
+  # comment here
+  foo1: hello world # And what's this?
+`,
+		},
+		{
+			name: "field fixer",
+			input: `
+testfixfoo:
+  foo4: hello world # keep me
+`,
+			output: `testfixfoo:
+  foo1: hello world # keep me
+`,
+			res: []docs.Lint{
+				docs.NewLintError(3, docs.LintDeprecated, errors.New("field foo4 is deprecated, renamed to foo1")),
+			},
+		},
+		{
+			name: "component fixer",
+			input: `
+label: meow
+testfixbar:
+  bar1: hello world
+  bar2: nope
+`,
+			output: `label: meow
+testfixfoo:
+  foo1: hello world
+  bar2: nope
+`,
+			res: []docs.Lint{
+				docs.NewLintError(2, docs.LintDeprecated, errors.New("component testfixbar is deprecated, replaced with testfixfoo")),
+			},
+		},
+		{
+			name: "rename typos",
+			input: `
+lable: meow
+testfixfoo:
+  timout: 5s
+  fooo1: ambiguous
+  foo7: ambiguous
+`,
+			output: `label: meow
+testfixfoo:
+  timeout: 5s
+  fooo1: ambiguous
+  foo7: ambiguous
+`,
+			res: []docs.Lint{
+				docs.NewLintError(2, docs.LintUnknown, errors.New("field lable is invalid when the component type is testfixfoo (input), renamed to label")),
+				docs.NewLintError(4, docs.LintUnknown, errors.New("field timout not recognised, renamed to timeout")),
+			},
+		},
+		{
+			name: "do not rename to existing field",
+			input: `
+testfixfoo:
+  timeout: 5s
+  timout: 10s
+`,
+			output: `testfixfoo:
+  timeout: 5s
+  timout: 10s
+`,
+		},
+		{
+			name: "remove omitted fields",
+			input: `
+testfixfoo:
+  foo1: hello world
+  foo2: drop me
+plugin:
+  foo1: nope
+`,
+			output: `testfixfoo:
+  foo1: hello world
+`,
+			res: []docs.Lint{
+				docs.NewLintError(4, docs.LintShouldOmit, errors.New("removed field foo2: because foo")),
+				docs.NewLintError(5, docs.LintShouldOmit, errors.New("removed ineffective plugin object")),
+			},
+		},
+		{
+			name: "wrap single array value",
+			input: `
+testfixfoo:
+  foo3:
+    testfixproc: hello world # a processor
+`,
+			output: `testfixfoo:
+  foo3:
+    - testfixproc: hello world # a processor
+`,
+			res: []docs.Lint{
+				docs.NewLintError(4, docs.LintExpectedArray, errors.New("expected array value, wrapped value in an array")),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			lConf := docs.NewLintConfig(bundle.GlobalEnvironment)
+			lConf.DocsProvider = prov
+
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.input), &node))
+
+			lints, err := docs.FixYAML(docs.NewLintContext(lConf), docs.TypeInput, &node)
+			require.NoError(t, err)
+			assert.Equal(t, test.res, lints)
+
+			var buf bytes.Buffer
+			enc := yaml.NewEncoder(&buf)
+			enc.SetIndent(2)
+			require.NoError(t, enc.Encode(&node))
+			assert.Equal(t, test.output, buf.String())
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+const (
+	fieldAuthorization           = "authorization"
+	fieldAuthorizationPrincipals = "principals"
+	fieldAuthorizationPaths      = "paths"
+	fieldAuthorizationMethods    = "methods"
+)
+
+// AuthorizationRule grants a list of principals access to endpoints.
+type AuthorizationRule struct {
+	Principals []string `json:"principals" yaml:"principals"`
+	Paths      []string `json:"paths" yaml:"paths"`
+	Methods    []string `json:"methods" yaml:"methods"`
+}
+
+// AuthConfig contains the authentication and authorization fields of an HTTP
+// server.
+type AuthConfig struct {
+	BasicAuth      BasicAuthConfig      `json:"basic_auth" yaml:"basic_auth"`
+	TokenAuth      TokenAuthConfig      `json:"token_auth" yaml:"token_auth"`
+	JWTAuth        JWTAuthConfig        `json:"jwt_auth" yaml:"jwt_auth"`
+	ClientCertAuth ClientCertAuthConfig `json:"client_cert_auth" yaml:"client_cert_auth"`
+	Authorization  []AuthorizationRule  `json:"authorization" yaml:"authorization"`
+}
+
+// NewAuthConfig returns an AuthConfig with default values.
+func NewAuthConfig() AuthConfig {
+	return AuthConfig{
+		BasicAuth:      NewBasicAuthConfig(),
+		TokenAuth:      NewTokenAuthConfig(),
+		JWTAuth:        NewJWTAuthConfig(),
+		ClientCertAuth: NewClientCertAuthConfig(),
+		Authorization:  []AuthorizationRule{},
+	}
+}
+
+// AuthorizationFieldSpec returns the spec for the authorization rules of an
+// HTTP server.
+func AuthorizationFieldSpec() docs.FieldSpec {
+	return docs.FieldObject(fieldAuthorization, "An optional list of rules that restrict which endpoints authenticated principals are allowed to access. When empty any authenticated principal may access all endpoints, otherwise a request is only allowed when a rule matches its principal, endpoint path and method, and a 403 is returned for requests that are not allowed.").Array().WithChildren(
+		docs.FieldString(fieldAuthorizationPrincipals, "The principals that this rule applies to, where `*` matches any authenticated principal.").Array(),
+		docs.FieldString(fieldAuthorizationPaths, "The endpoint paths that this rule allows access to, matched against the path of an endpoint as it is registered (without the `root_path` prefix) where `*` matches any sequence of characters. When empty all paths are allowed.", []string{"/streams*", "/ready"}).Array().HasDefault([]any{}),
+		docs.FieldString(fieldAuthorizationMethods, "The HTTP methods that this rule allows. When empty all methods are allowed.", []string{"GET"}).Array().HasDefault([]any{}),
+	).HasDefault([]any{}).Advanced().AtVersion("4.28.0")
+}
+
+// AuthFieldSpecs returns the specs for all authentication and authorization
+// fields of an HTTP server.
+func AuthFieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		BasicAuthFieldSpec(),
+		TokenAuthFieldSpec(),
+		JWTAuthFieldSpec(),
+		ClientCertAuthFieldSpec(),
+		AuthorizationFieldSpec(),
+	}
+}
+
+func AuthConfigFromParsed(pConf *docs.ParsedConfig) (conf AuthConfig, err error) {
+	if conf.BasicAuth, err = BasicAuthConfigFromParsed(pConf); err != nil {
+		return
+	}
+	if conf.TokenAuth, err = TokenAuthConfigFromParsed(pConf); err != nil {
+		return
+	}
+	if conf.JWTAuth, err = JWTAuthConfigFromParsed(pConf); err != nil {
+		return
+	}
+	if conf.ClientCertAuth, err = ClientCertAuthConfigFromParsed(pConf); err != nil {
+		return
+	}
+	var rules []*docs.ParsedConfig
+	if rules, err = pConf.FieldObjectList(fieldAuthorization); err != nil {
+		return
+	}
+	conf.Authorization = []AuthorizationRule{}
+	for _, rConf := range rules {
+		var rule AuthorizationRule
+		if rule.Principals, err = rConf.FieldStringList(fieldAuthorizationPrincipals); err != nil {
+			return
+		}
+		if rule.Paths, err = rConf.FieldStringList(fieldAuthorizationPaths); err != nil {
+			return
+		}
+		if rule.Methods, err = rConf.FieldStringList(fieldAuthorizationMethods); err != nil {
+			return
+		}
+		conf.Authorization = append(conf.Authorization, rule)
+	}
+	return
+}
+
+// Enabled returns true if any method of authentication is enabled.
+func (a AuthConfig) Enabled() bool {
+	return a.BasicAuth.Enabled || a.TokenAuth.Enabled || a.JWTAuth.Enabled || a.ClientCertAuth.Enabled
+}
+
+// Validate confirms that the AuthConfig is properly configured.
+func (a AuthConfig) Validate() error {
+	if err := a.BasicAuth.Validate(); err != nil {
+		return err
+	}
+	if err := a.TokenAuth.Validate(); err != nil {
+		return fmt.Errorf("token_auth: %w", err)
+	}
+	if err := a.JWTAuth.Validate(); err != nil {
+		return fmt.Errorf("jwt_auth: %w", err)
+	}
+	if err := a.ClientCertAuth.Validate(); err != nil {
+		return fmt.Errorf("client_cert_auth: %w", err)
+	}
+	if len(a.Authorization) > 0 && !a.Enabled() {
+		return errors.New("authorization rules require a method of authentication to be enabled")
+	}
+	for i, rule := range a.Authorization {
+		if len(rule.Principals) == 0 {
+			return fmt.Errorf("authorization rule %v must specify at least one principal", i)
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type authorizationRule struct {
+	principals map[string]struct{}
+	paths      []*regexp.Regexp
+	methods    map[string]struct{}
+}
+
+func (r authorizationRule) allows(principal, endpoint, method string) bool {
+	if _, exists := r.principals[principal]; !exists {
+		if _, wildcard := r.principals["*"]; !wildcard {
+			return false
+		}
+	}
+	if len(r.methods) > 0 {
+		if _, exists := r.methods[strings.ToUpper(method)]; !exists {
+			return false
+		}
+	}
+	if len(r.paths) == 0 {
+		return true
+	}
+	for _, p := range r.paths {
+		if p.MatchString(endpoint) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator enforces the authentication and authorization of requests to
+// the endpoints of an HTTP server.
+type Authenticator struct {
+	conf   AuthConfig
+	tokens []TokenConfig
+	jwt    *jwtValidator
+	rules  []authorizationRule
+}
+
+// NewAuthenticator validates an AuthConfig and creates an Authenticator from
+// it, reading any token and key files that are configured.
+func NewAuthenticator(conf AuthConfig) (*Authenticator, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	a := &Authenticator{conf: conf}
+
+	var err error
+	if conf.TokenAuth.Enabled {
+		if a.tokens, err = conf.TokenAuth.loadTokens(); err != nil {
+			return nil, fmt.Errorf("token_auth: %w", err)
+		}
+	}
+	if conf.JWTAuth.Enabled {
+		if a.jwt, err = newJWTValidator(conf.JWTAuth); err != nil {
+			return nil, fmt.Errorf("jwt_auth: %w", err)
+		}
+	}
+
+	for _, rule := range conf.Authorization {
+		r := authorizationRule{
+			principals: map[string]struct{}{},
+			methods:    map[string]struct{}{},
+		}
+		for _, p := range rule.Principals {
+			r.principals[p] = struct{}{}
+		}
+		for _, m := range rule.Methods {
+			r.methods[strings.ToUpper(m)] = struct{}{}
+		}
+		for _, p := range rule.Paths {
+			expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(p), `\*`, ".*") + "$"
+			r.paths = append(r.paths, regexp.MustCompile(expr))
+		}
+		a.rules = append(a.rules, r)
+	}
+	return a, nil
+}
+
+// TLSConfig returns a TLS config for serving the provided certificate that
+// requests client certificates when client certificate authentication is
+// enabled. Returns nil when client certificate authentication is disabled.
+func (a *Authenticator) TLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	if !a.conf.ClientCertAuth.Enabled {
+		return nil, nil
+	}
+	return a.conf.ClientCertAuth.tlsConfig(certFile, keyFile)
+}
+
+// authenticate returns the principal of a request, or false if the request
+// could not be authenticated by any enabled method.
+func (a *Authenticator) authenticate(r *http.Request) (string, bool, error) {
+	if a.conf.ClientCertAuth.Enabled {
+		if principal, ok := a.conf.ClientCertAuth.matchClientCert(r); ok {
+			return principal, true, nil
+		}
+	}
+
+	if a.conf.TokenAuth.Enabled || a.conf.JWTAuth.Enabled {
+		if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+			if a.conf.TokenAuth.Enabled {
+				if principal, ok := matchToken(a.tokens, token); ok {
+					return principal, true, nil
+				}
+			}
+			if a.jwt != nil {
+				if principal, ok := a.jwt.validate(token); ok {
+					return principal, true, nil
+				}
+			}
+		}
+	}
+
+	if a.conf.BasicAuth.Enabled {
+		if user, pass, ok := r.BasicAuth(); ok {
+			matched, err := a.conf.BasicAuth.matches(user, pass)
+			if err != nil {
+				return "", false, err
+			}
+			if matched {
+				return user, true, nil
+			}
+		}
+	}
+	return "", false, nil
+}
+
+func (a *Authenticator) authorized(principal, endpoint, method string) bool {
+	if len(a.rules) == 0 {
+		return true
+	}
+	for _, r := range a.rules {
+		if r.allows(principal, endpoint, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// WrapHandler wraps the provided HTTP handler of an endpoint with middleware
+// that enforces authentication and authorization, if enabled.
+func (a *Authenticator) WrapHandler(endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	if !a.conf.Enabled() {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok, err := a.authenticate(r)
+		if err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			if a.conf.BasicAuth.Enabled {
+				w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, a.conf.BasicAuth.Realm))
+			}
+			if a.conf.TokenAuth.Enabled || a.conf.JWTAuth.Enabled {
+				w.Header().Add("WWW-Authenticate", "Bearer")
+			}
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !a.authorized(principal, endpoint, r.Method) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
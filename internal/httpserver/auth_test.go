@@ -0,0 +1,283 @@
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func authTestStatus(t *testing.T, a *Authenticator, endpoint, method string, setReq func(r *http.Request)) int {
+	t.Helper()
+
+	handler := a.WrapHandler(endpoint, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	request, _ := http.NewRequest(method, endpoint, http.NoBody)
+	if setReq != nil {
+		setReq(request)
+	}
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	return response.Code
+}
+
+func bearer(token string) func(r *http.Request) {
+	return func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+func TestAuthDisabled(t *testing.T) {
+	a, err := NewAuthenticator(NewAuthConfig())
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, authTestStatus(t, a, "/foo", "POST", nil))
+}
+
+func TestAuthConfigValidation(t *testing.T) {
+	conf := NewAuthConfig()
+	conf.Authorization = []AuthorizationRule{{Principals: []string{"foo"}}}
+	_, err := NewAuthenticator(conf)
+	assert.Error(t, err)
+
+	conf = NewAuthConfig()
+	conf.TokenAuth.Enabled = true
+	_, err = NewAuthenticator(conf)
+	assert.Error(t, err)
+
+	conf = NewAuthConfig()
+	conf.JWTAuth.Enabled = true
+	_, err = NewAuthenticator(conf)
+	assert.Error(t, err)
+
+	conf = NewAuthConfig()
+	conf.ClientCertAuth.Enabled = true
+	_, err = NewAuthenticator(conf)
+	assert.Error(t, err)
+}
+
+func TestAuthTokens(t *testing.T) {
+	tokensPath := filepath.Join(t.TempDir(), "tokens")
+	require.NoError(t, os.WriteFile(tokensPath, []byte(`
+# Monitoring credentials
+monitoring:montoken
+
+admin: admintoken
+`), 0o644))
+
+	conf := NewAuthConfig()
+	conf.TokenAuth.Enabled = true
+	conf.TokenAuth.Tokens = []TokenConfig{{Principal: "static", Token: "statictoken"}}
+	conf.TokenAuth.TokensFile = tokensPath
+
+	a, err := NewAuthenticator(conf)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, authTestStatus(t, a, "/foo", "GET", bearer("statictoken")))
+	assert.Equal(t, http.StatusOK, authTestStatus(t, a, "/foo", "GET", bearer("montoken")))
+	assert.Equal(t, http.StatusOK, authTestStatus(t, a, "/foo", "GET", bearer("admintoken")))
+	assert.Equal(t, http.StatusUnauthorized, authTestStatus(t, a, "/foo", "GET", bearer("nope")))
+	assert.Equal(t, http.StatusUnauthorized, authTestStatus(t, a, "/foo", "GET", nil))
+}
+
+func TestAuthTokensFileBad(t *testing.T) {
+	tokensPath := filepath.Join(t.TempDir(), "tokens")
+	require.NoError(t, os.WriteFile(tokensPath, []byte("nocolon\n"), 0o644))
+
+	conf := NewAuthConfig()
+	conf.TokenAuth.Enabled = true
+	conf.TokenAuth.TokensFile = tokensPath
+
+	_, err := NewAuthenticator(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 1")
+}
+
+func TestAuthorizationRules(t *testing.T) {
+	conf := NewAuthConfig()
+	conf.TokenAuth.Enabled = true
+	conf.TokenAuth.Tokens = []TokenConfig{
+		{Principal: "monitoring", Token: "montoken"},
+		{Principal: "admin", Token: "admintoken"},
+	}
+	conf.Authorization = []AuthorizationRule{
+		{Principals: []string{"monitoring"}, Paths: []string{"/streams*", "/ready"}, Methods: []string{"get"}},
+		{Principals: []string{"admin"}},
+		{Principals: []string{"*"}, Paths: []string{"/ping"}},
+	}
+
+	a, err := NewAuthenticator(conf)
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		token    string
+		endpoint string
+		method   string
+		status   int
+	}{
+		{token: "montoken", endpoint: "/streams", method: "GET", status: http.StatusOK},
+		{token: "montoken", endpoint: "/streams/{id}/stats", method: "GET", status: http.StatusOK},
+		{token: "montoken", endpoint: "/ready", method: "GET", status: http.StatusOK},
+		{token: "montoken", endpoint: "/streams/{id}", method: "POST", status: http.StatusForbidden},
+		{token: "montoken", endpoint: "/streams/{id}", method: "DELETE", status: http.StatusForbidden},
+		{token: "montoken", endpoint: "/debug/pprof/heap", method: "GET", status: http.StatusForbidden},
+		{token: "montoken", endpoint: "/ping", method: "GET", status: http.StatusOK},
+		{token: "admintoken", endpoint: "/streams/{id}", method: "DELETE", status: http.StatusOK},
+		{token: "admintoken", endpoint: "/debug/pprof/heap", method: "GET", status: http.StatusOK},
+		{token: "nope", endpoint: "/ping", method: "GET", status: http.StatusUnauthorized},
+	} {
+		assert.Equal(t, test.status, authTestStatus(t, a, test.endpoint, test.method, bearer(test.token)), "%v %v %v", test.token, test.method, test.endpoint)
+	}
+}
+
+func TestAuthBasicWithRules(t *testing.T) {
+	conf := NewAuthConfig()
+	conf.BasicAuth.Enabled = true
+	conf.BasicAuth.Username = "myuser"
+	conf.BasicAuth.PasswordHash = "K7gNU3sdo+OL0wNhqoVWhr3g6s1xYv72ol/pe/Unols="
+	conf.Authorization = []AuthorizationRule{
+		{Principals: []string{"myuser"}, Methods: []string{"GET"}},
+	}
+
+	a, err := NewAuthenticator(conf)
+	require.NoError(t, err)
+
+	basic := func(r *http.Request) { r.SetBasicAuth("myuser", "secret") }
+	assert.Equal(t, http.StatusOK, authTestStatus(t, a, "/foo", "GET", basic))
+	assert.Equal(t, http.StatusForbidden, authTestStatus(t, a, "/foo", "POST", basic))
+}
+
+func b64Int(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func TestAuthJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwksBytes, err := json.Marshal(map[string]any{
+		"keys": []any{
+			map[string]any{
+				"kty": "RSA", "kid": "rsa1", "use": "sig",
+				"n": b64Int(rsaKey.N), "e": b64Int(big.NewInt(int64(rsaKey.E))),
+			},
+			map[string]any{
+				"kty": "EC", "kid": "ec1", "crv": "P-256",
+				"x": b64Int(ecKey.X), "y": b64Int(ecKey.Y),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksPath, jwksBytes, 0o644))
+
+	conf := NewAuthConfig()
+	conf.JWTAuth.Enabled = true
+	conf.JWTAuth.JWKSFile = jwksPath
+	conf.JWTAuth.Issuer = "benthos-tests"
+	conf.JWTAuth.Audience = "api"
+	conf.Authorization = []AuthorizationRule{
+		{Principals: []string{"alice"}},
+	}
+
+	a, err := NewAuthenticator(conf)
+	require.NoError(t, err)
+
+	sign := func(method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		s, err := token.SignedString(key)
+		require.NoError(t, err)
+		return s
+	}
+	claims := func(sub string, exp time.Duration) jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub": sub,
+			"iss": "benthos-tests",
+			"aud": "api",
+			"exp": time.Now().Add(exp).Unix(),
+		}
+	}
+
+	for _, test := range []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"rsa", sign(jwt.SigningMethodRS256, "rsa1", rsaKey, claims("alice", time.Hour)), http.StatusOK},
+		{"ecdsa", sign(jwt.SigningMethodES256, "ec1", ecKey, claims("alice", time.Hour)), http.StatusOK},
+		{"not authorized", sign(jwt.SigningMethodRS256, "rsa1", rsaKey, claims("bob", time.Hour)), http.StatusForbidden},
+		{"expired", sign(jwt.SigningMethodRS256, "rsa1", rsaKey, claims("alice", -time.Hour)), http.StatusUnauthorized},
+		{"wrong key", sign(jwt.SigningMethodRS256, "rsa1", otherKey, claims("alice", time.Hour)), http.StatusUnauthorized},
+		{"unknown kid", sign(jwt.SigningMethodRS256, "nope", rsaKey, claims("alice", time.Hour)), http.StatusUnauthorized},
+		{"mismatched method", sign(jwt.SigningMethodHS256, "rsa1", []byte("secret"), claims("alice", time.Hour)), http.StatusUnauthorized},
+		{"wrong issuer", sign(jwt.SigningMethodRS256, "rsa1", rsaKey, jwt.MapClaims{
+			"sub": "alice", "iss": "nope", "aud": "api",
+		}), http.StatusUnauthorized},
+		{"wrong audience", sign(jwt.SigningMethodRS256, "rsa1", rsaKey, jwt.MapClaims{
+			"sub": "alice", "iss": "benthos-tests", "aud": "nope",
+		}), http.StatusUnauthorized},
+	} {
+		assert.Equal(t, test.status, authTestStatus(t, a, "/foo", "GET", bearer(test.token)), test.name)
+	}
+}
+
+func TestAuthClientCert(t *testing.T) {
+	conf := NewClientCertAuthConfig()
+	conf.Enabled = true
+	conf.ClientCAFile = "unused"
+	conf.AllowedSubjects = []string{"monitoring", "CN=admin,O=Acme"}
+
+	authConf := NewAuthConfig()
+	authConf.ClientCertAuth = conf
+	authConf.Authorization = []AuthorizationRule{
+		{Principals: []string{"monitoring"}, Methods: []string{"GET"}},
+		{Principals: []string{"admin"}},
+	}
+
+	a, err := NewAuthenticator(authConf)
+	require.NoError(t, err)
+
+	withCert := func(subject pkix.Name) func(r *http.Request) {
+		return func(r *http.Request) {
+			r.TLS = &tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{{Subject: subject}}},
+			}
+		}
+	}
+
+	assert.Equal(t, http.StatusOK, authTestStatus(t, a, "/foo", "GET", withCert(pkix.Name{CommonName: "monitoring"})))
+	assert.Equal(t, http.StatusForbidden, authTestStatus(t, a, "/foo", "POST", withCert(pkix.Name{CommonName: "monitoring"})))
+	assert.Equal(t, http.StatusOK, authTestStatus(t, a, "/foo", "POST", withCert(pkix.Name{CommonName: "admin", Organization: []string{"Acme"}})))
+	assert.Equal(t, http.StatusUnauthorized, authTestStatus(t, a, "/foo", "GET", withCert(pkix.Name{CommonName: "admin"})))
+	assert.Equal(t, http.StatusUnauthorized, authTestStatus(t, a, "/foo", "GET", withCert(pkix.Name{CommonName: "someone"})))
+	assert.Equal(t, http.StatusUnauthorized, authTestStatus(t, a, "/foo", "GET", nil))
+
+	_, err = a.TLSConfig("", "")
+	assert.Error(t, err)
+}
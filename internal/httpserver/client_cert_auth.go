@@ -0,0 +1,119 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+const (
+	fieldClientCertAuth                = "client_cert_auth"
+	fieldClientCertAuthEnabled         = "enabled"
+	fieldClientCertAuthClientCAFile    = "client_ca_file"
+	fieldClientCertAuthAllowedSubjects = "allowed_subjects"
+)
+
+// ClientCertAuthConfig contains struct based fields for authenticating
+// requests with TLS client certificates.
+type ClientCertAuthConfig struct {
+	Enabled         bool     `json:"enabled" yaml:"enabled"`
+	ClientCAFile    string   `json:"client_ca_file" yaml:"client_ca_file"`
+	AllowedSubjects []string `json:"allowed_subjects" yaml:"allowed_subjects"`
+}
+
+// NewClientCertAuthConfig returns a ClientCertAuthConfig with default values.
+func NewClientCertAuthConfig() ClientCertAuthConfig {
+	return ClientCertAuthConfig{
+		Enabled:         false,
+		ClientCAFile:    "",
+		AllowedSubjects: []string{},
+	}
+}
+
+// Validate confirms that the ClientCertAuth is properly configured.
+func (c ClientCertAuthConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.ClientCAFile == "" {
+		return errors.New("a client_ca_file is required")
+	}
+	return nil
+}
+
+// ClientCertAuthFieldSpec returns the spec for an HTTP ClientCertAuth
+// component.
+func ClientCertAuthFieldSpec() docs.FieldSpec {
+	return docs.FieldObject(fieldClientCertAuth, "Allows you to authenticate requests to the HTTP server with TLS client certificates, which requires TLS to be enabled. Clients that do not provide a certificate may still authenticate with other enabled methods.").WithChildren(
+		docs.FieldBool(fieldClientCertAuthEnabled, "Enable client certificate authentication.").HasDefault(false),
+		docs.FieldString(fieldClientCertAuthClientCAFile, "A path to a file of PEM encoded certificate authorities used to verify client certificates.").HasDefault(""),
+		docs.FieldString(fieldClientCertAuthAllowedSubjects, "An optional list of subjects that are allowed to authenticate, each matched against either the common name or the full distinguished name of a certificate subject. When empty any certificate signed by a client certificate authority is allowed. The common name of a certificate identifies its principal, which can be referenced by `authorization` rules.", []string{"monitoring", "CN=admin,O=Acme"}).Array().HasDefault([]any{}),
+	).Advanced().AtVersion("4.28.0")
+}
+
+func ClientCertAuthConfigFromParsed(pConf *docs.ParsedConfig) (conf ClientCertAuthConfig, err error) {
+	pConf = pConf.Namespace(fieldClientCertAuth)
+	if conf.Enabled, err = pConf.FieldBool(fieldClientCertAuthEnabled); err != nil {
+		return
+	}
+	if conf.ClientCAFile, err = pConf.FieldString(fieldClientCertAuthClientCAFile); err != nil {
+		return
+	}
+	if conf.AllowedSubjects, err = pConf.FieldStringList(fieldClientCertAuthAllowedSubjects); err != nil {
+		return
+	}
+	return
+}
+
+// tlsConfig returns a TLS config that serves the provided certificate and
+// verifies client certificates against the configured authorities, if any
+// are presented.
+func (c ClientCertAuthConfig) tlsConfig(certFile, keyFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("client certificate authentication requires TLS to be enabled with a cert_file and key_file")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	caBytes, err := os.ReadFile(c.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, errors.New("no certificates found in client CA file")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// matchClientCert returns the principal identified by the verified client
+// certificate of a request, or false if no allowed certificate was provided.
+func (c ClientCertAuthConfig) matchClientCert(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+
+	subject := r.TLS.VerifiedChains[0][0].Subject
+	if len(c.AllowedSubjects) == 0 {
+		return subject.CommonName, true
+	}
+	for _, allowed := range c.AllowedSubjects {
+		if allowed == subject.CommonName || allowed == subject.String() {
+			return subject.CommonName, true
+		}
+	}
+	return "", false
+}
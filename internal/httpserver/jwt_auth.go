@@ -0,0 +1,251 @@
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+const (
+	fieldJWTAuth               = "jwt_auth"
+	fieldJWTAuthEnabled        = "enabled"
+	fieldJWTAuthJWKSFile       = "jwks_file"
+	fieldJWTAuthIssuer         = "issuer"
+	fieldJWTAuthAudience       = "audience"
+	fieldJWTAuthPrincipalClaim = "principal_claim"
+)
+
+// JWTAuthConfig contains struct based fields for authenticating requests with
+// JSON Web Tokens.
+type JWTAuthConfig struct {
+	Enabled        bool   `json:"enabled" yaml:"enabled"`
+	JWKSFile       string `json:"jwks_file" yaml:"jwks_file"`
+	Issuer         string `json:"issuer" yaml:"issuer"`
+	Audience       string `json:"audience" yaml:"audience"`
+	PrincipalClaim string `json:"principal_claim" yaml:"principal_claim"`
+}
+
+// NewJWTAuthConfig returns a JWTAuthConfig with default values.
+func NewJWTAuthConfig() JWTAuthConfig {
+	return JWTAuthConfig{
+		Enabled:        false,
+		JWKSFile:       "",
+		Issuer:         "",
+		Audience:       "",
+		PrincipalClaim: "sub",
+	}
+}
+
+// Validate confirms that the JWTAuth is properly configured.
+func (j JWTAuthConfig) Validate() error {
+	if !j.Enabled {
+		return nil
+	}
+	if j.JWKSFile == "" {
+		return errors.New("a jwks_file is required")
+	}
+	if j.PrincipalClaim == "" {
+		return errors.New("a principal_claim is required")
+	}
+	return nil
+}
+
+// JWTAuthFieldSpec returns the spec for an HTTP JWTAuth component.
+func JWTAuthFieldSpec() docs.FieldSpec {
+	return docs.FieldObject(fieldJWTAuth, "Allows you to enforce authentication of requests to the HTTP server with JSON Web Tokens provided in a header of the form `Authorization: Bearer <token>`. Tokens must be signed with an RSA or ECDSA key from a local JSON Web Key Set.").WithChildren(
+		docs.FieldBool(fieldJWTAuthEnabled, "Enable JWT authentication.").HasDefault(false),
+		docs.FieldString(fieldJWTAuthJWKSFile, "A path to a file containing a JSON Web Key Set of the public keys used to verify tokens. Keys are selected by the `kid` header of a token, which can be omitted when the set contains a single key.").HasDefault(""),
+		docs.FieldString(fieldJWTAuthIssuer, "An optional issuer that tokens must specify with the `iss` claim.").HasDefault(""),
+		docs.FieldString(fieldJWTAuthAudience, "An optional audience that tokens must specify with the `aud` claim.").HasDefault(""),
+		docs.FieldString(fieldJWTAuthPrincipalClaim, "The claim of a token that identifies its principal, which can be referenced by `authorization` rules.").HasDefault("sub"),
+	).Advanced().AtVersion("4.28.0")
+}
+
+func JWTAuthConfigFromParsed(pConf *docs.ParsedConfig) (conf JWTAuthConfig, err error) {
+	pConf = pConf.Namespace(fieldJWTAuth)
+	if conf.Enabled, err = pConf.FieldBool(fieldJWTAuthEnabled); err != nil {
+		return
+	}
+	if conf.JWKSFile, err = pConf.FieldString(fieldJWTAuthJWKSFile); err != nil {
+		return
+	}
+	if conf.Issuer, err = pConf.FieldString(fieldJWTAuthIssuer); err != nil {
+		return
+	}
+	if conf.Audience, err = pConf.FieldString(fieldJWTAuthAudience); err != nil {
+		return
+	}
+	if conf.PrincipalClaim, err = pConf.FieldString(fieldJWTAuthPrincipalClaim); err != nil {
+		return
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+type jwtValidator struct {
+	conf JWTAuthConfig
+	keys map[string]any
+}
+
+func newJWTValidator(conf JWTAuthConfig) (*jwtValidator, error) {
+	jwksBytes, err := os.ReadFile(conf.JWKSFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks file: %w", err)
+	}
+	keys, err := parseJWKS(jwksBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jwks file: %w", err)
+	}
+	return &jwtValidator{conf: conf, keys: keys}, nil
+}
+
+func (v *jwtValidator) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, exists := v.keys[kid]
+	if !exists && kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			key, exists = k, true
+		}
+	}
+	if !exists {
+		return nil, fmt.Errorf("unknown key id: %v", kid)
+	}
+
+	// Ensure that the signing method of the token matches the type of the
+	// key, otherwise a token could be validated using an unintended method.
+	switch key.(type) {
+	case *rsa.PublicKey:
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return key, nil
+		}
+	case *ecdsa.PublicKey:
+		if _, ok := token.Method.(*jwt.SigningMethodECDSA); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("signing method %v does not match key %v", token.Method.Alg(), kid)
+}
+
+// validate parses and verifies a token and returns the principal it
+// identifies, or false if the token is not valid.
+func (v *jwtValidator) validate(tokenStr string) (string, bool) {
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokenStr, claims, v.keyFunc); err != nil {
+		return "", false
+	}
+	if v.conf.Issuer != "" && !claims.VerifyIssuer(v.conf.Issuer, true) {
+		return "", false
+	}
+	if v.conf.Audience != "" && !claims.VerifyAudience(v.conf.Audience, true) {
+		return "", false
+	}
+	principal, _ := claims[v.conf.PrincipalClaim].(string)
+	if principal == "" {
+		return "", false
+	}
+	return principal, true
+}
+
+//------------------------------------------------------------------------------
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS parses the public signing keys of a JSON Web Key Set into a map
+// of key ids to keys.
+func parseJWKS(jwksBytes []byte) (map[string]any, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(jwksBytes, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]any{}
+	for i, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %v: %w", i, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys found")
+	}
+	return keys, nil
+}
+
+func decodeJWKInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %v", k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type: %v", k.Kty)
+}
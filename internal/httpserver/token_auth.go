@@ -0,0 +1,146 @@
+package httpserver
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+const (
+	fieldTokenAuth           = "token_auth"
+	fieldTokenAuthEnabled    = "enabled"
+	fieldTokenAuthTokens     = "tokens"
+	fieldTokenAuthPrincipal  = "principal"
+	fieldTokenAuthToken      = "token"
+	fieldTokenAuthTokensFile = "tokens_file"
+)
+
+// TokenConfig is a static bearer token and the principal it identifies.
+type TokenConfig struct {
+	Principal string `json:"principal" yaml:"principal"`
+	Token     string `json:"token" yaml:"token"`
+}
+
+// TokenAuthConfig contains struct based fields for bearer token
+// authentication.
+type TokenAuthConfig struct {
+	Enabled    bool          `json:"enabled" yaml:"enabled"`
+	Tokens     []TokenConfig `json:"tokens" yaml:"tokens"`
+	TokensFile string        `json:"tokens_file" yaml:"tokens_file"`
+}
+
+// NewTokenAuthConfig returns a TokenAuthConfig with default values.
+func NewTokenAuthConfig() TokenAuthConfig {
+	return TokenAuthConfig{
+		Enabled:    false,
+		Tokens:     []TokenConfig{},
+		TokensFile: "",
+	}
+}
+
+// Validate confirms that the TokenAuth is properly configured.
+func (t TokenAuthConfig) Validate() error {
+	if !t.Enabled {
+		return nil
+	}
+
+	if len(t.Tokens) == 0 && t.TokensFile == "" {
+		return errors.New("either tokens or a tokens_file are required")
+	}
+
+	for i, tok := range t.Tokens {
+		if tok.Token == "" {
+			return fmt.Errorf("token %v must not be empty", i)
+		}
+	}
+	return nil
+}
+
+// TokenAuthFieldSpec returns the spec for an HTTP TokenAuth component.
+func TokenAuthFieldSpec() docs.FieldSpec {
+	return docs.FieldObject(fieldTokenAuth, "Allows you to enforce bearer token authentication for requests to the HTTP server, where requests must provide a header of the form `Authorization: Bearer <token>`.").WithChildren(
+		docs.FieldBool(fieldTokenAuthEnabled, "Enable bearer token authentication.").HasDefault(false),
+		docs.FieldObject(fieldTokenAuthTokens, "A list of static tokens along with the principal that each token identifies, which can be referenced by `authorization` rules.").Array().WithChildren(
+			docs.FieldString(fieldTokenAuthPrincipal, "The name of the principal identified by the token."),
+			docs.FieldString(fieldTokenAuthToken, "The token value.").Secret(),
+		).HasDefault([]any{}),
+		docs.FieldString(fieldTokenAuthTokensFile, "An optional path to a file of tokens, where each line is of the form `<principal>:<token>`. Empty lines and lines beginning with `#` are ignored.").HasDefault(""),
+	).Advanced().AtVersion("4.28.0")
+}
+
+func TokenAuthConfigFromParsed(pConf *docs.ParsedConfig) (conf TokenAuthConfig, err error) {
+	pConf = pConf.Namespace(fieldTokenAuth)
+	if conf.Enabled, err = pConf.FieldBool(fieldTokenAuthEnabled); err != nil {
+		return
+	}
+	var tokens []*docs.ParsedConfig
+	if tokens, err = pConf.FieldObjectList(fieldTokenAuthTokens); err != nil {
+		return
+	}
+	conf.Tokens = []TokenConfig{}
+	for _, tConf := range tokens {
+		var tok TokenConfig
+		if tok.Principal, err = tConf.FieldString(fieldTokenAuthPrincipal); err != nil {
+			return
+		}
+		if tok.Token, err = tConf.FieldString(fieldTokenAuthToken); err != nil {
+			return
+		}
+		conf.Tokens = append(conf.Tokens, tok)
+	}
+	if conf.TokensFile, err = pConf.FieldString(fieldTokenAuthTokensFile); err != nil {
+		return
+	}
+	return
+}
+
+// loadTokens returns the static tokens of the config along with any tokens
+// read from the tokens file.
+func (t TokenAuthConfig) loadTokens() ([]TokenConfig, error) {
+	tokens := append([]TokenConfig{}, t.Tokens...)
+	if t.TokensFile == "" {
+		return tokens, nil
+	}
+
+	fileBytes, err := os.ReadFile(t.TokensFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens file: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(fileBytes))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		principal, token, ok := strings.Cut(line, ":")
+		if !ok || token == "" {
+			return nil, fmt.Errorf("tokens file line %v: expected the form <principal>:<token>", lineNum)
+		}
+		tokens = append(tokens, TokenConfig{
+			Principal: strings.TrimSpace(principal),
+			Token:     strings.TrimSpace(token),
+		})
+	}
+	return tokens, scanner.Err()
+}
+
+// matchToken returns the principal identified by a bearer token, or false if
+// the token does not match any known tokens.
+func matchToken(tokens []TokenConfig, given string) (string, bool) {
+	principal, matched := "", false
+	for _, tok := range tokens {
+		// Every token is compared in order to avoid leaking timing
+		// information.
+		if subtle.ConstantTimeCompare([]byte(given), []byte(tok.Token)) == 1 && !matched {
+			principal, matched = tok.Principal, true
+		}
+	}
+	return principal, matched
+}
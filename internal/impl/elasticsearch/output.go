@@ -232,6 +232,7 @@ It's possible to enable AWS connectivity with this output using the `+"`aws`"+`
 				Default("5s"),
 			service.NewTLSToggledField(esoFieldTLS),
 			service.NewOutputMaxInFlightField(),
+			service.NewOutputAdaptiveConcurrencyField(),
 		).
 		Fields(pure.CommonRetryBackOffFields(0, "1s", "5s", "30s")...).
 		Fields(
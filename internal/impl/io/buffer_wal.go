@@ -0,0 +1,805 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	walFieldPath         = "path"
+	walFieldSegmentSize  = "segment_size"
+	walFieldMaxDiskSize  = "max_disk_size"
+	walFieldSyncPolicy   = "sync_policy"
+	walFieldSyncInterval = "sync_interval"
+)
+
+const (
+	walSyncAlways   = "always"
+	walSyncInterval = "interval"
+	walSyncNone     = "none"
+)
+
+func walBufferConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Summary("Stores messages in an append-only log of segment files on disk and acknowledges them at the input level.").
+		Description(`
+Messages are appended to the active segment file of the log as they are consumed, and are acknowledged at the input level once written. Once a segment reaches the configured `+"`segment_size`"+` a new segment is started, and segments are deleted once all of the messages within them have been successfully delivered at the output level.
+
+When a segment is blocking the deletion of disk space because a small number of its messages are yet to be delivered those remaining messages are compacted by copying them into the active segment, allowing the older segment to be deleted.
+
+## Delivery Guarantees
+
+Messages are not acknowledged at the input level until they have been written to the log, and are not removed from the log until they have been successfully delivered. When Benthos is restarted the log is replayed and all messages that have not yet been delivered, including their metadata, are consumed again in the order that they were written.
+
+Whether a written message survives a crash of the machine (rather than of Benthos) depends on the `+"`sync_policy`"+`, where `+"`always`"+` flushes each write to disk before it is acknowledged, `+"`interval`"+` flushes writes periodically, and `+"`none`"+` leaves it to the operating system. These delivery guarantees are not resilient to disk corruption or loss, but records that were partially written during a crash are detected and discarded.
+
+## Back Pressure
+
+When the size of the log on disk reaches `+"`max_disk_size`"+` writes are blocked, applying back pressure upstream, until enough messages have been delivered for older segments to be deleted.
+
+## Batching
+
+Messages that are logically batched at the point where they are added to the buffer will continue to be associated with that batch when they are consumed.`).
+		Field(service.NewStringField(walFieldPath).
+			Description("The path of a directory in which segment files of the log are stored, which will be created if it does not already exist. Each buffer must have its own directory.")).
+		Field(service.NewIntField(walFieldSegmentSize).
+			Description("The size (in bytes) at which a segment is closed and a new one is started. Segments are the unit at which disk space is reclaimed.").
+			Default(67108864).
+			Advanced()).
+		Field(service.NewIntField(walFieldMaxDiskSize).
+			Description("The maximum total size (in bytes) of the log on disk before back pressure is applied upstream. Set to zero in order to disable the limit.").
+			Default(1073741824)).
+		Field(service.NewStringAnnotatedEnumField(walFieldSyncPolicy, map[string]string{
+			walSyncAlways:   "Flush each write to disk before acknowledging it, which is the most durable and the slowest option.",
+			walSyncInterval: "Flush writes to disk periodically according to `sync_interval`.",
+			walSyncNone:     "Never explicitly flush writes and rely on the operating system to do so.",
+		}).
+			Description("Determines when writes to the log are flushed to disk.").
+			Default(walSyncInterval)).
+		Field(service.NewDurationField(walFieldSyncInterval).
+			Description("The period at which writes are flushed to disk when the `sync_policy` is `interval`.").
+			Default("1s").
+			Advanced()).
+		Example("Durable buffer", "Messages are written to disk and flushed before being acknowledged at the input level, and are redelivered after a restart until they are successfully delivered.", `
+buffer:
+  wal:
+    path: ./data/buffer
+    sync_policy: always
+    max_disk_size: 10737418240
+`)
+}
+
+func init() {
+	err := service.RegisterBatchBuffer(
+		"wal", walBufferConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchBuffer, error) {
+			return newWALBufferFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newWALBufferFromConfig(conf *service.ParsedConfig, res *service.Resources) (*walBuffer, error) {
+	path, err := conf.FieldString(walFieldPath)
+	if err != nil {
+		return nil, err
+	}
+	segmentSize, err := conf.FieldInt(walFieldSegmentSize)
+	if err != nil {
+		return nil, err
+	}
+	if segmentSize <= 0 {
+		return nil, fmt.Errorf("%v must be greater than zero", walFieldSegmentSize)
+	}
+	maxDiskSize, err := conf.FieldInt(walFieldMaxDiskSize)
+	if err != nil {
+		return nil, err
+	}
+	syncPolicy, err := conf.FieldString(walFieldSyncPolicy)
+	if err != nil {
+		return nil, err
+	}
+	syncInterval, err := conf.FieldDuration(walFieldSyncInterval)
+	if err != nil {
+		return nil, err
+	}
+	if syncPolicy == walSyncInterval && syncInterval <= 0 {
+		return nil, fmt.Errorf("%v must be greater than zero", walFieldSyncInterval)
+	}
+	return newWALBuffer(res.Logger(), path, int64(segmentSize), int64(maxDiskSize), syncPolicy, syncInterval)
+}
+
+//------------------------------------------------------------------------------
+
+// Each record of the log is framed with a header containing the length of the
+// record payload followed by a CRC32 (Castagnoli) checksum of it. The payload
+// begins with the record type and the ID of the batch it refers to.
+const (
+	walRecordBatch byte = 1
+	walRecordAck   byte = 2
+
+	walHeaderSize  = 8
+	walPayloadBase = 9
+
+	walSegmentExt = ".wal"
+)
+
+var (
+	walCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+	errWALCorrupt = errors.New("record is corrupt")
+)
+
+func walEncodeRecord(typ byte, id uint64, data []byte) []byte {
+	rec := make([]byte, walHeaderSize+walPayloadBase+len(data))
+	payload := rec[walHeaderSize:]
+	payload[0] = typ
+	binary.BigEndian.PutUint64(payload[1:], id)
+	copy(payload[walPayloadBase:], data)
+
+	binary.BigEndian.PutUint32(rec[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(rec[4:], crc32.Checksum(payload, walCRCTable))
+	return rec
+}
+
+// walReadRecord reads a single record of at most limit bytes, which is the
+// remainder of the segment being read, returning io.EOF when there are no more
+// records and errWALCorrupt when the record is incomplete or invalid. A length
+// that exceeds the limit can only be the result of corruption, and is rejected
+// before the payload is allocated.
+func walReadRecord(r io.Reader, limit int64) (typ byte, id uint64, data []byte, size int64, err error) {
+	var header [walHeaderSize]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			err = errWALCorrupt
+		}
+		return
+	}
+
+	length := binary.BigEndian.Uint32(header[0:])
+	if length < walPayloadBase || int64(length) > limit-walHeaderSize {
+		err = errWALCorrupt
+		return
+	}
+
+	payload := make([]byte, length)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		err = errWALCorrupt
+		return
+	}
+	if crc32.Checksum(payload, walCRCTable) != binary.BigEndian.Uint32(header[4:]) {
+		err = errWALCorrupt
+		return
+	}
+
+	typ = payload[0]
+	id = binary.BigEndian.Uint64(payload[1:])
+	data = payload[walPayloadBase:]
+	size = int64(walHeaderSize + length)
+	return
+}
+
+func walEncodeBatch(batch service.MessageBatch) ([]byte, error) {
+	buf := binary.BigEndian.AppendUint32(nil, uint32(len(batch)))
+	for _, msg := range batch {
+		meta := map[string]any{}
+		_ = msg.MetaWalkMut(func(key string, value any) error {
+			meta[key] = value
+			return nil
+		})
+		metaBytes, err := msgpack.Marshal(meta)
+		if err != nil {
+			return nil, err
+		}
+		msgBytes, err := msg.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(metaBytes)))
+		buf = append(buf, metaBytes...)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(msgBytes)))
+		buf = append(buf, msgBytes...)
+	}
+	return buf, nil
+}
+
+func walDecodeBatch(b []byte) (service.MessageBatch, error) {
+	readBytes := func() ([]byte, error) {
+		if len(b) < 4 {
+			return nil, errWALCorrupt
+		}
+		l := binary.BigEndian.Uint32(b)
+		if uint32(len(b)-4) < l {
+			return nil, errWALCorrupt
+		}
+		v := b[4 : 4+l]
+		b = b[4+l:]
+		return v, nil
+	}
+
+	if len(b) < 4 {
+		return nil, errWALCorrupt
+	}
+	count := binary.BigEndian.Uint32(b)
+	b = b[4:]
+
+	batch := make(service.MessageBatch, 0, count)
+	for i := uint32(0); i < count; i++ {
+		metaBytes, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+		contentBytes, err := readBytes()
+		if err != nil {
+			return nil, err
+		}
+
+		msg := service.NewMessage(contentBytes)
+		meta := map[string]any{}
+		if err := msgpack.Unmarshal(metaBytes, &meta); err != nil {
+			return nil, err
+		}
+		for k, v := range meta {
+			msg.MetaSetMut(k, v)
+		}
+		batch = append(batch, msg)
+	}
+	return batch, nil
+}
+
+//------------------------------------------------------------------------------
+
+type walSegment struct {
+	seq  uint64
+	path string
+	file *os.File
+	size int64
+
+	// The IDs of batches within this segment that have not been acknowledged.
+	live      map[uint64]struct{}
+	liveBytes int64
+}
+
+type walEntry struct {
+	id     uint64
+	seg    *walSegment
+	offset int64
+	size   int64
+}
+
+type walBuffer struct {
+	log          *service.Logger
+	dir          string
+	segmentSize  int64
+	maxDiskSize  int64
+	syncPolicy   string
+	syncInterval time.Duration
+
+	cond     *sync.Cond
+	segments []*walSegment
+	diskSize int64
+	nextID   uint64
+	nextSeq  uint64
+	unsynced bool
+
+	// Entries that have not yet been acknowledged, either waiting in the queue
+	// to be read or pending delivery.
+	entries map[uint64]*walEntry
+	queue   []*walEntry
+	pending int
+
+	// The IDs of delivered batches where writing the acknowledgement to the
+	// log failed, which are written again along with subsequent records.
+	unwrittenAcks []uint64
+
+	endOfInput bool
+	closed     bool
+	closeChan  chan struct{}
+}
+
+func newWALBuffer(log *service.Logger, dir string, segmentSize, maxDiskSize int64, syncPolicy string, syncInterval time.Duration) (*walBuffer, error) {
+	b := &walBuffer{
+		log:          log,
+		dir:          dir,
+		segmentSize:  segmentSize,
+		maxDiskSize:  maxDiskSize,
+		syncPolicy:   syncPolicy,
+		syncInterval: syncInterval,
+		cond:         sync.NewCond(&sync.Mutex{}),
+		nextID:       1,
+		entries:      map[uint64]*walEntry{},
+		closeChan:    make(chan struct{}),
+	}
+	if err := b.recover(); err != nil {
+		b.closeFiles()
+		return nil, err
+	}
+	if syncPolicy == walSyncInterval {
+		go b.syncLoop()
+	}
+	return b, nil
+}
+
+// recover replays the segments of an existing log in order to determine the
+// batches that have not yet been acknowledged, and then starts a new active
+// segment.
+func (b *walBuffer) recover() error {
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(b.dir, "*"+walSegmentExt))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		seq, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), walSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		seg := &walSegment{seq: seq, path: path, live: map[uint64]struct{}{}}
+		if seg.file, err = os.OpenFile(path, os.O_RDWR, 0o644); err != nil {
+			return err
+		}
+		b.segments = append(b.segments, seg)
+		if err := b.replaySegment(seg); err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+		b.diskSize += seg.size
+		if seq >= b.nextSeq {
+			b.nextSeq = seq + 1
+		}
+	}
+
+	for _, e := range b.entries {
+		b.queue = append(b.queue, e)
+	}
+	sort.Slice(b.queue, func(i, j int) bool {
+		return b.queue[i].id < b.queue[j].id
+	})
+
+	if err := b.newSegment(); err != nil {
+		return err
+	}
+	b.compact()
+	return nil
+}
+
+func (b *walBuffer) replaySegment(seg *walSegment) error {
+	info, err := seg.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(seg.file)
+	for {
+		typ, id, _, size, err := walReadRecord(r, info.Size()-seg.size)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if !errors.Is(err, errWALCorrupt) {
+				return err
+			}
+			// A record that was partially written before a crash is
+			// discarded along with anything after it.
+			b.log.Warnf("Discarding corrupt record at offset %v of segment %v", seg.size, seg.path)
+			return seg.file.Truncate(seg.size)
+		}
+
+		if id >= b.nextID {
+			b.nextID = id + 1
+		}
+		switch typ {
+		case walRecordBatch:
+			// Compaction may leave duplicates of a batch when interrupted.
+			if prev, exists := b.entries[id]; exists {
+				b.removeLive(prev)
+			}
+			e := &walEntry{id: id, seg: seg, offset: seg.size, size: size}
+			b.entries[id] = e
+			seg.live[id] = struct{}{}
+			seg.liveBytes += size
+		case walRecordAck:
+			if e, exists := b.entries[id]; exists {
+				b.removeLive(e)
+				delete(b.entries, id)
+			}
+		}
+		seg.size += size
+	}
+}
+
+func (b *walBuffer) removeLive(e *walEntry) {
+	delete(e.seg.live, e.id)
+	e.seg.liveBytes -= e.size
+}
+
+func (b *walBuffer) active() *walSegment {
+	return b.segments[len(b.segments)-1]
+}
+
+func (b *walBuffer) newSegment() error {
+	if len(b.segments) > 0 && b.syncPolicy != walSyncNone && b.unsynced {
+		if err := b.active().file.Sync(); err != nil {
+			return err
+		}
+		b.unsynced = false
+	}
+
+	path := filepath.Join(b.dir, fmt.Sprintf("%020d%v", b.nextSeq, walSegmentExt))
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	b.segments = append(b.segments, &walSegment{
+		seq:  b.nextSeq,
+		path: path,
+		file: f,
+		live: map[uint64]struct{}{},
+	})
+	b.nextSeq++
+	return nil
+}
+
+func (b *walBuffer) writeRecord(rec []byte) (*walSegment, int64, error) {
+	seg := b.active()
+	if seg.size > 0 && seg.size+int64(len(rec)) > b.segmentSize {
+		if err := b.newSegment(); err != nil {
+			return nil, 0, err
+		}
+		seg = b.active()
+	}
+
+	offset := seg.size
+	if _, err := seg.file.Write(rec); err != nil {
+		// Attempt to remove a partial write so that later records remain
+		// readable, recovery would otherwise discard them.
+		_ = seg.file.Truncate(offset)
+		return nil, 0, err
+	}
+	seg.size += int64(len(rec))
+	b.diskSize += int64(len(rec))
+
+	switch b.syncPolicy {
+	case walSyncAlways:
+		if err := seg.file.Sync(); err != nil {
+			return nil, 0, err
+		}
+	case walSyncInterval:
+		b.unsynced = true
+	}
+	return seg, offset, nil
+}
+
+func (b *walBuffer) hasSpace(n int64) bool {
+	return b.maxDiskSize <= 0 || b.diskSize+n <= b.maxDiskSize
+}
+
+// compact deletes the oldest segments once all of their batches have been
+// acknowledged. Segments are only ever deleted in order as acknowledgements
+// within a segment may refer to batches of older segments.
+//
+// When the oldest segment has a small number of unacknowledged batches these
+// are copied into the active segment so that the remainder of the segment can
+// be reclaimed.
+func (b *walBuffer) compact() {
+	for len(b.segments) > 1 {
+		head := b.segments[0]
+		if len(head.live) > 0 {
+			if head.liveBytes*4 > head.size || !b.hasSpace(head.liveBytes) {
+				return
+			}
+			if err := b.relocate(head); err != nil {
+				b.log.Errorf("Failed to compact segment %v: %v", head.path, err)
+				return
+			}
+		}
+
+		_ = head.file.Close()
+		if err := os.Remove(head.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			b.log.Errorf("Failed to remove segment %v: %v", head.path, err)
+			return
+		}
+		b.diskSize -= head.size
+		b.segments[0] = nil
+		b.segments = b.segments[1:]
+	}
+}
+
+// relocate copies the unacknowledged batches of a segment into the active
+// segment.
+func (b *walBuffer) relocate(seg *walSegment) error {
+	ids := make([]uint64, 0, len(seg.live))
+	for id := range seg.live {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		e := b.entries[id]
+		rec := make([]byte, e.size)
+		if _, err := seg.file.ReadAt(rec, e.offset); err != nil {
+			return err
+		}
+
+		// Relocated records do not trigger a new segment as the active
+		// segment could otherwise be the one being compacted.
+		active := b.active()
+		offset := active.size
+		if _, err := active.file.Write(rec); err != nil {
+			_ = active.file.Truncate(offset)
+			return err
+		}
+		active.size += e.size
+		b.diskSize += e.size
+
+		b.removeLive(e)
+		e.seg, e.offset = active, offset
+		active.live[id] = struct{}{}
+		active.liveBytes += e.size
+	}
+	if b.syncPolicy != walSyncNone {
+		if err := b.active().file.Sync(); err != nil {
+			return err
+		}
+		b.unsynced = false
+	}
+	return nil
+}
+
+func (b *walBuffer) syncLoop() {
+	ticker := time.NewTicker(b.syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.cond.L.Lock()
+			if !b.closed && b.unsynced {
+				if err := b.active().file.Sync(); err != nil {
+					b.log.Errorf("Failed to sync segment: %v", err)
+				} else {
+					b.unsynced = false
+				}
+			}
+			b.cond.L.Unlock()
+		case <-b.closeChan:
+			return
+		}
+	}
+}
+
+func (b *walBuffer) closeFiles() {
+	for _, seg := range b.segments {
+		_ = seg.file.Close()
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (b *walBuffer) requeue(e *walEntry) {
+	i := sort.Search(len(b.queue), func(i int) bool {
+		return b.queue[i].id > e.id
+	})
+	b.queue = append(b.queue, nil)
+	copy(b.queue[i+1:], b.queue[i:])
+	b.queue[i] = e
+}
+
+// ack removes a delivered batch from the log. The batch is removed even when
+// its acknowledgement cannot be written, as it would otherwise prevent its
+// segment from ever being reclaimed. Failed acknowledgements are written again
+// along with subsequent records, and until then the batch is delivered again if
+// the log is replayed.
+func (b *walBuffer) ack(e *walEntry) error {
+	b.removeLive(e)
+	delete(b.entries, e.id)
+	b.unwrittenAcks = append(b.unwrittenAcks, e.id)
+
+	err := b.writeAcks()
+	b.compact()
+	return err
+}
+
+// writeAcks writes the acknowledgements that are yet to be written to the log.
+func (b *walBuffer) writeAcks() error {
+	for len(b.unwrittenAcks) > 0 {
+		id := b.unwrittenAcks[0]
+		if _, _, err := b.writeRecord(walEncodeRecord(walRecordAck, id, nil)); err != nil {
+			return fmt.Errorf("failed to write acknowledgement of batch %v: %w", id, err)
+		}
+		b.unwrittenAcks = b.unwrittenAcks[1:]
+	}
+	return nil
+}
+
+func (b *walBuffer) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	ctx, done := context.WithCancel(ctx)
+	defer done()
+
+	go func() {
+		<-ctx.Done()
+		b.cond.Broadcast()
+	}()
+
+	b.cond.L.Lock()
+	defer b.cond.L.Unlock()
+
+	for len(b.queue) == 0 || b.closed {
+		if b.closed {
+			return nil, nil, service.ErrEndOfBuffer
+		}
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		if b.endOfInput && b.pending == 0 {
+			return nil, nil, service.ErrEndOfBuffer
+		}
+		b.cond.Wait()
+	}
+
+	e := b.queue[0]
+	b.queue[0] = nil
+	b.queue = b.queue[1:]
+
+	rec := make([]byte, e.size)
+	if _, err := e.seg.file.ReadAt(rec, e.offset); err != nil {
+		b.requeue(e)
+		return nil, nil, err
+	}
+	_, _, data, size, err := walReadRecord(bytes.NewReader(rec), e.size)
+	if err == nil && size != e.size {
+		err = errWALCorrupt
+	}
+	if err != nil {
+		b.requeue(e)
+		return nil, nil, fmt.Errorf("failed to read batch %v from segment %v: %w", e.id, e.seg.path, err)
+	}
+	batch, err := walDecodeBatch(data)
+	if err != nil {
+		b.requeue(e)
+		return nil, nil, fmt.Errorf("failed to decode batch %v from segment %v: %w", e.id, e.seg.path, err)
+	}
+
+	b.pending++
+	return batch, func(ctx context.Context, err error) error {
+		b.cond.L.Lock()
+		defer b.cond.L.Unlock()
+		defer b.cond.Broadcast()
+
+		b.pending--
+		if b.closed {
+			// The batch will be delivered again once the log is replayed.
+			return nil
+		}
+		if err != nil {
+			b.requeue(e)
+			return nil
+		}
+		return b.ack(e)
+	}, nil
+}
+
+func (b *walBuffer) WriteBatch(ctx context.Context, msgBatch service.MessageBatch, aFn service.AckFunc) error {
+	data, err := walEncodeBatch(msgBatch)
+	if err != nil {
+		return err
+	}
+
+	size := int64(walHeaderSize + walPayloadBase + len(data))
+	if b.maxDiskSize > 0 && size > b.maxDiskSize {
+		return component.ErrMessageTooLarge
+	}
+
+	ctx, done := context.WithCancel(ctx)
+	defer done()
+
+	go func() {
+		<-ctx.Done()
+		b.cond.Broadcast()
+	}()
+
+	b.cond.L.Lock()
+	for {
+		if b.closed {
+			b.cond.L.Unlock()
+			return component.ErrTypeClosed
+		}
+		if b.hasSpace(size) {
+			break
+		}
+		if ctx.Err() != nil {
+			b.cond.L.Unlock()
+			return ctx.Err()
+		}
+		if b.active().size > 0 {
+			// Start a new segment so that the current one can be reclaimed
+			// once its batches are acknowledged.
+			if err := b.newSegment(); err != nil {
+				b.cond.L.Unlock()
+				return err
+			}
+			b.compact()
+			continue
+		}
+		b.cond.Wait()
+	}
+
+	if err := b.writeAcks(); err != nil {
+		b.cond.L.Unlock()
+		return err
+	}
+
+	id := b.nextID
+	seg, offset, err := b.writeRecord(walEncodeRecord(walRecordBatch, id, data))
+	if err != nil {
+		b.cond.L.Unlock()
+		return err
+	}
+	b.nextID++
+
+	e := &walEntry{id: id, seg: seg, offset: offset, size: size}
+	b.entries[id] = e
+	seg.live[id] = struct{}{}
+	seg.liveBytes += size
+	b.queue = append(b.queue, e)
+
+	b.cond.Broadcast()
+	b.cond.L.Unlock()
+
+	return aFn(ctx, nil)
+}
+
+func (b *walBuffer) EndOfInput() {
+	go func() {
+		b.cond.L.Lock()
+		defer b.cond.L.Unlock()
+
+		b.endOfInput = true
+		b.cond.Broadcast()
+	}()
+}
+
+func (b *walBuffer) Close(ctx context.Context) error {
+	b.cond.L.Lock()
+	defer b.cond.L.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	close(b.closeChan)
+	b.cond.Broadcast()
+
+	var err error
+	if b.syncPolicy != walSyncNone && b.unsynced {
+		err = b.active().file.Sync()
+	}
+	b.closeFiles()
+	return err
+}
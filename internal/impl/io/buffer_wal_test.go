@@ -0,0 +1,414 @@
+package io
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func walBufFromConf(t testing.TB, conf string) *walBuffer {
+	t.Helper()
+
+	parsedConf, err := walBufferConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	buf, err := newWALBufferFromConfig(parsedConf, service.MockResources())
+	require.NoError(t, err)
+	return buf
+}
+
+func walSegmentFiles(t testing.TB, dir string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+	require.NoError(t, err)
+	return paths
+}
+
+func noopAck(ctx context.Context, err error) error {
+	return nil
+}
+
+func TestWALBufferBasic(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	buf := walBufFromConf(t, fmt.Sprintf(`path: %v`, dir))
+	defer buf.Close(ctx)
+
+	n := 100
+	for i := 0; i < n; i++ {
+		require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{
+			service.NewMessage([]byte(fmt.Sprintf("test%v", i))),
+		}, noopAck))
+	}
+
+	for i := 0; i < n; i++ {
+		m, ackFn, err := buf.ReadBatch(ctx)
+		require.NoError(t, err)
+		require.Len(t, m, 1)
+
+		mBytes, err := m[0].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("test%v", i), string(mBytes))
+		require.NoError(t, ackFn(ctx, nil))
+	}
+}
+
+func TestWALBufferNackRequeue(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	buf := walBufFromConf(t, fmt.Sprintf(`path: %v`, dir))
+	defer buf.Close(ctx)
+
+	for _, s := range []string{"a", "b", "c"} {
+		require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{service.NewMessage([]byte(s))}, noopAck))
+	}
+
+	m, ackA, err := buf.ReadBatch(ctx)
+	require.NoError(t, err)
+	mBytes, _ := m[0].AsBytes()
+	assert.Equal(t, "a", string(mBytes))
+
+	m, ackB, err := buf.ReadBatch(ctx)
+	require.NoError(t, err)
+	mBytes, _ = m[0].AsBytes()
+	assert.Equal(t, "b", string(mBytes))
+
+	require.NoError(t, ackB(ctx, nil))
+	require.NoError(t, ackA(ctx, errors.New("nope")))
+
+	for _, exp := range []string{"a", "c"} {
+		m, ackFn, err := buf.ReadBatch(ctx)
+		require.NoError(t, err)
+		mBytes, _ := m[0].AsBytes()
+		assert.Equal(t, exp, string(mBytes))
+		require.NoError(t, ackFn(ctx, nil))
+	}
+}
+
+func TestWALBufferRecovery(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	buf := walBufFromConf(t, fmt.Sprintf(`
+path: %v
+sync_policy: always
+`, dir))
+
+	for i := 0; i < 5; i++ {
+		msgA := service.NewMessage([]byte(fmt.Sprintf("a%v", i)))
+		msgA.MetaSetMut("index", i)
+		msgA.MetaSetMut("name", "first")
+		msgB := service.NewMessage([]byte(fmt.Sprintf("b%v", i)))
+		require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{msgA, msgB}, noopAck))
+	}
+
+	// Acknowledge the first two, leave the third pending and nack the fourth
+	for i := 0; i < 4; i++ {
+		_, ackFn, err := buf.ReadBatch(ctx)
+		require.NoError(t, err)
+		switch i {
+		case 0, 1:
+			require.NoError(t, ackFn(ctx, nil))
+		case 3:
+			require.NoError(t, ackFn(ctx, errors.New("nope")))
+		}
+	}
+	require.NoError(t, buf.Close(ctx))
+
+	buf = walBufFromConf(t, fmt.Sprintf(`path: %v`, dir))
+	defer buf.Close(ctx)
+
+	for i := 2; i < 5; i++ {
+		readCtx, done := context.WithTimeout(ctx, time.Second)
+		m, ackFn, err := buf.ReadBatch(readCtx)
+		done()
+		require.NoError(t, err)
+		require.Len(t, m, 2)
+
+		mBytes, _ := m[0].AsBytes()
+		assert.Equal(t, fmt.Sprintf("a%v", i), string(mBytes))
+		v, exists := m[0].MetaGetMut("index")
+		require.True(t, exists)
+		assert.EqualValues(t, i, v)
+		v, _ = m[0].MetaGetMut("name")
+		assert.Equal(t, "first", v)
+
+		mBytes, _ = m[1].AsBytes()
+		assert.Equal(t, fmt.Sprintf("b%v", i), string(mBytes))
+		require.NoError(t, ackFn(ctx, nil))
+	}
+
+	readCtx, done := context.WithTimeout(ctx, time.Millisecond*50)
+	defer done()
+	_, _, err := buf.ReadBatch(readCtx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWALBufferTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	buf := walBufFromConf(t, fmt.Sprintf(`path: %v`, dir))
+	for _, s := range []string{"a", "b"} {
+		require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{service.NewMessage([]byte(s))}, noopAck))
+	}
+	require.NoError(t, buf.Close(ctx))
+
+	// Simulate a crash part way through writing a third record.
+	segments := walSegmentFiles(t, dir)
+	require.Len(t, segments, 1)
+	partial := walEncodeRecord(walRecordBatch, 3, []byte("this record was not finished"))
+	f, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write(partial[:len(partial)-5])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	buf = walBufFromConf(t, fmt.Sprintf(`path: %v`, dir))
+	defer buf.Close(ctx)
+
+	require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{service.NewMessage([]byte("c"))}, noopAck))
+
+	for _, exp := range []string{"a", "b", "c"} {
+		m, ackFn, err := buf.ReadBatch(ctx)
+		require.NoError(t, err)
+		mBytes, _ := m[0].AsBytes()
+		assert.Equal(t, exp, string(mBytes))
+		require.NoError(t, ackFn(ctx, nil))
+	}
+}
+
+func TestWALBufferCorruptLength(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	buf := walBufFromConf(t, fmt.Sprintf(`path: %v`, dir))
+	for _, s := range []string{"a", "b"} {
+		require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{service.NewMessage([]byte(s))}, noopAck))
+	}
+	require.NoError(t, buf.Close(ctx))
+
+	// Corrupt the length of the second record such that it exceeds the
+	// remainder of the segment.
+	segments := walSegmentFiles(t, dir)
+	require.Len(t, segments, 1)
+	recSize := int64(len(walEncodeRecord(walRecordBatch, 1, mustWALEncodeBatch(t, "a"))))
+	f, err := os.OpenFile(segments[0], os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte{0xff, 0xff, 0xff, 0xf0}, recSize)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	buf = walBufFromConf(t, fmt.Sprintf(`path: %v`, dir))
+	defer buf.Close(ctx)
+
+	// The corrupt record is discarded along with anything after it.
+	info, err := os.Stat(segments[0])
+	require.NoError(t, err)
+	assert.Equal(t, recSize, info.Size())
+
+	m, ackFn, err := buf.ReadBatch(ctx)
+	require.NoError(t, err)
+	mBytes, _ := m[0].AsBytes()
+	assert.Equal(t, "a", string(mBytes))
+	require.NoError(t, ackFn(ctx, nil))
+
+	readCtx, done := context.WithTimeout(ctx, time.Millisecond*50)
+	defer done()
+	_, _, err = buf.ReadBatch(readCtx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func mustWALEncodeBatch(t testing.TB, s string) []byte {
+	t.Helper()
+	b, err := walEncodeBatch(service.MessageBatch{service.NewMessage([]byte(s))})
+	require.NoError(t, err)
+	return b
+}
+
+func TestWALBufferAckWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	buf := walBufFromConf(t, fmt.Sprintf(`
+path: %v
+sync_policy: none
+`, dir))
+
+	require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{service.NewMessage([]byte("a"))}, noopAck))
+	_, ackFn, err := buf.ReadBatch(ctx)
+	require.NoError(t, err)
+
+	// Close the file of the active segment so that writing the
+	// acknowledgement fails.
+	buf.cond.L.Lock()
+	active := buf.active()
+	require.NoError(t, active.file.Close())
+	buf.cond.L.Unlock()
+
+	require.Error(t, ackFn(ctx, nil))
+
+	// The delivered batch is no longer held by the log.
+	buf.cond.L.Lock()
+	assert.Empty(t, buf.entries)
+	assert.Empty(t, active.live)
+	assert.Equal(t, []uint64{1}, buf.unwrittenAcks)
+
+	active.file, err = os.OpenFile(active.path, os.O_RDWR|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	buf.cond.L.Unlock()
+
+	// The acknowledgement is written along with the next batch.
+	require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{service.NewMessage([]byte("b"))}, noopAck))
+	buf.cond.L.Lock()
+	assert.Empty(t, buf.unwrittenAcks)
+	buf.cond.L.Unlock()
+	require.NoError(t, buf.Close(ctx))
+
+	buf = walBufFromConf(t, fmt.Sprintf(`path: %v`, dir))
+	defer buf.Close(ctx)
+
+	m, ackFn, err := buf.ReadBatch(ctx)
+	require.NoError(t, err)
+	mBytes, _ := m[0].AsBytes()
+	assert.Equal(t, "b", string(mBytes))
+	require.NoError(t, ackFn(ctx, nil))
+
+	readCtx, done := context.WithTimeout(ctx, time.Millisecond*50)
+	defer done()
+	_, _, err = buf.ReadBatch(readCtx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWALBufferSegmentDeletion(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	buf := walBufFromConf(t, fmt.Sprintf(`
+path: %v
+segment_size: 100
+sync_policy: none
+`, dir))
+	defer buf.Close(ctx)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{
+			service.NewMessage([]byte(fmt.Sprintf("hello world %v", i))),
+		}, noopAck))
+	}
+	assert.Greater(t, len(walSegmentFiles(t, dir)), 3)
+
+	for i := 0; i < 10; i++ {
+		_, ackFn, err := buf.ReadBatch(ctx)
+		require.NoError(t, err)
+		require.NoError(t, ackFn(ctx, nil))
+	}
+	assert.Len(t, walSegmentFiles(t, dir), 1)
+}
+
+func TestWALBufferCompaction(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	buf := walBufFromConf(t, fmt.Sprintf(`
+path: %v
+segment_size: 1000
+sync_policy: none
+`, dir))
+	defer buf.Close(ctx)
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{
+			service.NewMessage([]byte(fmt.Sprintf("hello world %v", i))),
+		}, noopAck))
+	}
+
+	// Leave the first batch unacknowledged, which would otherwise prevent the
+	// first segment from being deleted.
+	_, _, err := buf.ReadBatch(ctx)
+	require.NoError(t, err)
+	for i := 1; i < 20; i++ {
+		_, ackFn, err := buf.ReadBatch(ctx)
+		require.NoError(t, err)
+		require.NoError(t, ackFn(ctx, nil))
+	}
+
+	buf.cond.L.Lock()
+	segments, live := len(buf.segments), len(buf.entries)
+	buf.cond.L.Unlock()
+	assert.Equal(t, 1, segments)
+	assert.Equal(t, 1, live)
+}
+
+func TestWALBufferBackPressure(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	buf := walBufFromConf(t, fmt.Sprintf(`
+path: %v
+segment_size: 100
+max_disk_size: 200
+sync_policy: none
+`, dir))
+	defer buf.Close(ctx)
+
+	payload := make([]byte, 60)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{service.NewMessage(payload)}, noopAck))
+	}
+
+	writeErr := make(chan error, 1)
+	go func() {
+		writeErr <- buf.WriteBatch(ctx, service.MessageBatch{service.NewMessage(payload)}, noopAck)
+	}()
+
+	select {
+	case err := <-writeErr:
+		t.Fatalf("write should be blocked, got: %v", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	_, ackFn, err := buf.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, ackFn(ctx, nil))
+
+	select {
+	case err := <-writeErr:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("write should have been unblocked")
+	}
+
+	require.ErrorIs(t, buf.WriteBatch(ctx, service.MessageBatch{service.NewMessage(make([]byte, 300))}, noopAck), component.ErrMessageTooLarge)
+}
+
+func TestWALBufferEndOfInput(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	buf := walBufFromConf(t, fmt.Sprintf(`path: %v`, dir))
+	defer buf.Close(ctx)
+
+	require.NoError(t, buf.WriteBatch(ctx, service.MessageBatch{service.NewMessage([]byte("a"))}, noopAck))
+	buf.EndOfInput()
+
+	_, ackFn, err := buf.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, ackFn(ctx, nil))
+
+	_, _, err = buf.ReadBatch(ctx)
+	require.ErrorIs(t, err, service.ErrEndOfBuffer)
+}
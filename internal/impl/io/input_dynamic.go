@@ -181,17 +181,20 @@ func newDynamicInputFromParsed(conf *service.ParsedConfig, res *service.Resource
 		path.Join(prefix, "/inputs/{id}/uptime"),
 		`Returns the uptime of a specific input as a duration string, or "stopped" for inputs that are no longer running and have gracefully terminated.`,
 		dynAPI.HandleUptime,
+		api.DynamicUptimeOperations()...,
 	)
 	mgr.RegisterEndpoint(
 		path.Join(prefix, "/inputs/{id}"),
 		"Perform CRUD operations on the configuration of dynamic inputs. For"+
 			" more information read the `dynamic` input type documentation.",
 		dynAPI.HandleCRUD,
+		api.DynamicCRUDOperations()...,
 	)
 	mgr.RegisterEndpoint(
 		path.Join(prefix, "/inputs"),
 		"Get a map of running input identifiers with their current uptimes.",
 		dynAPI.HandleList,
+		api.DynamicListOperations()...,
 	)
 
 	return fanIn, nil
@@ -29,6 +29,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/interop"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/httpserver"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -70,6 +71,7 @@ type hsiConfig struct {
 	CertFile           string
 	KeyFile            string
 	CORS               httpserver.CORSConfig
+	Auth               httpserver.AuthConfig
 	Response           hsiResponseConfig
 }
 
@@ -124,6 +126,9 @@ func hsiConfigFromParsed(pConf *service.ParsedConfig) (conf hsiConfig, err error
 	if conf.CORS, err = corsConfigFromParsed(pConf.Namespace(hsiFieldCORS)); err != nil {
 		return
 	}
+	if conf.Auth, err = authConfigFromParsed(pConf, conf.Address); err != nil {
+		return
+	}
 	if conf.Response, err = hsiResponseConfigFromParsed(pConf.Namespace(hsiFieldResponse)); err != nil {
 		return
 	}
@@ -140,6 +145,43 @@ func corsConfigFromParsed(pConf *service.ParsedConfig) (conf httpserver.CORSConf
 	return
 }
 
+// authConfigFromParsed extracts the authentication fields of an HTTP server
+// from a parsed config. Authentication is only supported by servers with a
+// custom address, as endpoints registered with the service-wide server are
+// authenticated by its own configuration.
+func authConfigFromParsed(pConf *service.ParsedConfig, address string) (conf httpserver.AuthConfig, err error) {
+	authSpecs := httpserver.AuthFieldSpecs()
+
+	rawConf := map[string]any{}
+	for _, f := range authSpecs {
+		if rawConf[f.Name], err = pConf.FieldAny(f.Name); err != nil {
+			return
+		}
+	}
+
+	var dConf *docs.ParsedConfig
+	if dConf, err = authSpecs.ParsedConfigFromAny(rawConf); err != nil {
+		return
+	}
+	if conf, err = httpserver.AuthConfigFromParsed(dConf); err != nil {
+		return
+	}
+	if address == "" && (conf.Enabled() || len(conf.Authorization) > 0) {
+		err = errors.New("authentication requires a custom address, the service-wide HTTP server must be secured within the http section of the config instead")
+	}
+	return
+}
+
+// authFields returns the authentication fields of an HTTP server, which are
+// only valid with a custom address.
+func authFields() (fields []*service.ConfigField) {
+	for _, f := range httpserver.AuthFieldSpecs() {
+		f.Description += " Only valid with a custom `address`."
+		fields = append(fields, service.NewInternalField(f))
+	}
+	return
+}
+
 func hsiResponseConfigFromParsed(pConf *service.ParsedConfig) (conf hsiResponseConfig, err error) {
 	if conf.Status, err = pConf.FieldInterpolatedString(hsiFieldResponseStatus); err != nil {
 		return
@@ -252,6 +294,9 @@ You can access these metadata fields using [function interpolation](/docs/config
 				Advanced().
 				Default(""),
 			service.NewInternalField(corsSpec),
+		).
+		Fields(authFields()...).
+		Fields(
 			service.NewObjectField(hsiFieldResponse,
 				service.NewInterpolatedStringField(hsiFieldResponseStatus).
 					Description("Specify the status code to return with synchronous responses. This is a string value, which allows you to customize it based on resulting payloads and their metadata.").
@@ -380,6 +425,16 @@ func newHTTPServerInput(conf hsiConfig, mgr bundle.NewManagement) (input.Streame
 		}
 	}
 
+	auth, err := httpserver.NewAuthenticator(conf.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("bad auth configuration: %w", err)
+	}
+	if server != nil {
+		if server.TLSConfig, err = auth.TLSConfig(conf.CertFile, conf.KeyFile); err != nil {
+			return nil, fmt.Errorf("bad auth configuration: %w", err)
+		}
+	}
+
 	mRcvd := mgr.Metrics().GetCounter("input_received")
 	h := httpServerInput{
 		shutSig:      shutdown.NewSignaller(),
@@ -399,10 +454,10 @@ func newHTTPServerInput(conf hsiConfig, mgr bundle.NewManagement) (input.Streame
 	wsHdlr := gzipHandler(h.wsHandler)
 	if gMux != nil {
 		if h.conf.Path != "" {
-			api.GetMuxRoute(gMux, h.conf.Path).Handler(postHdlr)
+			api.GetMuxRoute(gMux, h.conf.Path).Handler(auth.WrapHandler(h.conf.Path, postHdlr))
 		}
 		if h.conf.WSPath != "" {
-			api.GetMuxRoute(gMux, h.conf.WSPath).Handler(wsHdlr)
+			api.GetMuxRoute(gMux, h.conf.WSPath).Handler(auth.WrapHandler(h.conf.WSPath, wsHdlr))
 		}
 	} else {
 		if h.conf.Path != "" {
@@ -39,7 +39,7 @@ type apiRegGorillaMutWrapper struct {
 	mut *mux.Router
 }
 
-func (a apiRegGorillaMutWrapper) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
+func (a apiRegGorillaMutWrapper) RegisterEndpoint(path, desc string, h http.HandlerFunc, ops ...api.OperationSpec) {
 	api.GetMuxRoute(a.mut, path).Handler(h)
 }
 
@@ -1336,3 +1336,98 @@ http_server:
 	assert.Equal(t, "200 OK", resp.Status)
 	assert.Equal(t, "foo", resp.Header.Get("Access-Control-Allow-Origin"))
 }
+
+func TestHTTPServerInputTokenAuth(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	freePort := getFreePort(t)
+
+	conf := parseYAMLInputConf(t, `
+http_server:
+  address: 0.0.0.0:%v
+  path: /test
+  token_auth:
+    enabled: true
+    tokens:
+      - principal: writer
+        token: writertoken
+      - principal: reader
+        token: readertoken
+  authorization:
+    - principals: [ writer ]
+      paths: [ /test ]
+`, freePort)
+
+	server, err := mock.NewManager().NewInput(conf)
+	require.NoError(t, err)
+
+	defer func() {
+		server.TriggerStopConsuming()
+		assert.NoError(t, server.WaitForClose(tCtx))
+	}()
+
+	post := func(token string) (status int, cerr error) {
+		req, cerr := http.NewRequest("POST", fmt.Sprintf("http://localhost:%v/test", freePort), bytes.NewReader([]byte("hello")))
+		require.NoError(t, cerr)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		var resp *http.Response
+		if resp, cerr = http.DefaultClient.Do(req); cerr == nil {
+			status = resp.StatusCode
+			resp.Body.Close()
+		}
+		return
+	}
+
+	require.Eventually(t, func() bool {
+		status, cerr := post("")
+		return cerr == nil && status == http.StatusUnauthorized
+	}, time.Second, 50*time.Millisecond)
+
+	status, err := post("readertoken")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	go func() {
+		select {
+		case tran := <-server.TransactionChan():
+			assert.Equal(t, "hello", string(tran.Payload.Get(0).AsBytes()))
+			require.NoError(t, tran.Ack(tCtx, nil))
+		case <-tCtx.Done():
+		}
+	}()
+
+	status, err = post("writertoken")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestHTTPServerAuthRequiresAddress(t *testing.T) {
+	mgr := mock.NewManager()
+
+	_, err := mgr.NewInput(parseYAMLInputConf(t, `
+http_server:
+  path: /test
+  token_auth:
+    enabled: true
+    tokens:
+      - principal: writer
+        token: writertoken
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "authentication requires a custom address")
+
+	_, err = mgr.NewOutput(parseYAMLOutputConf(t, `
+http_server:
+  path: /test
+  basic_auth:
+    enabled: true
+    username: foo
+    password: bar
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "authentication requires a custom address")
+}
@@ -176,17 +176,20 @@ func newDynamicOutputFromParsed(conf *service.ParsedConfig, res *service.Resourc
 		path.Join(prefix, "/outputs/{id}/uptime"),
 		`Returns the uptime of a specific output as a duration string.`,
 		dynAPI.HandleUptime,
+		api.DynamicUptimeOperations()...,
 	)
 	mgr.RegisterEndpoint(
 		path.Join(prefix, "/outputs/{id}"),
 		"Perform CRUD operations on the configuration of dynamic outputs. For"+
 			" more information read the `dynamic` output type documentation.",
 		dynAPI.HandleCRUD,
+		api.DynamicCRUDOperations()...,
 	)
 	mgr.RegisterEndpoint(
 		path.Join(prefix, "/outputs"),
 		"Get a map of running output identifiers with their current uptimes.",
 		dynAPI.HandleList,
+		api.DynamicListOperations()...,
 	)
 
 	return fanOut, nil
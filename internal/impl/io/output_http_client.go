@@ -32,6 +32,7 @@ It's possible to propagate the response from each HTTP request back to the input
 			service.NewIntField("max_in_flight").
 				Description("The maximum number of parallel message batches to have in flight at any given time.").
 				Default(64),
+			service.NewOutputAdaptiveConcurrencyField(),
 			service.NewBatchPolicyField("batching"),
 			service.NewObjectListField("multipart",
 				service.NewInterpolatedStringField("content_type").
@@ -54,6 +54,7 @@ type hsoConfig struct {
 	CertFile     string
 	KeyFile      string
 	CORS         httpserver.CORSConfig
+	Auth         httpserver.AuthConfig
 }
 
 func hsoConfigFromParsed(pConf *service.ParsedConfig) (conf hsoConfig, err error) {
@@ -95,6 +96,9 @@ func hsoConfigFromParsed(pConf *service.ParsedConfig) (conf hsoConfig, err error
 	if conf.CORS, err = corsConfigFromParsed(pConf.Namespace(hsoFieldCORS)); err != nil {
 		return
 	}
+	if conf.Auth, err = authConfigFromParsed(pConf, conf.Address); err != nil {
+		return
+	}
 	return
 }
 
@@ -145,7 +149,8 @@ Please note, messages are considered delivered as soon as the data is written to
 				Advanced().
 				Default(""),
 			service.NewInternalField(corsSpec),
-		)
+		).
+		Fields(authFields()...)
 }
 
 func init() {
@@ -214,6 +219,16 @@ func newHTTPServerOutput(conf hsoConfig, mgr bundle.NewManagement) (output.Strea
 		}
 	}
 
+	auth, err := httpserver.NewAuthenticator(conf.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("bad auth configuration: %w", err)
+	}
+	if server != nil {
+		if server.TLSConfig, err = auth.TLSConfig(conf.CertFile, conf.KeyFile); err != nil {
+			return nil, fmt.Errorf("bad auth configuration: %w", err)
+		}
+	}
+
 	stats := mgr.Metrics()
 	mSent := stats.GetCounter("output_sent")
 	mBatchSent := stats.GetCounter("output_batch_sent")
@@ -240,13 +255,13 @@ func newHTTPServerOutput(conf hsoConfig, mgr bundle.NewManagement) (output.Strea
 
 	if gMux != nil {
 		if h.conf.Path != "" {
-			api.GetMuxRoute(gMux, h.conf.Path).HandlerFunc(h.getHandler)
+			api.GetMuxRoute(gMux, h.conf.Path).HandlerFunc(auth.WrapHandler(h.conf.Path, h.getHandler))
 		}
 		if h.conf.StreamPath != "" {
-			api.GetMuxRoute(gMux, h.conf.StreamPath).HandlerFunc(h.streamHandler)
+			api.GetMuxRoute(gMux, h.conf.StreamPath).HandlerFunc(auth.WrapHandler(h.conf.StreamPath, h.streamHandler))
 		}
 		if h.conf.WSPath != "" {
-			api.GetMuxRoute(gMux, h.conf.WSPath).HandlerFunc(h.wsHandler)
+			api.GetMuxRoute(gMux, h.conf.WSPath).HandlerFunc(auth.WrapHandler(h.conf.WSPath, h.wsHandler))
 		}
 	} else {
 		if h.conf.Path != "" {
@@ -0,0 +1,194 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newFakeKafkaCluster(t testing.TB, topic string, partitions int32) string {
+	t.Helper()
+
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(partitions, topic))
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+
+	return strings.Join(cluster.ListenAddrs(), ",")
+}
+
+func newFakeKafkaWriter(t testing.TB, conf string) *franzKafkaWriter {
+	t.Helper()
+
+	pConf, err := franzKafkaOutputConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	w, err := newFranzKafkaWriterFromConfig(pConf, service.MockResources().Logger())
+	require.NoError(t, err)
+	return w
+}
+
+func consumeFakeKafka(t testing.TB, addr, topic string, n int) []*kgo.Record {
+	t.Helper()
+
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(addr),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	var records []*kgo.Record
+	for len(records) < n {
+		fetches := client.PollFetches(ctx)
+		require.NoError(t, ctx.Err())
+		records = append(records, fetches.Records()...)
+	}
+	return records
+}
+
+func TestKafkaFranzFakeOutputManualPartition(t *testing.T) {
+	addr := newFakeKafkaCluster(t, "foo", 3)
+
+	w := newFakeKafkaWriter(t, fmt.Sprintf(`
+seed_brokers: [ %v ]
+topic: foo
+partition: '${! meta("partition") }'
+partitioner: manual
+`, addr))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, w.Connect(ctx))
+	defer func() {
+		assert.NoError(t, w.Close(ctx))
+	}()
+
+	var batch service.MessageBatch
+	for i := 0; i < 6; i++ {
+		msg := service.NewMessage([]byte(fmt.Sprintf("hello world %v", i)))
+		msg.MetaSetMut("partition", fmt.Sprintf("%v", i%3))
+		batch = append(batch, msg)
+	}
+	require.NoError(t, w.WriteBatch(ctx, batch))
+
+	records := consumeFakeKafka(t, addr, "foo", 6)
+	require.Len(t, records, 6)
+	for _, r := range records {
+		var i int32
+		_, err := fmt.Sscanf(string(r.Value), "hello world %d", &i)
+		require.NoError(t, err)
+		assert.Equal(t, i%3, r.Partition, string(r.Value))
+	}
+}
+
+func TestKafkaFranzFakeOutputReconnectWhileWriting(t *testing.T) {
+	addr := newFakeKafkaCluster(t, "foo", 1)
+
+	w := newFakeKafkaWriter(t, fmt.Sprintf(`
+seed_brokers: [ %v ]
+topic: foo
+`, addr))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, w.Connect(ctx))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				// Writes may fail whilst the client is swapped, we're only
+				// interested in whether the client is accessed safely.
+				_ = w.WriteBatch(ctx, service.MessageBatch{
+					service.NewMessage([]byte(fmt.Sprintf("%v-%v", i, j))),
+				})
+			}
+		}(i)
+	}
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, w.Close(ctx))
+		require.NoError(t, w.Connect(ctx))
+	}
+	wg.Wait()
+
+	require.NoError(t, w.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte("final")),
+	}))
+	require.NoError(t, w.Close(ctx))
+}
+
+func TestKafkaFranzFakeInputConsumerGroup(t *testing.T) {
+	addr := newFakeKafkaCluster(t, "foo", 3)
+
+	w := newFakeKafkaWriter(t, fmt.Sprintf(`
+seed_brokers: [ %v ]
+topic: foo
+`, addr))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	require.NoError(t, w.Connect(ctx))
+	var batch service.MessageBatch
+	for i := 0; i < 10; i++ {
+		batch = append(batch, service.NewMessage([]byte(fmt.Sprintf("hello world %v", i))))
+	}
+	require.NoError(t, w.WriteBatch(ctx, batch))
+	require.NoError(t, w.Close(ctx))
+
+	builder := service.NewStreamBuilder()
+	require.NoError(t, builder.AddInputYAML(fmt.Sprintf(`
+kafka_franz:
+  seed_brokers: [ %v ]
+  topics: [ foo ]
+  consumer_group: foo_group
+  start_from_oldest: true
+`, addr)))
+
+	var consumedMut sync.Mutex
+	consumed := map[string]struct{}{}
+	require.NoError(t, builder.AddConsumerFunc(func(ctx context.Context, m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+		consumedMut.Lock()
+		consumed[string(b)] = struct{}{}
+		consumedMut.Unlock()
+		return nil
+	}))
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	go func() {
+		_ = strm.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		consumedMut.Lock()
+		defer consumedMut.Unlock()
+		return len(consumed) == 10
+	}, time.Second*20, time.Millisecond*50)
+
+	require.NoError(t, strm.Stop(ctx))
+}
@@ -0,0 +1,250 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+var (
+	errFranzTxnEnded   = errors.New("the transaction that the message was consumed within has ended")
+	errFranzTxnMissing = errors.New("message is missing the transaction of the kafka_franz input it was consumed from, which happens when a processor creates new messages rather than modifying the consumed messages")
+)
+
+// franzTxnSession is the subset of a kgo.GroupTransactSession used for
+// consuming within transactions.
+type franzTxnSession interface {
+	PollFetches(ctx context.Context) kgo.Fetches
+	Begin() error
+	End(ctx context.Context, commit kgo.TransactionEndTry) (bool, error)
+	ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults
+}
+
+// franzTxn is a transaction of a kafka_franz input with a transactional ID.
+// Messages consumed within the transaction that are written by a kafka_franz
+// output are produced within it, and are therefore committed along with the
+// consumed offsets.
+type franzTxn struct {
+	session franzTxnSession
+
+	// Producing records takes a read lock and ending the transaction takes the
+	// write lock, as records must not be produced whilst a transaction ends.
+	mut   sync.RWMutex
+	ended bool
+}
+
+// produce records within the transaction.
+func (t *franzTxn) produce(ctx context.Context, records ...*kgo.Record) error {
+	t.mut.RLock()
+	defer t.mut.RUnlock()
+	if t.ended {
+		return errFranzTxnEnded
+	}
+	return t.session.ProduceSync(ctx, records...).FirstErr()
+}
+
+// end the transaction, returning whether it was committed.
+func (t *franzTxn) end(ctx context.Context, commit bool) (bool, error) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.ended = true
+	return t.session.End(ctx, kgo.TransactionEndTry(commit))
+}
+
+type franzTxnKey struct{}
+
+func withFranzTxn(msg *service.Message, txn *franzTxn) *service.Message {
+	return msg.WithContext(context.WithValue(msg.Context(), franzTxnKey{}, txn))
+}
+
+func franzTxnFromMessage(msg *service.Message) *franzTxn {
+	txn, _ := msg.Context().Value(franzTxnKey{}).(*franzTxn)
+	return txn
+}
+
+type franzManualPartitionKey struct{}
+
+// withFranzManualPartition marks a record as having an explicit partition that
+// must be respected when it's produced within a transaction of an input.
+func withFranzManualPartition(r *kgo.Record) {
+	r.Context = context.WithValue(context.Background(), franzManualPartitionKey{}, true)
+}
+
+// franzTxnPartitioner is the partitioner of the client of a transactional
+// input, which produces the records written by outputs within its
+// transactions. Records with an explicit partition are written to it, records
+// with a key are partitioned by the hash of the key, and all others are
+// distributed round-robin.
+func franzTxnPartitioner() kgo.Partitioner {
+	return kgo.BasicConsistentPartitioner(func(topic string) func(r *kgo.Record, n int) int {
+		keyed := kgo.StickyKeyPartitioner(nil).ForTopic(topic)
+		var counter atomic.Uint64
+		return func(r *kgo.Record, n int) int {
+			if r.Context != nil {
+				if manual, _ := r.Context.Value(franzManualPartitionKey{}).(bool); manual {
+					return int(r.Partition)
+				}
+			}
+			if r.Key != nil {
+				return keyed.Partition(r, n)
+			}
+			return int(counter.Add(1) % uint64(n))
+		}
+	})
+}
+
+//------------------------------------------------------------------------------
+
+func (f *franzKafkaReader) connectTransactional() error {
+	clientOpts := append(f.clientOpts(),
+		kgo.TransactionalID(f.txnID),
+		kgo.TransactionTimeout(f.txnTimeout),
+		kgo.RecordPartitioner(franzTxnPartitioner()),
+		kgo.RequireStableFetchOffsets(),
+		kgo.DisableAutoCommit(),
+		kgo.WithLogger(&kgoLogger{f.log}),
+	)
+
+	session, err := kgo.NewGroupTransactSession(clientOpts...)
+	if err != nil {
+		return err
+	}
+
+	batchChan := make(chan batchWithAckFn)
+	go func() {
+		defer func() {
+			session.Close()
+			f.storeBatchChan(nil)
+			close(batchChan)
+			if f.shutSig.IsSoftStopSignalled() {
+				f.shutSig.TriggerHasStopped()
+			}
+		}()
+
+		closeCtx, done := f.shutSig.SoftStopCtx(context.Background())
+		defer done()
+
+		f.transactLoop(closeCtx, session, batchChan)
+	}()
+
+	f.storeBatchChan(batchChan)
+	return nil
+}
+
+// transactLoop consumes records within transactions until the input is closed
+// or the client fails. The records of each poll are dispatched as a batch per
+// partition, and the transaction is only committed once all of them have been
+// delivered. When the input is closed the batches already dispatched are given
+// until the transaction times out to be delivered, as ending the transaction
+// beforehand would leave their writes without a transaction.
+func (f *franzKafkaReader) transactLoop(closeCtx context.Context, session franzTxnSession, batchChan chan<- batchWithAckFn) {
+	for {
+		stallCtx, pollDone := context.WithTimeout(closeCtx, time.Second)
+		fetches := session.PollFetches(stallCtx)
+		pollDone()
+
+		if f.fetchesFailed(fetches) || closeCtx.Err() != nil {
+			return
+		}
+		if fetches.NumRecords() == 0 {
+			continue
+		}
+
+		if err := session.Begin(); err != nil {
+			f.log.Errorf("Failed to begin transaction: %v", err)
+			return
+		}
+		txn := &franzTxn{session: session}
+
+		var pending sync.WaitGroup
+		var rejectedMut sync.Mutex
+		var rejectedErr error
+
+		// Records that are never dispatched would otherwise have their offsets
+		// committed without being delivered.
+		var undispatched bool
+
+		fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+			if len(p.Records) == 0 {
+				return
+			}
+			if closeCtx.Err() != nil {
+				undispatched = true
+				return
+			}
+
+			batch := make(service.MessageBatch, 0, len(p.Records))
+			for _, r := range p.Records {
+				batch = append(batch, withFranzTxn(f.recordToMessage(r).msg, txn))
+			}
+
+			var ackOnce sync.Once
+			pending.Add(1)
+			select {
+			case batchChan <- batchWithAckFn{
+				batch: batch,
+				onAck: func(err error) {
+					ackOnce.Do(func() {
+						if err != nil {
+							rejectedMut.Lock()
+							rejectedErr = err
+							rejectedMut.Unlock()
+						}
+						pending.Done()
+					})
+				},
+			}:
+			case <-closeCtx.Done():
+				undispatched = true
+				pending.Done()
+			}
+		})
+
+		deliveredChan := make(chan struct{})
+		go func() {
+			pending.Wait()
+			close(deliveredChan)
+		}()
+
+		delivered := true
+		select {
+		case <-deliveredChan:
+		case <-closeCtx.Done():
+			drainTimer := time.NewTimer(f.txnTimeout)
+			select {
+			case <-deliveredChan:
+			case <-drainTimer.C:
+				delivered = false
+				f.log.Warn("Aborting transaction as its messages were not delivered before the transaction timed out, messages will be consumed again")
+			}
+			drainTimer.Stop()
+		}
+
+		rejectedMut.Lock()
+		commit := rejectedErr == nil && delivered && !undispatched
+		if rejectedErr != nil {
+			f.log.Warnf("Aborting transaction as a message was rejected: %v", rejectedErr)
+		}
+		rejectedMut.Unlock()
+
+		endCtx, endDone := context.WithTimeout(context.Background(), f.txnTimeout)
+		committed, err := txn.end(endCtx, commit)
+		endDone()
+		if err != nil {
+			f.log.Errorf("Failed to end transaction: %v", err)
+			return
+		}
+		if commit && !committed {
+			f.log.Warn("Transaction aborted due to a rebalance, messages will be consumed again")
+		}
+		if closeCtx.Err() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestFranzTxnFromMessage(t *testing.T) {
+	txn := &franzTxn{}
+
+	msg := withFranzTxn(service.NewMessage([]byte("foo")), txn)
+	assert.Equal(t, txn, franzTxnFromMessage(msg))
+	assert.Equal(t, txn, franzTxnFromMessage(msg.Copy()))
+	assert.Nil(t, franzTxnFromMessage(service.NewMessage([]byte("bar"))))
+}
+
+func TestFranzTxnProduceAfterEnd(t *testing.T) {
+	txn := &franzTxn{ended: true}
+	require.ErrorIs(t, txn.produce(context.Background(), &kgo.Record{}), errFranzTxnEnded)
+}
+
+func TestFranzTxnPartitioner(t *testing.T) {
+	part := franzTxnPartitioner().ForTopic("foo")
+
+	manual := &kgo.Record{Partition: 7}
+	withFranzManualPartition(manual)
+	assert.Equal(t, 7, part.Partition(manual, 10))
+
+	keyed := part.Partition(&kgo.Record{Key: []byte("bar")}, 10)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, keyed, part.Partition(&kgo.Record{Key: []byte("bar")}, 10))
+	}
+
+	seen := map[int]struct{}{}
+	for i := 0; i < 10; i++ {
+		seen[part.Partition(&kgo.Record{}, 10)] = struct{}{}
+	}
+	assert.Len(t, seen, 10)
+}
+
+func TestKafkaFranzInputTransactionalBadParams(t *testing.T) {
+	err := service.NewStreamBuilder().AddInputYAML(`
+kafka_franz:
+  seed_brokers: [ foo:1234 ]
+  topics: [ foo ]
+  transactional_id: foo_txn
+`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a consumer_group must be specified when a transactional_id is set")
+
+	require.NoError(t, service.NewStreamBuilder().AddInputYAML(`
+kafka_franz:
+  seed_brokers: [ foo:1234 ]
+  topics: [ foo ]
+  consumer_group: foo_group
+  transactional_id: foo_txn
+`))
+}
+
+// fakeFranzTxnSession stands in for a group transact session, as the kfake
+// cluster does not support transactions.
+type fakeFranzTxnSession struct {
+	mut      sync.Mutex
+	fetches  []kgo.Fetches
+	begun    int
+	ended    []bool
+	produced []string
+}
+
+func (s *fakeFranzTxnSession) PollFetches(ctx context.Context) kgo.Fetches {
+	s.mut.Lock()
+	if len(s.fetches) > 0 {
+		f := s.fetches[0]
+		s.fetches = s.fetches[1:]
+		s.mut.Unlock()
+		return f
+	}
+	s.mut.Unlock()
+	<-ctx.Done()
+	return kgo.Fetches{}
+}
+
+func (s *fakeFranzTxnSession) Begin() error {
+	s.mut.Lock()
+	s.begun++
+	s.mut.Unlock()
+	return nil
+}
+
+func (s *fakeFranzTxnSession) End(ctx context.Context, commit kgo.TransactionEndTry) (bool, error) {
+	s.mut.Lock()
+	s.ended = append(s.ended, bool(commit))
+	s.mut.Unlock()
+	return bool(commit), nil
+}
+
+func (s *fakeFranzTxnSession) ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults {
+	s.mut.Lock()
+	for _, r := range rs {
+		s.produced = append(s.produced, string(r.Value))
+	}
+	s.mut.Unlock()
+	return nil
+}
+
+func (s *fakeFranzTxnSession) result() (ended []bool, produced []string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]bool(nil), s.ended...), append([]string(nil), s.produced...)
+}
+
+func fakeFranzTxnFetches(topic string, values ...string) kgo.Fetches {
+	records := make([]*kgo.Record, len(values))
+	for i, v := range values {
+		records[i] = &kgo.Record{Topic: topic, Offset: int64(i), Value: []byte(v)}
+	}
+	return kgo.Fetches{{Topics: []kgo.FetchTopic{{
+		Topic:      topic,
+		Partitions: []kgo.FetchPartition{{Records: records}},
+	}}}}
+}
+
+// startFakeFranzTxnLoop runs a transact loop against a fake session, returning
+// the channel of dispatched batches, a func that closes the input, and a
+// channel that's closed once the loop has returned.
+func startFakeFranzTxnLoop(t *testing.T, session *fakeFranzTxnSession, txnTimeout time.Duration) (<-chan batchWithAckFn, func(), <-chan struct{}) {
+	t.Helper()
+
+	rdr := &franzKafkaReader{
+		log:        service.MockResources().Logger(),
+		txnTimeout: txnTimeout,
+	}
+
+	closeCtx, closeFn := context.WithCancel(context.Background())
+	t.Cleanup(closeFn)
+
+	batchChan := make(chan batchWithAckFn)
+	doneChan := make(chan struct{})
+	go func() {
+		defer close(doneChan)
+		rdr.transactLoop(closeCtx, session, batchChan)
+	}()
+	return batchChan, closeFn, doneChan
+}
+
+func newFakeFranzTxnWriter(t *testing.T) *franzKafkaWriter {
+	t.Helper()
+
+	addr := newFakeKafkaCluster(t, "bar", 1)
+	w := newFakeKafkaWriter(t, fmt.Sprintf(`
+seed_brokers: [ %v ]
+topic: bar
+`, addr))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, w.Connect(ctx))
+	t.Cleanup(func() {
+		_ = w.Close(context.Background())
+	})
+	return w
+}
+
+func readFakeFranzTxnBatch(t *testing.T, batchChan <-chan batchWithAckFn) batchWithAckFn {
+	t.Helper()
+	select {
+	case b := <-batchChan:
+		return b
+	case <-time.After(time.Second * 10):
+		t.Fatal("timed out waiting for batch")
+	}
+	return batchWithAckFn{}
+}
+
+func TestFranzTxnConsumeProduceCommit(t *testing.T) {
+	session := &fakeFranzTxnSession{fetches: []kgo.Fetches{fakeFranzTxnFetches("foo", "hello", "world")}}
+	w := newFakeFranzTxnWriter(t)
+
+	batchChan, closeFn, doneChan := startFakeFranzTxnLoop(t, session, time.Second*10)
+
+	b := readFakeFranzTxnBatch(t, batchChan)
+	require.NoError(t, w.WriteBatch(context.Background(), b.batch))
+	b.onAck(nil)
+
+	require.Eventually(t, func() bool {
+		ended, _ := session.result()
+		return len(ended) == 1
+	}, time.Second*10, time.Millisecond*10)
+
+	ended, produced := session.result()
+	assert.Equal(t, []bool{true}, ended)
+	assert.Equal(t, []string{"hello", "world"}, produced)
+
+	closeFn()
+	<-doneChan
+}
+
+func TestFranzTxnConsumeProduceAbort(t *testing.T) {
+	session := &fakeFranzTxnSession{fetches: []kgo.Fetches{fakeFranzTxnFetches("foo", "hello")}}
+	w := newFakeFranzTxnWriter(t)
+
+	batchChan, closeFn, doneChan := startFakeFranzTxnLoop(t, session, time.Second*10)
+
+	b := readFakeFranzTxnBatch(t, batchChan)
+	require.NoError(t, w.WriteBatch(context.Background(), b.batch))
+	b.onAck(errors.New("nope"))
+
+	require.Eventually(t, func() bool {
+		ended, _ := session.result()
+		return len(ended) == 1
+	}, time.Second*10, time.Millisecond*10)
+
+	ended, produced := session.result()
+	assert.Equal(t, []bool{false}, ended)
+	assert.Equal(t, []string{"hello"}, produced)
+
+	closeFn()
+	<-doneChan
+}
+
+func TestFranzTxnDrainOnClose(t *testing.T) {
+	session := &fakeFranzTxnSession{fetches: []kgo.Fetches{fakeFranzTxnFetches("foo", "hello")}}
+	w := newFakeFranzTxnWriter(t)
+
+	batchChan, closeFn, doneChan := startFakeFranzTxnLoop(t, session, time.Second*10)
+
+	// Closing the input whilst a batch is in flight must not end the
+	// transaction before the batch is delivered.
+	b := readFakeFranzTxnBatch(t, batchChan)
+	closeFn()
+
+	<-time.After(time.Millisecond * 50)
+	ended, _ := session.result()
+	assert.Empty(t, ended)
+
+	require.NoError(t, w.WriteBatch(context.Background(), b.batch))
+	b.onAck(nil)
+	<-doneChan
+
+	ended, produced := session.result()
+	assert.Equal(t, []bool{true}, ended)
+	assert.Equal(t, []string{"hello"}, produced)
+}
+
+func TestFranzTxnDrainTimeout(t *testing.T) {
+	session := &fakeFranzTxnSession{fetches: []kgo.Fetches{fakeFranzTxnFetches("foo", "hello")}}
+	w := newFakeFranzTxnWriter(t)
+
+	batchChan, closeFn, doneChan := startFakeFranzTxnLoop(t, session, time.Millisecond*50)
+
+	b := readFakeFranzTxnBatch(t, batchChan)
+	closeFn()
+	<-doneChan
+
+	ended, _ := session.result()
+	assert.Equal(t, []bool{false}, ended)
+
+	// Writes of the aborted transaction are dropped rather than failing, as
+	// its messages are consumed again.
+	require.NoError(t, w.WriteBatch(context.Background(), b.batch))
+	_, produced := session.result()
+	assert.Empty(t, produced)
+}
+
+func TestFranzTxnMissing(t *testing.T) {
+	w := newFakeFranzTxnWriter(t)
+
+	txn := &franzTxn{session: &fakeFranzTxnSession{}}
+	require.ErrorIs(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		withFranzTxn(service.NewMessage([]byte("foo")), txn),
+		service.NewMessage([]byte("bar")),
+	}), errFranzTxnMissing)
+
+	// Once messages of input transactions have been seen all messages must
+	// have a transaction.
+	require.ErrorIs(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("baz")),
+	}), errFranzTxnMissing)
+}
@@ -30,6 +30,14 @@ When a consumer group is specified this input consumes one or more topics where
 
 This input often out-performs the traditional ` + "`kafka`" + ` input as well as providing more useful logs and error messages.
 
+### Exactly-Once Delivery
+
+When a ` + "`transactional_id`" + ` is specified along with a consumer group this input consumes messages within transactions, where the messages of each poll are processed within a single transaction. Messages written by a ` + "[`kafka_franz` output](/docs/components/outputs/kafka_franz)" + ` within the same stream are produced as part of the transaction, which is only committed along with the consumed offsets once all of its messages have been delivered. This results in exactly-once delivery from topic to topic, provided that the output writes to the same cluster as this input.
+
+When a message of a transaction is rejected, or the partitions of the consumer are rebalanced before the transaction is committed, the transaction is aborted and its messages are consumed again. When the input is closed the messages of the current transaction are given until the ` + "`transaction_timeout`" + ` to be delivered before the transaction is aborted. In this mode each batch consists of the messages of a partition within a poll, and the fields ` + "`batching`, `checkpoint_limit`, `commit_period` and `auto_replay_nacks`" + ` have no effect.
+
+The transactional ID must be unique to each running instance of the input, and should remain the same across restarts so that transactions left open by a previous instance are fenced off.
+
 ### Metadata
 
 This input adds the following metadata fields to each message:
@@ -89,6 +97,21 @@ Finally, it's also possible to specify an explicit offset to consume from by add
 			Description("Determines whether to consume from the oldest available offset, otherwise messages are consumed from the latest offset. The setting is applied when creating a new consumer group or the saved offset no longer exists.").
 			Default(true).
 			Advanced()).
+		Field(service.NewBoolField("read_committed").
+			Description("Whether to only consume records of transactions that have been committed, records of aborted transactions are skipped. This should be enabled when consuming topics written to by transactional producers, such as a `kafka_franz` output with a `transactional_id`.").
+			Default(false).
+			Advanced().
+			Version("4.28.0")).
+		Field(service.NewStringField("transactional_id").
+			Description("An optional transactional ID, when set along with a `consumer_group` messages are consumed within transactions that commit the consumed offsets along with any messages written by a `kafka_franz` output within the same stream.").
+			Optional().
+			Advanced().
+			Version("4.28.0")).
+		Field(service.NewDurationField("transaction_timeout").
+			Description("The maximum period of time that a transaction may remain open before it is aborted by the broker. This field is only relevant when a `transactional_id` is set.").
+			Default("1m").
+			Advanced().
+			Version("4.28.0")).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField()).
 		Field(service.NewBoolField("multi_header").Description("Decode headers into lists to allow handling of multiple values with the same key").Default(false).Advanced()).
@@ -103,6 +126,8 @@ root = if $has_topic_partitions {
   } else if this.regexp_topics {
     "this input does not support both regular expression topics and explicit topic partitions"
   }
+} else if this.transactional_id.or("") != "" && this.consumer_group.or("") == "" {
+  "a consumer_group must be specified when a transactional_id is set"
 }
 `)
 }
@@ -114,6 +139,11 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
+			if rdr.txnID != "" {
+				// Rejected messages abort their transaction, after which they
+				// are consumed again.
+				return rdr, nil
+			}
 			return service.AutoRetryNacksBatchedToggled(conf, rdr)
 		})
 	if err != nil {
@@ -124,7 +154,7 @@ func init() {
 //------------------------------------------------------------------------------
 
 type batchWithAckFn struct {
-	onAck func()
+	onAck func(err error)
 	batch service.MessageBatch
 }
 
@@ -139,7 +169,10 @@ type franzKafkaReader struct {
 	saslConfs       []sasl.Mechanism
 	checkpointLimit int
 	startFromOldest bool
+	readCommitted   bool
 	commitPeriod    time.Duration
+	txnID           string
+	txnTimeout      time.Duration
 	regexPattern    bool
 	multiHeader     bool
 	batchPolicy     service.BatchPolicy
@@ -178,6 +211,10 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, res *service.Reso
 		return nil, err
 	}
 
+	if f.readCommitted, err = conf.FieldBool("read_committed"); err != nil {
+		return nil, err
+	}
+
 	topicList, err := conf.FieldStringList("topics")
 	if err != nil {
 		return nil, err
@@ -227,6 +264,19 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, res *service.Reso
 		return nil, err
 	}
 
+	if conf.Contains("transactional_id") {
+		if f.txnID, err = conf.FieldString("transactional_id"); err != nil {
+			return nil, err
+		}
+		if f.txnID != "" && f.consumerGroup == "" {
+			return nil, errors.New("a consumer_group must be specified when a transactional_id is set")
+		}
+	}
+
+	if f.txnTimeout, err = conf.FieldDuration("transaction_timeout"); err != nil {
+		return nil, err
+	}
+
 	if f.batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
 		return nil, err
 	}
@@ -406,7 +456,7 @@ func (p *partitionTracker) sendBatch(ctx context.Context, b service.MessageBatch
 		return ctx.Err()
 	case p.outBatchChan <- batchWithAckFn{
 		batch: b,
-		onAck: func() {
+		onAck: func(error) {
 			p.checkpointerLock.Lock()
 			releaseRecord := releaseFn()
 			p.checkpointerLock.Unlock()
@@ -576,6 +626,65 @@ func (c *checkpointTracker) removeTopicPartitions(ctx context.Context, m map[str
 
 //------------------------------------------------------------------------------
 
+// clientOpts returns the options common to all clients of the input.
+func (f *franzKafkaReader) clientOpts() []kgo.Opt {
+	var initialOffset kgo.Offset
+	if f.startFromOldest {
+		initialOffset = kgo.NewOffset().AtStart()
+	} else {
+		initialOffset = kgo.NewOffset().AtEnd()
+	}
+
+	clientOpts := []kgo.Opt{
+		kgo.SeedBrokers(f.seedBrokers...),
+		kgo.ConsumeTopics(f.topics...),
+		kgo.ConsumePartitions(f.topicPartitions),
+		kgo.ConsumeResetOffset(initialOffset),
+		kgo.SASL(f.saslConfs...),
+		kgo.ConsumerGroup(f.consumerGroup),
+		kgo.ClientID(f.clientID),
+		kgo.Rack(f.rackID),
+	}
+
+	if f.tlsConf != nil {
+		clientOpts = append(clientOpts, kgo.DialTLSConfig(f.tlsConf))
+	}
+
+	if f.regexPattern {
+		clientOpts = append(clientOpts, kgo.ConsumeRegex())
+	}
+
+	if f.readCommitted {
+		clientOpts = append(clientOpts, kgo.FetchIsolationLevel(kgo.ReadCommitted()))
+	}
+	return clientOpts
+}
+
+// fetchesFailed logs the errors of a poll and returns true if any of them
+// require the client to be closed and reconnected.
+func (f *franzKafkaReader) fetchesFailed(fetches kgo.Fetches) bool {
+	// Any non-temporal error sets this true and we close the client forcing a
+	// reconnect.
+	nonTemporalErr := false
+
+	for _, kerr := range fetches.Errors() {
+		// TODO: The documentation from franz-go is top-tier, it should be
+		// straight forward to expand this to include more errors that are safe
+		// to disregard.
+		if errors.Is(kerr.Err, context.DeadlineExceeded) ||
+			errors.Is(kerr.Err, context.Canceled) {
+			continue
+		}
+
+		nonTemporalErr = true
+
+		if !errors.Is(kerr.Err, kgo.ErrClientClosed) {
+			f.log.Errorf("Kafka poll error on topic %v, partition %v: %v", kerr.Topic, kerr.Partition, kerr.Err)
+		}
+	}
+	return nonTemporalErr
+}
+
 func (f *franzKafkaReader) Connect(ctx context.Context) error {
 	if f.getBatchChan() != nil {
 		return nil
@@ -586,11 +695,8 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 		return service.ErrEndOfInput
 	}
 
-	var initialOffset kgo.Offset
-	if f.startFromOldest {
-		initialOffset = kgo.NewOffset().AtStart()
-	} else {
-		initialOffset = kgo.NewOffset().AtEnd()
+	if f.txnID != "" {
+		return f.connectTransactional()
 	}
 
 	batchChan := make(chan batchWithAckFn)
@@ -607,17 +713,7 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 	}
 	checkpoints := newCheckpointTracker(f.res, batchChan, commitFn, f.batchPolicy)
 
-	clientOpts := []kgo.Opt{
-		kgo.SeedBrokers(f.seedBrokers...),
-		kgo.ConsumeTopics(f.topics...),
-		kgo.ConsumePartitions(f.topicPartitions),
-		kgo.ConsumeResetOffset(initialOffset),
-		kgo.SASL(f.saslConfs...),
-		kgo.ConsumerGroup(f.consumerGroup),
-		kgo.ClientID(f.clientID),
-		kgo.Rack(f.rackID),
-	}
-
+	clientOpts := f.clientOpts()
 	if f.consumerGroup != "" {
 		clientOpts = append(clientOpts,
 			kgo.OnPartitionsRevoked(func(rctx context.Context, c *kgo.Client, m map[string][]int32) {
@@ -636,14 +732,6 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 		)
 	}
 
-	if f.tlsConf != nil {
-		clientOpts = append(clientOpts, kgo.DialTLSConfig(f.tlsConf))
-	}
-
-	if f.regexPattern {
-		clientOpts = append(clientOpts, kgo.ConsumeRegex())
-	}
-
 	var err error
 	if cl, err = kgo.NewClient(clientOpts...); err != nil {
 		return err
@@ -674,31 +762,9 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 			fetches := cl.PollFetches(stallCtx)
 			pollDone()
 
-			if errs := fetches.Errors(); len(errs) > 0 {
-				// Any non-temporal error sets this true and we close the client
-				// forcing a reconnect.
-				nonTemporalErr := false
-
-				for _, kerr := range errs {
-					// TODO: The documentation from franz-go is top-tier, it
-					// should be straight forward to expand this to include more
-					// errors that are safe to disregard.
-					if errors.Is(kerr.Err, context.DeadlineExceeded) ||
-						errors.Is(kerr.Err, context.Canceled) {
-						continue
-					}
-
-					nonTemporalErr = true
-
-					if !errors.Is(kerr.Err, kgo.ErrClientClosed) {
-						f.log.Errorf("Kafka poll error on topic %v, partition %v: %v", kerr.Topic, kerr.Partition, kerr.Err)
-					}
-				}
-
-				if nonTemporalErr {
-					cl.Close()
-					return
-				}
+			if f.fetchesFailed(fetches) {
+				cl.Close()
+				return
 			}
 			if closeCtx.Err() != nil {
 				return
@@ -751,8 +817,9 @@ func (f *franzKafkaReader) ReadBatch(ctx context.Context) (service.MessageBatch,
 	}
 
 	return mAck.batch, func(ctx context.Context, res error) error {
-		// Res will always be nil because we initialize with service.AutoRetryNacks
-		mAck.onAck()
+		// Res will always be nil because we initialize with
+		// service.AutoRetryNacks, unless consuming within transactions.
+		mAck.onAck(res)
 		return nil
 	}, nil
 }
@@ -210,6 +210,39 @@ input:
 			integration.StreamTestOptVarSet("VAR1", ""),
 		)
 	})
+
+	transactionalTemplate := `
+output:
+  kafka_franz:
+    seed_brokers: [ localhost:$PORT ]
+    topic: topic-$ID
+    transactional_id: txn-out-$ID
+    timeout: "5s"
+    metadata:
+      include_patterns: [ .* ]
+    batching:
+      count: $OUTPUT_BATCH_COUNT
+
+input:
+  kafka_franz:
+    seed_brokers: [ localhost:$PORT ]
+    topics: [ topic-$ID$VAR1 ]
+    consumer_group: "$VAR4"
+    transactional_id: txn-in-$ID
+    read_committed: true
+`
+	t.Run("transactional", func(t *testing.T) {
+		t.Parallel()
+		suite.Run(
+			t, transactionalTemplate,
+			integration.StreamTestOptPreTest(func(t testing.TB, ctx context.Context, vars *integration.StreamTestConfigVars) {
+				vars.General["VAR4"] = "group" + vars.ID
+				require.NoError(t, createKafkaTopic(ctx, "localhost:"+kafkaPortStr, vars.ID, 4))
+			}),
+			integration.StreamTestOptPort(kafkaPortStr),
+			integration.StreamTestOptVarSet("VAR1", ""),
+		)
+	})
 }
 
 func createKafkaTopicSasl(address, id string, partitions int32) error {
@@ -3,13 +3,17 @@ package kafka
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"math"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/sasl"
 
@@ -26,6 +30,16 @@ func franzKafkaOutputConfig() *service.ConfigSpec {
 Writes a batch of messages to Kafka brokers and waits for acknowledgement before propagating it back to the input.
 
 This output often out-performs the traditional ` + "`kafka`" + ` output as well as providing more useful logs and error messages.
+
+### Transactions
+
+When a ` + "`transactional_id`" + ` is configured each batch is written within a producer transaction, which is only committed once all messages of the batch have been written. Consumers of the written topics should enable ` + "`read_committed`" + ` in order to skip the records of aborted transactions.
+
+The transactional ID must be unique to each running instance of the output, and should remain the same across restarts so that transactions left open by a previous instance are fenced off.
+
+### Exactly-Once Delivery
+
+Messages consumed by a ` + "[`kafka_franz` input](/docs/components/inputs/kafka_franz)" + ` with a ` + "`transactional_id`" + ` within the same stream are written within the transaction of the input instead, which commits them along with the consumed offsets. These messages are produced by the client of the input and therefore the output must write to the same cluster, and the fields ` + "`partitioner`, `compression`, `max_message_bytes`, `timeout` and `transactional_id`" + ` do not apply to them, with the exception of explicit partitions. Once messages consumed within a transaction have been written the output rejects any message that is missing a transaction, which happens when a processor creates new messages rather than modifying the consumed messages, as these would otherwise be written outside of the transaction.
 `).
 		Field(service.NewStringListField("seed_brokers").
 			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
@@ -60,6 +74,16 @@ This output often out-performs the traditional ` + "`kafka`" + ` output as well
 			Description("Enable the idempotent write producer option. This requires the `IDEMPOTENT_WRITE` permission on `CLUSTER` and can be disabled if this permission is not available.").
 			Default(true).
 			Advanced()).
+		Field(service.NewStringField("transactional_id").
+			Description("An optional transactional ID, when set each batch of messages is written within a transaction. Transactions are written one at a time and therefore `max_in_flight` has no effect.").
+			Optional().
+			Advanced().
+			Version("4.28.0")).
+		Field(service.NewDurationField("transaction_timeout").
+			Description("The maximum period of time that a transaction may remain open before it is aborted by the broker.").
+			Default("1m").
+			Advanced().
+			Version("4.28.0")).
 		Field(service.NewMetadataFilterField("metadata").
 			Description("Determine which (if any) metadata values should be added to messages as headers.").
 			Optional()).
@@ -90,6 +114,8 @@ root = if this.partitioner == "manual" {
   }
 } else if this.partition.or("") != "" {
   "a partition cannot be specified unless the partitioner is set to manual"
+} else if this.transactional_id.or("") != "" && this.idempotent_write == false {
+  "idempotent_write must be enabled when a transactional_id is set"
 }`)
 }
 
@@ -133,8 +159,18 @@ type franzKafkaWriter struct {
 	timeout          time.Duration
 	produceMaxBytes  int32
 	compressionPrefs []kgo.CompressionCodec
+	txnID            string
+	txnTimeout       time.Duration
+
+	// Transactions are written one at a time.
+	txnMut sync.Mutex
 
-	client *kgo.Client
+	// Set once messages consumed within the transaction of an input have been
+	// written, after which messages without a transaction are rejected.
+	inputTxnSeen atomic.Bool
+
+	clientMut sync.RWMutex
+	client    *kgo.Client
 
 	log *service.Logger
 }
@@ -244,6 +280,19 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 		return nil, err
 	}
 
+	if conf.Contains("transactional_id") {
+		if f.txnID, err = conf.FieldString("transactional_id"); err != nil {
+			return nil, err
+		}
+		if f.txnID != "" && !f.idempotentWrite {
+			return nil, errors.New("idempotent_write must be enabled when a transactional_id is set")
+		}
+	}
+
+	if f.txnTimeout, err = conf.FieldDuration("transaction_timeout"); err != nil {
+		return nil, err
+	}
+
 	if conf.Contains("metadata") {
 		if f.metaFilter, err = conf.FieldMetadataFilter("metadata"); err != nil {
 			return nil, err
@@ -266,7 +315,16 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 
 //------------------------------------------------------------------------------
 
+func (f *franzKafkaWriter) getClient() *kgo.Client {
+	f.clientMut.RLock()
+	defer f.clientMut.RUnlock()
+	return f.client
+}
+
 func (f *franzKafkaWriter) Connect(ctx context.Context) error {
+	f.clientMut.Lock()
+	defer f.clientMut.Unlock()
+
 	if f.client != nil {
 		return nil
 	}
@@ -293,6 +351,12 @@ func (f *franzKafkaWriter) Connect(ctx context.Context) error {
 	if len(f.compressionPrefs) > 0 {
 		clientOpts = append(clientOpts, kgo.ProducerBatchCompression(f.compressionPrefs...))
 	}
+	if f.txnID != "" {
+		clientOpts = append(clientOpts,
+			kgo.TransactionalID(f.txnID),
+			kgo.TransactionTimeout(f.txnTimeout),
+		)
+	}
 
 	cl, err := kgo.NewClient(clientOpts...)
 	if err != nil {
@@ -303,56 +367,147 @@ func (f *franzKafkaWriter) Connect(ctx context.Context) error {
 	return nil
 }
 
-func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatch) (err error) {
-	if f.client == nil {
+func (f *franzKafkaWriter) messageToRecord(b service.MessageBatch, i int) (*kgo.Record, error) {
+	topic, err := b.TryInterpolatedString(i, f.topic)
+	if err != nil {
+		return nil, fmt.Errorf("topic interpolation error: %w", err)
+	}
+
+	record := &kgo.Record{Topic: topic}
+	if record.Value, err = b[i].AsBytes(); err != nil {
+		return nil, err
+	}
+	if f.key != nil {
+		if record.Key, err = b.TryInterpolatedBytes(i, f.key); err != nil {
+			return nil, fmt.Errorf("key interpolation error: %w", err)
+		}
+	}
+	if f.partition != nil {
+		partStr, err := b.TryInterpolatedString(i, f.partition)
+		if err != nil {
+			return nil, fmt.Errorf("partition interpolation error: %w", err)
+		}
+		partInt, err := strconv.Atoi(partStr)
+		if err != nil {
+			return nil, fmt.Errorf("partition parse error: %w", err)
+		}
+		record.Partition = int32(partInt)
+		withFranzManualPartition(record)
+	}
+	_ = f.metaFilter.Walk(b[i], func(key, value string) error {
+		record.Headers = append(record.Headers, kgo.RecordHeader{
+			Key:   key,
+			Value: []byte(value),
+		})
+		return nil
+	})
+	return record, nil
+}
+
+func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	client := f.getClient()
+	if client == nil {
 		return service.ErrNotConnected
 	}
 
-	records := make([]*kgo.Record, 0, len(b))
-	for i, msg := range b {
-		var topic string
-		if topic, err = b.TryInterpolatedString(i, f.topic); err != nil {
-			return fmt.Errorf("topic interpolation error: %w", err)
+	// Messages consumed within a transaction of a kafka_franz input are
+	// produced within that transaction, which commits them along with the
+	// consumed offsets.
+	var txnRecords map[*franzTxn][]*kgo.Record
+
+	// Once messages of input transactions are seen, any message without a
+	// transaction has lost it and would otherwise be silently produced outside
+	// of it.
+	inputTxn := f.inputTxnSeen.Load()
+	for _, m := range b {
+		if !inputTxn && franzTxnFromMessage(m) != nil {
+			inputTxn = true
+			f.inputTxnSeen.Store(true)
 		}
+	}
 
-		record := &kgo.Record{Topic: topic}
-		if record.Value, err = msg.AsBytes(); err != nil {
-			return
+	records := make([]*kgo.Record, 0, len(b))
+	for i := range b {
+		record, err := f.messageToRecord(b, i)
+		if err != nil {
+			return err
 		}
-		if f.key != nil {
-			if record.Key, err = b.TryInterpolatedBytes(i, f.key); err != nil {
-				return fmt.Errorf("key interpolation error: %w", err)
+		if txn := franzTxnFromMessage(b[i]); txn != nil {
+			if txnRecords == nil {
+				txnRecords = map[*franzTxn][]*kgo.Record{}
 			}
+			txnRecords[txn] = append(txnRecords[txn], record)
+			continue
 		}
-		if f.partition != nil {
-			partStr, err := b.TryInterpolatedString(i, f.partition)
-			if err != nil {
-				return fmt.Errorf("partition interpolation error: %w", err)
-			}
-			partInt, err := strconv.Atoi(partStr)
-			if err != nil {
-				return fmt.Errorf("partition parse error: %w", err)
-			}
-			record.Partition = int32(partInt)
+		if inputTxn {
+			return errFranzTxnMissing
 		}
-		_ = f.metaFilter.Walk(msg, func(key, value string) error {
-			record.Headers = append(record.Headers, kgo.RecordHeader{
-				Key:   key,
-				Value: []byte(value),
-			})
-			return nil
-		})
 		records = append(records, record)
 	}
 
+	for txn, recs := range txnRecords {
+		if err := txn.produce(ctx, recs...); err != nil {
+			if errors.Is(err, errFranzTxnEnded) {
+				// The transaction was aborted without these messages and so
+				// they are consumed again, retrying them would never succeed.
+				f.log.Warnf("Dropping %v messages as the transaction they were consumed within has ended, they will be consumed again", len(recs))
+				continue
+			}
+			return err
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	if f.txnID != "" {
+		return f.writeTransaction(ctx, client, records)
+	}
+
 	// TODO: This is very cool and allows us to easily return granular errors,
 	// so we should honor travis by doing it.
-	err = f.client.ProduceSync(ctx, records...).FirstErr()
-	return
+	return client.ProduceSync(ctx, records...).FirstErr()
 }
 
-func (f *franzKafkaWriter) disconnect() {
-	if f.client == nil {
+// writeTransaction writes records within a transaction.
+func (f *franzKafkaWriter) writeTransaction(ctx context.Context, client *kgo.Client, records []*kgo.Record) error {
+	f.txnMut.Lock()
+	defer f.txnMut.Unlock()
+
+	if err := client.BeginTransaction(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	err := client.ProduceSync(ctx, records...).FirstErr()
+	if err == nil {
+		if err = client.EndTransaction(ctx, kgo.TryCommit); err == nil {
+			return nil
+		}
+		err = fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if errors.Is(err, kerr.ProducerFenced) || errors.Is(err, kerr.TransactionalIDAuthorizationFailed) {
+		// The producer can no longer be used and so we reset the client, which
+		// results in a new producer being initialised on reconnect.
+		f.log.Errorf("Transactional producer failed fatally, reconnecting: %v", err)
+		f.disconnect(client)
+		return err
+	}
+
+	if abortErr := client.AbortBufferedRecords(ctx); abortErr != nil {
+		f.log.Errorf("Failed to abort transaction: %v", abortErr)
+	} else if abortErr = client.EndTransaction(ctx, kgo.TryAbort); abortErr != nil {
+		f.log.Errorf("Failed to abort transaction: %v", abortErr)
+	}
+	return err
+}
+
+// disconnect closes a client, unless it has already been replaced.
+func (f *franzKafkaWriter) disconnect(client *kgo.Client) {
+	f.clientMut.Lock()
+	defer f.clientMut.Unlock()
+
+	if f.client == nil || f.client != client {
 		return
 	}
 	f.client.Close()
@@ -360,6 +515,6 @@ func (f *franzKafkaWriter) disconnect() {
 }
 
 func (f *franzKafkaWriter) Close(ctx context.Context) error {
-	f.disconnect()
+	f.disconnect(f.getClient())
 	return nil
 }
@@ -53,6 +53,26 @@ kafka_franz:
 `,
 			errContains: "a partition cannot be specified unless the partitioner is set to manual",
 		},
+		{
+			name: "transactional id",
+			conf: `
+kafka_franz:
+  seed_brokers: [ foo:1234 ]
+  topic: foo
+  transactional_id: foo_txn
+`,
+		},
+		{
+			name: "transactional id without idempotent writes",
+			conf: `
+kafka_franz:
+  seed_brokers: [ foo:1234 ]
+  topic: foo
+  transactional_id: foo_txn
+  idempotent_write: false
+`,
+			errContains: "idempotent_write must be enabled when a transactional_id is set",
+		},
 	}
 
 	for _, test := range testCases {
@@ -159,6 +159,7 @@ Both the `+"`id` and `index`"+` fields can be dynamically set using function int
 				Default(""),
 			service.NewTLSToggledField(esoFieldTLS),
 			service.NewOutputMaxInFlightField(),
+			service.NewOutputAdaptiveConcurrencyField(),
 		).
 		Fields(
 			httpclient.BasicAuthField(),
@@ -18,7 +18,7 @@ func tumblingWindowBufferConfig() *service.ConfigSpec {
 		Beta().
 		Version("3.53.0").
 		Categories("Windowing").
-		Summary("Chops a stream of messages into tumbling or sliding windows of fixed temporal size, following the system clock.").
+		Summary("Chops a stream of messages into tumbling or sliding windows of fixed temporal size, or session windows that close after a gap of inactivity, following the system clock.").
 		Description(`
 A window is a grouping of messages that fit within a discrete measure of time following the system clock. Messages are allocated to a window either by the processing time (the time at which they're ingested) or by the event time, and this is controlled via the `+"[`timestamp_mapping` field](#timestamp_mapping)"+`.
 
@@ -26,18 +26,30 @@ In tumbling mode (default) the beginning of a window immediately follows the end
 
 A window is flushed only once the system clock surpasses its scheduled end. If an `+"[`allowed_lateness`](#allowed_lateness)"+` is specified then the window will not be flushed until the scheduled end plus that length of time.
 
-When a message is added to a window it has a metadata field `+"`window_end_timestamp`"+` added to it containing the timestamp of the end of the window as an RFC3339 string.
+When a window is flushed its messages have the metadata fields `+"`window_start_timestamp`"+` and `+"`window_end_timestamp`"+` added to them containing the timestamps of the beginning and end of the window as RFC3339 strings.
 
 ## Sliding Windows
 
 Sliding windows begin from an offset of the prior windows' beginning rather than its end, and therefore messages may belong to multiple windows. In order to produce sliding windows specify a `+"[`slide` duration](#slide)"+`.
 
+## Session Windows
+
+Session windows group messages that arrive within a gap of inactivity of each other, and are therefore of a variable size. A session begins with the timestamp of its first message and ends with the timestamp of its last, and is flushed once the system clock surpasses its end plus the `+"[`gap`](#gap)"+` (plus any `+"[`allowed_lateness`](#allowed_lateness)"+`). A message that arrives within the gap of two sessions merges them into one. In order to produce session windows specify a `+"[`gap` duration](#gap)"+` instead of a `+"`size`"+`.
+
+Messages that would only belong to a session that has already been flushed are dropped.
+
+## Keyed Windows
+
+When a `+"[`key_mapping`](#key_mapping)"+` is specified each message is given a key and windows are computed independently for each key, where each flushed window is emitted as a batch containing only messages of a single key. The key is added to each message of the window as the metadata field `+"`window_key`"+`. For tumbling and sliding windows the keys of a window share the same boundaries, whereas session windows are opened and closed independently for each key.
+
 ## Back Pressure
 
 If back pressure is applied to this buffer either due to output services being unavailable or resources being saturated, windows older than the current and last according to the system clock will be dropped in order to prevent unbounded resource usage. This means you should ensure that under the worst case scenario you have enough system memory to store two windows' worth of data at a given time (plus extra for redundancy and other services).
 
 If messages could potentially arrive with event timestamps in the future (according to the system clock) then you should also factor in these extra messages in memory usage estimates.
 
+Session windows are not dropped under back pressure, and since a session remains open for as long as messages continue to arrive within the gap you should ensure that the maximum length of a session is bounded.
+
 ## Delivery Guarantees
 
 This buffer honours the transaction model within Benthos in order to ensure that messages are not acknowledged until they are either intentionally dropped or successfully delivered to outputs. However, since messages belonging to an expired window are intentionally dropped there are circumstances where not all messages entering the system will be delivered.
@@ -54,8 +66,14 @@ The timestamp value assigned to `+"`root`"+` must either be a numerical unix tim
 `).
 			Default("root = now()").
 			Example("root = this.created_at").Example(`root = meta("kafka_timestamp_unix").number()`)).
+		Field(service.NewBloblangField("key_mapping").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) applied to each message during ingestion that provides a key, where windows are computed independently for each key. The result of the mapping is converted into a string. If the mapping fails the message will be dropped (with logging to describe the problem).").
+			Optional().
+			Example(`root = this.user_id`).Example(`root = meta("kafka_key")`).
+			Version("4.28.0")).
 		Field(service.NewStringField("size").
-			Description("A duration string describing the size of each window. By default windows are aligned to the zeroth minute and zeroth hour on the UTC clock, meaning windows of 1 hour duration will match the turn of each hour in the day, this can be adjusted with the `offset` field.").
+			Description("A duration string describing the size of each window. By default windows are aligned to the zeroth minute and zeroth hour on the UTC clock, meaning windows of 1 hour duration will match the turn of each hour in the day, this can be adjusted with the `offset` field. This field is required unless a session `gap` is specified.").
+			Default("").
 			Example("30s").Example("10m")).
 		Field(service.NewStringField("slide").
 			Description("An optional duration string describing by how much time the beginning of each window should be offset from the beginning of the previous, and therefore creates sliding windows instead of tumbling. When specified this duration must be smaller than the `size` of the window.").
@@ -65,10 +83,24 @@ The timestamp value assigned to `+"`root`"+` must either be a numerical unix tim
 			Description("An optional duration string to offset the beginning of each window by, otherwise they are aligned to the zeroth minute and zeroth hour on the UTC clock. The offset cannot be a larger or equal measure to the window size or the slide.").
 			Default("").
 			Example("-6h").Example("30m")).
+		Field(service.NewStringField("gap").
+			Description("An optional duration string describing the gap of inactivity after which a session window is closed. When specified the buffer produces session windows instead of tumbling or sliding windows, and the fields `size`, `slide` and `offset` must not be set.").
+			Default("").
+			Example("30s").Example("10m").
+			Version("4.28.0")).
 		Field(service.NewStringField("allowed_lateness").
 			Description("An optional duration string describing the length of time to wait after a window has ended before flushing it, allowing late arrivals to be included. Since this windowing buffer uses the system clock an allowed lateness can improve the matching of messages when using event time.").
 			Default("").
 			Example("10s").Example("1m")).
+		LintRule(`
+root = if this.gap.or("") == "" {
+  if this.size.or("") == "" {
+    "field size is required unless a session gap is specified"
+  }
+} else if this.size.or("") != "" || this.slide.or("") != "" || this.offset.or("") != "" {
+  "fields size, slide and offset cannot be set when a session gap is specified"
+}
+`).
 		Example("Counting Passengers at Traffic", `Given a stream of messages relating to cars passing through various traffic lights of the form:
 
 `+"```json"+`
@@ -96,25 +128,56 @@ With the following config:`,
 buffer:
   system_window:
     timestamp_mapping: root = this.created_at
+    key_mapping: root = this.traffic_light
     size: 1h
 
 pipeline:
   processors:
-    # Group messages of the window into batches of common traffic light IDs
-    - group_by_value:
-        value: '${! json("traffic_light") }'
-
-    # Reduce each batch to a single message by deleting indexes > 0, and
-    # aggregate the car and passenger counts.
+    # Each window is a batch of messages of a common traffic light ID, which we
+    # reduce to a single message by deleting indexes > 0, and aggregate the car
+    # and passenger counts.
     - mapping: |
         root = if batch_index() == 0 {
           {
-            "traffic_light": this.traffic_light,
+            "traffic_light": meta("window_key"),
             "created_at": meta("window_end_timestamp"),
             "total_cars": json("registration_plate").from_all().unique().length(),
             "passengers": json("passengers").from_all().sum(),
           }
         } else { deleted() }
+`,
+		).
+		Example("User Sessions", `Given a stream of page views of the form:
+
+`+"```json"+`
+{
+  "user_id": "4a6d6b3e",
+  "page": "/checkout",
+  "viewed_at": "2021-08-07T09:49:35Z"
+}
+`+"```"+`
+
+We can use keyed session windows in order to emit a summary of each visit of a user to our site, where a visit ends after ten minutes without a page view:`,
+			`
+buffer:
+  system_window:
+    timestamp_mapping: root = this.viewed_at
+    key_mapping: root = this.user_id
+    gap: 10m
+    allowed_lateness: 1m
+
+pipeline:
+  processors:
+    # Each window is a batch containing the page views of a single user
+    - mapping: |
+        root = if batch_index() == 0 {
+          {
+            "user_id": meta("window_key"),
+            "started_at": meta("window_start_timestamp"),
+            "ended_at": meta("window_end_timestamp"),
+            "pages": json("page").from_all(),
+          }
+        } else { deleted() }
 `,
 		)
 }
@@ -134,45 +197,110 @@ func getDuration(conf *service.ParsedConfig, required bool, name string) (time.D
 	return period, nil
 }
 
+// mappedTimestamp executes a timestamp mapping on a message of a batch and
+// parses the result as either a numerical unix time in seconds or an ISO 8601
+// string.
+func mappedTimestamp(tsMapping *bloblang.Executor, i int, batch service.MessageBatch) (ts time.Time, err error) {
+	var tsValueMsg *service.Message
+	if tsValueMsg, err = batch.BloblangQuery(i, tsMapping); err != nil {
+		err = fmt.Errorf("timestamp mapping failed: %w", err)
+		return
+	}
+
+	var tsValue any
+	if tsValue, err = tsValueMsg.AsStructured(); err != nil {
+		if tsBytes, _ := tsValueMsg.AsBytes(); len(tsBytes) > 0 {
+			tsValue = string(tsBytes)
+			err = nil
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("unable to parse result of timestamp mapping as structured value: %w", err)
+		return
+	}
+
+	if ts, err = value.IGetTimestamp(tsValue); err != nil {
+		err = fmt.Errorf("unable to parse result of timestamp mapping as timestamp: %w", err)
+	}
+	return
+}
+
+// mappedKey executes a key mapping on a message of a batch and returns the
+// result as a string.
+func mappedKey(keyMapping *bloblang.Executor, i int, batch service.MessageBatch) (string, error) {
+	keyMsg, err := batch.BloblangQuery(i, keyMapping)
+	if err != nil {
+		return "", fmt.Errorf("key mapping failed: %w", err)
+	}
+	if keyMsg == nil {
+		return "", errors.New("key mapping failed: message was deleted")
+	}
+	keyBytes, err := keyMsg.AsBytes()
+	if err != nil {
+		return "", fmt.Errorf("key mapping failed: %w", err)
+	}
+	return string(keyBytes), nil
+}
+
 func init() {
 	err := service.RegisterBatchBuffer(
 		"system_window", tumblingWindowBufferConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchBuffer, error) {
-			size, err := getDuration(conf, true, "size")
+			tsMapping, err := conf.FieldBloblang("timestamp_mapping")
 			if err != nil {
 				return nil, err
 			}
-			slide, err := getDuration(conf, false, "slide")
+			var keyMapping *bloblang.Executor
+			if conf.Contains("key_mapping") {
+				if keyMapping, err = conf.FieldBloblang("key_mapping"); err != nil {
+					return nil, err
+				}
+			}
+			clock := func() time.Time {
+				return time.Now().UTC()
+			}
+			allowedLateness, err := getDuration(conf, false, "allowed_lateness")
 			if err != nil {
 				return nil, err
 			}
-			if slide >= size {
-				return nil, fmt.Errorf("invalid window slide '%v' must be lower than the size '%v'", slide, size)
+			size, err := getDuration(conf, false, "size")
+			if err != nil {
+				return nil, err
+			}
+			slide, err := getDuration(conf, false, "slide")
+			if err != nil {
+				return nil, err
 			}
 			offset, err := getDuration(conf, false, "offset")
 			if err != nil {
 				return nil, err
 			}
+			gap, err := getDuration(conf, false, "gap")
+			if err != nil {
+				return nil, err
+			}
+			if gap > 0 {
+				if size != 0 || slide != 0 || offset != 0 {
+					return nil, errors.New("a window size, slide or offset cannot be specified alongside a session gap")
+				}
+				return newSessionWindowBuffer(tsMapping, keyMapping, clock, gap, allowedLateness, mgr.Logger())
+			}
+			if size <= 0 {
+				return nil, errors.New("a window size or session gap must be specified")
+			}
+			if slide >= size {
+				return nil, fmt.Errorf("invalid window slide '%v' must be lower than the size '%v'", slide, size)
+			}
 			if offset >= size {
 				return nil, fmt.Errorf("invalid offset '%v' must be lower than the size '%v'", offset, size)
 			}
 			if slide > 0 && offset >= slide {
 				return nil, fmt.Errorf("invalid offset '%v' must be lower than the slide '%v'", offset, slide)
 			}
-			allowedLateness, err := getDuration(conf, false, "allowed_lateness")
-			if err != nil {
-				return nil, err
-			}
 			if allowedLateness >= size {
 				return nil, fmt.Errorf("invalid allowed_lateness '%v' must be lower than the size '%v'", allowedLateness, size)
 			}
-			tsMapping, err := conf.FieldBloblang("timestamp_mapping")
-			if err != nil {
-				return nil, err
-			}
-			return newSystemWindowBuffer(tsMapping, func() time.Time {
-				return time.Now().UTC()
-			}, size, slide, offset, allowedLateness, mgr.Logger())
+			return newSystemWindowBuffer(tsMapping, keyMapping, clock, size, slide, offset, allowedLateness, mgr.Logger())
 		})
 	if err != nil {
 		panic(err)
@@ -183,22 +311,75 @@ func init() {
 
 type tsMessage struct {
 	ts    time.Time
+	key   string
 	m     *service.Message
 	ackFn service.AckFunc
 }
 
 type utcNowProvider func() time.Time
 
+// windowBatch is a flushed window that is ready to be read.
+type windowBatch struct {
+	batch service.MessageBatch
+	acks  []service.AckFunc
+}
+
+func (b *windowBatch) add(m *service.Message, ackFn service.AckFunc) {
+	b.batch = append(b.batch, m)
+	b.acks = append(b.acks, ackFn)
+}
+
+func (b *windowBatch) ackFn(ctx context.Context, err error) error {
+	for _, aFn := range b.acks {
+		_ = aFn(ctx, err)
+	}
+	return nil
+}
+
+// windowKeyGroups groups the messages of a window by their key, preserving the
+// order in which keys first appear.
+type windowKeyGroups struct {
+	indexes map[string]int
+	batches []*windowBatch
+}
+
+func (g *windowKeyGroups) add(key string, m *service.Message, ackFn service.AckFunc) {
+	if g.indexes == nil {
+		g.indexes = map[string]int{}
+	}
+	i, exists := g.indexes[key]
+	if !exists {
+		i = len(g.batches)
+		g.indexes[key] = i
+		g.batches = append(g.batches, &windowBatch{})
+	}
+	g.batches[i].add(m, ackFn)
+}
+
+// windowedMessage returns a copy of a message with the metadata of its window
+// added.
+func windowedMessage(m *service.Message, keyed bool, key string, start, end time.Time) *service.Message {
+	tmpMsg := m.Copy()
+	tmpMsg.MetaSet("window_start_timestamp", start.Format(time.RFC3339Nano))
+	tmpMsg.MetaSet("window_end_timestamp", end.Format(time.RFC3339Nano))
+	if keyed {
+		tmpMsg.MetaSet("window_key", key)
+	}
+	return tmpMsg
+}
+
 type systemWindowBuffer struct {
 	logger *service.Logger
 
 	tsMapping                            *bloblang.Executor
+	keyMapping                           *bloblang.Executor
 	clock                                utcNowProvider
 	size, slide, offset, allowedLateness time.Duration
 
 	latestFlushedWindowEnd time.Time
 	oldestTS               time.Time
 	pending                []*tsMessage
+	ready                  []*windowBatch
 	pendingMut             sync.Mutex
 
 	closedTimerChan <-chan time.Time
@@ -208,13 +389,14 @@ type systemWindowBuffer struct {
 }
 
 func newSystemWindowBuffer(
-	tsMapping *bloblang.Executor,
+	tsMapping, keyMapping *bloblang.Executor,
 	clock utcNowProvider,
 	size, slide, offset, allowedLateness time.Duration,
 	logger *service.Logger,
 ) (*systemWindowBuffer, error) {
 	w := &systemWindowBuffer{
 		tsMapping:       tsMapping,
+		keyMapping:      keyMapping,
 		clock:           clock,
 		size:            size,
 		slide:           slide,
@@ -266,29 +448,18 @@ func (w *systemWindowBuffer) nextSystemWindow() (prevStart, prevEnd, start, end
 }
 
 func (w *systemWindowBuffer) getTimestamp(i int, batch service.MessageBatch) (ts time.Time, err error) {
-	var tsValueMsg *service.Message
-	if tsValueMsg, err = batch.BloblangQuery(i, w.tsMapping); err != nil {
+	if ts, err = mappedTimestamp(w.tsMapping, i, batch); err != nil {
 		w.logger.Errorf("Timestamp mapping failed for message: %v", err)
-		err = fmt.Errorf("timestamp mapping failed: %w", err)
-		return
 	}
+	return
+}
 
-	var tsValue any
-	if tsValue, err = tsValueMsg.AsStructured(); err != nil {
-		if tsBytes, _ := tsValueMsg.AsBytes(); len(tsBytes) > 0 {
-			tsValue = string(tsBytes)
-			err = nil
-		}
-	}
-	if err != nil {
-		w.logger.Errorf("Timestamp mapping failed for message: unable to parse result as structured value: %v", err)
-		err = fmt.Errorf("unable to parse result of timestamp mapping as structured value: %w", err)
+func (w *systemWindowBuffer) getKey(i int, batch service.MessageBatch) (key string, err error) {
+	if w.keyMapping == nil {
 		return
 	}
-
-	if ts, err = value.IGetTimestamp(tsValue); err != nil {
-		w.logger.Errorf("Timestamp mapping failed for message: %v", err)
-		err = fmt.Errorf("unable to parse result of timestamp mapping as timestamp: %w", err)
+	if key, err = mappedKey(w.keyMapping, i, batch); err != nil {
+		w.logger.Errorf("Key mapping failed for message: %v", err)
 	}
 	return
 }
@@ -333,9 +504,14 @@ func (w *systemWindowBuffer) WriteBatch(ctx context.Context, msgBatch service.Me
 			continue
 		}
 
+		key, err := w.getKey(i, msgBatch)
+		if err != nil {
+			return err
+		}
+
 		messageAdded = true
 		w.pending = append(w.pending, &tsMessage{
-			ts: ts, m: msg, ackFn: service.AckFunc(aggregatedAck.Derive()),
+			ts: ts, key: key, m: msg, ackFn: service.AckFunc(aggregatedAck.Derive()),
 		})
 		if ts.Before(w.oldestTS) {
 			w.oldestTS = ts
@@ -360,8 +536,7 @@ func (w *systemWindowBuffer) flushWindow(ctx context.Context, start, end time.Ti
 		nextStart = start.Add(w.slide)
 	}
 
-	var flushBatch service.MessageBatch
-	var flushAcks []service.AckFunc
+	var groups windowKeyGroups
 
 	newPending := make([]*tsMessage, 0, len(w.pending))
 	newOldest := w.clock()
@@ -370,10 +545,8 @@ func (w *systemWindowBuffer) flushWindow(ctx context.Context, start, end time.Ti
 		preserve := !pending.ts.Before(nextStart)                    //nolint: gocritic
 
 		if flush {
-			tmpMsg := pending.m.Copy()
-			tmpMsg.MetaSet("window_end_timestamp", end.Format(time.RFC3339Nano))
-			flushBatch = append(flushBatch, tmpMsg)
-			flushAcks = append(flushAcks, pending.ackFn)
+			tmpMsg := windowedMessage(pending.m, w.keyMapping != nil, pending.key, start.Add(-1), end)
+			groups.add(pending.key, tmpMsg, pending.ackFn)
 		}
 		if preserve {
 			if pending.ts.Before(newOldest) {
@@ -390,17 +563,34 @@ func (w *systemWindowBuffer) flushWindow(ctx context.Context, start, end time.Ti
 	w.latestFlushedWindowEnd = end
 	w.oldestTS = newOldest
 
-	return flushBatch, func(ctx context.Context, err error) error {
-		for _, aFn := range flushAcks {
-			_ = aFn(ctx, err)
-		}
-		return nil
-	}, nil
+	if len(groups.batches) == 0 {
+		return nil, nil, nil
+	}
+
+	// Each key of the window is emitted as its own batch, and so all but the
+	// first are queued for subsequent reads.
+	w.ready = append(w.ready, groups.batches[1:]...)
+	return groups.batches[0].batch, groups.batches[0].ackFn, nil
+}
+
+func (w *systemWindowBuffer) popReady() (*windowBatch, bool) {
+	w.pendingMut.Lock()
+	defer w.pendingMut.Unlock()
+	if len(w.ready) == 0 {
+		return nil, false
+	}
+	b := w.ready[0]
+	w.ready = w.ready[1:]
+	return b, true
 }
 
 var errWindowClosed = errors.New("message rejected as window did not complete")
 
 func (w *systemWindowBuffer) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	if b, ok := w.popReady(); ok {
+		return b.batch, b.ackFn, nil
+	}
+
 	prevStart, prevEnd, nextStart, nextEnd := w.nextSystemWindow()
 
 	// We haven't been read since the previous window ended, so create that one
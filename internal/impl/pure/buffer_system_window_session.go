@@ -0,0 +1,250 @@
+package pure
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// sessionWindow is an open session of messages that share a key, where start
+// and end are the earliest and latest timestamps of its messages.
+type sessionWindow struct {
+	key        string
+	start, end time.Time
+	pending    []*tsMessage
+}
+
+type sessionWindowBuffer struct {
+	logger *service.Logger
+
+	tsMapping            *bloblang.Executor
+	keyMapping           *bloblang.Executor
+	clock                utcNowProvider
+	gap, allowedLateness time.Duration
+
+	sessions map[string][]*sessionWindow
+	ready    []*windowBatch
+	mut      sync.Mutex
+
+	// Signals the reader that sessions were added or extended, and therefore
+	// the next session to close might have changed.
+	addedChan chan struct{}
+
+	endOfInputChan      chan struct{}
+	closeEndOfInputOnce sync.Once
+}
+
+func newSessionWindowBuffer(
+	tsMapping, keyMapping *bloblang.Executor,
+	clock utcNowProvider,
+	gap, allowedLateness time.Duration,
+	logger *service.Logger,
+) (*sessionWindowBuffer, error) {
+	return &sessionWindowBuffer{
+		tsMapping:       tsMapping,
+		keyMapping:      keyMapping,
+		clock:           clock,
+		gap:             gap,
+		allowedLateness: allowedLateness,
+		logger:          logger,
+		sessions:        map[string][]*sessionWindow{},
+		addedChan:       make(chan struct{}, 1),
+		endOfInputChan:  make(chan struct{}),
+	}, nil
+}
+
+// closesAt returns the time at which a session is flushed, which is once the
+// gap (plus allowed lateness) has passed since its latest message.
+func (w *sessionWindowBuffer) closesAt(s *sessionWindow) time.Time {
+	return s.end.Add(w.gap + w.allowedLateness)
+}
+
+// add a message to the session of its key that it falls within the gap of,
+// merging any sessions that the message bridges. Returns false if the message
+// does not belong to an open session and would only open a session that has
+// already closed.
+func (w *sessionWindowBuffer) add(now time.Time, m *tsMessage) bool {
+	merged := &sessionWindow{key: m.key, start: m.ts, end: m.ts}
+
+	var remaining []*sessionWindow
+	for _, s := range w.sessions[m.key] {
+		if m.ts.Before(s.start.Add(-w.gap)) || m.ts.After(s.end.Add(w.gap)) {
+			remaining = append(remaining, s)
+			continue
+		}
+		if s.start.Before(merged.start) {
+			merged.start = s.start
+		}
+		if s.end.After(merged.end) {
+			merged.end = s.end
+		}
+		merged.pending = append(merged.pending, s.pending...)
+	}
+
+	if len(merged.pending) == 0 && !w.closesAt(merged).After(now) {
+		return false
+	}
+
+	merged.pending = append(merged.pending, m)
+	w.sessions[m.key] = append(remaining, merged)
+	return true
+}
+
+func (w *sessionWindowBuffer) WriteBatch(ctx context.Context, msgBatch service.MessageBatch, aFn service.AckFunc) error {
+	tsMsgs := make([]*tsMessage, len(msgBatch))
+	for i, msg := range msgBatch {
+		ts, err := mappedTimestamp(w.tsMapping, i, msgBatch)
+		if err != nil {
+			w.logger.Errorf("Timestamp mapping failed for message: %v", err)
+			return err
+		}
+		var key string
+		if w.keyMapping != nil {
+			if key, err = mappedKey(w.keyMapping, i, msgBatch); err != nil {
+				w.logger.Errorf("Key mapping failed for message: %v", err)
+				return err
+			}
+		}
+		tsMsgs[i] = &tsMessage{ts: ts, key: key, m: msg}
+	}
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	messageAdded := false
+	aggregatedAck := batch.NewCombinedAcker(batch.AckFunc(aFn))
+
+	now := w.clock()
+	for _, m := range tsMsgs {
+		// The ack is derived once added, which is safe as sessions can't be
+		// flushed until we release the lock.
+		if w.add(now, m) {
+			m.ackFn = service.AckFunc(aggregatedAck.Derive())
+			messageAdded = true
+		}
+	}
+
+	if !messageAdded {
+		// If none of the messages have fit into a session we reject them by
+		// acknowledging the batch.
+		_ = aFn(ctx, nil)
+		return nil
+	}
+
+	select {
+	case w.addedChan <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// flushClosed moves all sessions that have closed according to the system
+// clock into the ready queue, and returns the time at which the next open
+// session closes.
+func (w *sessionWindowBuffer) flushClosed() (nextClose time.Time, hasNext bool) {
+	now := w.clock()
+
+	var closed []*sessionWindow
+	for key, sessions := range w.sessions {
+		var remaining []*sessionWindow
+		for _, s := range sessions {
+			closesAt := w.closesAt(s)
+			if !closesAt.After(now) {
+				closed = append(closed, s)
+				continue
+			}
+			remaining = append(remaining, s)
+			if !hasNext || closesAt.Before(nextClose) {
+				nextClose, hasNext = closesAt, true
+			}
+		}
+		if len(remaining) == 0 {
+			delete(w.sessions, key)
+		} else {
+			w.sessions[key] = remaining
+		}
+	}
+
+	sort.Slice(closed, func(i, j int) bool {
+		if !closed[i].start.Equal(closed[j].start) {
+			return closed[i].start.Before(closed[j].start)
+		}
+		return closed[i].key < closed[j].key
+	})
+	for _, s := range closed {
+		sort.SliceStable(s.pending, func(i, j int) bool {
+			return s.pending[i].ts.Before(s.pending[j].ts)
+		})
+		b := &windowBatch{}
+		for _, pending := range s.pending {
+			b.add(windowedMessage(pending.m, w.keyMapping != nil, s.key, s.start, s.end), pending.ackFn)
+		}
+		w.ready = append(w.ready, b)
+	}
+	return
+}
+
+func (w *sessionWindowBuffer) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for {
+		w.mut.Lock()
+		nextClose, hasNext := w.flushClosed()
+		if len(w.ready) > 0 {
+			b := w.ready[0]
+			w.ready = w.ready[1:]
+			w.mut.Unlock()
+			return b.batch, b.ackFn, nil
+		}
+		w.mut.Unlock()
+
+		// Without open sessions we wait indefinitely for new messages.
+		var nextCloseChan <-chan time.Time
+		var nextCloseTimer *time.Timer
+		if hasNext {
+			nextCloseTimer = time.NewTimer(nextClose.Sub(w.clock()))
+			nextCloseChan = nextCloseTimer.C
+		}
+
+		var err error
+		select {
+		case <-nextCloseChan:
+		case <-w.addedChan:
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-w.endOfInputChan:
+			// Nack all pending messages so that we re-consume them on the next
+			// start up.
+			w.mut.Lock()
+			for _, sessions := range w.sessions {
+				for _, s := range sessions {
+					for _, pending := range s.pending {
+						_ = pending.ackFn(ctx, errWindowClosed)
+					}
+				}
+			}
+			w.sessions = map[string][]*sessionWindow{}
+			w.mut.Unlock()
+			err = service.ErrEndOfBuffer
+		}
+		if nextCloseTimer != nil {
+			nextCloseTimer.Stop()
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+func (w *sessionWindowBuffer) EndOfInput() {
+	w.closeEndOfInputOnce.Do(func() {
+		close(w.endOfInputChan)
+	})
+}
+
+func (w *sessionWindowBuffer) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,176 @@
+package pure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestSessionWindowBuffer(t *testing.T) {
+	ctx := context.Background()
+
+	tsMapping, err := bloblang.Parse(`root = this.ts`)
+	require.NoError(t, err)
+
+	keyMapping, err := bloblang.Parse(`root = this.key`)
+	require.NoError(t, err)
+
+	currentTS := time.Unix(10, 600_000_000).UTC()
+	w, err := newSessionWindowBuffer(tsMapping, keyMapping, func() time.Time {
+		return currentTS
+	}, time.Second, 0, nil)
+	require.NoError(t, err)
+
+	writeMsgs := func(msgs ...string) (acked *bool) {
+		t.Helper()
+
+		var batch service.MessageBatch
+		for _, m := range msgs {
+			batch = append(batch, service.NewMessage([]byte(m)))
+		}
+		acked = new(bool)
+		require.NoError(t, w.WriteBatch(ctx, batch, func(ctx context.Context, err error) error {
+			*acked = true
+			return nil
+		}))
+		return
+	}
+
+	assertSession := func(key, start, end string, exp ...string) {
+		t.Helper()
+
+		resBatch, aFn, err := w.ReadBatch(ctx)
+		require.NoError(t, err)
+		require.Len(t, resBatch, len(exp))
+		for i, msg := range resBatch {
+			msgBytes, err := msg.AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, exp[i], string(msgBytes))
+
+			v, _ := msg.MetaGet("window_key")
+			assert.Equal(t, key, v)
+			v, _ = msg.MetaGet("window_start_timestamp")
+			assert.Equal(t, start, v)
+			v, _ = msg.MetaGet("window_end_timestamp")
+			assert.Equal(t, end, v)
+		}
+		require.NoError(t, aFn(ctx, nil))
+	}
+
+	assertNoSession := func() {
+		t.Helper()
+
+		smallWaitCtx, done := context.WithTimeout(ctx, time.Millisecond*50)
+		resBatch, _, err := w.ReadBatch(smallWaitCtx)
+		done()
+		require.Error(t, err)
+		assert.Empty(t, resBatch)
+	}
+
+	writeMsgs(
+		`{"key":"a","ts":10}`,
+		`{"key":"b","ts":10.25}`,
+		`{"key":"a","ts":10.5}`,
+		`{"key":"a","ts":12}`,
+	)
+	assertNoSession()
+
+	currentTS = time.Unix(11, 300_000_000).UTC()
+	assertSession("b", "1970-01-01T00:00:10.25Z", "1970-01-01T00:00:10.25Z", `{"key":"b","ts":10.25}`)
+	assertNoSession()
+
+	currentTS = time.Unix(11, 600_000_000).UTC()
+	assertSession("a", "1970-01-01T00:00:10Z", "1970-01-01T00:00:10.5Z", `{"key":"a","ts":10}`, `{"key":"a","ts":10.5}`)
+	assertNoSession()
+
+	// A message within the gap of two sessions merges them.
+	writeMsgs(`{"key":"a","ts":14}`)
+	writeMsgs(`{"key":"a","ts":12.75}`)
+	writeMsgs(`{"key":"a","ts":13.5}`)
+	assert.Len(t, w.sessions["a"], 1)
+
+	// A message that would only open a session that has already closed is
+	// dropped.
+	acked := writeMsgs(`{"key":"c","ts":10}`)
+	assert.True(t, *acked)
+
+	currentTS = time.Unix(15, 0).UTC()
+	assertSession("a", "1970-01-01T00:00:12Z", "1970-01-01T00:00:14Z",
+		`{"key":"a","ts":12}`,
+		`{"key":"a","ts":12.75}`,
+		`{"key":"a","ts":13.5}`,
+		`{"key":"a","ts":14}`,
+	)
+	assert.Empty(t, w.sessions)
+}
+
+func TestSessionWindowBufferUnkeyed(t *testing.T) {
+	ctx := context.Background()
+
+	tsMapping, err := bloblang.Parse(`root = this.ts`)
+	require.NoError(t, err)
+
+	currentTS := time.Unix(10, 0).UTC()
+	w, err := newSessionWindowBuffer(tsMapping, nil, func() time.Time {
+		return currentTS
+	}, time.Second, time.Second, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"key":"a","ts":9.5}`)),
+		service.NewMessage([]byte(`{"key":"b","ts":10}`)),
+	}, func(ctx context.Context, err error) error {
+		return nil
+	}))
+
+	// Sessions are flushed once both the gap and allowed lateness have passed.
+	currentTS = time.Unix(11, 900_000_000).UTC()
+
+	smallWaitCtx, done := context.WithTimeout(ctx, time.Millisecond*50)
+	resBatch, _, err := w.ReadBatch(smallWaitCtx)
+	done()
+	require.Error(t, err)
+	assert.Empty(t, resBatch)
+
+	currentTS = time.Unix(12, 0).UTC()
+
+	resBatch, _, err = w.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, resBatch, 2)
+
+	_, exists := resBatch[0].MetaGet("window_key")
+	assert.False(t, exists)
+}
+
+func TestSessionWindowBufferEndOfInput(t *testing.T) {
+	ctx := context.Background()
+
+	tsMapping, err := bloblang.Parse(`root = this.ts`)
+	require.NoError(t, err)
+
+	currentTS := time.Unix(10, 0).UTC()
+	w, err := newSessionWindowBuffer(tsMapping, nil, func() time.Time {
+		return currentTS
+	}, time.Second, 0, nil)
+	require.NoError(t, err)
+
+	var ackErr error
+	require.NoError(t, w.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"ts":10}`)),
+	}, func(ctx context.Context, err error) error {
+		ackErr = err
+		return nil
+	}))
+
+	w.EndOfInput()
+
+	_, _, err = w.ReadBatch(ctx)
+	require.ErrorIs(t, err, service.ErrEndOfBuffer)
+	assert.ErrorIs(t, ackErr, errWindowClosed)
+}
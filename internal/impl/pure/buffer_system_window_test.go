@@ -90,6 +90,22 @@ system_window:
 `,
 			buildErrContains: "invalid allowed_lateness",
 		},
+		{
+			config: `
+system_window:
+  key_mapping: root = this.id
+  gap: 30s
+  allowed_lateness: 2m
+`,
+		},
+		{
+			config: `
+system_window:
+  size: 60m
+  gap: 30s
+`,
+			lintErrContains: "cannot be set when a session gap is specified",
+		},
 	}
 
 	for i, test := range tests {
@@ -216,7 +232,7 @@ func TestSystemCurrentWindowCalc(t *testing.T) {
 
 	for i, test := range tests {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
-			w, err := newSystemWindowBuffer(nil, func() time.Time {
+			w, err := newSystemWindowBuffer(nil, nil, func() time.Time {
 				ts, err := time.Parse(time.RFC3339Nano, test.now)
 				require.NoError(t, err)
 				return ts.UTC()
@@ -242,7 +258,7 @@ func TestSystemWindowWritePurge(t *testing.T) {
 	require.NoError(t, err)
 
 	currentTS := time.Unix(10, 1).UTC()
-	w, err := newSystemWindowBuffer(mapping, func() time.Time {
+	w, err := newSystemWindowBuffer(mapping, nil, func() time.Time {
 		return currentTS
 	}, time.Second, 0, 0, 0, nil)
 	require.NoError(t, err)
@@ -271,7 +287,7 @@ func TestSystemWindowCreation(t *testing.T) {
 	require.NoError(t, err)
 
 	currentTS := time.Unix(10, 1).UTC()
-	w, err := newSystemWindowBuffer(mapping, func() time.Time {
+	w, err := newSystemWindowBuffer(mapping, nil, func() time.Time {
 		return currentTS
 	}, time.Second, 0, 0, 0, nil)
 	require.NoError(t, err)
@@ -343,7 +359,7 @@ func TestSystemWindowCreationSliding(t *testing.T) {
 	require.NoError(t, err)
 
 	currentTS := time.Unix(10, 0).UTC()
-	w, err := newSystemWindowBuffer(mapping, func() time.Time {
+	w, err := newSystemWindowBuffer(mapping, nil, func() time.Time {
 		return currentTS
 	}, time.Second, time.Millisecond*500, 0, 0, nil)
 	require.NoError(t, err)
@@ -428,7 +444,7 @@ func TestSystemWindowAckOneToMany(t *testing.T) {
 	require.NoError(t, err)
 
 	currentTS := time.Unix(10, 1).UTC()
-	w, err := newSystemWindowBuffer(mapping, func() time.Time {
+	w, err := newSystemWindowBuffer(mapping, nil, func() time.Time {
 		return currentTS
 	}, time.Second, 0, 0, 0, nil)
 	require.NoError(t, err)
@@ -500,7 +516,7 @@ func TestSystemWindowAckManyToOne(t *testing.T) {
 	require.NoError(t, err)
 
 	currentTS := time.Unix(10, 1).UTC()
-	w, err := newSystemWindowBuffer(mapping, func() time.Time {
+	w, err := newSystemWindowBuffer(mapping, nil, func() time.Time {
 		return currentTS
 	}, time.Second, 0, 0, 0, nil)
 	require.NoError(t, err)
@@ -559,7 +575,7 @@ func TestSystemWindowParallelReadAndWrites(t *testing.T) {
 	require.NoError(t, err)
 
 	currentTS := time.Unix(10, 500000000).UTC()
-	w, err := newSystemWindowBuffer(mapping, func() time.Time {
+	w, err := newSystemWindowBuffer(mapping, nil, func() time.Time {
 		return currentTS
 	}, time.Second, 0, 0, 0, nil)
 	require.NoError(t, err)
@@ -599,7 +615,7 @@ func TestSystemWindowOwnership(t *testing.T) {
 	require.NoError(t, err)
 
 	currentTS := time.Unix(10, 1).UTC()
-	w, err := newSystemWindowBuffer(mapping, func() time.Time {
+	w, err := newSystemWindowBuffer(mapping, nil, func() time.Time {
 		return currentTS
 	}, time.Second, 0, 0, 0, nil)
 	require.NoError(t, err)
@@ -644,3 +660,59 @@ func TestSystemWindowOwnership(t *testing.T) {
 		"ts":    10,
 	}, inStruct)
 }
+
+func TestSystemWindowKeyed(t *testing.T) {
+	ctx := context.Background()
+
+	tsMapping, err := bloblang.Parse(`root = this.ts`)
+	require.NoError(t, err)
+
+	keyMapping, err := bloblang.Parse(`root = this.key`)
+	require.NoError(t, err)
+
+	currentTS := time.Unix(10, 1).UTC()
+	w, err := newSystemWindowBuffer(tsMapping, keyMapping, func() time.Time {
+		return currentTS
+	}, time.Second, 0, 0, 0, nil)
+	require.NoError(t, err)
+
+	var ackErr error
+	require.NoError(t, w.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"1","key":"a","ts":9.5}`)),
+		service.NewMessage([]byte(`{"id":"2","key":"b","ts":9.6}`)),
+		service.NewMessage([]byte(`{"id":"3","key":"a","ts":9.7}`)),
+		service.NewMessage([]byte(`{"id":"4","key":"b","ts":10.5}`)),
+	}, func(ctx context.Context, err error) error {
+		ackErr = err
+		return nil
+	}))
+
+	assertWindow := func(key string, exp ...string) service.AckFunc {
+		t.Helper()
+
+		resBatch, aFn, err := w.ReadBatch(ctx)
+		require.NoError(t, err)
+		require.Len(t, resBatch, len(exp))
+		for i, msg := range resBatch {
+			msgBytes, err := msg.AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, exp[i], string(msgBytes))
+
+			v, _ := msg.MetaGet("window_key")
+			assert.Equal(t, key, v)
+			v, _ = msg.MetaGet("window_start_timestamp")
+			assert.Equal(t, "1970-01-01T00:00:09Z", v)
+			v, _ = msg.MetaGet("window_end_timestamp")
+			assert.Equal(t, "1970-01-01T00:00:10Z", v)
+		}
+		return aFn
+	}
+
+	aFnA := assertWindow("a", `{"id":"1","key":"a","ts":9.5}`, `{"id":"3","key":"a","ts":9.7}`)
+	aFnB := assertWindow("b", `{"id":"2","key":"b","ts":9.6}`)
+
+	require.Len(t, w.pending, 1)
+	require.NoError(t, aFnA(ctx, nil))
+	require.NoError(t, aFnB(ctx, errors.New("custom error")))
+	assert.NoError(t, ackErr)
+}
@@ -2,7 +2,10 @@ package pure
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/input/batcher"
 	"github.com/benthosdev/benthos/v4/internal/component/interop"
@@ -13,9 +16,11 @@ import (
 var ErrBrokerNoInputs = errors.New("attempting to create broker input type with no inputs")
 
 const (
-	ibFieldCopies   = "copies"
-	ibFieldInputs   = "inputs"
-	ibFieldBatching = "batching"
+	ibFieldCopies     = "copies"
+	ibFieldInputs     = "inputs"
+	ibFieldScheduling = "scheduling"
+	ibFieldPriorities = "priorities"
+	ibFieldBatching   = "batching"
 )
 
 func brokerInputSpec() *service.ConfigSpec {
@@ -62,7 +67,29 @@ It's possible to configure a [batch policy](/docs/configuration/batching#batch-p
 
 ### Processors
 
-It is possible to configure [processors](/docs/components/processors/about) at the broker level, where they will be applied to _all_ child inputs, as well as on the individual child inputs. If you have processors at both the broker level _and_ on child inputs then the broker processors will be applied _after_ the child nodes processors.`).
+It is possible to configure [processors](/docs/components/processors/about) at the broker level, where they will be applied to _all_ child inputs, as well as on the individual child inputs. If you have processors at both the broker level _and_ on child inputs then the broker processors will be applied _after_ the child nodes processors.
+
+### Scheduling
+
+By default the child inputs compete equally for the attention of the rest of the pipeline, which means under load messages from a low volume input can end up waiting behind those of a busier input. The field `+"`scheduling`"+` allows you to instead prioritise child inputs with a priority for each input specified in the field `+"`priorities`"+`, in the same order as `+"`inputs`"+`. For example, the following always delivers pending messages from the `+"`alerts`"+` input before those of the `+"`telemetry`"+` input:
+
+`+"```yaml"+`
+input:
+  broker:
+    scheduling: strict_priority
+    priorities: [ 10, 1 ]
+    inputs:
+      - kafka:
+          addresses: [ localhost:9092 ]
+          topics: [ alerts ]
+          consumer_group: benthos_alerts
+      - kafka:
+          addresses: [ localhost:9092 ]
+          topics: [ telemetry ]
+          consumer_group: benthos_telemetry
+`+"```"+`
+
+When `+"`copies`"+` is greater than one each copy of an input has the same priority as the original. The time that messages from each child wait to be delivered is tracked with the timing metric `+"`input_broker_queue_delay_ns`"+`, labelled by the label of the child, or its path when it has no label, where copies of a child share its label.`).
 		Fields(
 			service.NewIntField(ibFieldCopies).
 				Description("Whatever is specified within `inputs` will be created this many times.").
@@ -70,8 +97,26 @@ It is possible to configure [processors](/docs/components/processors/about) at t
 				Default(1),
 			service.NewInputListField(ibFieldInputs).
 				Description("A list of inputs to create."),
+			service.NewStringAnnotatedEnumField(ibFieldScheduling, map[string]string{
+				"none":            "Child inputs compete equally for delivery.",
+				"strict_priority": "Pending messages of the child input with the highest priority are always delivered first, children of equal priority take turns.",
+				"weighted_fair":   "Pending messages of child inputs are delivered in proportion to their priorities, which are treated as weights, such that no child is starved.",
+			}).
+				Description("The strategy used for choosing which child input to deliver messages from next when several have messages pending.").
+				Advanced().
+				Default("none").
+				Version("4.28.0"),
+			service.NewIntListField(ibFieldPriorities).
+				Description("A list of priorities, one for each of the `inputs` in the same order, used by the `scheduling` strategy. Higher values are given precedence.").
+				Advanced().
+				Example([]int{10, 1}).
+				Optional().
+				Version("4.28.0"),
 			service.NewBatchPolicyField("batching"),
-		)
+		).
+		LintRule(`root = if this.scheduling.or("none") != "none" && this.priorities.or([]).length() != this.inputs.or([]).length() {
+  "a priority must be specified for each input when scheduling is enabled"
+}`)
 }
 
 func init() {
@@ -120,7 +165,7 @@ func newBrokerInputFromParsed(conf *service.ParsedConfig, mgr *service.Resources
 				inputs = append(inputs, interop.UnwrapOwnedInput(v))
 			}
 		}
-		if b, err = newFanInInputBroker(inputs); err != nil {
+		if b, err = newFanInFromParsed(conf, mgr, len(children), inputs); err != nil {
 			return nil, err
 		}
 	}
@@ -142,3 +187,75 @@ func newBrokerInputFromParsed(conf *service.ParsedConfig, mgr *service.Resources
 	iBatcher := interop.UnwrapBatcher(pubBatcher)
 	return batcher.New(iBatcher, b, interop.UnwrapManagement(mgr).Logger()), nil
 }
+
+func newFanInFromParsed(conf *service.ParsedConfig, mgr *service.Resources, nChildren int, inputs []input.Streamed) (input.Streamed, error) {
+	schedulingStr, err := conf.FieldString(ibFieldScheduling)
+	if err != nil {
+		return nil, err
+	}
+
+	var scheduling priorityScheduling
+	switch schedulingStr {
+	case "none":
+		return newFanInInputBroker(inputs)
+	case "strict_priority":
+		scheduling = schedulingStrictPriority
+	case "weighted_fair":
+		scheduling = schedulingWeightedFair
+	default:
+		return nil, fmt.Errorf("scheduling strategy not recognised: %v", schedulingStr)
+	}
+
+	var priorities []int
+	if conf.Contains(ibFieldPriorities) {
+		if priorities, err = conf.FieldIntList(ibFieldPriorities); err != nil {
+			return nil, err
+		}
+	}
+	if len(priorities) != nChildren {
+		return nil, fmt.Errorf("expected %v priorities, one for each input, but received %v", nChildren, len(priorities))
+	}
+
+	names, err := brokerInputChildNames(conf, mgr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copies of the inputs share the priorities and names of the originals.
+	expandedPriorities := make([]int, 0, len(inputs))
+	expandedNames := make([]string, 0, len(inputs))
+	for len(expandedPriorities) < len(inputs) {
+		expandedPriorities = append(expandedPriorities, priorities...)
+		expandedNames = append(expandedNames, names...)
+	}
+	return newPriorityFanInInputBroker(inputs, expandedNames, expandedPriorities, scheduling, interop.UnwrapManagement(mgr).Metrics())
+}
+
+// brokerInputChildNames returns the label of each child input of a broker, or
+// the path of the child when it has no label.
+func brokerInputChildNames(conf *service.ParsedConfig, mgr *service.Resources) ([]string, error) {
+	childrenAny, err := conf.FieldAny(ibFieldInputs)
+	if err != nil {
+		return nil, err
+	}
+	children, ok := childrenAny.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value, expected array, got %T", childrenAny)
+	}
+
+	iMgr := interop.UnwrapManagement(mgr)
+	names := make([]string, len(children))
+	for i, c := range children {
+		childConf, err := input.FromAny(iMgr.Environment(), c)
+		if err != nil {
+			return nil, fmt.Errorf("value %v: %w", i, err)
+		}
+		if names[i] = childConf.Label; names[i] == "" {
+			path := make([]string, 0, len(iMgr.Path())+2)
+			path = append(path, iMgr.Path()...)
+			path = append(path, ibFieldInputs, strconv.Itoa(i))
+			names[i] = "root." + query.SliceToDotPath(path...)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,289 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/shutdown"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type priorityScheduling int
+
+const (
+	// Always deliver the pending transaction of the child with the highest
+	// priority.
+	schedulingStrictPriority priorityScheduling = iota
+
+	// Deliver pending transactions of children in proportion to their
+	// priorities, which are treated as weights.
+	schedulingWeightedFair
+)
+
+type priorityFanInChild struct {
+	input    input.Streamed
+	priority int
+	mDelay   metrics.StatTimer
+
+	// Protected by the mutex of the broker.
+	pending      *message.Transaction
+	pendingSince time.Time
+	current      int
+	closed       bool
+
+	takenChan chan struct{}
+}
+
+// priorityFanInInputBroker combines the transactions of multiple inputs where,
+// rather than each child competing equally for the output channel, the
+// transaction delivered next is chosen according to the priorities of the
+// children with transactions pending.
+type priorityFanInInputBroker struct {
+	scheduling priorityScheduling
+	children   []*priorityFanInChild
+
+	mut       sync.Mutex
+	lastIndex int
+	readyChan chan struct{}
+
+	transactions chan message.Transaction
+	shutSig      *shutdown.Signaller
+}
+
+// The names of the children are used to label their metrics, and are expected
+// to be the labels of the children or otherwise their paths.
+func newPriorityFanInInputBroker(inputs []input.Streamed, names []string, priorities []int, scheduling priorityScheduling, stats metrics.Type) (*priorityFanInInputBroker, error) {
+	if len(inputs) == 0 {
+		return nil, errors.New("fan in broker requires at least one input")
+	}
+	if len(priorities) != len(inputs) {
+		return nil, errors.New("fan in broker requires a priority for each input")
+	}
+	if len(names) != len(inputs) {
+		return nil, errors.New("fan in broker requires a name for each input")
+	}
+	if scheduling == schedulingWeightedFair {
+		for _, p := range priorities {
+			if p < 1 {
+				return nil, errors.New("weighted fair scheduling requires priorities greater than zero")
+			}
+		}
+	}
+
+	mDelay := stats.GetTimerVec("input_broker_queue_delay_ns", "child")
+
+	i := &priorityFanInInputBroker{
+		scheduling:   scheduling,
+		lastIndex:    -1,
+		readyChan:    make(chan struct{}, 1),
+		transactions: make(chan message.Transaction),
+		shutSig:      shutdown.NewSignaller(),
+	}
+	for n, in := range inputs {
+		i.children = append(i.children, &priorityFanInChild{
+			input:     in,
+			priority:  priorities[n],
+			mDelay:    mDelay.With(names[n]),
+			takenChan: make(chan struct{}, 1),
+		})
+	}
+
+	for _, c := range i.children {
+		go i.childLoop(c)
+	}
+	go i.loop()
+	return i, nil
+}
+
+func (i *priorityFanInInputBroker) notifyReady() {
+	select {
+	case i.readyChan <- struct{}{}:
+	default:
+	}
+}
+
+// childLoop reads transactions from a child input and places them as pending
+// one at a time, waiting for each to be taken by the scheduler.
+func (i *priorityFanInInputBroker) childLoop(c *priorityFanInChild) {
+	defer func() {
+		i.mut.Lock()
+		c.closed = true
+		i.mut.Unlock()
+		i.notifyReady()
+	}()
+
+	for {
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-c.input.TransactionChan():
+			if !open {
+				return
+			}
+		case <-i.shutSig.HardStopChan():
+			return
+		}
+
+		i.mut.Lock()
+		c.pending = &tran
+		c.pendingSince = time.Now()
+		i.mut.Unlock()
+		i.notifyReady()
+
+		select {
+		case <-c.takenChan:
+		case <-i.shutSig.HardStopChan():
+			return
+		}
+	}
+}
+
+// next returns the index of the child whose pending transaction should be
+// delivered next, or -1 if there are no pending transactions. Must be called
+// whilst holding the mutex.
+func (i *priorityFanInInputBroker) next() int {
+	chosen := -1
+	for n := range i.children {
+		// Start from the child after the last one chosen so that children of
+		// equal priority take turns.
+		index := (i.lastIndex + 1 + n) % len(i.children)
+		c := i.children[index]
+		if c.pending == nil {
+			continue
+		}
+		if chosen == -1 {
+			chosen = index
+			continue
+		}
+		switch i.scheduling {
+		case schedulingStrictPriority:
+			if c.priority > i.children[chosen].priority {
+				chosen = index
+			}
+		case schedulingWeightedFair:
+			if c.current+c.priority > i.children[chosen].current+i.children[chosen].priority {
+				chosen = index
+			}
+		}
+	}
+	return chosen
+}
+
+// taken updates the scheduling state once the pending transaction of a child
+// has been delivered. Must be called whilst holding the mutex.
+func (i *priorityFanInInputBroker) taken(index int) {
+	if i.scheduling == schedulingWeightedFair {
+		// Smooth weighted round-robin, where each child with a pending
+		// transaction accrues its weight, and the chosen child pays for its
+		// turn with the total weight of all contenders.
+		total := 0
+		for _, c := range i.children {
+			if c.pending != nil {
+				c.current += c.priority
+				total += c.priority
+			}
+		}
+		i.children[index].current -= total
+	}
+
+	c := i.children[index]
+	c.mDelay.Timing(time.Since(c.pendingSince).Nanoseconds())
+	c.pending = nil
+	i.lastIndex = index
+}
+
+func (i *priorityFanInInputBroker) loop() {
+	defer func() {
+		close(i.transactions)
+		i.shutSig.TriggerHasStopped()
+	}()
+
+	for {
+		i.mut.Lock()
+		index := i.next()
+		var tran message.Transaction
+		if index >= 0 {
+			tran = *i.children[index].pending
+		}
+		remaining := 0
+		for _, c := range i.children {
+			if !c.closed {
+				remaining++
+			}
+		}
+		i.mut.Unlock()
+
+		if index == -1 {
+			if remaining == 0 {
+				return
+			}
+			select {
+			case <-i.readyChan:
+			case <-i.shutSig.HardStopChan():
+				return
+			}
+			continue
+		}
+
+		select {
+		case i.transactions <- tran:
+			i.mut.Lock()
+			i.taken(index)
+			i.mut.Unlock()
+			i.children[index].takenChan <- struct{}{}
+		case <-i.readyChan:
+			// A new transaction is pending which might take precedence over
+			// the one we're attempting to deliver.
+		case <-i.shutSig.HardStopChan():
+			return
+		}
+	}
+}
+
+func (i *priorityFanInInputBroker) TransactionChan() <-chan message.Transaction {
+	return i.transactions
+}
+
+func (i *priorityFanInInputBroker) Connected() bool {
+	i.mut.Lock()
+	defer i.mut.Unlock()
+
+	remaining := 0
+	for _, c := range i.children {
+		if c.closed {
+			continue
+		}
+		remaining++
+		if !c.input.Connected() {
+			return false
+		}
+	}
+	return remaining > 0
+}
+
+func (i *priorityFanInInputBroker) TriggerStopConsuming() {
+	for _, c := range i.children {
+		c.input.TriggerStopConsuming()
+	}
+}
+
+func (i *priorityFanInInputBroker) TriggerCloseNow() {
+	for _, c := range i.children {
+		c.input.TriggerCloseNow()
+	}
+	i.shutSig.TriggerHardStop()
+}
+
+func (i *priorityFanInInputBroker) WaitForClose(ctx context.Context) error {
+	select {
+	case <-i.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
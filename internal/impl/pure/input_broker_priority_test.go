@@ -0,0 +1,134 @@
+package pure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+var _ input.Streamed = &priorityFanInInputBroker{}
+
+func TestPriorityFanInConfigErrors(t *testing.T) {
+	inputs := []input.Streamed{
+		&mock.Input{TChan: make(chan message.Transaction)},
+		&mock.Input{TChan: make(chan message.Transaction)},
+	}
+
+	_, err := newPriorityFanInInputBroker(inputs, []string{"a", "b"}, []int{1}, schedulingStrictPriority, metrics.Noop())
+	require.Error(t, err)
+
+	_, err = newPriorityFanInInputBroker(inputs, []string{"a", "b"}, []int{1, 0}, schedulingWeightedFair, metrics.Noop())
+	require.Error(t, err)
+}
+
+// Places a transaction as pending for each of the children directly, which
+// allows us to test the decisions of the scheduler deterministically.
+func testPriorityScheduleOrder(t *testing.T, scheduling priorityScheduling, priorities []int, rounds int) []int {
+	t.Helper()
+
+	i := &priorityFanInInputBroker{
+		scheduling: scheduling,
+		lastIndex:  -1,
+	}
+	for _, p := range priorities {
+		i.children = append(i.children, &priorityFanInChild{
+			priority: p,
+			mDelay:   metrics.Noop().GetTimer("delay"),
+		})
+	}
+
+	var order []int
+	for n := 0; n < rounds; n++ {
+		for _, c := range i.children {
+			if c.pending == nil {
+				c.pending = &message.Transaction{}
+			}
+		}
+		index := i.next()
+		require.GreaterOrEqual(t, index, 0)
+		i.taken(index)
+		order = append(order, index)
+	}
+	return order
+}
+
+func TestPriorityFanInStrictOrder(t *testing.T) {
+	assert.Equal(t, []int{1, 1, 1, 1}, testPriorityScheduleOrder(t, schedulingStrictPriority, []int{1, 5, 2}, 4))
+
+	// Children of equal priority take turns.
+	assert.Equal(t, []int{0, 2, 0, 2}, testPriorityScheduleOrder(t, schedulingStrictPriority, []int{5, 1, 5}, 4))
+}
+
+func TestPriorityFanInWeightedOrder(t *testing.T) {
+	assert.Equal(t, []int{0, 1, 0, 0, 0, 1, 0, 0}, testPriorityScheduleOrder(t, schedulingWeightedFair, []int{3, 1}, 8))
+	assert.Equal(t, []int{0, 1, 0, 1}, testPriorityScheduleOrder(t, schedulingWeightedFair, []int{1, 1}, 4))
+}
+
+func TestPriorityFanInDelivery(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	lowChan, highChan := make(chan message.Transaction), make(chan message.Transaction)
+	inputs := []input.Streamed{
+		&mock.Input{TChan: lowChan},
+		&mock.Input{TChan: highChan},
+	}
+
+	stats := metrics.NewLocal()
+	fanIn, err := newPriorityFanInInputBroker(inputs, []string{"low", "high"}, []int{1, 10}, schedulingStrictPriority, stats)
+	require.NoError(t, err)
+
+	resChan := make(chan error, 2)
+	send := func(c chan message.Transaction, content string) {
+		select {
+		case c <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(content)}), resChan):
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+
+	// Both transactions are pending before we begin reading, and therefore the
+	// high priority transaction is delivered first.
+	send(lowChan, "low")
+	send(highChan, "high")
+	require.Eventually(t, func() bool {
+		fanIn.mut.Lock()
+		defer fanIn.mut.Unlock()
+		return fanIn.children[0].pending != nil && fanIn.children[1].pending != nil
+	}, time.Second, time.Millisecond)
+
+	for _, exp := range []string{"high", "low"} {
+		select {
+		case tran, open := <-fanIn.TransactionChan():
+			require.True(t, open)
+			assert.Equal(t, exp, string(tran.Payload.Get(0).AsBytes()))
+			require.NoError(t, tran.Ack(ctx, nil))
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+
+	timings := stats.GetTimings()
+	assert.Contains(t, timings, `input_broker_queue_delay_ns{child="low"}`)
+	assert.Contains(t, timings, `input_broker_queue_delay_ns{child="high"}`)
+
+	assert.True(t, fanIn.Connected())
+	close(lowChan)
+	close(highChan)
+
+	select {
+	case _, open := <-fanIn.TransactionChan():
+		assert.False(t, open)
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+	require.NoError(t, fanIn.WaitForClose(ctx))
+}
@@ -2,12 +2,17 @@ package pure_test
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/testutil"
+	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
@@ -98,6 +103,49 @@ processors:
 				"meow HELLO WORLD 1\nHELLO WORLD 1\nHELLO WORLD 1 woof": 1,
 			},
 		},
+		{
+			name: "inputs with strict priority copies",
+			config: `
+broker:
+  copies: 2
+  scheduling: strict_priority
+  priorities: [ 2, 1 ]
+  inputs:
+    - generate:
+        count: 2
+        interval: ""
+        mapping: 'root = "hello world 1"'
+    - generate:
+        count: 1
+        interval: ""
+        mapping: 'root = "hello world 2"'
+`,
+			output: map[string]int{
+				"hello world 1": 4,
+				"hello world 2": 2,
+			},
+		},
+		{
+			name: "inputs with weighted fair scheduling",
+			config: `
+broker:
+  scheduling: weighted_fair
+  priorities: [ 3, 1 ]
+  inputs:
+    - generate:
+        count: 3
+        interval: ""
+        mapping: 'root = "hello world 1"'
+    - generate:
+        count: 3
+        interval: ""
+        mapping: 'root = "hello world 2"'
+`,
+			output: map[string]int{
+				"hello world 1": 3,
+				"hello world 2": 3,
+			},
+		},
 	} {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
@@ -123,3 +171,71 @@ processors:
 		})
 	}
 }
+
+func TestBrokerSchedulingLint(t *testing.T) {
+	builder := service.NewEnvironment().NewStreamBuilder()
+	require.ErrorContains(t, builder.AddInputYAML(`
+broker:
+  scheduling: strict_priority
+  priorities: [ 1 ]
+  inputs:
+    - generate:
+        mapping: 'root = "hello world 1"'
+    - generate:
+        mapping: 'root = "hello world 2"'
+`), "a priority must be specified for each input")
+}
+
+func TestBrokerSchedulingChildMetrics(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	conf, err := testutil.InputFromYAML(`
+broker:
+  scheduling: weighted_fair
+  priorities: [ 1, 2 ]
+  inputs:
+    - label: first
+      generate:
+        count: 1
+        interval: ""
+        mapping: 'root = "hello world 1"'
+    - generate:
+        count: 1
+        interval: ""
+        mapping: 'root = "hello world 2"'
+`)
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetMetrics(metrics.NewNamespaced(stats)))
+	require.NoError(t, err)
+
+	in, err := mgr.IntoPath("input").NewInput(conf)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case tran, open := <-in.TransactionChan():
+			require.True(t, open)
+			require.NoError(t, tran.Ack(ctx, nil))
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+
+	var delayKeys []string
+	for k := range stats.GetTimings() {
+		if strings.HasPrefix(k, "input_broker_queue_delay_ns") {
+			delayKeys = append(delayKeys, k)
+		}
+	}
+	sort.Strings(delayKeys)
+	assert.Equal(t, []string{
+		`input_broker_queue_delay_ns{child="first",label="",path="root.input"}`,
+		`input_broker_queue_delay_ns{child="root.input.inputs.1",label="",path="root.input"}`,
+	}, delayKeys)
+
+	in.TriggerCloseNow()
+	require.NoError(t, in.WaitForClose(ctx))
+}
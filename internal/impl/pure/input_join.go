@@ -0,0 +1,647 @@
+package pure
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/shutdown"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	jiFieldLeft            = "left"
+	jiFieldRight           = "right"
+	jiFieldSideInput       = "input"
+	jiFieldSideKey         = "key"
+	jiFieldSideTSMapping   = "timestamp_mapping"
+	jiFieldType            = "type"
+	jiFieldWindow          = "window"
+	jiFieldAllowedLateness = "allowed_lateness"
+	jiFieldMerge           = "merge"
+	jiFieldTypeInner       = "inner"
+	jiFieldTypeLeft        = "left"
+	jiFieldTypeFullOuter   = "full_outer"
+	jiDefaultTSMapping     = "root = now()"
+)
+
+func joinInputSideFields(name string) *service.ConfigField {
+	return service.NewObjectField(name,
+		service.NewInputField(jiFieldSideInput).
+			Description("The input to consume from."),
+		service.NewBloblangField(jiFieldSideKey).
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the key used to match messages of this input with messages of the other input. The result of the mapping is converted into a string.").
+			Example("root = this.impression_id").
+			Example(`root = meta("kafka_key")`),
+		service.NewBloblangField(jiFieldSideTSMapping).
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the timestamp of each message, which determines the window within which the message can be matched and how long it is held for. By default the processing time is used, whereas this mapping can instead extract a timestamp from the message itself (the event time). The timestamp value assigned to `root` must either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format.").
+			Default(jiDefaultTSMapping).
+			Example("root = this.created_at").
+			Example(`root = meta("kafka_timestamp_unix").number()`),
+	).Description(fmt.Sprintf("The %v side of the join.", name))
+}
+
+func joinInputSpec() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.28.0").
+		Categories("Windowing").
+		Summary("Consumes messages from two inputs and joins messages that share a common key and arrive within a window of time of each other.").
+		Description(`
+Each message consumed from either input is given a key by the `+"`key`"+` mapping of its side, and a timestamp by the `+"`timestamp_mapping`"+` of its side. A message is matched with every message of the other side that shares its key and has a timestamp within the `+"[`window`](#window)"+` duration of its own, and each matching pair is emitted as a single message produced by the `+"[`merge` mapping](#merge)"+`.
+
+Messages are held for the length of the window after their timestamp (plus any `+"[`allowed_lateness`](#allowed_lateness)"+`) following the system clock, after which they expire. When a message expires without having been matched it is either dropped or emitted on its own depending on the join `+"[`type`](#type)"+`:
+
+- `+"`inner`"+`: Unmatched messages are dropped.
+- `+"`left`"+`: Unmatched messages of the left input are emitted with a `+"`null`"+` right side, and unmatched messages of the right input are dropped.
+- `+"`full_outer`"+`: Unmatched messages of both inputs are emitted with a `+"`null`"+` for the missing side.
+
+### Merging
+
+The merge mapping is executed on a message where `+"`this.left`"+` is the structured contents of the left message and `+"`this.right`"+` is the structured contents of the right message, where a missing side is `+"`null`"+`. The metadata of both messages is carried over, where metadata of the left message takes precedence. If either the key or timestamp mapping fails for a message it is emitted on its own with the error flagged, and can be handled with [error handling patterns](/docs/configuration/error_handling).
+
+### Delivery Guarantees
+
+A message consumed from either input is only acknowledged once it has expired and every joined message it was part of has been delivered. During graceful termination messages that are still held are rejected, such that they are consumed again the next time the service starts, and therefore joined messages might be delivered more than once.
+
+Since messages are held in memory for the length of the window you should ensure that there is enough memory available for holding a window's worth of messages from both inputs.
+`).
+		Fields(
+			joinInputSideFields(jiFieldLeft),
+			joinInputSideFields(jiFieldRight),
+			service.NewStringEnumField(jiFieldType, jiFieldTypeInner, jiFieldTypeLeft, jiFieldTypeFullOuter).
+				Description("The type of join to perform, which determines what happens to messages that expire without being matched.").
+				Default(jiFieldTypeInner),
+			service.NewDurationField(jiFieldWindow).
+				Description("The maximum difference in time between the timestamps of two messages for them to be matched, which is also the length of time that messages are held for.").
+				Example("30s").Example("5m"),
+			service.NewDurationField(jiFieldAllowedLateness).
+				Description("An optional length of time to continue holding messages after their window has ended, allowing late arrivals to be matched. This is useful when using event time as messages of one input might be consumed later than messages of the other.").
+				Default("0s").
+				Example("10s").Example("1m"),
+			service.NewBloblangField(jiFieldMerge).
+				Description("A [Bloblang mapping](/docs/guides/bloblang/about) that produces a joined message from a message where `this.left` and `this.right` contain the contents of the matched messages.").
+				Default("root = this").
+				Example(`root = this.left.merge(this.right)`).
+				Example(`root = this.right.assign({"impression": this.left})`),
+		).
+		Example(
+			"Enriching Clicks with Impressions",
+			"Given a stream of ad impressions and a stream of clicks that refer to them, where a click usually follows its impression within a few minutes, we can emit clicks enriched with their impression. Clicks that don't match an impression within the window are emitted without one.",
+			`
+input:
+  join:
+    left:
+      input:
+        kafka_franz:
+          seed_brokers: [ TODO ]
+          topics: [ clicks ]
+          consumer_group: enrich_clicks
+      key: root = this.impression_id
+      timestamp_mapping: root = this.clicked_at
+    right:
+      input:
+        kafka_franz:
+          seed_brokers: [ TODO ]
+          topics: [ impressions ]
+          consumer_group: enrich_clicks
+      key: root = this.id
+      timestamp_mapping: root = this.shown_at
+    type: left
+    window: 5m
+    allowed_lateness: 30s
+    merge: |
+      root = this.left
+      root.impression = this.right
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"join", joinInputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			i, err := newJoinInputFromParsed(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksBatched(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type joinSideConfig struct {
+	input     *service.OwnedInput
+	key       *bloblang.Executor
+	tsMapping *bloblang.Executor
+}
+
+func joinSideFromParsed(conf *service.ParsedConfig) (s joinSideConfig, err error) {
+	if s.input, err = conf.FieldInput(jiFieldSideInput); err != nil {
+		return
+	}
+	if s.key, err = conf.FieldBloblang(jiFieldSideKey); err != nil {
+		return
+	}
+	s.tsMapping, err = conf.FieldBloblang(jiFieldSideTSMapping)
+	return
+}
+
+type joinSide int
+
+const (
+	joinSideLeft joinSide = iota
+	joinSideRight
+)
+
+func (s joinSide) String() string {
+	if s == joinSideLeft {
+		return jiFieldLeft
+	}
+	return jiFieldRight
+}
+
+// joinSource is a message consumed from one of the inputs, which is held until
+// it expires and is acknowledged once it has expired and all joined messages it
+// is part of have been acknowledged.
+type joinSource struct {
+	side     joinSide
+	msg      *service.Message
+	key      string
+	ts       time.Time
+	expireAt time.Time
+	matched  bool
+
+	// Index within the expiry heap.
+	index int
+
+	mut   sync.Mutex
+	refs  int
+	err   error
+	ackFn service.AckFunc
+}
+
+func (s *joinSource) retain() {
+	s.mut.Lock()
+	s.refs++
+	s.mut.Unlock()
+}
+
+func (s *joinSource) release(ctx context.Context, err error) {
+	s.mut.Lock()
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	s.refs--
+	if s.refs > 0 {
+		s.mut.Unlock()
+		return
+	}
+	err = s.err
+	s.mut.Unlock()
+	_ = s.ackFn(ctx, err)
+}
+
+// joinExpiryHeap orders held messages by the time at which they expire.
+type joinExpiryHeap []*joinSource
+
+func (h joinExpiryHeap) Len() int           { return len(h) }
+func (h joinExpiryHeap) Less(i, j int) bool { return h[i].expireAt.Before(h[j].expireAt) }
+
+func (h joinExpiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *joinExpiryHeap) Push(x any) {
+	s := x.(*joinSource)
+	s.index = len(*h)
+	*h = append(*h, s)
+}
+
+func (h *joinExpiryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	s := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return s
+}
+
+type joinBatch struct {
+	b     service.MessageBatch
+	ackFn service.AckFunc
+}
+
+type joinRead struct {
+	side  joinSide
+	b     service.MessageBatch
+	ackFn service.AckFunc
+}
+
+var errJoinClosed = errors.New("message rejected as the join input was closed before it expired")
+
+type joinInput struct {
+	log *service.Logger
+
+	sides           [2]joinSideConfig
+	joinType        string
+	window          time.Duration
+	allowedLateness time.Duration
+	merge           *bloblang.Executor
+	clock           utcNowProvider
+
+	held    [2]map[string][]*joinSource
+	expiry  joinExpiryHeap
+	readsIn chan joinRead
+	joined  chan joinBatch
+
+	connectOnce sync.Once
+	shutSig     *shutdown.Signaller
+}
+
+func newJoinInputFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*joinInput, error) {
+	j := &joinInput{
+		log: mgr.Logger(),
+		clock: func() time.Time {
+			return time.Now().UTC()
+		},
+		held:    [2]map[string][]*joinSource{{}, {}},
+		readsIn: make(chan joinRead),
+		joined:  make(chan joinBatch),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if j.sides[joinSideLeft], err = joinSideFromParsed(conf.Namespace(jiFieldLeft)); err != nil {
+		return nil, err
+	}
+	if j.sides[joinSideRight], err = joinSideFromParsed(conf.Namespace(jiFieldRight)); err != nil {
+		return nil, err
+	}
+	if j.joinType, err = conf.FieldString(jiFieldType); err != nil {
+		return nil, err
+	}
+	if j.window, err = conf.FieldDuration(jiFieldWindow); err != nil {
+		return nil, err
+	}
+	if j.window <= 0 {
+		return nil, fmt.Errorf("invalid window '%v' must be greater than zero", j.window)
+	}
+	if j.allowedLateness, err = conf.FieldDuration(jiFieldAllowedLateness); err != nil {
+		return nil, err
+	}
+	if j.merge, err = conf.FieldBloblang(jiFieldMerge); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (j *joinInput) Connect(ctx context.Context) error {
+	j.connectOnce.Do(func() {
+		var readersWG sync.WaitGroup
+		readersWG.Add(2)
+		for _, side := range []joinSide{joinSideLeft, joinSideRight} {
+			go func(side joinSide) {
+				defer readersWG.Done()
+				j.readLoop(side)
+			}(side)
+		}
+
+		readersDone := make(chan struct{})
+		go func() {
+			readersWG.Wait()
+			close(readersDone)
+		}()
+		go j.joinLoop(readersDone)
+	})
+	return nil
+}
+
+func (j *joinInput) readLoop(side joinSide) {
+	ctx, done := j.shutSig.SoftStopCtx(context.Background())
+	defer done()
+
+	in := j.sides[side].input
+	for {
+		b, ackFn, err := in.ReadBatch(ctx)
+		if err != nil {
+			if errors.Is(err, service.ErrEndOfInput) || ctx.Err() != nil {
+				return
+			}
+			j.log.Errorf("Failed to read from %v input: %v", side, err)
+			continue
+		}
+		select {
+		case j.readsIn <- joinRead{side: side, b: b, ackFn: ackFn}:
+		case <-ctx.Done():
+			_ = ackFn(context.Background(), errJoinClosed)
+			return
+		}
+	}
+}
+
+func (j *joinInput) joinLoop(readersDone <-chan struct{}) {
+	ctx, done := j.shutSig.HardStopCtx(context.Background())
+	defer done()
+
+	defer func() {
+		// Held messages that haven't expired are rejected so that they're
+		// consumed again.
+		for _, s := range j.expiry {
+			j.removeHeld(s)
+			s.release(ctx, errJoinClosed)
+		}
+		j.expiry = nil
+
+		for _, side := range j.sides {
+			_ = side.input.Close(ctx)
+		}
+		close(j.joined)
+		j.shutSig.TriggerHasStopped()
+	}()
+
+	for {
+		var expiryTimer *time.Timer
+		var expiryChan <-chan time.Time
+		if len(j.expiry) > 0 {
+			expiryTimer = time.NewTimer(j.expiry[0].expireAt.Sub(j.clock()))
+			expiryChan = expiryTimer.C
+		}
+
+		open := true
+		select {
+		case r := <-j.readsIn:
+			open = j.add(ctx, r)
+		case <-expiryChan:
+			open = j.expire(ctx, j.clock())
+		case <-readersDone:
+			// Inputs only finish once all of their messages are acknowledged,
+			// and therefore nothing remains held at this point.
+			open = false
+		case <-j.shutSig.SoftStopChan():
+			open = false
+		}
+
+		if expiryTimer != nil {
+			expiryTimer.Stop()
+		}
+		if !open {
+			return
+		}
+	}
+}
+
+// add registers the messages of a batch consumed from one of the inputs,
+// emitting any joined messages that result. Returns false if the input is
+// shutting down.
+func (j *joinInput) add(ctx context.Context, r joinRead) bool {
+	now := j.clock()
+	side := j.sides[r.side]
+
+	// All ack funcs are derived before any message is released, otherwise a
+	// message released early (such as one that arrives too late to be held)
+	// would acknowledge the whole batch before the rest are registered.
+	acker := batch.NewCombinedAcker(batch.AckFunc(r.ackFn))
+	ackFns := make([]service.AckFunc, len(r.b))
+	for i := range ackFns {
+		ackFns[i] = service.AckFunc(acker.Derive())
+	}
+	rejectFrom := func(i int) {
+		for _, fn := range ackFns[i:] {
+			_ = fn(ctx, errJoinClosed)
+		}
+	}
+
+	for i, msg := range r.b {
+		s := &joinSource{
+			side:  r.side,
+			msg:   msg,
+			refs:  1,
+			ackFn: ackFns[i],
+		}
+
+		var err error
+		if s.ts, err = mappedTimestamp(side.tsMapping, i, r.b); err != nil {
+			err = fmt.Errorf("%v %w", r.side, err)
+		} else {
+			s.key, err = mappedKey(side.key, i, r.b)
+		}
+		if err != nil {
+			j.log.Errorf("Unable to join message: %v", err)
+			flagged := msg.Copy()
+			flagged.SetError(err)
+			if !j.emit(ctx, []*joinSource{s}, service.MessageBatch{flagged}) {
+				s.release(ctx, errJoinClosed)
+				rejectFrom(i + 1)
+				return false
+			}
+			s.release(ctx, nil)
+			continue
+		}
+		s.expireAt = s.ts.Add(j.window + j.allowedLateness)
+
+		var pairs []*joinSource
+		var pairBatch service.MessageBatch
+		for _, other := range j.held[1-r.side][s.key] {
+			diff := s.ts.Sub(other.ts)
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff > j.window {
+				continue
+			}
+
+			s.matched, other.matched = true, true
+			left, right := s, other
+			if r.side == joinSideRight {
+				left, right = other, s
+			}
+			if joinedMsg := j.mergeMessages(left, right); joinedMsg != nil {
+				pairs = append(pairs, left, right)
+				pairBatch = append(pairBatch, joinedMsg)
+			}
+		}
+		if len(pairBatch) > 0 {
+			if !j.emit(ctx, pairs, pairBatch) {
+				s.release(ctx, errJoinClosed)
+				rejectFrom(i + 1)
+				return false
+			}
+		}
+
+		if !s.expireAt.After(now) {
+			if !j.expireSource(ctx, s) {
+				rejectFrom(i + 1)
+				return false
+			}
+			continue
+		}
+		j.held[r.side][s.key] = append(j.held[r.side][s.key], s)
+		heap.Push(&j.expiry, s)
+	}
+	return true
+}
+
+// expire all held messages that expire before or at a given time. Returns false
+// if the input is shutting down.
+func (j *joinInput) expire(ctx context.Context, t time.Time) bool {
+	for len(j.expiry) > 0 {
+		s := j.expiry[0]
+		if s.expireAt.After(t) {
+			return true
+		}
+		heap.Pop(&j.expiry)
+		j.removeHeld(s)
+		if !j.expireSource(ctx, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func (j *joinInput) removeHeld(s *joinSource) {
+	sources := j.held[s.side][s.key]
+	for i, other := range sources {
+		if other == s {
+			sources = append(sources[:i], sources[i+1:]...)
+			break
+		}
+	}
+	if len(sources) == 0 {
+		delete(j.held[s.side], s.key)
+	} else {
+		j.held[s.side][s.key] = sources
+	}
+}
+
+// expireSource releases a message that will no longer be matched, emitting it
+// on its own if it was never matched and the join type calls for it.
+func (j *joinInput) expireSource(ctx context.Context, s *joinSource) bool {
+	emitUnmatched := !s.matched && (j.joinType == jiFieldTypeFullOuter ||
+		(j.joinType == jiFieldTypeLeft && s.side == joinSideLeft))
+	if emitUnmatched {
+		var joinedMsg *service.Message
+		if s.side == joinSideLeft {
+			joinedMsg = j.mergeMessages(s, nil)
+		} else {
+			joinedMsg = j.mergeMessages(nil, s)
+		}
+		if joinedMsg != nil {
+			if !j.emit(ctx, []*joinSource{s}, service.MessageBatch{joinedMsg}) {
+				s.release(ctx, errJoinClosed)
+				return false
+			}
+		}
+	}
+	s.release(ctx, nil)
+	return true
+}
+
+func joinStructured(s *joinSource) any {
+	if s == nil {
+		return nil
+	}
+	v, err := s.msg.AsStructured()
+	if err != nil {
+		b, _ := s.msg.AsBytes()
+		return string(b)
+	}
+	return v
+}
+
+// mergeMessages creates a joined message from a left and right message, either
+// of which may be nil. Returns nil if the merge mapping deleted the message.
+func (j *joinInput) mergeMessages(left, right *joinSource) *service.Message {
+	joinedMsg := service.NewMessage(nil)
+	for _, s := range []*joinSource{right, left} {
+		if s == nil {
+			continue
+		}
+		_ = s.msg.MetaWalkMut(func(k string, v any) error {
+			joinedMsg.MetaSetMut(k, v)
+			return nil
+		})
+	}
+	joinedMsg.SetStructuredMut(map[string]any{
+		jiFieldLeft:  joinStructured(left),
+		jiFieldRight: joinStructured(right),
+	})
+
+	res, err := joinedMsg.BloblangQuery(j.merge)
+	if err != nil {
+		j.log.Errorf("Merge mapping failed: %v", err)
+		joinedMsg.SetError(fmt.Errorf("merge mapping failed: %w", err))
+		return joinedMsg
+	}
+	return res
+}
+
+// emit a batch of joined messages, where each message source is retained until
+// the batch is acknowledged.
+func (j *joinInput) emit(ctx context.Context, sources []*joinSource, b service.MessageBatch) bool {
+	for _, s := range sources {
+		s.retain()
+	}
+	select {
+	case j.joined <- joinBatch{
+		b: b,
+		ackFn: func(ctx context.Context, err error) error {
+			for _, s := range sources {
+				s.release(ctx, err)
+			}
+			return nil
+		},
+	}:
+		return true
+	case <-j.shutSig.SoftStopChan():
+	case <-ctx.Done():
+	}
+	for _, s := range sources {
+		s.release(ctx, errJoinClosed)
+	}
+	return false
+}
+
+func (j *joinInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	select {
+	case jb, open := <-j.joined:
+		if !open {
+			return nil, nil, service.ErrEndOfInput
+		}
+		return jb.b, jb.ackFn, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (j *joinInput) Close(ctx context.Context) error {
+	j.shutSig.TriggerSoftStop()
+	j.connectOnce.Do(func() {
+		// Never connected, so nothing to wait for besides the child inputs.
+		for _, side := range j.sides {
+			_ = side.input.Close(ctx)
+		}
+		j.shutSig.TriggerHasStopped()
+	})
+	select {
+	case <-j.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		j.shutSig.TriggerHardStop()
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package pure
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func runJoinInput(t *testing.T, conf string) []string {
+	t.Helper()
+
+	builder := service.NewStreamBuilder()
+	require.NoError(t, builder.AddInputYAML(conf))
+	require.NoError(t, builder.SetLoggerYAML(`level: none`))
+
+	var resMut sync.Mutex
+	var results []string
+	require.NoError(t, builder.AddConsumerFunc(func(ctx context.Context, msg *service.Message) error {
+		mBytes, err := msg.AsBytes()
+		require.NoError(t, err)
+
+		res := string(mBytes)
+		if err := msg.GetError(); err != nil {
+			res = "error: " + err.Error()
+		}
+
+		resMut.Lock()
+		results = append(results, res)
+		resMut.Unlock()
+		return nil
+	}))
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+	require.NoError(t, strm.Run(ctx))
+
+	resMut.Lock()
+	defer resMut.Unlock()
+	sort.Strings(results)
+	return results
+}
+
+func joinInputConf(joinType, leftMapping, rightMapping string) string {
+	return `
+join:
+  type: ` + joinType + `
+  window: 500ms
+  left:
+    input:
+      generate:
+        count: 3
+        interval: ""
+        mapping: '` + leftMapping + `'
+    key: root = this.id
+  right:
+    input:
+      generate:
+        count: 3
+        interval: ""
+        mapping: '` + rightMapping + `'
+    key: root = this.id
+  merge: |
+    root.id = (this.left | this.right).id
+    root.left = this.left.side
+    root.right = this.right.side
+`
+}
+
+func TestJoinInputInner(t *testing.T) {
+	results := runJoinInput(t, joinInputConf(
+		"inner",
+		`root = {"id":count("join_inner_left"),"side":"l"}`,
+		`root = {"id":count("join_inner_right")+1,"side":"r"}`,
+	))
+	assert.Equal(t, []string{
+		`{"id":2,"left":"l","right":"r"}`,
+		`{"id":3,"left":"l","right":"r"}`,
+	}, results)
+}
+
+func TestJoinInputLeft(t *testing.T) {
+	results := runJoinInput(t, joinInputConf(
+		"left",
+		`root = {"id":count("join_left_left"),"side":"l"}`,
+		`root = {"id":count("join_left_right")+1,"side":"r"}`,
+	))
+	assert.Equal(t, []string{
+		`{"id":1,"left":"l","right":null}`,
+		`{"id":2,"left":"l","right":"r"}`,
+		`{"id":3,"left":"l","right":"r"}`,
+	}, results)
+}
+
+func TestJoinInputFullOuter(t *testing.T) {
+	results := runJoinInput(t, joinInputConf(
+		"full_outer",
+		`root = {"id":count("join_outer_left"),"side":"l"}`,
+		`root = {"id":count("join_outer_right")+1,"side":"r"}`,
+	))
+	assert.Equal(t, []string{
+		`{"id":1,"left":"l","right":null}`,
+		`{"id":2,"left":"l","right":"r"}`,
+		`{"id":3,"left":"l","right":"r"}`,
+		`{"id":4,"left":null,"right":"r"}`,
+	}, results)
+}
+
+func TestJoinInputManyToMany(t *testing.T) {
+	results := runJoinInput(t, joinInputConf(
+		"inner",
+		`root = {"id":"foo","side":"l" + count("join_many_left").string()}`,
+		`root = {"id":"foo","side":"r" + count("join_many_right").string()}`,
+	))
+	assert.Len(t, results, 9)
+	assert.Contains(t, results, `{"id":"foo","left":"l1","right":"r3"}`)
+	assert.Contains(t, results, `{"id":"foo","left":"l3","right":"r1"}`)
+}
+
+func TestJoinInputEventTime(t *testing.T) {
+	results := runJoinInput(t, `
+join:
+  type: full_outer
+  window: 1s
+  left:
+    input:
+      generate:
+        count: 2
+        interval: ""
+        mapping: |
+          let n = count("join_event_left")
+          root.id = "foo"
+          root.n = "l" + $n.string()
+          root.ts = now().ts_unix_nano() - (($n - 1) * 5000000000)
+    key: root = this.id
+    timestamp_mapping: root = this.ts / 1000000000
+  right:
+    input:
+      generate:
+        count: 1
+        interval: ""
+        mapping: 'root = {"id":"foo","n":"r1","ts":now().ts_unix_nano()}'
+    key: root = this.id
+    timestamp_mapping: root = this.ts / 1000000000
+  merge: root = [ this.left.n, this.right.n ]
+`)
+	assert.Equal(t, []string{
+		`["l1","r1"]`,
+		`["l2",null]`,
+	}, results)
+}
+
+func TestJoinInputKeyError(t *testing.T) {
+	results := runJoinInput(t, `
+join:
+  type: inner
+  window: 500ms
+  left:
+    input:
+      generate:
+        count: 1
+        interval: ""
+        mapping: 'root = {"id":"foo"}'
+    key: root = this.nope.uppercase()
+  right:
+    input:
+      generate:
+        count: 1
+        interval: ""
+        mapping: 'root = {"id":"foo"}'
+    key: root = this.id
+`)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0], "error: key mapping failed")
+}
+
+func TestJoinInputBadWindow(t *testing.T) {
+	builder := service.NewStreamBuilder()
+	require.NoError(t, builder.AddInputYAML(`
+join:
+  window: 0s
+  left:
+    input:
+      generate:
+        mapping: 'root = {}'
+    key: root = this.id
+  right:
+    input:
+      generate:
+        mapping: 'root = {}'
+    key: root = this.id
+`))
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	err = strm.Run(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be greater than zero")
+}
+
+func TestJoinInputLateMessageInBatch(t *testing.T) {
+	conf, err := joinInputSpec().ParseYAML(`
+type: inner
+window: 1s
+left:
+  input:
+    generate:
+      mapping: 'root = {}'
+  key: root = this.id
+  timestamp_mapping: root = this.ts
+right:
+  input:
+    generate:
+      mapping: 'root = {}'
+  key: root = this.id
+  timestamp_mapping: root = this.ts
+`, nil)
+	require.NoError(t, err)
+
+	j, err := newJoinInputFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	now := time.Unix(1000, 0).UTC()
+	j.clock = func() time.Time { return now }
+
+	var ackMut sync.Mutex
+	var acks []error
+	ackFn := func(ctx context.Context, err error) error {
+		ackMut.Lock()
+		acks = append(acks, err)
+		ackMut.Unlock()
+		return nil
+	}
+	getAcks := func() []error {
+		ackMut.Lock()
+		defer ackMut.Unlock()
+		return append([]error(nil), acks...)
+	}
+
+	// The first message is too late to be held and is released immediately,
+	// which must not acknowledge the batch whilst the second is still held.
+	require.True(t, j.add(context.Background(), joinRead{
+		side: joinSideLeft,
+		b: service.MessageBatch{
+			service.NewMessage([]byte(`{"id":"foo","ts":900}`)),
+			service.NewMessage([]byte(`{"id":"bar","ts":1000}`)),
+		},
+		ackFn: ackFn,
+	}))
+	assert.Empty(t, getAcks())
+
+	require.True(t, j.expire(context.Background(), now.Add(time.Second)))
+	assert.Equal(t, []error{nil}, getAcks())
+}
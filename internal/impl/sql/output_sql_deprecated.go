@@ -1,12 +1,15 @@
 package sql
 
 import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component/interop"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 func sqlDeprecatedOutputConfig() *service.ConfigSpec {
-	return service.NewConfigSpec().
+	spec := service.NewConfigSpec().
 		Deprecated().
 		Categories("Services").
 		Summary("Executes an arbitrary SQL query for each message.").
@@ -28,6 +31,9 @@ For basic inserts use the ` + "[`sql_insert`](/docs/components/outputs/sql)" + `
 			Default(64)).
 		Field(service.NewBatchPolicyField("batching")).
 		Version("3.65.0")
+	return interop.WithFixerFunc(spec, func(component *yaml.Node) (string, error) {
+		return fixDeprecatedSQLComponent(component, nil)
+	})
 }
 
 func init() {
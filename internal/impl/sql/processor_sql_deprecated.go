@@ -1,13 +1,16 @@
 package sql
 
 import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component/interop"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 // DeprecatedProcessorConfig returns a config spec for an sql processor.
 func DeprecatedProcessorConfig() *service.ConfigSpec {
-	return service.NewConfigSpec().
+	spec := service.NewConfigSpec().
 		Deprecated().
 		Categories("Integration").
 		Summary("Runs an arbitrary SQL query against a database and (optionally) returns the result as an array of objects, one for each row returned.").
@@ -35,6 +38,31 @@ For basic inserts or select queries use either the ` + "[`sql_insert`](/docs/com
 			Default("none")).
 		Version("3.65.0")
 	// TODO: Add example
+	return interop.WithFixerFunc(spec, func(component *yaml.Node) (string, error) {
+		return fixDeprecatedSQLComponent(component, fixDeprecatedProcessorResultCodec)
+	})
+}
+
+// fixDeprecatedProcessorResultCodec replaces the result_codec field with the
+// equivalent exec_only field of the sql_raw processor.
+func fixDeprecatedProcessorResultCodec(conf *yaml.Node) {
+	execOnly := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"}
+	for i := 0; i < len(conf.Content)-1; i += 2 {
+		if conf.Content[i].Value != "result_codec" {
+			continue
+		}
+		if conf.Content[i+1].Value != "none" {
+			conf.Content = append(conf.Content[:i], conf.Content[i+2:]...)
+			return
+		}
+		conf.Content[i].Value = "exec_only"
+		conf.Content[i+1] = execOnly
+		return
+	}
+	conf.Content = append(conf.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "exec_only"},
+		execOnly,
+	)
 }
 
 func init() {
@@ -0,0 +1,94 @@
+package sql_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/sql"
+)
+
+func TestSQLDeprecatedFix(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		cType  docs.Type
+		input  string
+		output string
+	}{
+		{
+			name:  "processor without results",
+			cType: docs.TypeProcessor,
+			input: `sql:
+  driver: postgres # the driver
+  data_source_name: postgres://foo
+  query: INSERT INTO footable (foo) VALUES (?);
+  args_mapping: root = [ this.foo ]
+`,
+			output: `sql_raw:
+  driver: postgres # the driver
+  dsn: postgres://foo
+  query: INSERT INTO footable (foo) VALUES (?);
+  args_mapping: root = [ this.foo ]
+  exec_only: true
+`,
+		},
+		{
+			name:  "processor with results",
+			cType: docs.TypeProcessor,
+			input: `label: foo
+type: sql
+sql:
+  driver: postgres
+  data_source_name: postgres://foo
+  query: SELECT * FROM footable;
+  result_codec: json_array
+`,
+			output: `label: foo
+type: sql_raw
+sql_raw:
+  driver: postgres
+  dsn: postgres://foo
+  query: SELECT * FROM footable;
+`,
+		},
+		{
+			name:  "output",
+			cType: docs.TypeOutput,
+			input: `sql:
+  driver: postgres
+  data_source_name: postgres://foo
+  query: INSERT INTO footable (foo) VALUES (?);
+  max_in_flight: 10
+`,
+			output: `sql_raw:
+  driver: postgres
+  dsn: postgres://foo
+  query: INSERT INTO footable (foo) VALUES (?);
+  max_in_flight: 10
+`,
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.input), &node))
+
+			lints, err := docs.FixYAML(docs.NewLintContext(docs.NewLintConfig(bundle.GlobalEnvironment)), test.cType, &node)
+			require.NoError(t, err)
+			require.Len(t, lints, 1)
+			assert.Equal(t, "component sql is deprecated, replaced with an equivalent sql_raw", lints[0].What)
+
+			var buf bytes.Buffer
+			enc := yaml.NewEncoder(&buf)
+			enc.SetIndent(2)
+			require.NoError(t, enc.Encode(&node))
+			assert.Equal(t, test.output, buf.String())
+		})
+	}
+}
@@ -2,8 +2,43 @@ package sql
 
 import (
 	"database/sql"
+
+	"gopkg.in/yaml.v3"
 )
 
+// fixDeprecatedSQLComponent rewrites a deprecated sql component config into the
+// equivalent sql_raw component, the fixConf func is called with the config of
+// the component in order to rewrite any fields that differ.
+func fixDeprecatedSQLComponent(component *yaml.Node, fixConf func(conf *yaml.Node)) (string, error) {
+	fixed := false
+	for i := 0; i < len(component.Content)-1; i += 2 {
+		keyNode, valueNode := component.Content[i], component.Content[i+1]
+		switch {
+		case keyNode.Value == "type" && valueNode.Value == "sql":
+			valueNode.Value = "sql_raw"
+			fixed = true
+		case keyNode.Value == "sql":
+			keyNode.Value = "sql_raw"
+			fixed = true
+			if valueNode.Kind != yaml.MappingNode {
+				continue
+			}
+			for j := 0; j < len(valueNode.Content)-1; j += 2 {
+				if valueNode.Content[j].Value == "data_source_name" {
+					valueNode.Content[j].Value = "dsn"
+				}
+			}
+			if fixConf != nil {
+				fixConf(valueNode)
+			}
+		}
+	}
+	if !fixed {
+		return "", nil
+	}
+	return "component sql is deprecated, replaced with an equivalent sql_raw", nil
+}
+
 func sqlRowsToArray(rows *sql.Rows) ([]any, error) {
 	columnNames, err := rows.Columns()
 	if err != nil {
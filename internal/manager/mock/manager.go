@@ -8,6 +8,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/noop"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -22,6 +23,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/state"
 )
 
 // Manager provides a mock benthos manager that components can use to test
@@ -35,6 +37,7 @@ type Manager struct {
 	Outputs    map[string]OutputWriter
 	Processors map[string]Processor
 	Pipes      map[string]<-chan message.Transaction
+	Staging    *state.Staging
 	lock       sync.Mutex
 
 	// OnRegisterEndpoint can be set in order to intercept endpoints registered
@@ -57,6 +60,7 @@ func NewManager() *Manager {
 		Outputs:    map[string]OutputWriter{},
 		Processors: map[string]Processor{},
 		Pipes:      map[string]<-chan message.Transaction{},
+		Staging:    state.NewStaging(log.Noop()),
 		CustomFS:   ifs.OS(),
 		M:          metrics.Noop(),
 		L:          log.Noop(),
@@ -68,6 +72,12 @@ func (m *Manager) EngineVersion() string {
 	return m.Version
 }
 
+// StateStaging returns the mutations of state staged by components using the
+// mock manager that are yet to be committed.
+func (m *Manager) StateStaging() *state.Staging {
+	return m.Staging
+}
+
 // ForStream returns the same mock manager.
 func (m *Manager) ForStream(id string) bundle.NewManagement { return m }
 
@@ -153,7 +163,7 @@ func (m *Manager) Logger() log.Modular { return m.L }
 func (m *Manager) Tracer() trace.TracerProvider { return m.T }
 
 // RegisterEndpoint registers a server wide HTTP endpoint.
-func (m *Manager) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
+func (m *Manager) RegisterEndpoint(path, desc string, h http.HandlerFunc, ops ...api.OperationSpec) {
 	if m.OnRegisterEndpoint != nil {
 		m.OnRegisterEndpoint(path, h)
 	}
@@ -0,0 +1,150 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/health"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// Resources that do not have a connection lifecycle visible to the manager are
+// wrapped in order to track their health based on the outcome of each
+// operation. Inputs and outputs track their own health.
+
+type healthProcessor struct {
+	processor.V1
+	health *health.Tracker
+}
+
+func wrapProcessorHealth(p processor.V1, tracker *health.Tracker) processor.V1 {
+	if p == nil {
+		return nil
+	}
+	tracker.SetConnected()
+	tracker.Open()
+	return &healthProcessor{V1: p, health: tracker}
+}
+
+func (h *healthProcessor) ProcessBatch(ctx context.Context, b message.Batch) ([]message.Batch, error) {
+	batches, err := h.V1.ProcessBatch(ctx, b)
+	if err != nil {
+		h.health.Failed(err)
+		return batches, err
+	}
+	for _, batch := range batches {
+		for _, p := range batch {
+			if pErr := p.ErrorGet(); pErr != nil {
+				h.health.Failed(pErr)
+				return batches, nil
+			}
+		}
+	}
+	h.health.Succeeded()
+	return batches, nil
+}
+
+// UnwrapProc returns the underlying processor.
+func (h *healthProcessor) UnwrapProc() processor.V1 {
+	return h.V1
+}
+
+func (h *healthProcessor) Close(ctx context.Context) error {
+	h.health.Close()
+	return h.V1.Close(ctx)
+}
+
+//------------------------------------------------------------------------------
+
+type healthCache struct {
+	cache.V1
+	health *health.Tracker
+}
+
+func wrapCacheHealth(c cache.V1, tracker *health.Tracker) cache.V1 {
+	if c == nil {
+		return nil
+	}
+	tracker.SetConnected()
+	tracker.Open()
+	return &healthCache{V1: c, health: tracker}
+}
+
+func (h *healthCache) record(err error) {
+	if err == nil || errors.Is(err, component.ErrKeyNotFound) || errors.Is(err, component.ErrKeyAlreadyExists) {
+		h.health.Succeeded()
+		return
+	}
+	h.health.Failed(err)
+}
+
+func (h *healthCache) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := h.V1.Get(ctx, key)
+	h.record(err)
+	return b, err
+}
+
+func (h *healthCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	err := h.V1.Set(ctx, key, value, ttl)
+	h.record(err)
+	return err
+}
+
+func (h *healthCache) SetMulti(ctx context.Context, items map[string]cache.TTLItem) error {
+	err := h.V1.SetMulti(ctx, items)
+	h.record(err)
+	return err
+}
+
+func (h *healthCache) Add(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	err := h.V1.Add(ctx, key, value, ttl)
+	h.record(err)
+	return err
+}
+
+func (h *healthCache) Delete(ctx context.Context, key string) error {
+	err := h.V1.Delete(ctx, key)
+	h.record(err)
+	return err
+}
+
+func (h *healthCache) Close(ctx context.Context) error {
+	h.health.Close()
+	return h.V1.Close(ctx)
+}
+
+//------------------------------------------------------------------------------
+
+type healthRateLimit struct {
+	ratelimit.V1
+	health *health.Tracker
+}
+
+func wrapRateLimitHealth(r ratelimit.V1, tracker *health.Tracker) ratelimit.V1 {
+	if r == nil {
+		return nil
+	}
+	tracker.SetConnected()
+	tracker.Open()
+	return &healthRateLimit{V1: r, health: tracker}
+}
+
+func (h *healthRateLimit) Access(ctx context.Context) (time.Duration, error) {
+	d, err := h.V1.Access(ctx)
+	if err != nil {
+		h.health.Failed(err)
+	} else {
+		h.health.Succeeded()
+	}
+	return d, err
+}
+
+func (h *healthRateLimit) Close(ctx context.Context) error {
+	h.health.Close()
+	return h.V1.Close(ctx)
+}
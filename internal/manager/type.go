@@ -10,23 +10,27 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/noop"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/buffer"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/health"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
 	"github.com/benthosdev/benthos/v4/internal/component/scanner"
+	"github.com/benthosdev/benthos/v4/internal/component/tap"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/state"
 )
 
 // ErrResourceNotFound represents an error where a named resource could not be
@@ -42,7 +46,7 @@ func (e ErrResourceNotFound) Error() string {
 
 // APIReg is an interface representing an API builder.
 type APIReg interface {
-	RegisterEndpoint(path, desc string, h http.HandlerFunc)
+	RegisterEndpoint(path, desc string, h http.HandlerFunc, ops ...api.OperationSpec)
 }
 
 //------------------------------------------------------------------------------
@@ -89,6 +93,10 @@ type Type struct {
 	logger log.Modular
 	stats  *metrics.Namespaced
 	tracer trace.TracerProvider
+	taps   *tap.Registry
+	health *health.Registry
+
+	stateStaging *state.Staging
 
 	pipes    map[string]<-chan message.Transaction
 	pipeLock *sync.RWMutex
@@ -199,6 +207,8 @@ func New(conf ResourceConfig, opts ...OptFunc) (*Type, error) {
 		logger: log.Noop(),
 		stats:  metrics.Noop(),
 		tracer: noop.NewTracerProvider(),
+		taps:   tap.NewRegistry(),
+		health: health.NewRegistry(),
 
 		fs: ifs.OS(),
 
@@ -210,6 +220,13 @@ func New(conf ResourceConfig, opts ...OptFunc) (*Type, error) {
 		opt(t)
 	}
 
+	t.stateStaging = state.NewStaging(t.logger)
+
+	var err error
+	if t.bloblEnv, err = state.BindFunctions(t.bloblEnv, t); err != nil {
+		return nil, err
+	}
+
 	seen := map[string]struct{}{}
 
 	checkLabel := func(typeStr, label string) error {
@@ -315,6 +332,7 @@ func (t *Type) forStream(id string) *Type {
 		"stream": id,
 	})
 	newT.stats = t.stats.WithLabels("stream", id)
+	newT.health = t.health.ForStream(id)
 	return &newT
 }
 
@@ -371,12 +389,12 @@ func (t *Type) WithAddedMetrics(m metrics.Type) bundle.NewManagement {
 //------------------------------------------------------------------------------
 
 // RegisterEndpoint registers a server wide HTTP endpoint.
-func (t *Type) RegisterEndpoint(apiPath, desc string, h http.HandlerFunc) {
+func (t *Type) RegisterEndpoint(apiPath, desc string, h http.HandlerFunc, ops ...api.OperationSpec) {
 	if t.stream != "" && t.namespaceStreamEndpoints {
 		apiPath = path.Join("/", t.stream, apiPath)
 	}
 	if t.apiReg != nil {
-		t.apiReg.RegisterEndpoint(apiPath, desc, h)
+		t.apiReg.RegisterEndpoint(apiPath, desc, h, ops...)
 	}
 }
 
@@ -434,6 +452,24 @@ func (t *Type) Logger() log.Modular {
 	return t.logger
 }
 
+// Taps returns the registry of taps from which copies of messages leaving the
+// labelled components of the manager can be observed.
+func (t *Type) Taps() *tap.Registry {
+	return t.taps
+}
+
+// Health returns the registry that tracks the health of the components of the
+// manager.
+func (t *Type) Health() *health.Registry {
+	return t.health
+}
+
+// StateStaging returns the mutations of state staged by the components of the
+// manager that are yet to be committed.
+func (t *Type) StateStaging() *state.Staging {
+	return t.stateStaging
+}
+
 // Tracer returns a tracer provider with the current component context.
 func (t *Type) Tracer() trace.TracerProvider {
 	return t.tracer
@@ -517,6 +553,7 @@ func (t *Type) StoreCache(ctx context.Context, name string, conf cache.Config) e
 		if newCache, initErr = t.intoPath("cache_resources").NewCache(conf); initErr != nil {
 			return
 		}
+		newCache = wrapCacheHealth(newCache, t.health.NewTracker(health.KindCache, name, conf.Type))
 		set(&newCache)
 	}); err != nil {
 		return err
@@ -592,6 +629,7 @@ func (t *Type) StoreInput(ctx context.Context, name string, conf input.Config) e
 			initErr = fmt.Errorf("label '%v' must be empty or match the resource name '%v'", conf.Label, name)
 			return
 		}
+		conf.Label = name
 
 		var newInput input.Streamed
 		if newInput, initErr = t.intoPath("input_resources").NewInput(conf); initErr != nil {
@@ -680,6 +718,7 @@ func (t *Type) StoreProcessor(ctx context.Context, name string, conf processor.C
 		if newProc, initErr = t.intoPath("processor_resources").NewProcessor(conf); initErr != nil {
 			return
 		}
+		newProc = wrapProcessorHealth(newProc, t.health.NewTracker(health.KindProcessor, name, conf.Type))
 		set(&newProc)
 	}); err != nil {
 		return err
@@ -757,6 +796,7 @@ func (t *Type) StoreOutput(ctx context.Context, name string, conf output.Config)
 			initErr = fmt.Errorf("label '%v' must be empty or match the resource name '%v'", conf.Label, name)
 			return
 		}
+		conf.Label = name
 
 		var newOutput output.Streamed
 		if newOutput, initErr = t.intoPath("output_resources").NewOutput(conf); initErr != nil {
@@ -848,6 +888,7 @@ func (t *Type) StoreRateLimit(ctx context.Context, name string, conf ratelimit.C
 		if newRL, initErr = t.intoPath("rate_limit_resources").NewRateLimit(conf); initErr != nil {
 			return
 		}
+		newRL = wrapRateLimitHealth(newRL, t.health.NewTracker(health.KindRateLimit, name, conf.Type))
 		set(&newRL)
 	}); err != nil {
 		return err
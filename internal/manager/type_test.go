@@ -11,6 +11,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/health"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -539,3 +540,71 @@ func TestManagerPipeGetSet(t *testing.T) {
 		t.Error("Wrong transaction chan returned")
 	}
 }
+
+func TestManagerResourceHealth(t *testing.T) {
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	inConf := input.NewConfig()
+	inConf.Type = "generate"
+	inConf.Plugin = map[string]any{
+		"mapping":  `root = "hello world"`,
+		"interval": "@every 1h",
+	}
+
+	outConf := output.NewConfig()
+	outConf.Type = "drop"
+
+	procConf := processor.NewConfig()
+	procConf.Type = "bloblang"
+	procConf.Plugin = `root = throw("nope")`
+
+	require.NoError(t, mgr.StoreCache(tCtx, "foocache", cache.NewConfig()))
+	require.NoError(t, mgr.StoreInput(tCtx, "fooinput", inConf))
+	require.NoError(t, mgr.StoreOutput(tCtx, "foooutput", outConf))
+	require.NoError(t, mgr.StoreProcessor(tCtx, "fooproc", procConf))
+	require.NoError(t, mgr.StoreRateLimit(tCtx, "fooratelimit", ratelimit.NewConfig()))
+
+	states := func() map[string]health.State {
+		m := map[string]health.State{}
+		for _, s := range mgr.Health().Statuses() {
+			m[string(s.Kind)+":"+s.Name] = s.State
+		}
+		return m
+	}
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(map[string]health.State{
+			"cache:foocache":          health.StateConnected,
+			"input:fooinput":          health.StateConnected,
+			"output:foooutput":        health.StateConnected,
+			"processor:fooproc":       health.StateConnected,
+			"rate_limit:fooratelimit": health.StateConnected,
+		}, states())
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, mgr.AccessCache(tCtx, "foocache", func(c cache.V1) {
+		_, err := c.Get(tCtx, "does not exist")
+		require.Error(t, err)
+	}))
+	require.NoError(t, mgr.AccessProcessor(tCtx, "fooproc", func(p processor.V1) {
+		_, err := p.ProcessBatch(tCtx, message.QuickBatch([][]byte{[]byte("hello")}))
+		require.NoError(t, err)
+	}))
+
+	assert.Equal(t, health.StateConnected, states()["cache:foocache"])
+	assert.Equal(t, health.StateDegraded, states()["processor:fooproc"])
+
+	require.NoError(t, mgr.RemoveCache(tCtx, "foocache"))
+	require.NoError(t, mgr.RemoveProcessor(tCtx, "fooproc"))
+	require.NoError(t, mgr.RemoveRateLimit(tCtx, "fooratelimit"))
+	require.NoError(t, mgr.RemoveInput(tCtx, "fooinput"))
+	require.NoError(t, mgr.RemoveOutput(tCtx, "foooutput"))
+
+	assert.Eventually(t, func() bool {
+		return len(mgr.Health().Statuses()) == 0
+	}, time.Second*5, time.Millisecond*10)
+}
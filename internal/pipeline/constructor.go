@@ -1,14 +1,18 @@
 package pipeline
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
 	"github.com/benthosdev/benthos/v4/internal/value"
 )
 
@@ -48,6 +52,40 @@ func NewConfig() Config {
 
 // New creates an input type based on an input configuration.
 func New(conf Config, mgr bundle.NewManagement) (processor.Pipeline, error) {
+	return NewWrapped(conf, mgr, false, nil)
+}
+
+// NewWrapped creates a processing pipeline as New would, or as NewRejecting
+// would when rejecting is true, where each processor is passed through wrap
+// once constructed. The index provided to wrap is the position of the processor
+// within the pipeline config.
+func NewWrapped(conf Config, mgr bundle.NewManagement, rejecting bool, wrap func(index int, p processor.V1) processor.V1) (processor.Pipeline, error) {
+	processors, err := newProcessors(conf, mgr, rejecting, wrap)
+	if err != nil {
+		return nil, err
+	}
+	if rejecting {
+		if conf.Threads == 1 {
+			return NewRejectingProcessor(processors...), nil
+		}
+		return NewRejectingPool(conf.Threads, mgr.Logger(), processors...)
+	}
+	if conf.Threads == 1 {
+		return NewProcessor(processors...), nil
+	}
+	return NewPool(conf.Threads, mgr.Logger(), processors...)
+}
+
+// NewRejecting creates a processing pipeline based on a configuration where
+// messages that remain flagged as having failed after all processors have been
+// applied are rejected rather than being sent downstream. Errors of rejected
+// messages are wrapped with a transaction.ComponentError that identifies the
+// processor that flagged them.
+func NewRejecting(conf Config, mgr bundle.NewManagement) (processor.Pipeline, error) {
+	return NewWrapped(conf, mgr, true, nil)
+}
+
+func newProcessors(conf Config, mgr bundle.NewManagement, labelErrors bool, wrap func(int, processor.V1) processor.V1) ([]processor.V1, error) {
 	processors := make([]processor.V1, len(conf.Processors))
 	for j, procConf := range conf.Processors {
 		var err error
@@ -56,11 +94,45 @@ func New(conf Config, mgr bundle.NewManagement) (processor.Pipeline, error) {
 		if err != nil {
 			return nil, err
 		}
+		if wrap != nil {
+			processors[j] = wrap(j, processors[j])
+		}
+		if labelErrors {
+			label := procConf.Label
+			if label == "" {
+				label = query.SliceToDotPath(pMgr.Path()...)
+			}
+			processors[j] = &errLabeller{label: label, p: processors[j]}
+		}
 	}
-	if conf.Threads == 1 {
-		return NewProcessor(processors...), nil
+	return processors, nil
+}
+
+// errLabeller wraps a processor and wraps the errors of any messages it flags
+// as having failed with the label of the processor.
+type errLabeller struct {
+	label string
+	p     processor.V1
+}
+
+func (e *errLabeller) ProcessBatch(ctx context.Context, b message.Batch) ([]message.Batch, error) {
+	batches, err := e.p.ProcessBatch(ctx, b)
+	for _, rb := range batches {
+		for _, m := range rb {
+			mErr := m.ErrorGet()
+			if mErr == nil {
+				continue
+			}
+			if _, isLabelled := mErr.(*transaction.ComponentError); !isLabelled {
+				m.ErrorSet(&transaction.ComponentError{Label: e.label, Err: mErr})
+			}
+		}
 	}
-	return NewPool(conf.Threads, mgr.Logger(), processors...)
+	return batches, err
+}
+
+func (e *errLabeller) Close(ctx context.Context) error {
+	return e.p.Close(ctx)
 }
 
 func FromAny(prov docs.Provider, value any) (conf Config, err error) {
@@ -0,0 +1,178 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Jeffail/shutdown"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
+)
+
+// DeadLetter is a pipeline that retains the original payload of transactions
+// passing through it. Messages that are rejected by downstream components are
+// attempted again until a maximum number of attempts is reached, at which point
+// the original payloads are sent to a dead letter channel instead of the
+// rejection being propagated upstream.
+type DeadLetter struct {
+	router transaction.DeadLetterRouter
+
+	messagesIn  <-chan message.Transaction
+	messagesOut chan message.Transaction
+	deadOut     chan message.Transaction
+
+	pending sync.WaitGroup
+	shutSig *shutdown.Signaller
+}
+
+// NewDeadLetter returns a new dead letter pipeline, where messages are
+// attempted up to maxAttempts times before being routed to the dead letter
+// channel. The default label is used to annotate failed messages when the
+// component responsible isn't known, which is usually the output.
+func NewDeadLetter(maxAttempts int, defaultLabel string) *DeadLetter {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	d := &DeadLetter{
+		messagesOut: make(chan message.Transaction),
+		deadOut:     make(chan message.Transaction),
+		shutSig:     shutdown.NewSignaller(),
+	}
+	d.router = transaction.DeadLetterRouter{
+		MaxAttempts:  maxAttempts,
+		DefaultLabel: defaultLabel,
+		Retry:        d.retry,
+		Route:        d.route,
+	}
+	return d
+}
+
+//------------------------------------------------------------------------------
+
+func (d *DeadLetter) retry(ctx context.Context, t *transaction.DeadLetter) error {
+	if d.shutSig.IsHardStopSignalled() {
+		return component.ErrTypeClosed
+	}
+
+	// Rejections are often acknowledged synchronously by the downstream
+	// component that consumes our transactions and therefore the retry must
+	// be dispatched asynchronously in order to avoid a deadlock.
+	go func() {
+		select {
+		case d.messagesOut <- message.NewTransactionFunc(t.Message(), t.Ack):
+		case <-d.shutSig.HardStopChan():
+		}
+	}()
+	return nil
+}
+
+func (d *DeadLetter) route(ctx context.Context, b message.Batch) error {
+	resChan := make(chan error, 1)
+	select {
+	case d.deadOut <- message.NewTransaction(b, resChan):
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-d.shutSig.HardStopChan():
+		return component.ErrTypeClosed
+	}
+
+	select {
+	case err := <-resChan:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-d.shutSig.HardStopChan():
+		return component.ErrTypeClosed
+	}
+}
+
+// loop is the processing loop of this pipeline.
+func (d *DeadLetter) loop() {
+	defer func() {
+		// Retries and dead letters are dispatched for as long as transactions
+		// remain pending, and therefore our channels can only be closed once
+		// they're all resolved. When forced to stop we abandon the channels
+		// rather than risk a send on a closed channel.
+		drained := make(chan struct{})
+		go func() {
+			d.pending.Wait()
+			close(drained)
+		}()
+		select {
+		case <-drained:
+			close(d.messagesOut)
+			close(d.deadOut)
+		case <-d.shutSig.HardStopChan():
+		}
+		d.shutSig.TriggerHasStopped()
+	}()
+
+	for {
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-d.messagesIn:
+			if !open {
+				return
+			}
+		case <-d.shutSig.HardStopChan():
+			return
+		}
+
+		d.pending.Add(1)
+		upstreamAck := tran.Ack
+		t := d.router.NewTransaction(tran.Payload, func(ctx context.Context, err error) error {
+			defer d.pending.Done()
+			return upstreamAck(ctx, err)
+		})
+
+		select {
+		case d.messagesOut <- message.NewTransactionFunc(t.Message(), t.Ack):
+		case <-d.shutSig.HardStopChan():
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Consume assigns a messages channel for the pipeline to read.
+func (d *DeadLetter) Consume(msgs <-chan message.Transaction) error {
+	if d.messagesIn != nil {
+		return component.ErrAlreadyStarted
+	}
+	d.messagesIn = msgs
+	go d.loop()
+	return nil
+}
+
+// TransactionChan returns the channel used for consuming messages from this
+// pipeline.
+func (d *DeadLetter) TransactionChan() <-chan message.Transaction {
+	return d.messagesOut
+}
+
+// DeadLetterChan returns the channel used for consuming messages that have
+// exhausted their attempts, which should be consumed by a dead letter output.
+func (d *DeadLetter) DeadLetterChan() <-chan message.Transaction {
+	return d.deadOut
+}
+
+// TriggerCloseNow signals that the pipeline should close immediately.
+func (d *DeadLetter) TriggerCloseNow() {
+	d.shutSig.TriggerHardStop()
+}
+
+// WaitForClose blocks until the component has closed down or the context is
+// cancelled. Closing occurs either when the input transaction channel is closed
+// and all pending transactions are resolved, or when TriggerCloseNow is called.
+func (d *DeadLetter) WaitForClose(ctx context.Context) error {
+	select {
+	case <-d.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -48,6 +48,20 @@ func NewPool(threads int, log log.Modular, msgProcessors ...processor.V1) (*Pool
 	return p, nil
 }
 
+// NewRejectingPool creates a new processing pool where messages that remain
+// flagged as having failed after all processors have been applied are rejected
+// rather than being sent downstream.
+func NewRejectingPool(threads int, log log.Modular, msgProcessors ...processor.V1) (*Pool, error) {
+	p, err := NewPool(threads, log, msgProcessors...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range p.workers {
+		p.workers[i] = NewRejectingProcessor(msgProcessors...)
+	}
+	return p, nil
+}
+
 //------------------------------------------------------------------------------
 
 // loop is the processing loop of this pipeline.
@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"sync"
 
 	"github.com/Jeffail/shutdown"
@@ -10,6 +11,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/state"
 )
 
 // Processor is a pipeline that supports both Consumer and Producer interfaces.
@@ -23,6 +25,8 @@ type Processor struct {
 
 	messagesIn <-chan message.Transaction
 
+	rejectErrored bool
+
 	shutSig *shutdown.Signaller
 }
 
@@ -36,6 +40,55 @@ func NewProcessor(msgProcessors ...processor.V1) *Processor {
 	}
 }
 
+// NewRejectingProcessor returns a new message processing pipeline where
+// messages that remain flagged as having failed after all processors have been
+// applied are not propagated downstream. Instead, they are rejected within the
+// acknowledgement of the transaction they came from.
+func NewRejectingProcessor(msgProcessors ...processor.V1) *Processor {
+	p := NewProcessor(msgProcessors...)
+	p.rejectErrored = true
+	return p
+}
+
+// rejectErrored removes all messages flagged with errors from a slice of
+// batches and returns a batch error that marks each of them as failed relative
+// to the source batch. A nil error is returned if no messages were flagged.
+func rejectErrored(sorter *message.SortGroup, source message.Batch, batches []message.Batch) ([]message.Batch, error) {
+	var batchErr *batch.Error
+	var generalErr error
+
+	remaining := make([]message.Batch, 0, len(batches))
+	for _, b := range batches {
+		var kept message.Batch
+		for _, m := range b {
+			err := m.ErrorGet()
+			if err == nil {
+				kept = append(kept, m)
+				continue
+			}
+			if batchErr == nil {
+				batchErr = batch.NewError(source, err)
+			}
+			if bIndex := sorter.GetIndex(m); bIndex >= 0 {
+				batchErr.Failed(bIndex, err)
+			} else {
+				generalErr = err
+			}
+		}
+		if len(kept) > 0 {
+			remaining = append(remaining, kept)
+		}
+	}
+
+	if generalErr != nil {
+		return remaining, generalErr
+	}
+	if batchErr != nil {
+		return remaining, batchErr
+	}
+	return remaining, nil
+}
+
 //------------------------------------------------------------------------------
 
 // loop is the processing loop of this pipeline.
@@ -67,19 +120,39 @@ func (p *Processor) loop() {
 			return
 		}
 
-		sorter, sortBatch := message.NewSortGroup(tran.Payload)
+		// State mutations made by processors are staged until the transaction
+		// is acknowledged, at which point they're either committed or dropped.
+		ackFn := tran.Ack
+		txn, payload := state.BeginBatch(tran.Payload)
+		if txn != nil {
+			ackFn = func(ctx context.Context, err error) error {
+				txn.Resolve(ctx, err)
+				return tran.Ack(ctx, err)
+			}
+		}
+
+		sorter, sortBatch := message.NewSortGroup(payload)
 
 		resultBatches, err := processor.ExecuteAll(closeNowCtx, p.msgProcessors, sortBatch)
+
+		var rejectedErr error
+		if err == nil && p.rejectErrored {
+			resultBatches, rejectedErr = rejectErrored(sorter, sortBatch, resultBatches)
+			if len(resultBatches) == 0 {
+				err = rejectedErr
+			}
+		}
+
 		if len(resultBatches) == 0 || err != nil {
-			if _ = tran.Ack(closeNowCtx, err); closeNowCtx.Err() != nil {
+			if _ = ackFn(closeNowCtx, err); closeNowCtx.Err() != nil {
 				return
 			}
 			continue
 		}
 
-		if len(resultBatches) == 1 {
+		if len(resultBatches) == 1 && rejectedErr == nil {
 			select {
-			case p.messagesOut <- message.NewTransactionFunc(resultBatches[0], tran.Ack):
+			case p.messagesOut <- message.NewTransactionFunc(resultBatches[0], ackFn):
 			case <-p.shutSig.HardStopChan():
 				return
 			}
@@ -92,6 +165,11 @@ func (p *Processor) loop() {
 			generalErr error
 			batchWG    sync.WaitGroup
 		)
+		if rejectedErr != nil {
+			if !errors.As(rejectedErr, &batchErr) {
+				generalErr = rejectedErr
+			}
+		}
 
 		for _, b := range resultBatches {
 			var wgOnce sync.Once
@@ -132,11 +210,11 @@ func (p *Processor) loop() {
 		batchWG.Wait()
 
 		if generalErr != nil {
-			_ = tran.Ack(closeNowCtx, generalErr)
+			_ = ackFn(closeNowCtx, generalErr)
 		} else if batchErr != nil {
-			_ = tran.Ack(closeNowCtx, batchErr)
+			_ = ackFn(closeNowCtx, batchErr)
 		} else {
-			_ = tran.Ack(closeNowCtx, nil)
+			_ = ackFn(closeNowCtx, nil)
 		}
 	}
 }
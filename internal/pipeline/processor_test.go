@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -13,8 +14,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/pipeline"
+	"github.com/benthosdev/benthos/v4/internal/state"
 )
 
 var errMockProc = errors.New("this is an error from mock processor")
@@ -460,3 +463,224 @@ func TestProcessorMultiMsgsBatchUnknownError(t *testing.T) {
 		t.Error("Expected mockproc to have waited for close")
 	}
 }
+
+type mockFlagProcessor struct{}
+
+func (m *mockFlagProcessor) ProcessBatch(ctx context.Context, msg message.Batch) ([]message.Batch, error) {
+	for _, p := range msg {
+		if string(p.AsBytes()) == "bar" {
+			p.ErrorSet(errors.New("bar is not allowed"))
+		}
+	}
+	return []message.Batch{msg}, nil
+}
+
+func (m *mockFlagProcessor) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestRejectingProcessorErrored(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	proc := pipeline.NewRejectingProcessor(&mockFlagProcessor{})
+
+	tChan, resChan := make(chan message.Transaction), make(chan error)
+	require.NoError(t, proc.Consume(tChan))
+
+	sortGroup, inputBatch := message.NewSortGroup(message.Batch{
+		message.NewPart([]byte("foo")),
+		message.NewPart([]byte("bar")),
+		message.NewPart([]byte("baz")),
+	})
+
+	select {
+	case tChan <- message.NewTransaction(inputBatch, resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case procT, open := <-proc.TransactionChan():
+		require.True(t, open)
+		require.Len(t, procT.Payload, 2)
+		assert.Equal(t, "foo", string(procT.Payload[0].AsBytes()))
+		assert.Equal(t, "baz", string(procT.Payload[1].AsBytes()))
+		require.NoError(t, procT.Ack(ctx, nil))
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case err, open := <-resChan:
+		require.True(t, open)
+
+		var batchErr *batch.Error
+		require.ErrorAs(t, err, &batchErr)
+
+		indexErrs := map[int]string{}
+		batchErr.WalkPartsBySource(sortGroup, inputBatch, func(i int, p *message.Part, err error) bool {
+			if err != nil {
+				indexErrs[i] = err.Error()
+			}
+			return true
+		})
+		assert.Equal(t, map[int]string{
+			1: "bar is not allowed",
+		}, indexErrs)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	// A batch where all messages are flagged is rejected without being sent
+	// downstream.
+	select {
+	case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("bar")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case err := <-resChan:
+		require.EqualError(t, err, "bar is not allowed")
+	case <-proc.TransactionChan():
+		t.Fatal("Unexpected transaction")
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	proc.TriggerCloseNow()
+	require.NoError(t, proc.WaitForClose(ctx))
+}
+
+func TestProcessorStateCommit(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+	store := state.NewStore(mgr, "foocache", "")
+
+	proc := pipeline.NewProcessor(mock.Processor(func(b message.Batch) ([]message.Batch, error) {
+		for _, p := range b {
+			pCtx := p.GetContext()
+			if err := store.Set(pCtx, state.TxnFromContext(pCtx), string(p.AsBytes()), []byte("seen")); err != nil {
+				return nil, err
+			}
+		}
+		return []message.Batch{b}, nil
+	}))
+
+	tChan, resChan := make(chan message.Transaction), make(chan error, 1)
+	require.NoError(t, proc.Consume(tChan))
+
+	for _, test := range []struct {
+		key    string
+		ackErr error
+	}{
+		{key: "foo", ackErr: errors.New("nope")},
+		{key: "bar"},
+	} {
+		select {
+		case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(test.key)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+
+		select {
+		case procT := <-proc.TransactionChan():
+			assert.NotContains(t, mgr.Caches["foocache"], test.key)
+			require.NoError(t, procT.Ack(ctx, test.ackErr))
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+
+		select {
+		case err := <-resChan:
+			assert.Equal(t, test.ackErr, err)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	assert.Equal(t, map[string]mock.CacheItem{
+		"bar": {Value: "seen"},
+	}, mgr.Caches["foocache"])
+
+	proc.TriggerCloseNow()
+	require.NoError(t, proc.WaitForClose(ctx))
+}
+
+func TestProcessorStateTwoBatchesInFlight(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+	store := state.NewStore(mgr, "foocache", "")
+	missingStore := state.NewStore(mgr, "nope", "")
+
+	proc := pipeline.NewProcessor(mock.Processor(func(b message.Batch) ([]message.Batch, error) {
+		for _, p := range b {
+			pCtx := p.GetContext()
+			txn := state.TxnFromContext(pCtx)
+
+			count := 0
+			if v, err := store.Get(pCtx, txn, "count"); err == nil {
+				count, _ = strconv.Atoi(string(v))
+			}
+			if err := store.Set(pCtx, txn, "count", []byte(strconv.Itoa(count+1))); err != nil {
+				return nil, err
+			}
+			if err := missingStore.Set(pCtx, txn, "count", []byte("meh")); err != nil {
+				return nil, err
+			}
+			p.SetBytes([]byte(strconv.Itoa(count + 1)))
+		}
+		return []message.Batch{b}, nil
+	}))
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, proc.Consume(tChan))
+
+	var resChans []chan error
+	var procTs []message.Transaction
+	for i := 0; i < 2; i++ {
+		resChan := make(chan error, 1)
+		resChans = append(resChans, resChan)
+
+		select {
+		case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("foo")}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+
+		select {
+		case procT := <-proc.TransactionChan():
+			procTs = append(procTs, procT)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	// The second batch observes the count staged by the first.
+	assert.Equal(t, "1", string(procTs[0].Payload.Get(0).AsBytes()))
+	assert.Equal(t, "2", string(procTs[1].Payload.Get(0).AsBytes()))
+	assert.NotContains(t, mgr.Caches["foocache"], "count")
+
+	// Batches are acknowledged in reverse order, and failing to commit the
+	// state of the missing cache must not reject batches that were delivered.
+	for _, i := range []int{1, 0} {
+		require.NoError(t, procTs[i].Ack(ctx, nil))
+		select {
+		case err := <-resChans[i]:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		assert.Equal(t, "2", mgr.Caches["foocache"]["count"].Value)
+	}
+
+	proc.TriggerCloseNow()
+	require.NoError(t, proc.WaitForClose(ctx))
+}
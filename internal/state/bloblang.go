@@ -0,0 +1,180 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+var stateGetSpec = query.NewFunctionSpec(
+	query.FunctionCategoryEnvironment, "state_get",
+	"Returns the value of a key from a state store backed by a [cache resource](/docs/components/caches/about), or `null` if the key does not exist. Values are stored as JSON documents and are parsed back into structured values. Values written with [`state_set`](#state_set) are visible before they are committed, including those written by batches that are yet to be acknowledged. Reading and then setting a key is not atomic, and therefore read-modify-write updates such as counters can lose updates when messages are processed in parallel.",
+	query.NewNotTestedExampleSpec(
+		"Add the most recently recorded status of each user to their events.",
+		`root = this
+root.last_status = state_get("statuses", this.user)`,
+	),
+).MarkImpure().Beta().AtVersion("4.28.0").
+	Param(query.ParamString("resource", "The label of the cache resource that the state is stored within.")).
+	Param(query.ParamString("key", "The key to obtain.")).
+	Param(query.ParamString("namespace", "An optional namespace that the key is scoped to.").Default(""))
+
+var stateSetSpec = query.NewFunctionSpec(
+	query.FunctionCategoryEnvironment, "state_set",
+	"Sets the value of a key within a state store backed by a [cache resource](/docs/components/caches/about) and returns the value. Mutations are staged and only committed to the cache once the batch of messages being processed has been acknowledged, if the batch is rejected the mutations are discarded. Values are stored as JSON documents, and setting a value of `null` deletes the key.",
+	query.NewNotTestedExampleSpec(
+		"Remember the last known location of each device.",
+		`root = this
+root.previous_location = state_get("devices", this.device_id, "locations")
+root.location = state_set("devices", this.device_id, this.location, "locations")`,
+	),
+).MarkImpure().Beta().AtVersion("4.28.0").
+	Param(query.ParamString("resource", "The label of the cache resource that the state is stored within.")).
+	Param(query.ParamString("key", "The key to set.")).
+	Param(query.ParamAny("value", "The value to set.")).
+	Param(query.ParamString("namespace", "An optional namespace that the key is scoped to.").Default(""))
+
+func init() {
+	env := bloblang.GlobalEnvironment()
+	if err := env.RegisterFunction(stateGetSpec, unboundCtor("state_get")); err != nil {
+		panic(err)
+	}
+	if err := env.RegisterFunction(stateSetSpec, unboundCtor("state_set")); err != nil {
+		panic(err)
+	}
+}
+
+// The state functions are registered globally in order to be documented and to
+// allow mappings to be linted, but they can only be executed with an
+// environment that has been bound to the resources of a stream.
+func unboundCtor(name string) query.FunctionCtor {
+	return func(args *query.ParsedParams) (query.Function, error) {
+		return nil, fmt.Errorf("function %v requires access to cache resources and cannot be used in this context", name)
+	}
+}
+
+// BindFunctions returns a copy of a Bloblang environment where the state
+// functions are able to access the cache resources of a manager. If the
+// environment does not contain the state functions it is returned unchanged.
+func BindFunctions(env *bloblang.Environment, mgr CacheAccessor) (*bloblang.Environment, error) {
+	var names []string
+	env.WalkFunctions(func(name string, _ query.FunctionSpec) {
+		if name == stateGetSpec.Name || name == stateSetSpec.Name {
+			names = append(names, name)
+		}
+	})
+	if len(names) == 0 {
+		return env, nil
+	}
+
+	env = env.WithoutFunctions(names...)
+	for _, name := range names {
+		var err error
+		switch name {
+		case stateGetSpec.Name:
+			err = env.RegisterFunction(stateGetSpec, stateGetCtor(mgr))
+		case stateSetSpec.Name:
+			err = env.RegisterFunction(stateSetSpec, stateSetCtor(mgr))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+func storeFromArgs(mgr CacheAccessor, args *query.ParsedParams) (*Store, string, error) {
+	resource, err := args.FieldString("resource")
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := args.FieldString("key")
+	if err != nil {
+		return nil, "", err
+	}
+	namespace, err := args.FieldString("namespace")
+	if err != nil {
+		return nil, "", err
+	}
+	return NewStore(mgr, resource, namespace), key, nil
+}
+
+func stateGetCtor(mgr CacheAccessor) query.FunctionCtor {
+	return func(args *query.ParsedParams) (query.Function, error) {
+		store, key, err := storeFromArgs(mgr, args)
+		if err != nil {
+			return nil, err
+		}
+		return query.ClosureFunction("function state_get", func(ctx query.FunctionContext) (any, error) {
+			pCtx := messageContext(ctx)
+
+			b, err := store.Get(pCtx, TxnFromContext(pCtx), key)
+			if err != nil {
+				if errors.Is(err, component.ErrKeyNotFound) {
+					return nil, nil
+				}
+				return nil, err
+			}
+			return decodeValue(b), nil
+		}, nil), nil
+	}
+}
+
+func stateSetCtor(mgr CacheAccessor) query.FunctionCtor {
+	return func(args *query.ParsedParams) (query.Function, error) {
+		store, key, err := storeFromArgs(mgr, args)
+		if err != nil {
+			return nil, err
+		}
+		v, err := args.Field("value")
+		if err != nil {
+			return nil, err
+		}
+		return query.ClosureFunction("function state_set", func(ctx query.FunctionContext) (any, error) {
+			pCtx := messageContext(ctx)
+
+			var err error
+			if v == nil {
+				err = store.Delete(pCtx, TxnFromContext(pCtx), key)
+			} else {
+				err = store.Set(pCtx, TxnFromContext(pCtx), key, encodeValue(v))
+			}
+			if err != nil {
+				return nil, err
+			}
+			return v, nil
+		}, nil), nil
+	}
+}
+
+// messageContext returns the context of the message being mapped, which carries
+// the state transaction of the batch it belongs to.
+func messageContext(ctx query.FunctionContext) context.Context {
+	if ctx.MsgBatch == nil || ctx.MsgBatch.Len() <= ctx.Index {
+		return context.Background()
+	}
+	return ctx.MsgBatch.Get(ctx.Index).GetContext()
+}
+
+func encodeValue(v any) []byte {
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	p := message.NewPart(nil)
+	p.SetStructured(v)
+	return p.AsBytes()
+}
+
+func decodeValue(b []byte) any {
+	v, err := message.NewPart(b).AsStructured()
+	if err != nil {
+		// Values written by other means might not be JSON documents.
+		return b
+	}
+	return v
+}
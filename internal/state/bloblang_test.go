@@ -0,0 +1,76 @@
+package state_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/state"
+)
+
+func TestStateFunctionsUnbound(t *testing.T) {
+	_, err := bloblang.GlobalEnvironment().NewMapping(`root = state_get("foo", "bar")`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires access to cache resources")
+}
+
+func TestStateFunctions(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["counts"] = map[string]mock.CacheItem{
+		"users:bar": {Value: `10`},
+		"users:baz": {Value: `not json`},
+	}
+
+	env, err := state.BindFunctions(bloblang.GlobalEnvironment(), mgr)
+	require.NoError(t, err)
+
+	exec, err := env.NewMapping(`
+root.before = state_get("counts", this.user, "users")
+root.after = state_set("counts", this.user, state_get("counts", this.user, "users").or(0) + 1, "users")
+root.deleted = state_set("counts", "to_delete", null, "users")
+`)
+	require.NoError(t, err)
+
+	txn, b := state.BeginBatch(message.QuickBatch([][]byte{
+		[]byte(`{"user":"foo"}`),
+		[]byte(`{"user":"bar"}`),
+		[]byte(`{"user":"foo"}`),
+	}))
+	mgr.Caches["counts"]["users:to_delete"] = mock.CacheItem{Value: `"bye"`}
+
+	var results []string
+	for i := range b {
+		p, err := exec.MapPart(i, b)
+		require.NoError(t, err)
+		results = append(results, string(p.AsBytes()))
+	}
+	assert.Equal(t, []string{
+		`{"after":1,"before":null,"deleted":null}`,
+		`{"after":11,"before":10,"deleted":null}`,
+		`{"after":2,"before":1,"deleted":null}`,
+	}, results)
+
+	// Nothing is written until the transaction is resolved.
+	assert.Equal(t, `10`, mgr.Caches["counts"]["users:bar"].Value)
+	assert.NotContains(t, mgr.Caches["counts"], "users:foo")
+
+	require.NoError(t, txn.Commit(context.Background()))
+	assert.Equal(t, map[string]mock.CacheItem{
+		"users:foo": {Value: `2`},
+		"users:bar": {Value: `11`},
+		"users:baz": {Value: `not json`},
+	}, mgr.Caches["counts"])
+
+	// Values that aren't JSON documents are returned as raw bytes.
+	exec, err = env.NewMapping(`root = state_get("counts", "baz", "users").string()`)
+	require.NoError(t, err)
+
+	p, err := exec.MapPart(0, message.QuickBatch([][]byte{nil}))
+	require.NoError(t, err)
+	assert.Equal(t, "not json", string(p.AsBytes()))
+}
@@ -0,0 +1,157 @@
+package state
+
+import (
+	"context"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+// Staging tracks the mutations staged by transactions that are yet to be
+// resolved, so that they are visible to the other transactions accessing the
+// same state. It also tracks the order in which mutations were staged, so that
+// transactions resolved out of order do not overwrite newer values with older
+// ones.
+type Staging struct {
+	log log.Modular
+
+	mut  sync.Mutex
+	seq  uint64
+	keys map[txnKey]*stagedKey
+}
+
+type stagedKey struct {
+	// Held whilst a mutation of the key is applied to its cache, in order to
+	// prevent older mutations from being applied after newer ones.
+	applyMut sync.Mutex
+
+	// The following fields are protected by the mutex of the staging.
+	applying  int
+	committed uint64
+	pending   []stagedWrite
+}
+
+type stagedWrite struct {
+	txn *Txn
+	seq uint64
+	w   txnWrite
+}
+
+// NewStaging returns an empty staging of state mutations, where mutations that
+// fail to be committed are logged.
+func NewStaging(log log.Modular) *Staging {
+	return &Staging{
+		log:  log,
+		keys: map[txnKey]*stagedKey{},
+	}
+}
+
+// StagingProvider is implemented by managers that share the staged mutations
+// of state between the components that they manage.
+type StagingProvider interface {
+	StateStaging() *Staging
+}
+
+var defaultStaging = NewStaging(log.Noop())
+
+func stagingFor(mgr CacheAccessor) *Staging {
+	if p, ok := mgr.(StagingProvider); ok {
+		if s := p.StateStaging(); s != nil {
+			return s
+		}
+	}
+	return defaultStaging
+}
+
+// latest returns the most recently staged mutation of a key by an unresolved
+// transaction.
+func (s *Staging) latest(k txnKey) (w txnWrite, staged bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	sk, exists := s.keys[k]
+	if !exists || len(sk.pending) == 0 {
+		return
+	}
+	return sk.pending[len(sk.pending)-1].w, true
+}
+
+// stage records a mutation of a key by a transaction, replacing any mutation
+// of the key previously staged by the same transaction.
+func (s *Staging) stage(k txnKey, t *Txn, w txnWrite) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	sk, exists := s.keys[k]
+	if !exists {
+		sk = &stagedKey{}
+		s.keys[k] = sk
+	}
+	sk.remove(t)
+
+	s.seq++
+	sk.pending = append(sk.pending, stagedWrite{txn: t, seq: s.seq, w: w})
+}
+
+// discard drops the mutation of a key staged by a transaction.
+func (s *Staging) discard(k txnKey, t *Txn) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if sk, exists := s.keys[k]; exists {
+		sk.remove(t)
+		s.cleanup(k, sk)
+	}
+}
+
+// commit applies the mutation of a key staged by a transaction to its cache,
+// unless a mutation of the key staged more recently has already been applied.
+func (s *Staging) commit(ctx context.Context, k txnKey, t *Txn) error {
+	s.mut.Lock()
+	sk, exists := s.keys[k]
+	if !exists {
+		s.mut.Unlock()
+		return nil
+	}
+	sk.applying++
+	s.mut.Unlock()
+
+	sk.applyMut.Lock()
+	defer func() {
+		sk.applyMut.Unlock()
+
+		s.mut.Lock()
+		sk.applying--
+		s.cleanup(k, sk)
+		s.mut.Unlock()
+	}()
+
+	s.mut.Lock()
+	sw, staged := sk.remove(t)
+	superseded := staged && sw.seq < sk.committed
+	if staged && !superseded {
+		sk.committed = sw.seq
+	}
+	s.mut.Unlock()
+
+	if !staged || superseded {
+		return nil
+	}
+	return applyWrite(ctx, sw.w.mgr, k.cache, k.key, sw.w)
+}
+
+// cleanup removes a key once it has no pending mutations, must be called with
+// the mutex held.
+func (s *Staging) cleanup(k txnKey, sk *stagedKey) {
+	if len(sk.pending) == 0 && sk.applying == 0 {
+		delete(s.keys, k)
+	}
+}
+
+func (sk *stagedKey) remove(t *Txn) (sw stagedWrite, removed bool) {
+	for i, w := range sk.pending {
+		if w.txn == t {
+			sk.pending = append(sk.pending[:i], sk.pending[i+1:]...)
+			return w, true
+		}
+	}
+	return
+}
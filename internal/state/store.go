@@ -0,0 +1,92 @@
+package state
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+)
+
+// Store provides keyed access to state held within a cache resource, where
+// keys are scoped to a namespace. Mutations made with a transaction are staged
+// until the transaction is resolved, and mutations made without one (or with
+// one that has already been resolved) are applied to the cache immediately.
+//
+// Mutations staged by a transaction are visible to other transactions of the
+// same manager before they are resolved, and are committed in the order in
+// which they were staged. However, reading and then writing a key is not
+// atomic, and therefore concurrent transactions can overwrite each other's
+// mutations.
+type Store struct {
+	mgr       CacheAccessor
+	staging   *Staging
+	cache     string
+	namespace string
+}
+
+// NewStore returns a state store backed by a cache resource. If the manager is
+// a StagingProvider the mutations staged by transactions are shared between
+// the stores of the manager.
+func NewStore(mgr CacheAccessor, cacheName, namespace string) *Store {
+	storesCreated.Store(true)
+	return &Store{
+		mgr:       mgr,
+		staging:   stagingFor(mgr),
+		cache:     cacheName,
+		namespace: namespace,
+	}
+}
+
+// Key returns the key that a given state key is stored under within the cache.
+func (s *Store) Key(key string) string {
+	if s.namespace == "" {
+		return key
+	}
+	return s.namespace + ":" + key
+}
+
+// Get returns the value of a key, or component.ErrKeyNotFound if the key does
+// not exist.
+func (s *Store) Get(ctx context.Context, t *Txn, key string) ([]byte, error) {
+	key = s.Key(key)
+
+	w, staged := txnWrite{}, false
+	if t != nil {
+		w, staged = t.get(s.cache, key)
+	}
+	if !staged {
+		w, staged = s.staging.latest(txnKey{cache: s.cache, key: key})
+	}
+	if staged {
+		if w.deleted {
+			return nil, component.ErrKeyNotFound
+		}
+		return w.value, nil
+	}
+
+	var value []byte
+	var cErr error
+	if err := s.mgr.AccessCache(ctx, s.cache, func(c cache.V1) {
+		value, cErr = c.Get(ctx, key)
+	}); err != nil {
+		return nil, err
+	}
+	return value, cErr
+}
+
+// Set the value of a key.
+func (s *Store) Set(ctx context.Context, t *Txn, key string, value []byte) error {
+	return s.write(ctx, t, s.Key(key), txnWrite{mgr: s.mgr, staging: s.staging, value: value})
+}
+
+// Delete a key.
+func (s *Store) Delete(ctx context.Context, t *Txn, key string) error {
+	return s.write(ctx, t, s.Key(key), txnWrite{mgr: s.mgr, staging: s.staging, deleted: true})
+}
+
+func (s *Store) write(ctx context.Context, t *Txn, key string, w txnWrite) error {
+	if t != nil && t.stage(s.cache, key, w) {
+		return nil
+	}
+	return applyWrite(ctx, s.mgr, s.cache, key, w)
+}
@@ -0,0 +1,199 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// CacheAccessor provides access to the cache resources that state is stored
+// within.
+type CacheAccessor interface {
+	AccessCache(ctx context.Context, name string, fn func(cache.V1)) error
+}
+
+type txnKey struct {
+	cache string
+	key   string
+}
+
+type txnWrite struct {
+	mgr     CacheAccessor
+	staging *Staging
+	value   []byte
+	deleted bool
+}
+
+// Txn stages the state mutations caused by a batch of messages so that they
+// can be committed once the batch has been acknowledged, or discarded if it is
+// rejected. Reads made through a transaction observe the mutations it has
+// staged, followed by the mutations staged by other unresolved transactions.
+type Txn struct {
+	mut      sync.Mutex
+	resolved bool
+	writes   map[txnKey]txnWrite
+	order    []txnKey
+}
+
+// NewTxn returns a new state transaction.
+func NewTxn() *Txn {
+	return &Txn{}
+}
+
+type txnCtxKey struct{}
+
+// WithTxn returns a context carrying a state transaction.
+func WithTxn(ctx context.Context, t *Txn) context.Context {
+	return context.WithValue(ctx, txnCtxKey{}, t)
+}
+
+// TxnFromContext returns the state transaction carried by a context, or nil if
+// there isn't one.
+func TxnFromContext(ctx context.Context) *Txn {
+	t, _ := ctx.Value(txnCtxKey{}).(*Txn)
+	return t
+}
+
+// Whether any state stores have been created, until then there's no need to
+// attach transactions to batches.
+var storesCreated atomic.Bool
+
+// BeginBatch attaches a new state transaction to each message of a batch. When
+// all messages of the batch already belong to an unresolved transaction the
+// mutations they cause are left to that transaction, and a nil transaction is
+// returned along with the original batch. A nil transaction is also returned
+// when no state stores have been created.
+func BeginBatch(b message.Batch) (*Txn, message.Batch) {
+	if !storesCreated.Load() {
+		return nil, b
+	}
+
+	owned := len(b) > 0
+	for _, p := range b {
+		if t := TxnFromContext(p.GetContext()); t == nil || t.isResolved() {
+			owned = false
+			break
+		}
+	}
+	if owned {
+		return nil, b
+	}
+
+	t := NewTxn()
+	newBatch := make(message.Batch, len(b))
+	for i, p := range b {
+		newBatch[i] = p.WithContext(WithTxn(p.GetContext(), t))
+	}
+	return t, newBatch
+}
+
+func (t *Txn) isResolved() bool {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return t.resolved
+}
+
+// get returns a staged mutation of a key, if one exists.
+func (t *Txn) get(cacheName, key string) (w txnWrite, staged bool) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	w, staged = t.writes[txnKey{cache: cacheName, key: key}]
+	return
+}
+
+// stage records a mutation of a key, returns false if the transaction has
+// already been resolved, in which case the mutation must be applied directly.
+func (t *Txn) stage(cacheName, key string, w txnWrite) bool {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	if t.resolved {
+		return false
+	}
+	if t.writes == nil {
+		t.writes = map[txnKey]txnWrite{}
+	}
+	k := txnKey{cache: cacheName, key: key}
+	if _, exists := t.writes[k]; !exists {
+		t.order = append(t.order, k)
+	}
+	t.writes[k] = w
+	w.staging.stage(k, t, w)
+	return true
+}
+
+// take resolves the transaction and returns the mutations it staged in the
+// order in which their keys were first written.
+func (t *Txn) take() (keys []txnKey, writes map[txnKey]txnWrite) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.resolved = true
+	keys, writes = t.order, t.writes
+	t.order, t.writes = nil, nil
+	return
+}
+
+// Commit applies all staged mutations to their caches. Mutations of keys that
+// have since been superseded by the committed mutations of a transaction that
+// staged them more recently are skipped. An error is returned if any of the
+// mutations could not be applied, in which case the remaining mutations are
+// still attempted.
+func (t *Txn) Commit(ctx context.Context) error {
+	var errs []error
+	t.commit(ctx, func(_ txnKey, _ txnWrite, err error) {
+		errs = append(errs, err)
+	})
+	return errors.Join(errs...)
+}
+
+func (t *Txn) commit(ctx context.Context, onErr func(k txnKey, w txnWrite, err error)) {
+	keys, writes := t.take()
+	for _, k := range keys {
+		w := writes[k]
+		if err := w.staging.commit(ctx, k, t); err != nil {
+			onErr(k, w, err)
+		}
+	}
+}
+
+// Discard drops all staged mutations.
+func (t *Txn) Discard() {
+	keys, writes := t.take()
+	for _, k := range keys {
+		writes[k].staging.discard(k, t)
+	}
+}
+
+// Resolve either commits the transaction when the provided error is nil, or
+// discards it otherwise. Mutations that fail to be committed are logged rather
+// than returned, as by then the batch has already been delivered and rejecting
+// it would result in duplicates.
+func (t *Txn) Resolve(ctx context.Context, err error) {
+	if err != nil {
+		t.Discard()
+		return
+	}
+	t.commit(ctx, func(k txnKey, w txnWrite, err error) {
+		w.staging.log.Error("Failed to commit state of key '%v' to cache '%v': %v", k.key, k.cache, err)
+	})
+}
+
+func applyWrite(ctx context.Context, mgr CacheAccessor, cacheName, key string, w txnWrite) error {
+	var cErr error
+	if err := mgr.AccessCache(ctx, cacheName, func(c cache.V1) {
+		if w.deleted {
+			if cErr = c.Delete(ctx, key); errors.Is(cErr, component.ErrKeyNotFound) {
+				cErr = nil
+			}
+			return
+		}
+		cErr = c.Set(ctx, key, w.value, nil)
+	}); err != nil {
+		return err
+	}
+	return cErr
+}
@@ -0,0 +1,204 @@
+package state_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/state"
+)
+
+func TestStoreNoTxn(t *testing.T) {
+	ctx := context.Background()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	store := state.NewStore(mgr, "foocache", "bar")
+
+	_, err := store.Get(ctx, nil, "baz")
+	require.ErrorIs(t, err, component.ErrKeyNotFound)
+
+	require.NoError(t, store.Set(ctx, nil, "baz", []byte("buz")))
+	assert.Equal(t, "buz", mgr.Caches["foocache"]["bar:baz"].Value)
+
+	v, err := store.Get(ctx, nil, "baz")
+	require.NoError(t, err)
+	assert.Equal(t, "buz", string(v))
+
+	require.NoError(t, store.Delete(ctx, nil, "baz"))
+	assert.NotContains(t, mgr.Caches["foocache"], "bar:baz")
+
+	require.NoError(t, state.NewStore(mgr, "foocache", "").Set(ctx, nil, "baz", []byte("bev")))
+	assert.Equal(t, "bev", mgr.Caches["foocache"]["baz"].Value)
+}
+
+func TestStoreTxnCommit(t *testing.T) {
+	ctx := context.Background()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{
+		"ns:a": {Value: "old a"},
+		"ns:b": {Value: "old b"},
+	}
+
+	store := state.NewStore(mgr, "foocache", "ns")
+	txn := state.NewTxn()
+
+	require.NoError(t, store.Set(ctx, txn, "a", []byte("new a")))
+	require.NoError(t, store.Delete(ctx, txn, "b"))
+	require.NoError(t, store.Set(ctx, txn, "c", []byte("new c")))
+
+	// Staged mutations are visible through the transaction only.
+	v, err := store.Get(ctx, txn, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "new a", string(v))
+
+	_, err = store.Get(ctx, txn, "b")
+	require.ErrorIs(t, err, component.ErrKeyNotFound)
+
+	assert.Equal(t, "old a", mgr.Caches["foocache"]["ns:a"].Value)
+
+	txn.Resolve(ctx, nil)
+	assert.Equal(t, map[string]mock.CacheItem{
+		"ns:a": {Value: "new a"},
+		"ns:c": {Value: "new c"},
+	}, mgr.Caches["foocache"])
+
+	// Once resolved mutations are applied directly.
+	require.NoError(t, store.Set(ctx, txn, "d", []byte("new d")))
+	assert.Equal(t, "new d", mgr.Caches["foocache"]["ns:d"].Value)
+}
+
+func TestStoreTxnDiscard(t *testing.T) {
+	ctx := context.Background()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{
+		"a": {Value: "old a"},
+	}
+
+	store := state.NewStore(mgr, "foocache", "")
+	txn := state.NewTxn()
+
+	require.NoError(t, store.Set(ctx, txn, "a", []byte("new a")))
+	require.NoError(t, store.Set(ctx, txn, "b", []byte("new b")))
+
+	txn.Resolve(ctx, component.ErrTimeout)
+	assert.Equal(t, map[string]mock.CacheItem{
+		"a": {Value: "old a"},
+	}, mgr.Caches["foocache"])
+
+	v, err := store.Get(ctx, nil, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "old a", string(v))
+}
+
+func TestStoreTxnCommitError(t *testing.T) {
+	ctx := context.Background()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	txn := state.NewTxn()
+	require.NoError(t, state.NewStore(mgr, "nope", "").Set(ctx, txn, "a", []byte("a")))
+	require.NoError(t, state.NewStore(mgr, "foocache", "").Set(ctx, txn, "b", []byte("b")))
+
+	require.Error(t, txn.Commit(ctx))
+	assert.Equal(t, "b", mgr.Caches["foocache"]["b"].Value)
+}
+
+func TestStoreTxnConcurrentBatches(t *testing.T) {
+	ctx := context.Background()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{
+		"a": {Value: "0"},
+	}
+
+	storeA := state.NewStore(mgr, "foocache", "")
+	storeB := state.NewStore(mgr, "foocache", "")
+
+	first, second := state.NewTxn(), state.NewTxn()
+
+	require.NoError(t, storeA.Set(ctx, first, "a", []byte("1")))
+
+	// The second batch observes the mutations of the first before it has been
+	// acknowledged.
+	v, err := storeB.Get(ctx, second, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(v))
+	require.NoError(t, storeB.Set(ctx, second, "a", []byte("2")))
+
+	v, err = storeA.Get(ctx, nil, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(v))
+	assert.Equal(t, "0", mgr.Caches["foocache"]["a"].Value)
+
+	// Acknowledging the second batch before the first must not result in the
+	// older value of the first being committed last.
+	second.Resolve(ctx, nil)
+	assert.Equal(t, "2", mgr.Caches["foocache"]["a"].Value)
+
+	first.Resolve(ctx, nil)
+	assert.Equal(t, "2", mgr.Caches["foocache"]["a"].Value)
+}
+
+func TestStoreTxnConcurrentBatchesDiscard(t *testing.T) {
+	ctx := context.Background()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{
+		"a": {Value: "0"},
+	}
+
+	store := state.NewStore(mgr, "foocache", "")
+	first, second := state.NewTxn(), state.NewTxn()
+
+	require.NoError(t, store.Set(ctx, first, "a", []byte("1")))
+	require.NoError(t, store.Set(ctx, second, "a", []byte("2")))
+
+	// Once the newer mutation is discarded the older one is visible again.
+	second.Resolve(ctx, component.ErrTimeout)
+
+	v, err := store.Get(ctx, nil, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(v))
+
+	first.Resolve(ctx, nil)
+	assert.Equal(t, "1", mgr.Caches["foocache"]["a"].Value)
+
+	v, err = store.Get(ctx, nil, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(v))
+}
+
+func TestBeginBatch(t *testing.T) {
+	_ = state.NewStore(mock.NewManager(), "foocache", "")
+
+	b := message.QuickBatch([][]byte{[]byte("foo"), []byte("bar")})
+
+	txn, tracked := state.BeginBatch(b)
+	require.NotNil(t, txn)
+	require.Len(t, tracked, 2)
+	for _, p := range tracked {
+		assert.Equal(t, txn, state.TxnFromContext(p.GetContext()))
+	}
+	assert.Nil(t, state.TxnFromContext(b[0].GetContext()))
+
+	// Batches already owned by an open transaction are left alone.
+	nested, nestedBatch := state.BeginBatch(tracked)
+	assert.Nil(t, nested)
+	assert.Equal(t, tracked, nestedBatch)
+
+	// But not once that transaction is resolved.
+	txn.Discard()
+	nested, nestedBatch = state.BeginBatch(tracked)
+	require.NotNil(t, nested)
+	assert.Equal(t, nested, state.TxnFromContext(nestedBatch[0].GetContext()))
+}
@@ -13,6 +13,10 @@ const (
 	fieldBuffer   = "buffer"
 	fieldPipeline = "pipeline"
 	fieldOutput   = "output"
+
+	fieldDeadLetter            = "dead_letter"
+	fieldDeadLetterMaxAttempts = "max_attempts"
+	fieldDeadLetterOutput      = "output"
 )
 
 // Config is a configuration struct representing all four layers of a Benthos
@@ -23,9 +27,18 @@ type Config struct {
 	Pipeline pipeline.Config `yaml:"pipeline"`
 	Output   output.Config   `yaml:"output"`
 
+	DeadLetter *DeadLetterConfig `yaml:"dead_letter,omitempty"`
+
 	rawSource any
 }
 
+// DeadLetterConfig describes an optional output that receives the original
+// payload of messages that failed processing or were rejected by the output.
+type DeadLetterConfig struct {
+	MaxAttempts int           `yaml:"max_attempts"`
+	Output      output.Config `yaml:"output"`
+}
+
 func (c *Config) GetRawSource() any {
 	return c.rawSource
 }
@@ -60,5 +73,22 @@ func FromParsed(prov docs.Provider, pConf *docs.ParsedConfig, rawSource any) (co
 	if conf.Output, err = output.FromAny(prov, v); err != nil {
 		return
 	}
+
+	if pConf.Contains(fieldDeadLetter, fieldDeadLetterOutput) {
+		dConf := pConf.Namespace(fieldDeadLetter)
+
+		conf.DeadLetter = &DeadLetterConfig{MaxAttempts: 1}
+		if dConf.Contains(fieldDeadLetterMaxAttempts) {
+			if conf.DeadLetter.MaxAttempts, err = dConf.FieldInt(fieldDeadLetterMaxAttempts); err != nil {
+				return
+			}
+		}
+		if v, err = dConf.FieldAny(fieldDeadLetterOutput); err != nil {
+			return
+		}
+		if conf.DeadLetter.Output, err = output.FromAny(prov, v); err != nil {
+			return
+		}
+	}
 	return
 }
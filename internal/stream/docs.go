@@ -29,5 +29,9 @@ func Spec() docs.FieldSpecs {
 		}),
 		pipeline.ConfigSpec(),
 		docs.FieldOutput(fieldOutput, "An output to sink messages to.").HasDefault(defaultOutput),
+		docs.FieldObject(fieldDeadLetter, "An optional dead letter queue that receives the original payload of messages that failed processing or were rejected by the output. Messages sent to the dead letter output are annotated with the metadata fields `dead_letter_error`, `dead_letter_label`, `dead_letter_attempts` and `dead_letter_timestamp`.").WithChildren(
+			docs.FieldInt(fieldDeadLetterMaxAttempts, "The number of times a message is attempted, including processing and delivery to the output, before it is sent to the dead letter output.").HasDefault(1),
+			docs.FieldOutput(fieldDeadLetterOutput, "An output to send failed messages to. If the dead letter output also rejects messages then the rejection is propagated back to the input."),
+		).Optional().Advanced(),
 	}
 }
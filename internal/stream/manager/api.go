@@ -14,6 +14,7 @@ import (
 	"github.com/gorilla/mux"
 	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
@@ -31,6 +32,7 @@ func (m *Type) registerEndpoints(enableCrud bool) {
 		"/ready",
 		"Returns 200 OK if the inputs and outputs of all running streams are connected, otherwise a 503 is returned. If there are no active streams 200 is returned.",
 		m.HandleStreamReady,
+		api.ReadyOperations()...,
 	)
 	if !enableCrud {
 		return
@@ -39,11 +41,25 @@ func (m *Type) registerEndpoints(enableCrud bool) {
 		"/resources/{type}/{id}",
 		"POST: Create or replace a given resource configuration of a specified type. Types supported are `cache`, `input`, `output`, `processor` and `rate_limit`.",
 		m.HandleResourceCRUD,
+		resourceOperations()...,
 	)
 	m.manager.RegisterEndpoint(
 		"/streams/{id}/stats",
 		"GET a structured JSON object containing metrics for the stream.",
 		m.HandleStreamStats,
+		streamStatsOperations()...,
+	)
+	m.manager.RegisterEndpoint(
+		"/streams/{id}/pause",
+		"POST: Pause consuming from the input of a stream, leaving its components running.",
+		m.HandleStreamPause,
+		streamControlOperations()...,
+	)
+	m.manager.RegisterEndpoint(
+		"/streams/{id}/resume",
+		"POST: Resume consuming from the input of a paused stream.",
+		m.HandleStreamResume,
+		streamControlOperations()...,
 	)
 	m.manager.RegisterEndpoint(
 		"/streams/{id}",
@@ -51,6 +67,7 @@ func (m *Type) registerEndpoints(enableCrud bool) {
 			" GET (Read), PUT (Update), PATCH (Patch update)"+
 			" and DELETE (Delete).",
 		m.HandleStreamCRUD,
+		streamOperations()...,
 	)
 	m.manager.RegisterEndpoint(
 		"/streams",
@@ -58,6 +75,7 @@ func (m *Type) registerEndpoints(enableCrud bool) {
 			" POST: Post an object of stream ids to stream configs, all"+
 			" streams will be replaced by this new set.",
 		m.HandleStreamsCRUD,
+		streamsOperations()...,
 	)
 }
 
@@ -78,6 +96,44 @@ func (m *Type) lintStreamConfigNode(node *yaml.Node) (lints []string) {
 	return
 }
 
+// parseStreamConfig parses the YAML config of a stream as provided to the API,
+// substituting environment variables when resolveEnv is true. When chilled is
+// true lint errors are not returned, and missing environment variables are
+// replaced with empty strings rather than resulting in an error.
+func (m *Type) parseStreamConfig(id string, confBytes []byte, resolveEnv, chilled bool) (conf stream.Config, lints []string, err error) {
+	if resolveEnv {
+		if confBytes, err = config.ReplaceEnvVariables(confBytes, os.LookupEnv); err != nil {
+			var errEnvMissing *config.ErrMissingEnvVars
+			if !chilled || !errors.As(err, &errEnvMissing) {
+				return
+			}
+			confBytes, err = errEnvMissing.BestAttempt, nil
+		}
+	}
+
+	var node *yaml.Node
+	if node, err = docs.UnmarshalYAML(confBytes); err != nil {
+		return
+	}
+
+	if !chilled {
+		lints = m.lintStreamConfigNode(node)
+		for _, l := range lints {
+			m.manager.Logger().Info("Stream '%v' config: %v\n", id, l)
+		}
+	}
+
+	var rawSource any
+	_ = node.Decode(&rawSource)
+
+	var pConf *docs.ParsedConfig
+	if pConf, err = stream.Spec().ParsedConfigFromAny(node); err != nil {
+		return
+	}
+	conf, err = stream.FromParsed(m.manager.Environment(), pConf, rawSource)
+	return
+}
+
 // HandleStreamsCRUD is an http.HandleFunc for returning maps of active benthos
 // streams by their id, status and uptime or overwriting the entire set of
 // streams.
@@ -101,6 +157,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 
 	type confInfo struct {
 		Active    bool    `json:"active"`
+		Paused    bool    `json:"paused"`
 		Uptime    float64 `json:"uptime"`
 		UptimeStr string  `json:"uptime_str"`
 	}
@@ -110,6 +167,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	for id, strInfo := range m.streams {
 		infos[id] = confInfo{
 			Active:    strInfo.IsRunning(),
+			Paused:    strInfo.IsPaused(),
 			Uptime:    strInfo.Uptime().Seconds(),
 			UptimeStr: strInfo.Uptime().String(),
 		}
@@ -209,9 +267,23 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	errUpdate := make([]error, len(toUpdate))
 	errCreate := make([]error, len(toCreate))
 
+	// Configs of this endpoint do not have environment variables substituted
+	// and are therefore persisted such that they are also not substituted
+	// when restored.
+	storedConfs := map[string]StoredStream{}
+	for id, n := range nodeSet {
+		s := StoredStream{Chilled: r.URL.Query().Get("chilled") == "true"}
+		if s.Config, requestErr = yaml.Marshal(&n); requestErr != nil {
+			return
+		}
+		storedConfs[id] = s
+	}
+
 	for i, id := range toDelete {
 		go func(sid string, j int) {
-			errDelete[j] = m.Delete(r.Context(), sid)
+			errDelete[j] = m.persistDelete(sid, func() error {
+				return m.Delete(r.Context(), sid)
+			})
 			wg.Done()
 		}(id, i)
 	}
@@ -219,7 +291,9 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	for id, conf := range toUpdate {
 		newConf := conf
 		go func(sid string, sconf *stream.Config, j int) {
-			errUpdate[j] = m.Update(r.Context(), sid, *sconf)
+			errUpdate[j] = m.persistWrite(sid, storedConfs[sid], func() error {
+				return m.Update(r.Context(), sid, *sconf)
+			})
 			wg.Done()
 		}(id, &newConf, i)
 		i++
@@ -228,7 +302,9 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	for id, conf := range toCreate {
 		newConf := conf
 		go func(sid string, sconf *stream.Config, j int) {
-			errCreate[j] = m.Create(sid, *sconf)
+			errCreate[j] = m.persistWrite(sid, storedConfs[sid], func() error {
+				return m.Create(sid, *sconf)
+			})
 			wg.Done()
 		}(id, &newConf, i)
 		i++
@@ -284,67 +360,82 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The config of the request prior to the substitution of environment
+	// variables, which is what gets persisted.
+	var stored StoredStream
+
 	readConfig := func() (confOut stream.Config, lints []string, err error) {
 		var confBytes []byte
 		if confBytes, err = io.ReadAll(r.Body); err != nil {
 			return
 		}
-
-		ignoreLints := r.URL.Query().Get("chilled") == "true"
-
-		if confBytes, err = config.ReplaceEnvVariables(confBytes, os.LookupEnv); err != nil {
-			var errEnvMissing *config.ErrMissingEnvVars
-			if ignoreLints && errors.As(err, &errEnvMissing) {
-				confBytes = errEnvMissing.BestAttempt
-			} else {
-				return
-			}
+		stored = StoredStream{
+			Config:     confBytes,
+			ResolveEnv: true,
+			Chilled:    r.URL.Query().Get("chilled") == "true",
+		}
+		return m.parseStreamConfig(id, confBytes, stored.ResolveEnv, stored.Chilled)
+	}
+	mergePatch := func(root, patch any) (any, error) {
+		gObj := gabs.Wrap(root)
+		if err := gObj.MergeFn(gabs.Wrap(patch), func(destination, source any) any {
+			return source
+		}); err != nil {
+			return nil, err
+		}
+		return gObj.Data(), nil
+	}
+	patchConfig := func(confIn stream.Config) (confOut stream.Config, err error) {
+		var patchBytes []byte
+		if patchBytes, err = io.ReadAll(r.Body); err != nil {
+			return
 		}
 
-		var node *yaml.Node
-		if node, err = docs.UnmarshalYAML(confBytes); err != nil {
+		var pRoot any
+		if err = yaml.Unmarshal(patchBytes, &pRoot); err != nil {
 			return
 		}
 
-		if !ignoreLints {
-			lints = m.lintStreamConfigNode(node)
-			for _, l := range lints {
-				m.manager.Logger().Info("Stream '%v' config: %v\n", id, l)
+		// The persisted config of the stream is patched separately as it has
+		// not had environment variables substituted. Patches are applied
+		// without substituting environment variables or linting, and are
+		// therefore persisted such that neither happens when restored.
+		var storedRoot any
+		prev, hasPrev, sErr := m.storedStream(id)
+		if sErr != nil {
+			return confOut, sErr
+		}
+		if hasPrev {
+			if err = yaml.Unmarshal(prev.Config, &storedRoot); err != nil {
+				return
 			}
+		} else {
+			storedRoot = value.IClone(confIn.GetRawSource())
 		}
 
-		var rawSource any
-		_ = node.Decode(&rawSource)
-
-		var pConf *docs.ParsedConfig
-		if pConf, err = stream.Spec().ParsedConfigFromAny(node); err != nil {
+		storedPatch := patchBytes
+		if prev.ResolveEnv {
+			storedPatch = config.EscapeEnvVariables(patchBytes)
+		}
+		var storedPRoot any
+		if err = yaml.Unmarshal(storedPatch, &storedPRoot); err != nil {
 			return
 		}
-		confOut, err = stream.FromParsed(m.manager.Environment(), pConf, rawSource)
-		return
-	}
-	patchConfig := func(confIn stream.Config) (confOut stream.Config, err error) {
-		var patchBytes []byte
-		if patchBytes, err = io.ReadAll(r.Body); err != nil {
+		if storedRoot, err = mergePatch(storedRoot, storedPRoot); err != nil {
 			return
 		}
-
-		cRoot := value.IClone(confIn.GetRawSource())
-
-		var pRoot any
-		if err = yaml.Unmarshal(patchBytes, &pRoot); err != nil {
+		stored = StoredStream{ResolveEnv: prev.ResolveEnv, Chilled: true}
+		if stored.Config, err = yaml.Marshal(storedRoot); err != nil {
 			return
 		}
 
-		gObj := gabs.Wrap(cRoot)
-		if err = gObj.MergeFn(gabs.Wrap(pRoot), func(destination, source any) any {
-			return source
-		}); err != nil {
+		var cRoot any
+		if cRoot, err = mergePatch(value.IClone(confIn.GetRawSource()), pRoot); err != nil {
 			return
 		}
 
 		var confNode yaml.Node
-		if err = confNode.Encode(gObj.Data()); err != nil {
+		if err = confNode.Encode(cRoot); err != nil {
 			return
 		}
 
@@ -352,7 +443,7 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		if pConf, err = stream.Spec().ParsedConfigFromAny(&confNode); err != nil {
 			return
 		}
-		confOut, err = stream.FromParsed(m.manager.Environment(), pConf, gObj.Data())
+		confOut, err = stream.FromParsed(m.manager.Environment(), pConf, cRoot)
 		return
 	}
 
@@ -372,7 +463,9 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			_, _ = w.Write(errBytes)
 			return
 		}
-		serverErr = m.Create(id, conf)
+		serverErr = m.persistWrite(id, stored, func() error {
+			return m.Create(id, conf)
+		})
 	case "GET":
 		var info *StreamStatus
 		if info, serverErr = m.Read(id); serverErr == nil {
@@ -382,11 +475,13 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			var bodyBytes []byte
 			if bodyBytes, serverErr = json.Marshal(struct {
 				Active    bool    `json:"active"`
+				Paused    bool    `json:"paused"`
 				Uptime    float64 `json:"uptime"`
 				UptimeStr string  `json:"uptime_str"`
 				Config    any     `json:"config"`
 			}{
 				Active:    info.IsRunning(),
+				Paused:    info.IsPaused(),
 				Uptime:    info.Uptime().Seconds(),
 				UptimeStr: info.Uptime().String(),
 				Config:    sanit,
@@ -410,16 +505,22 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			_, _ = w.Write(errBytes)
 			return
 		}
-		serverErr = m.Update(r.Context(), id, conf)
+		serverErr = m.persistWrite(id, stored, func() error {
+			return m.Update(r.Context(), id, conf)
+		})
 	case "DELETE":
-		serverErr = m.Delete(r.Context(), id)
+		serverErr = m.persistDelete(id, func() error {
+			return m.Delete(r.Context(), id)
+		})
 	case "PATCH":
 		var info *StreamStatus
 		if info, serverErr = m.Read(id); serverErr == nil {
 			if conf, requestErr = patchConfig(info.Config()); requestErr != nil {
 				return
 			}
-			serverErr = m.Update(r.Context(), id, conf)
+			serverErr = m.persistWrite(id, stored, func() error {
+				return m.Update(r.Context(), id, conf)
+			})
 		}
 	default:
 		requestErr = fmt.Errorf("verb not supported: %v", r.Method)
@@ -631,6 +732,41 @@ func (m *Type) HandleStreamStats(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HandleStreamPause is an http.HandleFunc for pausing a stream.
+func (m *Type) HandleStreamPause(w http.ResponseWriter, r *http.Request) {
+	m.handleStreamPauseState(w, r, m.Pause)
+}
+
+// HandleStreamResume is an http.HandleFunc for resuming a paused stream.
+func (m *Type) HandleStreamResume(w http.ResponseWriter, r *http.Request) {
+	m.handleStreamPauseState(w, r, m.Resume)
+}
+
+func (m *Type) handleStreamPauseState(w http.ResponseWriter, r *http.Request, fn func(id string) error) {
+	if r.Body != nil {
+		defer r.Body.Close()
+	}
+
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		http.Error(w, "Var `id` must be set", http.StatusBadRequest)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, fmt.Sprintf("Error: verb not supported: %v", r.Method), http.StatusBadRequest)
+		return
+	}
+
+	if err := fn(id); err != nil {
+		if err == ErrStreamDoesNotExist {
+			http.Error(w, "Stream not found", http.StatusNotFound)
+			return
+		}
+		m.manager.Logger().Error("Stream pause state Error: %v\n", err)
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+	}
+}
+
 // HandleStreamReady is an http.HandleFunc for providing a ready check across
 // all streams.
 func (m *Type) HandleStreamReady(w http.ResponseWriter, r *http.Request) {
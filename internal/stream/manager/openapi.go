@@ -0,0 +1,141 @@
+package manager
+
+import (
+	"net/http"
+
+	"github.com/benthosdev/benthos/v4/internal/api"
+)
+
+var (
+	textBodySpec   = &api.BodySpec{ContentType: "text/plain", Schema: map[string]any{"type": "string"}}
+	configBodySpec = &api.BodySpec{
+		ContentType: "application/yaml",
+		Schema:      map[string]any{"type": "object", "description": "A stream config in YAML or JSON format."},
+	}
+	lintBodySpec = &api.BodySpec{
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"lint_errors": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			},
+		},
+	}
+
+	okResponse       = api.ResponseSpec{Status: http.StatusOK, Description: "The request was successful."}
+	badReqResponse   = api.ResponseSpec{Status: http.StatusBadRequest, Description: "The request or config was invalid.", Body: lintBodySpec}
+	notFoundResponse = api.ResponseSpec{Status: http.StatusNotFound, Description: "The stream does not exist.", Body: textBodySpec}
+)
+
+func streamStatusProps() map[string]any {
+	return map[string]any{
+		"active":     map[string]any{"type": "boolean"},
+		"paused":     map[string]any{"type": "boolean"},
+		"uptime":     map[string]any{"type": "number"},
+		"uptime_str": map[string]any{"type": "string"},
+	}
+}
+
+func streamsOperations() []api.OperationSpec {
+	return []api.OperationSpec{
+		{
+			Method: "GET",
+			Responses: []api.ResponseSpec{{
+				Status:      http.StatusOK,
+				Description: "A map of stream ids to their status.",
+				Body: &api.BodySpec{Schema: map[string]any{
+					"type":                 "object",
+					"additionalProperties": map[string]any{"type": "object", "properties": streamStatusProps()},
+				}},
+			}},
+		},
+		{
+			Method: "POST",
+			Request: &api.BodySpec{
+				ContentType: "application/yaml",
+				Schema: map[string]any{
+					"type":                 "object",
+					"description":          "A map of stream ids to stream configs.",
+					"additionalProperties": map[string]any{"type": "object"},
+				},
+			},
+			Responses: []api.ResponseSpec{okResponse, badReqResponse},
+		},
+	}
+}
+
+func streamOperations() []api.OperationSpec {
+	readProps := streamStatusProps()
+	readProps["config"] = map[string]any{"type": "object"}
+
+	return []api.OperationSpec{
+		{
+			Method:    "POST",
+			Summary:   "Create a new stream.",
+			Request:   configBodySpec,
+			Responses: []api.ResponseSpec{okResponse, badReqResponse},
+		},
+		{
+			Method:  "GET",
+			Summary: "Read the config and status of a stream.",
+			Responses: []api.ResponseSpec{
+				{
+					Status:      http.StatusOK,
+					Description: "The config and status of the stream.",
+					Body:        &api.BodySpec{Schema: map[string]any{"type": "object", "properties": readProps}},
+				},
+				notFoundResponse,
+			},
+		},
+		{
+			Method:    "PUT",
+			Summary:   "Replace the config of an existing stream.",
+			Request:   configBodySpec,
+			Responses: []api.ResponseSpec{okResponse, badReqResponse, notFoundResponse},
+		},
+		{
+			Method:    "PATCH",
+			Summary:   "Merge a partial config into the config of an existing stream.",
+			Request:   configBodySpec,
+			Responses: []api.ResponseSpec{okResponse, badReqResponse, notFoundResponse},
+		},
+		{
+			Method:    "DELETE",
+			Summary:   "Stop and remove a stream.",
+			Responses: []api.ResponseSpec{okResponse, notFoundResponse},
+		},
+	}
+}
+
+func streamStatsOperations() []api.OperationSpec {
+	return []api.OperationSpec{{
+		Method: "GET",
+		Responses: []api.ResponseSpec{
+			{
+				Status:      http.StatusOK,
+				Description: "The metrics of the stream.",
+				Body:        &api.BodySpec{Schema: map[string]any{"type": "object"}},
+			},
+			notFoundResponse,
+		},
+	}}
+}
+
+// streamControlOperations describes the endpoints that pause and resume a
+// stream.
+func streamControlOperations() []api.OperationSpec {
+	return []api.OperationSpec{{
+		Method:    "POST",
+		Responses: []api.ResponseSpec{okResponse, notFoundResponse},
+	}}
+}
+
+func resourceOperations() []api.OperationSpec {
+	return []api.OperationSpec{{
+		Method: "POST",
+		Request: &api.BodySpec{
+			ContentType: "application/yaml",
+			Schema:      map[string]any{"type": "object", "description": "A resource config in YAML or JSON format."},
+		},
+		Responses: []api.ResponseSpec{okResponse, badReqResponse},
+	}}
+}
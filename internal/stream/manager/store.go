@@ -0,0 +1,400 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+// StoredStream is the persisted record of a change made to a stream via the
+// HTTP API.
+type StoredStream struct {
+	// Config is the YAML config of the stream as it was provided to the API,
+	// prior to the substitution of environment variables.
+	Config []byte
+
+	// ResolveEnv is true when the API substituted environment variables
+	// within the config, in which case they are substituted again when the
+	// stream is restored. Otherwise the config is restored as it is.
+	ResolveEnv bool
+
+	// Chilled is true when the API accepted the config regardless of lint
+	// errors and missing environment variables, in which case they are also
+	// ignored when the stream is restored.
+	Chilled bool
+
+	// Deleted is true when the stream was deleted via the API, in which case
+	// the record prevents the stream from being created from a config file of
+	// the same ID.
+	Deleted bool
+
+	// Base is a fingerprint of the config file of the stream at the time it
+	// was changed via the API, or empty if there was no config file for the
+	// stream. When the config file has since changed the record is stale and
+	// the config file takes precedence.
+	Base string
+}
+
+// Store is a persistence backend for the streams that are created, updated
+// and deleted via the HTTP API, allowing them to be restored when the service
+// restarts.
+type Store interface {
+	// ReadAll returns all persisted streams keyed by their ID. Streams that
+	// cannot be read individually are omitted, in which case the readable
+	// streams are returned along with a *ReadErrors.
+	ReadAll() (map[string]StoredStream, error)
+
+	// Read returns the persisted stream of an ID, and whether it exists.
+	Read(id string) (StoredStream, bool, error)
+
+	// Write persists a stream, replacing any existing stream of the same ID.
+	Write(id string, s StoredStream) error
+
+	// Delete removes a persisted stream, it is not an error if no stream
+	// exists for the ID.
+	Delete(id string) error
+}
+
+// ReadErrors is returned by a Store when individual streams could not be read,
+// keyed by their ID.
+type ReadErrors map[string]error
+
+func (r ReadErrors) Error() string {
+	ids := make([]string, 0, len(r))
+	for id := range r {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	errs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		errs = append(errs, fmt.Sprintf("%v: %v", id, r[id]))
+	}
+	return fmt.Sprintf("failed to read persisted streams: %v", strings.Join(errs, ", "))
+}
+
+//------------------------------------------------------------------------------
+
+// DirectoryStore is a Store that persists each stream as a JSON file within a
+// directory, where the file name is the ID of the stream.
+type DirectoryStore struct {
+	dir string
+}
+
+// NewDirectoryStore creates a Store that persists streams to the provided
+// directory, which is created if it does not already exist.
+func NewDirectoryStore(dir string) (*DirectoryStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirectoryStore{dir: dir}, nil
+}
+
+const storedStreamExt = ".json"
+
+type storedStreamFile struct {
+	Config     string `json:"config,omitempty"`
+	ResolveEnv bool   `json:"resolve_env,omitempty"`
+	Chilled    bool   `json:"chilled,omitempty"`
+	Deleted    bool   `json:"deleted,omitempty"`
+	Base       string `json:"base,omitempty"`
+}
+
+func (d *DirectoryStore) pathOf(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return "", fmt.Errorf("stream id '%v' cannot be used as a file name", id)
+	}
+	return filepath.Join(d.dir, id+storedStreamExt), nil
+}
+
+func readStoredStream(path string) (s StoredStream, err error) {
+	var fileBytes []byte
+	if fileBytes, err = os.ReadFile(path); err != nil {
+		return
+	}
+	var f storedStreamFile
+	if err = json.Unmarshal(fileBytes, &f); err != nil {
+		return
+	}
+	return StoredStream{
+		Config:     []byte(f.Config),
+		ResolveEnv: f.ResolveEnv,
+		Chilled:    f.Chilled,
+		Deleted:    f.Deleted,
+		Base:       f.Base,
+	}, nil
+}
+
+// ReadAll returns all streams within the directory. Files that cannot be read
+// are omitted from the returned streams and reported as a *ReadErrors.
+func (d *DirectoryStore) ReadAll() (map[string]StoredStream, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var rErrs ReadErrors
+	streams := map[string]StoredStream{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), storedStreamExt) || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		id := strings.TrimSuffix(e.Name(), storedStreamExt)
+		s, err := readStoredStream(filepath.Join(d.dir, e.Name()))
+		if err != nil {
+			if rErrs == nil {
+				rErrs = ReadErrors{}
+			}
+			rErrs[id] = err
+			continue
+		}
+		streams[id] = s
+	}
+	if rErrs != nil {
+		return streams, rErrs
+	}
+	return streams, nil
+}
+
+// Read a stream from the directory.
+func (d *DirectoryStore) Read(id string) (StoredStream, bool, error) {
+	path, err := d.pathOf(id)
+	if err != nil {
+		return StoredStream{}, false, err
+	}
+	s, err := readStoredStream(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return StoredStream{}, false, nil
+	}
+	if err != nil {
+		return StoredStream{}, false, err
+	}
+	return s, true, nil
+}
+
+// Write a stream to a file within the directory. The file is first written to
+// a temporary file which then replaces the target, such that a partially
+// written stream is never read.
+func (d *DirectoryStore) Write(id string, s StoredStream) error {
+	path, err := d.pathOf(id)
+	if err != nil {
+		return err
+	}
+
+	fileBytes, err := json.Marshal(storedStreamFile{
+		Config:     string(s.Config),
+		ResolveEnv: s.ResolveEnv,
+		Chilled:    s.Chilled,
+		Deleted:    s.Deleted,
+		Base:       s.Base,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(d.dir, "."+id+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmpFile.Write(fileBytes); err == nil {
+		err = tmpFile.Sync()
+	}
+	if cErr := tmpFile.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+	}
+	return err
+}
+
+// Delete the file of a stream from the directory.
+func (d *DirectoryStore) Delete(id string) error {
+	path, err := d.pathOf(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// OptSetStore sets a Store to which the streams created, updated and deleted
+// via the HTTP API are persisted. Persisted streams can then be restored with
+// ApplyStored.
+func OptSetStore(s Store) func(*Type) {
+	return func(t *Type) {
+		t.store = s
+	}
+}
+
+// ApplyStored modifies a map of stream configs loaded from config files
+// according to the streams persisted to the store of the manager, and must be
+// called before the streams are created. The digests are those of the raw
+// contents of the config files, keyed by stream ID.
+//
+// Persisted streams reflect the most recent changes made via the API and
+// therefore replace or remove the streams of the same ID, unless the config
+// file of the stream has changed since, in which case the persisted stream is
+// stale and is removed from the store. Persisted streams that cannot be
+// restored are logged and skipped.
+func (m *Type) ApplyStored(confs map[string]stream.Config, digests map[string]string) error {
+	m.storeMut.Lock()
+	defer m.storeMut.Unlock()
+
+	m.fileBases = map[string]string{}
+	for id, digest := range digests {
+		m.fileBases[id] = digest
+	}
+
+	if m.store == nil {
+		return nil
+	}
+
+	stored, err := m.store.ReadAll()
+	if err != nil {
+		var rErrs ReadErrors
+		if !errors.As(err, &rErrs) {
+			return fmt.Errorf("failed to read persisted streams: %w", err)
+		}
+		for id, rErr := range rErrs {
+			m.manager.Logger().Error("Skipping persisted stream %v as it could not be read: %v", id, rErr)
+		}
+	}
+
+	for id, s := range stored {
+		if s.Base != m.fileBases[id] {
+			m.manager.Logger().Info("Discarding persisted stream %v as its config file has changed since it was persisted", id)
+			if err := m.store.Delete(id); err != nil {
+				return fmt.Errorf("failed to remove stale persisted stream %v: %w", id, err)
+			}
+			continue
+		}
+
+		if s.Deleted {
+			delete(confs, id)
+			continue
+		}
+
+		conf, lints, err := m.parseStreamConfig(id, s.Config, s.ResolveEnv, s.Chilled)
+		if err == nil && len(lints) > 0 {
+			err = fmt.Errorf("lint errors: %v", strings.Join(lints, ", "))
+		}
+		if err != nil {
+			m.manager.Logger().Error("Skipping persisted stream %v as it could not be restored: %v", id, err)
+			continue
+		}
+		if _, exists := confs[id]; exists {
+			m.manager.Logger().Info("Stream %v from a config file is replaced by its persisted config", id)
+		}
+		confs[id] = conf
+	}
+	return nil
+}
+
+// FileChanged records a change to the config file of a stream made whilst the
+// service is running, where the digest is that of the raw contents of the new
+// file, or empty when the file was removed. As the change is more recent than
+// any made via the API the persisted stream of the same ID is removed from the
+// store.
+func (m *Type) FileChanged(id, digest string) error {
+	m.storeMut.Lock()
+	defer m.storeMut.Unlock()
+
+	if m.fileBases == nil {
+		m.fileBases = map[string]string{}
+	}
+	if digest != "" {
+		m.fileBases[id] = digest
+	} else {
+		delete(m.fileBases, id)
+	}
+
+	if m.store == nil {
+		return nil
+	}
+	if err := m.store.Delete(id); err != nil {
+		return fmt.Errorf("failed to remove persisted stream %v: %w", id, err)
+	}
+	return nil
+}
+
+// storedStream returns the persisted stream of an ID, if it has one that has
+// not been deleted.
+func (m *Type) storedStream(id string) (StoredStream, bool, error) {
+	if m.store == nil {
+		return StoredStream{}, false, nil
+	}
+	s, exists, err := m.store.Read(id)
+	if err != nil || !exists || s.Deleted {
+		return StoredStream{}, false, err
+	}
+	return s, true, nil
+}
+
+// persistWrite persists the raw config of a stream and then applies the
+// change to the stream. If the change fails the persisted stream is rolled
+// back.
+func (m *Type) persistWrite(id string, s StoredStream, apply func() error) error {
+	return m.persist(id, &s, apply)
+}
+
+// persistDelete persists the deletion of a stream and then deletes it. If the
+// deletion fails the persisted stream is rolled back.
+func (m *Type) persistDelete(id string, apply func() error) error {
+	return m.persist(id, &StoredStream{Deleted: true}, apply)
+}
+
+func (m *Type) persist(id string, s *StoredStream, apply func() error) error {
+	if m.store == nil {
+		return apply()
+	}
+
+	m.storeMut.Lock()
+	s.Base = m.fileBases[id]
+	m.storeMut.Unlock()
+
+	prev, existed, err := m.store.Read(id)
+	if err != nil {
+		return fmt.Errorf("failed to read persisted stream %v: %w", id, err)
+	}
+
+	// The deletion of a stream without a config file is persisted by removing
+	// it, otherwise a tombstone is needed in order to prevent the stream being
+	// created from its config file again.
+	if s.Deleted && s.Base == "" {
+		err = m.store.Delete(id)
+	} else {
+		err = m.store.Write(id, *s)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to persist stream %v: %w", id, err)
+	}
+
+	if err := apply(); err != nil {
+		var rErr error
+		if existed {
+			rErr = m.store.Write(id, prev)
+		} else {
+			rErr = m.store.Delete(id)
+		}
+		if rErr != nil {
+			m.manager.Logger().Error("Failed to roll back persisted stream %v: %v", id, rErr)
+		}
+		return err
+	}
+	return nil
+}
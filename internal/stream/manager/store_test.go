@@ -0,0 +1,486 @@
+package manager_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/gabs/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component/testutil"
+	bmanager "github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+	"github.com/benthosdev/benthos/v4/internal/stream/manager"
+)
+
+func TestDirectoryStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "streams")
+
+	store, err := manager.NewDirectoryStore(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Write("foo", manager.StoredStream{Config: []byte("foo: 1")}))
+	require.NoError(t, store.Write("bar", manager.StoredStream{Config: []byte("bar: 1"), Base: "abc"}))
+	require.NoError(t, store.Write("foo", manager.StoredStream{Config: []byte("foo: 2")}))
+	require.NoError(t, store.Write("baz", manager.StoredStream{Deleted: true, Base: "def"}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("nope"), 0o644))
+
+	streams, err := store.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]manager.StoredStream{
+		"foo": {Config: []byte("foo: 2")},
+		"bar": {Config: []byte("bar: 1"), Base: "abc"},
+		"baz": {Config: []byte{}, Deleted: true, Base: "def"},
+	}, streams)
+
+	s, exists, err := store.Read("bar")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, manager.StoredStream{Config: []byte("bar: 1"), Base: "abc"}, s)
+
+	require.NoError(t, store.Delete("foo"))
+	require.NoError(t, store.Delete("does_not_exist"))
+
+	_, exists, err = store.Read("foo")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	streams, err = store.ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, streams, 2)
+
+	require.Error(t, store.Write("../escape", manager.StoredStream{Config: []byte("nope")}))
+}
+
+func fileStreamConfs(t testing.TB, mappings map[string]string) map[string]stream.Config {
+	t.Helper()
+
+	confs := map[string]stream.Config{}
+	for id, mapping := range mappings {
+		conf, err := testutil.StreamFromYAML(`
+input:
+  generate:
+    mapping: '` + mapping + `'
+output:
+  drop: {}
+`)
+		require.NoError(t, err)
+		confs[id] = conf
+	}
+	return confs
+}
+
+// fileDigests returns stand-in digests of the files of stream configs, which
+// change whenever the configs change.
+func fileDigests(t testing.TB, confs map[string]stream.Config) map[string]string {
+	t.Helper()
+
+	digests := map[string]string{}
+	for id, conf := range confs {
+		confBytes, err := yaml.Marshal(conf.GetRawSource())
+		require.NoError(t, err)
+		digests[id] = string(confBytes)
+	}
+	return digests
+}
+
+func newPersistedManager(t testing.TB, store manager.Store, fileConfs map[string]stream.Config) *manager.Type {
+	t.Helper()
+
+	res, err := bmanager.New(bmanager.NewResourceConfig())
+	require.NoError(t, err)
+
+	if fileConfs == nil {
+		fileConfs = map[string]stream.Config{}
+	}
+
+	mgr := manager.New(res, manager.OptSetStore(store))
+	require.NoError(t, mgr.ApplyStored(fileConfs, fileDigests(t, fileConfs)))
+	for id, conf := range fileConfs {
+		require.NoError(t, mgr.Create(id, conf))
+	}
+	t.Cleanup(func() {
+		ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+		defer done()
+		require.NoError(t, mgr.Stop(ctx))
+	})
+	return mgr
+}
+
+func streamMapping(t testing.TB, mgr *manager.Type, id string) any {
+	t.Helper()
+
+	info, err := mgr.Read(id)
+	require.NoError(t, err)
+	conf := info.Config()
+	return gabs.Wrap(conf.GetRawSource()).S("input", "generate", "mapping").Data()
+}
+
+func TestTypeAPIPersistence(t *testing.T) {
+	store, err := manager.NewDirectoryStore(t.TempDir())
+	require.NoError(t, err)
+
+	fileConfs := map[string]string{
+		"from_file": `root = "from a file"`,
+		"replaced":  `root = "from a file"`,
+	}
+
+	mgr := newPersistedManager(t, store, fileStreamConfs(t, fileConfs))
+	r := router(mgr)
+
+	for _, id := range []string{"foo", "bar"} {
+		request := genRequest("POST", "/streams/"+id, harmlessConf())
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	}
+
+	request := genRequest("PUT", "/streams/replaced", harmlessConf())
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	for _, id := range []string{"bar", "from_file"} {
+		request := genRequest("DELETE", "/streams/"+id, nil)
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	}
+
+	request = genRequest("PATCH", "/streams/foo", map[string]any{
+		"input": map[string]any{
+			"generate": map[string]any{
+				"mapping": `root = "patched"`,
+			},
+		},
+	})
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	streams, err := store.ReadAll()
+	require.NoError(t, err)
+	require.Contains(t, streams, "foo")
+	assert.NotContains(t, streams, "bar")
+
+	// Deleting a stream of a config file leaves a tombstone behind.
+	require.Contains(t, streams, "from_file")
+	assert.True(t, streams["from_file"].Deleted)
+
+	// A new manager restores the persisted streams, replacing or removing any
+	// streams of the same ID from config files.
+	mgr = newPersistedManager(t, store, fileStreamConfs(t, fileConfs))
+
+	assert.Equal(t, `root = "patched"`, streamMapping(t, mgr, "foo"))
+	assert.Equal(t, `root = deleted()`, streamMapping(t, mgr, "replaced"))
+
+	_, err = mgr.Read("bar")
+	require.ErrorIs(t, err, manager.ErrStreamDoesNotExist)
+
+	_, err = mgr.Read("from_file")
+	require.ErrorIs(t, err, manager.ErrStreamDoesNotExist)
+}
+
+func TestTypeAPIPersistenceStale(t *testing.T) {
+	store, err := manager.NewDirectoryStore(t.TempDir())
+	require.NoError(t, err)
+
+	mgr := newPersistedManager(t, store, fileStreamConfs(t, map[string]string{
+		"foo": `root = "from a file"`,
+		"bar": `root = "from a file"`,
+	}))
+	r := router(mgr)
+
+	request := genRequest("PUT", "/streams/foo", harmlessConf())
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	request = genRequest("DELETE", "/streams/bar", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	// Config files changed after the streams were persisted take precedence
+	// over the persisted streams, which are removed.
+	mgr = newPersistedManager(t, store, fileStreamConfs(t, map[string]string{
+		"foo": `root = "from an updated file"`,
+		"bar": `root = "from an updated file"`,
+	}))
+
+	assert.Equal(t, `root = "from an updated file"`, streamMapping(t, mgr, "foo"))
+	assert.Equal(t, `root = "from an updated file"`, streamMapping(t, mgr, "bar"))
+
+	streams, err := store.ReadAll()
+	require.NoError(t, err)
+	assert.Empty(t, streams)
+
+	// As do config files that change whilst running.
+	r = router(mgr)
+	request = genRequest("PUT", "/streams/foo", harmlessConf())
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	updated := fileStreamConfs(t, map[string]string{
+		"foo": `root = "from a file changed whilst running"`,
+	})
+	require.NoError(t, mgr.FileChanged("foo", fileDigests(t, updated)["foo"]))
+
+	streams, err = store.ReadAll()
+	require.NoError(t, err)
+	assert.Empty(t, streams)
+}
+
+func TestTypeAPIPersistenceRawConfig(t *testing.T) {
+	t.Setenv("BENTHOS_TEST_PERSIST_MAPPING", `root = "from the env"`)
+
+	store, err := manager.NewDirectoryStore(t.TempDir())
+	require.NoError(t, err)
+
+	mgr := newPersistedManager(t, store, nil)
+	r := router(mgr)
+
+	rawConf := `
+input:
+  generate:
+    mapping: '${BENTHOS_TEST_PERSIST_MAPPING}'
+output:
+  drop: {}
+`
+	request := genYAMLRequest("POST", "/streams/foo", rawConf)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.Equal(t, `root = "from the env"`, streamMapping(t, mgr, "foo"))
+
+	request = genRequest("PATCH", "/streams/foo", map[string]any{
+		"output": map[string]any{
+			"drop":  map[string]any{},
+			"label": "patched",
+		},
+	})
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	// The persisted config retains the reference to the environment variable.
+	s, exists, err := store.Read("foo")
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Contains(t, string(s.Config), "${BENTHOS_TEST_PERSIST_MAPPING}")
+	assert.Contains(t, string(s.Config), "patched")
+
+	t.Setenv("BENTHOS_TEST_PERSIST_MAPPING", `root = "from a new env"`)
+
+	mgr = newPersistedManager(t, store, nil)
+	assert.Equal(t, `root = "from a new env"`, streamMapping(t, mgr, "foo"))
+}
+
+func TestTypeAPIPersistenceRollback(t *testing.T) {
+	store, err := manager.NewDirectoryStore(t.TempDir())
+	require.NoError(t, err)
+
+	mgr := newPersistedManager(t, store, nil)
+	r := router(mgr)
+
+	request := genRequest("POST", "/streams/foo", harmlessConf())
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	before, exists, err := store.Read("foo")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// Creating a stream that already exists fails and therefore must not
+	// replace the persisted stream.
+	request = genRequest("POST", "/streams/foo", map[string]any{
+		"input": map[string]any{
+			"generate": map[string]any{
+				"mapping": `root = "nope"`,
+			},
+		},
+		"output": map[string]any{
+			"drop": map[string]any{},
+		},
+	})
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusBadRequest, response.Code, response.Body.String())
+
+	after, exists, err := store.Read("foo")
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, before, after)
+
+	// Neither must updating or deleting a stream that does not exist.
+	request = genRequest("PUT", "/streams/bar", harmlessConf())
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusNotFound, response.Code, response.Body.String())
+
+	request = genRequest("DELETE", "/streams/baz", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusNotFound, response.Code, response.Body.String())
+
+	streams, err := store.ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, streams, 1)
+}
+
+func TestTypeAPIPersistenceChilled(t *testing.T) {
+	store, err := manager.NewDirectoryStore(t.TempDir())
+	require.NoError(t, err)
+
+	mgr := newPersistedManager(t, store, nil)
+	r := router(mgr)
+
+	// Configs with lint errors and missing environment variables are only
+	// accepted when chilled, and are therefore restored in the same way. The
+	// streams endpoint replaces all streams and so is called first.
+	request := genYAMLRequest("POST", "/streams?chilled=true", `
+bar:
+  not_a_field: true
+  input:
+    generate:
+      mapping: 'root = "chilled"'
+  output:
+    drop: {}
+`)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	rawConf := `
+not_a_field: true
+input:
+  label: '${BENTHOS_TEST_PERSIST_UNSET}'
+  generate:
+    mapping: 'root = "chilled"'
+output:
+  drop: {}
+`
+	request = genYAMLRequest("POST", "/streams/foo", rawConf)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusBadRequest, response.Code, response.Body.String())
+
+	request = genYAMLRequest("POST", "/streams/foo?chilled=true", rawConf)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	mgr = newPersistedManager(t, store, nil)
+	assert.Equal(t, `root = "chilled"`, streamMapping(t, mgr, "foo"))
+	assert.Equal(t, `root = "chilled"`, streamMapping(t, mgr, "bar"))
+}
+
+func TestTypeAPIPersistenceSkipped(t *testing.T) {
+	t.Setenv("BENTHOS_TEST_PERSIST_MAPPING", `root = "from the env"`)
+
+	dir := t.TempDir()
+	store, err := manager.NewDirectoryStore(dir)
+	require.NoError(t, err)
+
+	mgr := newPersistedManager(t, store, nil)
+	r := router(mgr)
+
+	for id, mapping := range map[string]string{
+		"foo": `${BENTHOS_TEST_PERSIST_MAPPING}`,
+		"bar": `root = "bar"`,
+	} {
+		request := genYAMLRequest("POST", "/streams/"+id, `
+input:
+  generate:
+    mapping: '`+mapping+`'
+output:
+  drop: {}
+`)
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "baz.json"), []byte(`{"config":`), 0o644))
+
+	// Persisted streams that can no longer be restored, such as those that
+	// reference an environment variable that is now unset, or that cannot be
+	// read at all, are skipped rather than preventing the others from being
+	// restored.
+	require.NoError(t, os.Unsetenv("BENTHOS_TEST_PERSIST_MAPPING"))
+
+	mgr = newPersistedManager(t, store, nil)
+	assert.Equal(t, `root = "bar"`, streamMapping(t, mgr, "bar"))
+
+	for _, id := range []string{"foo", "baz"} {
+		_, err = mgr.Read(id)
+		require.ErrorIs(t, err, manager.ErrStreamDoesNotExist, id)
+	}
+}
+
+func TestTypeAPIPersistenceLiteralEnv(t *testing.T) {
+	t.Setenv("BENTHOS_TEST_PERSIST_LITERAL", "from_the_env")
+
+	store, err := manager.NewDirectoryStore(t.TempDir())
+	require.NoError(t, err)
+
+	mgr := newPersistedManager(t, store, nil)
+	r := router(mgr)
+
+	// Configs of the streams endpoint do not have environment variables
+	// substituted, and neither do patches.
+	request := genYAMLRequest("POST", "/streams", `
+foo:
+  input:
+    generate:
+      mapping: 'root = "${BENTHOS_TEST_PERSIST_LITERAL}"'
+  output:
+    drop: {}
+`)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	request = genYAMLRequest("POST", "/streams/bar", `
+input:
+  label: '${BENTHOS_TEST_PERSIST_LITERAL}'
+  generate:
+    mapping: 'root = "bar"'
+output:
+  drop: {}
+`)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	request = genRequest("PATCH", "/streams/bar", map[string]any{
+		"input": map[string]any{
+			"generate": map[string]any{
+				"mapping": `root = "${BENTHOS_TEST_PERSIST_LITERAL}"`,
+			},
+		},
+	})
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	assert.Equal(t, `root = "${BENTHOS_TEST_PERSIST_LITERAL}"`, streamMapping(t, mgr, "foo"))
+	assert.Equal(t, `root = "${BENTHOS_TEST_PERSIST_LITERAL}"`, streamMapping(t, mgr, "bar"))
+
+	mgr = newPersistedManager(t, store, nil)
+	assert.Equal(t, `root = "${BENTHOS_TEST_PERSIST_LITERAL}"`, streamMapping(t, mgr, "foo"))
+	assert.Equal(t, `root = "${BENTHOS_TEST_PERSIST_LITERAL}"`, streamMapping(t, mgr, "bar"))
+
+	info, err := mgr.Read("bar")
+	require.NoError(t, err)
+	conf := info.Config()
+	assert.Equal(t, "from_the_env", gabs.Wrap(conf.GetRawSource()).S("input", "label").Data())
+}
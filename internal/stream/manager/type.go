@@ -48,6 +48,12 @@ func (s *StreamStatus) IsReady() bool {
 	return s.strm.IsReady()
 }
 
+// IsPaused returns a boolean indicating whether consuming from the input of the
+// stream is currently paused.
+func (s *StreamStatus) IsPaused() bool {
+	return s.strm.IsPaused()
+}
+
 // Uptime returns a time.Duration indicating the current uptime of the stream.
 func (s *StreamStatus) Uptime() time.Duration {
 	if stoppedAfter := atomic.LoadInt64(&s.stoppedAfter); stoppedAfter > 0 {
@@ -87,6 +93,12 @@ type Type struct {
 
 	manager    bundle.NewManagement
 	apiEnabled bool
+	store      Store
+
+	// Protects the fingerprints of the config files of streams, which are
+	// recorded with persisted streams in order to detect stale changes.
+	storeMut  sync.Mutex
+	fileBases map[string]string
 
 	lock sync.Mutex
 }
@@ -128,6 +140,10 @@ var (
 // Create attempts to construct and run a new stream under a unique ID. If the
 // ID already exists an error is returned.
 func (m *Type) Create(id string, conf stream.Config) error {
+	return m.create(id, conf, false)
+}
+
+func (m *Type) create(id string, conf stream.Config, paused bool) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
@@ -148,9 +164,13 @@ func (m *Type) Create(id string, conf stream.Config) error {
 	// This seems a bit wonky but we can't rule out a race condition between
 	// the stream terminating and setClosed and actually initialising a status.
 	wrapper := newStreamStatus(conf, strmFlatMetrics)
+	pauseOpt := stream.OptPausable()
+	if paused {
+		pauseOpt = stream.OptPaused()
+	}
 	strm, err := stream.New(conf, sMgr, stream.OptOnClose(func() {
 		wrapper.setClosed()
-	}))
+	}), pauseOpt)
 	if err != nil {
 		return err
 	}
@@ -179,10 +199,11 @@ func (m *Type) Read(id string) (*StreamStatus, error) {
 }
 
 // Update attempts to stop an existing stream and replace it with a new version
-// of the same stream.
+// of the same stream. If the existing stream is paused then the new version is
+// created paused, and therefore consumes nothing until resumed.
 func (m *Type) Update(ctx context.Context, id string, conf stream.Config) error {
 	m.lock.Lock()
-	_, exists := m.streams[id]
+	wrapper, exists := m.streams[id]
 	closed := m.closed
 	m.lock.Unlock()
 
@@ -193,10 +214,32 @@ func (m *Type) Update(ctx context.Context, id string, conf stream.Config) error
 		return ErrStreamDoesNotExist
 	}
 
+	paused := wrapper.IsPaused()
 	if err := m.Delete(ctx, id); err != nil {
 		return err
 	}
-	return m.Create(id, conf)
+	return m.create(id, conf, paused)
+}
+
+// Pause stops a stream from consuming from its input without closing it, such
+// that it can later be resumed with Resume. Returns an error if the stream was
+// not found.
+func (m *Type) Pause(id string) error {
+	wrapper, err := m.Read(id)
+	if err != nil {
+		return err
+	}
+	return wrapper.strm.Pause()
+}
+
+// Resume continues consuming from the input of a paused stream. Returns an
+// error if the stream was not found.
+func (m *Type) Resume(id string) error {
+	wrapper, err := m.Read(id)
+	if err != nil {
+		return err
+	}
+	return wrapper.strm.Resume()
 }
 
 // Delete attempts to stop and remove a stream by its ID. Returns an error if
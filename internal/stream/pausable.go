@@ -0,0 +1,157 @@
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Jeffail/shutdown"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// pausableInput wraps an input such that consuming from it can be paused and
+// resumed. Whilst paused the input is left running and connected, but no
+// transactions are read from it.
+type pausableInput struct {
+	in      input.Streamed
+	mPaused metrics.StatGauge
+
+	mut        sync.Mutex
+	paused     bool
+	resumeChan chan struct{}
+
+	tranChan chan message.Transaction
+	shutSig  *shutdown.Signaller
+}
+
+func newPausableInput(in input.Streamed, stats metrics.Type, paused bool) *pausableInput {
+	p := &pausableInput{
+		in:         in,
+		mPaused:    stats.GetGauge("stream_paused"),
+		paused:     paused,
+		resumeChan: make(chan struct{}),
+		tranChan:   make(chan message.Transaction),
+		shutSig:    shutdown.NewSignaller(),
+	}
+	if paused {
+		p.mPaused.Set(1)
+	} else {
+		close(p.resumeChan)
+		p.mPaused.Set(0)
+	}
+	go p.loop()
+	return p
+}
+
+// waitForResume blocks until the input is no longer paused, returns false if
+// the input was hard stopped whilst waiting.
+func (p *pausableInput) waitForResume() bool {
+	p.mut.Lock()
+	resumeChan := p.resumeChan
+	p.mut.Unlock()
+
+	select {
+	case <-resumeChan:
+	case <-p.shutSig.SoftStopChan():
+		// When shutting down we deliver whatever remains so that the
+		// stream can drain.
+	case <-p.shutSig.HardStopChan():
+		return false
+	}
+	return true
+}
+
+func (p *pausableInput) loop() {
+	defer func() {
+		close(p.tranChan)
+		p.shutSig.TriggerHasStopped()
+	}()
+
+	for {
+		if !p.waitForResume() {
+			return
+		}
+
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-p.in.TransactionChan():
+			if !open {
+				return
+			}
+		case <-p.shutSig.HardStopChan():
+			return
+		}
+
+		// The stream might have been paused whilst we were waiting for the
+		// transaction, in which case it is held until resumed.
+		if !p.waitForResume() {
+			return
+		}
+
+		select {
+		case p.tranChan <- tran:
+		case <-p.shutSig.HardStopChan():
+			return
+		}
+	}
+}
+
+func (p *pausableInput) pause() {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if p.paused {
+		return
+	}
+	p.paused = true
+	p.resumeChan = make(chan struct{})
+	p.mPaused.Set(1)
+}
+
+func (p *pausableInput) resume() {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if !p.paused {
+		return
+	}
+	p.paused = false
+	close(p.resumeChan)
+	p.mPaused.Set(0)
+}
+
+func (p *pausableInput) isPaused() bool {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.paused
+}
+
+func (p *pausableInput) TransactionChan() <-chan message.Transaction {
+	return p.tranChan
+}
+
+func (p *pausableInput) Connected() bool {
+	return p.in.Connected()
+}
+
+func (p *pausableInput) TriggerStopConsuming() {
+	p.shutSig.TriggerSoftStop()
+	p.in.TriggerStopConsuming()
+}
+
+func (p *pausableInput) TriggerCloseNow() {
+	p.shutSig.TriggerHardStop()
+	p.in.TriggerCloseNow()
+}
+
+func (p *pausableInput) WaitForClose(ctx context.Context) error {
+	select {
+	case <-p.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.in.WaitForClose(ctx)
+}
@@ -0,0 +1,362 @@
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Jeffail/shutdown"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// swappableInput wraps an input such that it can be replaced without closing
+// the transaction channel consumed by the rest of the stream. When swapped the
+// old input is stopped and its remaining transactions are forwarded before the
+// new input is created.
+type swappableInput struct {
+	mut      sync.Mutex
+	current  input.Streamed
+	swapping bool
+
+	nextChan chan input.Streamed
+	tranChan chan message.Transaction
+	shutSig  *shutdown.Signaller
+}
+
+func newSwappableInput(in input.Streamed) *swappableInput {
+	s := &swappableInput{
+		current:  in,
+		nextChan: make(chan input.Streamed),
+		tranChan: make(chan message.Transaction),
+		shutSig:  shutdown.NewSignaller(),
+	}
+	go s.loop()
+	return s
+}
+
+func (s *swappableInput) loop() {
+	defer func() {
+		close(s.tranChan)
+		s.shutSig.TriggerHasStopped()
+	}()
+
+	s.mut.Lock()
+	in := s.current
+	s.mut.Unlock()
+
+	for {
+		inChan := in.TransactionChan()
+	forwardLoop:
+		for {
+			select {
+			case tran, open := <-inChan:
+				if !open {
+					break forwardLoop
+				}
+				select {
+				case s.tranChan <- tran:
+				case <-s.shutSig.HardStopChan():
+					return
+				}
+			case <-s.shutSig.HardStopChan():
+				return
+			}
+		}
+
+		// The input closing is only expected during a swap, otherwise it has
+		// either finished or was stopped, and the stream shuts down by proxy.
+		s.mut.Lock()
+		swapping := s.swapping
+		s.mut.Unlock()
+		if !swapping || s.shutSig.IsSoftStopSignalled() {
+			return
+		}
+
+		select {
+		case in = <-s.nextChan:
+		case <-s.shutSig.SoftStopChan():
+			return
+		}
+	}
+}
+
+// swap stops the current input, waits for its remaining transactions to be
+// resolved, and then replaces it with an input created by the provided
+// constructor.
+func (s *swappableInput) swap(ctx context.Context, ctor func() (input.Streamed, error)) error {
+	s.mut.Lock()
+	old := s.current
+	s.swapping = true
+	s.mut.Unlock()
+
+	if old != nil {
+		old.TriggerStopConsuming()
+		if err := old.WaitForClose(ctx); err != nil {
+			old.TriggerCloseNow()
+		}
+	}
+
+	newIn, err := ctor()
+
+	s.mut.Lock()
+	s.current = newIn
+	s.mut.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case s.nextChan <- newIn:
+	case <-s.shutSig.SoftStopChan():
+		newIn.TriggerCloseNow()
+		return component.ErrTypeClosed
+	}
+
+	s.mut.Lock()
+	s.swapping = false
+	s.mut.Unlock()
+	return nil
+}
+
+func (s *swappableInput) TransactionChan() <-chan message.Transaction {
+	return s.tranChan
+}
+
+func (s *swappableInput) Connected() bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.current != nil && s.current.Connected()
+}
+
+func (s *swappableInput) TriggerStopConsuming() {
+	s.shutSig.TriggerSoftStop()
+	s.mut.Lock()
+	if s.current != nil {
+		s.current.TriggerStopConsuming()
+	}
+	s.mut.Unlock()
+}
+
+func (s *swappableInput) TriggerCloseNow() {
+	s.shutSig.TriggerHardStop()
+	s.mut.Lock()
+	if s.current != nil {
+		s.current.TriggerCloseNow()
+	}
+	s.mut.Unlock()
+}
+
+func (s *swappableInput) WaitForClose(ctx context.Context) error {
+	select {
+	case <-s.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.mut.Lock()
+	current := s.current
+	s.mut.Unlock()
+	if current != nil {
+		return current.WaitForClose(ctx)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type outputSwapRequest struct {
+	ctx     context.Context
+	ctor    func() (output.Streamed, error)
+	resChan chan error
+}
+
+// swappableOutput wraps an output such that it can be replaced without closing
+// the transaction channel it consumes from the rest of the stream. When swapped
+// the old output is given the opportunity to finish writing its in-flight
+// transactions before the new output is created.
+type swappableOutput struct {
+	mut     sync.Mutex
+	current output.Streamed
+
+	swapChan chan outputSwapRequest
+	shutSig  *shutdown.Signaller
+}
+
+func newSwappableOutput(out output.Streamed) *swappableOutput {
+	return &swappableOutput{
+		current:  out,
+		swapChan: make(chan outputSwapRequest),
+		shutSig:  shutdown.NewSignaller(),
+	}
+}
+
+func (s *swappableOutput) Consume(tranChan <-chan message.Transaction) error {
+	outChan := make(chan message.Transaction)
+	if err := s.current.Consume(outChan); err != nil {
+		return err
+	}
+	go s.loop(tranChan, outChan)
+	return nil
+}
+
+func (s *swappableOutput) getCurrent() output.Streamed {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.current
+}
+
+func (s *swappableOutput) loop(tranChan <-chan message.Transaction, outChan chan message.Transaction) {
+	defer func() {
+		if outChan != nil {
+			close(outChan)
+		}
+		if current := s.getCurrent(); current != nil {
+			closeCtx, done := s.shutSig.HardStopCtx(context.Background())
+			if err := current.WaitForClose(closeCtx); err != nil {
+				current.TriggerCloseNow()
+				_ = current.WaitForClose(context.Background())
+			}
+			done()
+		}
+		s.shutSig.TriggerHasStopped()
+	}()
+
+	doSwap := func(req outputSwapRequest) {
+		// Closing the transaction channel of the old output allows it to finish
+		// writing in-flight transactions before shutting down.
+		if old := s.getCurrent(); old != nil {
+			close(outChan)
+			if err := old.WaitForClose(req.ctx); err != nil {
+				old.TriggerCloseNow()
+			}
+		}
+		outChan = nil
+
+		newOut, err := req.ctor()
+		if err == nil {
+			newChan := make(chan message.Transaction)
+			if err = newOut.Consume(newChan); err == nil {
+				outChan = newChan
+			}
+		}
+		if err != nil {
+			newOut = nil
+		}
+
+		s.mut.Lock()
+		s.current = newOut
+		s.mut.Unlock()
+		req.resChan <- err
+	}
+
+	for {
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-tranChan:
+			if !open {
+				return
+			}
+		case req := <-s.swapChan:
+			doSwap(req)
+			continue
+		case <-s.shutSig.HardStopChan():
+			return
+		}
+
+		for sent := false; !sent; {
+			// Without an output (a swap failed) we must wait for a successful
+			// swap before delivering.
+			var sendChan chan message.Transaction
+			if outChan != nil {
+				sendChan = outChan
+			}
+			select {
+			case sendChan <- tran:
+				sent = true
+			case req := <-s.swapChan:
+				doSwap(req)
+			case <-s.shutSig.HardStopChan():
+				return
+			}
+		}
+	}
+}
+
+// swap replaces the current output with an output created by the provided
+// constructor once the current output has finished writing its in-flight
+// transactions.
+func (s *swappableOutput) swap(ctx context.Context, ctor func() (output.Streamed, error)) error {
+	req := outputSwapRequest{ctx: ctx, ctor: ctor, resChan: make(chan error, 1)}
+	select {
+	case s.swapChan <- req:
+	case <-s.shutSig.HasStoppedChan():
+		return component.ErrTypeClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return <-req.resChan
+}
+
+func (s *swappableOutput) Connected() bool {
+	current := s.getCurrent()
+	return current != nil && current.Connected()
+}
+
+func (s *swappableOutput) TriggerCloseNow() {
+	s.shutSig.TriggerHardStop()
+	if current := s.getCurrent(); current != nil {
+		current.TriggerCloseNow()
+	}
+}
+
+func (s *swappableOutput) WaitForClose(ctx context.Context) error {
+	select {
+	case <-s.shutSig.HasStoppedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// swappableProcessor wraps a processor of the pipeline such that it can be
+// replaced without interrupting the pipeline. When swapped the old processor
+// finishes processing its in-flight batches before being closed.
+type swappableProcessor struct {
+	mut     sync.RWMutex
+	current processor.V1
+}
+
+func newSwappableProcessor(p processor.V1) *swappableProcessor {
+	return &swappableProcessor{current: p}
+}
+
+// swap replaces the current processor with an already constructed processor
+// once all batches currently being processed have finished, and then closes
+// the old processor.
+func (s *swappableProcessor) swap(ctx context.Context, newProc processor.V1) error {
+	s.mut.Lock()
+	old := s.current
+	s.current = newProc
+	s.mut.Unlock()
+	return old.Close(ctx)
+}
+
+func (s *swappableProcessor) ProcessBatch(ctx context.Context, b message.Batch) ([]message.Batch, error) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.current.ProcessBatch(ctx, b)
+}
+
+func (s *swappableProcessor) Close(ctx context.Context) error {
+	s.mut.RLock()
+	current := s.current
+	s.mut.RUnlock()
+	return current.Close(ctx)
+}
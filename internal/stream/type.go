@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"runtime/pprof"
+	"strconv"
 	"sync/atomic"
 	"time"
 
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/buffer"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
@@ -27,8 +32,24 @@ type Type struct {
 	pipelineLayer processor.Pipeline
 	outputLayer   output.Streamed
 
+	deadLetterLayer  *pipeline.DeadLetter
+	deadLetterOutput output.Streamed
+
 	manager bundle.NewManagement
 
+	// When enabled the input and output layers, as well as each processor of
+	// the pipeline, are wrapped such that they can be swapped with Update.
+	updatable  bool
+	swapInput  *swappableInput
+	swapOutput *swappableOutput
+	swapProcs  []*swappableProcessor
+
+	// When enabled the input layer is wrapped such that consuming from it can
+	// be paused.
+	pausable    bool
+	startPaused bool
+	pausedInput *pausableInput
+
 	onClose func()
 	closed  uint32
 }
@@ -51,13 +72,14 @@ func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, err
 	healthCheck := func(w http.ResponseWriter, r *http.Request) {
 		inputConnected := t.inputLayer.Connected()
 		outputConnected := t.outputLayer.Connected()
+		deadLetterConnected := t.deadLetterOutput == nil || t.deadLetterOutput.Connected()
 
 		if atomic.LoadUint32(&t.closed) == 1 {
 			http.Error(w, "Stream terminated", http.StatusNotFound)
 			return
 		}
 
-		if inputConnected && outputConnected {
+		if inputConnected && outputConnected && deadLetterConnected {
 			_, _ = w.Write([]byte("OK"))
 			return
 		}
@@ -69,11 +91,15 @@ func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, err
 		if !outputConnected {
 			_, _ = w.Write([]byte("output not connected\n"))
 		}
+		if !deadLetterConnected {
+			_, _ = w.Write([]byte("dead letter output not connected\n"))
+		}
 	}
 	t.manager.RegisterEndpoint(
 		"/ready",
 		"Returns 200 OK if all inputs and outputs are connected, otherwise a 503 is returned.",
 		healthCheck,
+		api.ReadyOperations()...,
 	)
 	return t, nil
 }
@@ -87,11 +113,71 @@ func OptOnClose(onClose func()) func(*Type) {
 	}
 }
 
+// OptIncrementalUpdates enables updating the stream with a new config via
+// Update, where only the components that have changed are replaced.
+func OptIncrementalUpdates() func(*Type) {
+	return func(t *Type) {
+		t.updatable = true
+	}
+}
+
+// OptPausable enables pausing and resuming the consumption of the input of the
+// stream via Pause and Resume.
+func OptPausable() func(*Type) {
+	return func(t *Type) {
+		t.pausable = true
+	}
+}
+
+// OptPaused enables pausing and resuming the consumption of the input of the
+// stream, where the stream is paused from the start and therefore consumes
+// nothing from its input until resumed.
+func OptPaused() func(*Type) {
+	return func(t *Type) {
+		t.pausable = true
+		t.startPaused = true
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ErrNotPausable is returned when attempting to pause a stream that was not
+// created with OptPausable.
+var ErrNotPausable = errors.New("stream does not support pausing")
+
+// Pause stops the stream from consuming further transactions from its input,
+// where the input, buffer, pipeline and output layers remain running and
+// connected such that in-flight transactions are still resolved.
+func (t *Type) Pause() error {
+	if t.pausedInput == nil {
+		return ErrNotPausable
+	}
+	t.pausedInput.pause()
+	return nil
+}
+
+// Resume continues consuming transactions from the input of a paused stream.
+func (t *Type) Resume() error {
+	if t.pausedInput == nil {
+		return ErrNotPausable
+	}
+	t.pausedInput.resume()
+	return nil
+}
+
+// IsPaused returns a boolean indicating whether the stream is paused.
+func (t *Type) IsPaused() bool {
+	return t.pausedInput != nil && t.pausedInput.isPaused()
+}
+
 //------------------------------------------------------------------------------
 
 // IsReady returns a boolean indicating whether both the input and output layers
-// of the stream are connected.
+// of the stream are connected, as well as the dead letter output if configured.
 func (t *Type) IsReady() bool {
+	if t.deadLetterOutput != nil && !t.deadLetterOutput.Connected() {
+		return false
+	}
 	return t.inputLayer.Connected() && t.outputLayer.Connected()
 }
 
@@ -101,6 +187,14 @@ func (t *Type) start() (err error) {
 	if t.inputLayer, err = iMgr.NewInput(t.conf.Input); err != nil {
 		return
 	}
+	if t.updatable {
+		t.swapInput = newSwappableInput(t.inputLayer)
+		t.inputLayer = t.swapInput
+	}
+	if t.pausable {
+		t.pausedInput = newPausableInput(t.inputLayer, t.manager.Metrics(), t.startPaused)
+		t.inputLayer = t.pausedInput
+	}
 	if t.conf.Buffer.Type != "none" {
 		bMgr := t.manager.IntoPath("buffer")
 		if t.bufferLayer, err = bMgr.NewBuffer(t.conf.Buffer); err != nil {
@@ -109,7 +203,15 @@ func (t *Type) start() (err error) {
 	}
 	if tLen := len(t.conf.Pipeline.Processors); tLen > 0 {
 		pMgr := t.manager.IntoPath("pipeline")
-		if t.pipelineLayer, err = pipeline.New(t.conf.Pipeline, pMgr); err != nil {
+		var wrap func(int, processor.V1) processor.V1
+		if t.updatable {
+			t.swapProcs = make([]*swappableProcessor, tLen)
+			wrap = func(i int, p processor.V1) processor.V1 {
+				t.swapProcs[i] = newSwappableProcessor(p)
+				return t.swapProcs[i]
+			}
+		}
+		if t.pipelineLayer, err = pipeline.NewWrapped(t.conf.Pipeline, pMgr, t.conf.DeadLetter != nil, wrap); err != nil {
 			return
 		}
 	}
@@ -117,6 +219,22 @@ func (t *Type) start() (err error) {
 	if t.outputLayer, err = oMgr.NewOutput(t.conf.Output); err != nil {
 		return
 	}
+	if t.updatable {
+		t.swapOutput = newSwappableOutput(t.outputLayer)
+		t.outputLayer = t.swapOutput
+	}
+	if t.conf.DeadLetter != nil {
+		dMgr := t.manager.IntoPath("dead_letter", "output")
+		if t.deadLetterOutput, err = dMgr.NewOutput(t.conf.DeadLetter.Output); err != nil {
+			return
+		}
+
+		outputLabel := t.conf.Output.Label
+		if outputLabel == "" {
+			outputLabel = "output"
+		}
+		t.deadLetterLayer = pipeline.NewDeadLetter(t.conf.DeadLetter.MaxAttempts, outputLabel)
+	}
 
 	// Start chaining components
 	var nextTranChan <-chan message.Transaction
@@ -128,6 +246,15 @@ func (t *Type) start() (err error) {
 		}
 		nextTranChan = t.bufferLayer.TransactionChan()
 	}
+	if t.deadLetterLayer != nil {
+		if err = t.deadLetterLayer.Consume(nextTranChan); err != nil {
+			return
+		}
+		nextTranChan = t.deadLetterLayer.TransactionChan()
+		if err = t.deadLetterOutput.Consume(t.deadLetterLayer.DeadLetterChan()); err != nil {
+			return
+		}
+	}
 	if t.pipelineLayer != nil {
 		if err = t.pipelineLayer.Consume(nextTranChan); err != nil {
 			return
@@ -151,6 +278,144 @@ func (t *Type) start() (err error) {
 	return nil
 }
 
+// Update attempts to apply a new config to a running stream by only replacing
+// the input, output and pipeline processors that have changed, where a replaced
+// input is stopped and its pending transactions are resolved before the new
+// input is created, a replaced output finishes writing its in-flight
+// transactions before the new output is created, and a replaced processor
+// finishes processing its in-flight batches before being closed. Components
+// that have not changed continue running uninterrupted. Brokers are replaced as
+// a whole when any of their children change.
+//
+// Returns false if the stream cannot be updated incrementally, either because
+// it was not created with OptIncrementalUpdates, because a layer that cannot be
+// swapped (the buffer, dead letter, or the number of pipeline processors or
+// threads) has changed, or because a component could not be replaced and the
+// previous component could not be restored, in which case the stream should be
+// recreated instead. When true is returned alongside an error the stream
+// continues running, with any component that failed to be replaced left as it
+// was before the update.
+func (t *Type) Update(ctx context.Context, newConf Config) (bool, error) {
+	if !t.updatable {
+		return false, nil
+	}
+
+	if configChanged(t.conf.Buffer, newConf.Buffer) ||
+		configChanged(t.conf.DeadLetter, newConf.DeadLetter) ||
+		(t.conf.DeadLetter != nil && t.conf.Output.Label != newConf.Output.Label) {
+		return false, nil
+	}
+
+	changedProcs, ok := t.changedProcessors(newConf.Pipeline)
+	if !ok {
+		return false, nil
+	}
+	inputChanged := configChanged(t.conf.Input, newConf.Input)
+	outputChanged := configChanged(t.conf.Output, newConf.Output)
+
+	// Replacement processors are created before any component is swapped so
+	// that a processor failing to initialise leaves the stream untouched.
+	newProcs := make([]processor.V1, 0, len(changedProcs))
+	for _, i := range changedProcs {
+		pMgr := t.manager.IntoPath("pipeline", "processors", strconv.Itoa(i))
+		p, err := pMgr.NewProcessor(newConf.Pipeline.Processors[i])
+		if err != nil {
+			for _, p := range newProcs {
+				_ = p.Close(ctx)
+			}
+			return true, fmt.Errorf("failed to replace processor %v: %w", i, err)
+		}
+		newProcs = append(newProcs, p)
+	}
+	for j, i := range changedProcs {
+		t.manager.Logger().Info("Processor %v config changed, replacing processor", i)
+		if err := t.swapProcs[i].swap(ctx, newProcs[j]); err != nil {
+			t.manager.Logger().Error("Failed to close replaced processor %v: %v", i, err)
+		}
+	}
+	t.conf.Pipeline = newConf.Pipeline
+
+	if inputChanged {
+		t.manager.Logger().Info("Input config changed, replacing input")
+		if err := t.swapInput.swap(ctx, t.inputCtor(newConf.Input)); err != nil {
+			err = fmt.Errorf("failed to replace input: %w", err)
+			t.manager.Logger().Error("%v, restoring previous input", err)
+			if rErr := t.swapInput.swap(ctx, t.inputCtor(t.conf.Input)); rErr != nil {
+				return false, fmt.Errorf("%w, and failed to restore previous input: %v", err, rErr)
+			}
+			return true, err
+		}
+		t.conf.Input = newConf.Input
+	}
+
+	if outputChanged {
+		t.manager.Logger().Info("Output config changed, replacing output")
+		if err := t.swapOutput.swap(ctx, t.outputCtor(newConf.Output)); err != nil {
+			err = fmt.Errorf("failed to replace output: %w", err)
+			t.manager.Logger().Error("%v, restoring previous output", err)
+			if rErr := t.swapOutput.swap(ctx, t.outputCtor(t.conf.Output)); rErr != nil {
+				return false, fmt.Errorf("%w, and failed to restore previous output: %v", err, rErr)
+			}
+			return true, err
+		}
+		t.conf.Output = newConf.Output
+	}
+
+	if !inputChanged && !outputChanged && len(changedProcs) == 0 {
+		t.manager.Logger().Info("Stream config unchanged, components left running")
+	}
+	return true, nil
+}
+
+// changedProcessors returns the indexes of pipeline processors that differ
+// from a new pipeline config, or false if the pipeline cannot be updated by
+// replacing individual processors.
+func (t *Type) changedProcessors(newConf pipeline.Config) ([]int, bool) {
+	oldConf := t.conf.Pipeline
+	if oldConf.Threads != newConf.Threads || len(oldConf.Processors) != len(newConf.Processors) {
+		return nil, false
+	}
+
+	var changed []int
+	for i, oldProc := range oldConf.Processors {
+		newProc := newConf.Processors[i]
+		if !configChanged(oldProc, newProc) {
+			continue
+		}
+		// Errors flagged by processors are labelled for the dead letter output
+		// with the label the pipeline was created with.
+		if t.conf.DeadLetter != nil && oldProc.Label != newProc.Label {
+			return nil, false
+		}
+		changed = append(changed, i)
+	}
+	return changed, true
+}
+
+func (t *Type) inputCtor(conf input.Config) func() (input.Streamed, error) {
+	return func() (input.Streamed, error) {
+		return t.manager.IntoPath("input").NewInput(conf)
+	}
+}
+
+func (t *Type) outputCtor(conf output.Config) func() (output.Streamed, error) {
+	return func() (output.Streamed, error) {
+		return t.manager.IntoPath("output").NewOutput(conf)
+	}
+}
+
+// configChanged returns true if two configs differ in their YAML
+// representation, which ignores the positions of nodes within the source
+// file.
+func configChanged(a, b any) bool {
+	aBytes, aErr := yaml.Marshal(a)
+	bBytes, bErr := yaml.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return true
+	}
+	return !bytes.Equal(aBytes, bBytes)
+}
+
 // StopGracefully attempts to close the stream in the most graceful way by only
 // closing the input layer and waiting for all other layers to terminate by
 // proxy. This should guarantee that all in-flight and buffered data is resolved
@@ -170,7 +435,15 @@ func (t *Type) StopGracefully(ctx context.Context) (err error) {
 		}
 	}
 
-	// After this point we can start closing the remaining components.
+	// After this point we can start closing the remaining components. The
+	// dead letter layer only closes once all pending transactions have been
+	// resolved.
+	if t.deadLetterLayer != nil {
+		if err = t.deadLetterLayer.WaitForClose(ctx); err != nil {
+			return
+		}
+	}
+
 	if t.pipelineLayer != nil {
 		if err = t.pipelineLayer.WaitForClose(ctx); err != nil {
 			return
@@ -180,6 +453,12 @@ func (t *Type) StopGracefully(ctx context.Context) (err error) {
 	if err = t.outputLayer.WaitForClose(ctx); err != nil {
 		return
 	}
+
+	if t.deadLetterOutput != nil {
+		if err = t.deadLetterOutput.WaitForClose(ctx); err != nil {
+			return
+		}
+	}
 	return nil
 }
 
@@ -191,10 +470,16 @@ func (t *Type) StopUnordered(ctx context.Context) (err error) {
 	if t.bufferLayer != nil {
 		t.bufferLayer.TriggerCloseNow()
 	}
+	if t.deadLetterLayer != nil {
+		t.deadLetterLayer.TriggerCloseNow()
+	}
 	if t.pipelineLayer != nil {
 		t.pipelineLayer.TriggerCloseNow()
 	}
 	t.outputLayer.TriggerCloseNow()
+	if t.deadLetterOutput != nil {
+		t.deadLetterOutput.TriggerCloseNow()
+	}
 
 	if err = t.inputLayer.WaitForClose(ctx); err != nil {
 		return
@@ -206,6 +491,12 @@ func (t *Type) StopUnordered(ctx context.Context) (err error) {
 		}
 	}
 
+	if t.deadLetterLayer != nil {
+		if err = t.deadLetterLayer.WaitForClose(ctx); err != nil {
+			return
+		}
+	}
+
 	if t.pipelineLayer != nil {
 		if err = t.pipelineLayer.WaitForClose(ctx); err != nil {
 			return
@@ -215,6 +506,12 @@ func (t *Type) StopUnordered(ctx context.Context) (err error) {
 	if err = t.outputLayer.WaitForClose(ctx); err != nil {
 		return
 	}
+
+	if t.deadLetterOutput != nil {
+		if err = t.deadLetterOutput.WaitForClose(ctx); err != nil {
+			return
+		}
+	}
 	return nil
 }
 
@@ -2,6 +2,7 @@ package stream_test
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +12,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/component/testutil"
 	"github.com/benthosdev/benthos/v4/internal/manager"
@@ -166,7 +169,7 @@ type mockAPIReg struct {
 	server *httptest.Server
 }
 
-func (ar mockAPIReg) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
+func (ar mockAPIReg) RegisterEndpoint(path, desc string, h http.HandlerFunc, ops ...api.OperationSpec) {
 	ar.server.Config.Handler = h
 }
 
@@ -231,3 +234,408 @@ output:
 
 	validateHealthCheckResponse(t, mockAPIReg.server.URL, "Stream terminated\n")
 }
+
+func readPipeTran(t testing.TB, ctx context.Context, tChan <-chan message.Transaction) message.Transaction {
+	t.Helper()
+
+	select {
+	case tran, open := <-tChan:
+		require.True(t, open)
+		return tran
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+	return message.Transaction{}
+}
+
+func TestStreamDeadLetterProcessorErrors(t *testing.T) {
+	conf, err := testutil.StreamFromYAML(`
+input:
+  generate:
+    count: 3
+    interval: ""
+    mapping: 'root.id = count("dead_letter_processor_errors")'
+pipeline:
+  threads: 1
+  processors:
+    - label: checker
+      mutation: |
+        root.mutated = true
+        root.id = if this.id == 2 { throw("bad id") } else { this.id }
+output:
+  inproc: dead_letter_proc_out
+dead_letter:
+  output:
+    inproc: dead_letter_proc_dlq
+`)
+	require.NoError(t, err)
+
+	newMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	outChan, err := newMgr.GetPipe("dead_letter_proc_out")
+	require.NoError(t, err)
+
+	dlqChan, err := newMgr.GetPipe("dead_letter_proc_dlq")
+	require.NoError(t, err)
+
+	// Delivery to the dead letter output applies back pressure to the pipeline
+	// and therefore messages arrive in order across both outputs.
+	tran := readPipeTran(t, ctx, outChan)
+	require.Len(t, tran.Payload, 1)
+	assert.Equal(t, `{"id":1,"mutated":true}`, string(tran.Payload[0].AsBytes()))
+	require.NoError(t, tran.Ack(ctx, nil))
+
+	tran = readPipeTran(t, ctx, dlqChan)
+	require.Len(t, tran.Payload, 1)
+
+	p := tran.Payload[0]
+	assert.Equal(t, `{"id":2}`, string(p.AsBytes()))
+	assert.Contains(t, p.MetaGetStr("dead_letter_error"), "bad id")
+	assert.Equal(t, "checker", p.MetaGetStr("dead_letter_label"))
+	assert.Equal(t, "1", p.MetaGetStr("dead_letter_attempts"))
+	_, err = time.Parse(time.RFC3339Nano, p.MetaGetStr("dead_letter_timestamp"))
+	assert.NoError(t, err)
+	require.NoError(t, tran.Ack(ctx, nil))
+
+	tran = readPipeTran(t, ctx, outChan)
+	require.Len(t, tran.Payload, 1)
+	assert.Equal(t, `{"id":3,"mutated":true}`, string(tran.Payload[0].AsBytes()))
+	require.NoError(t, tran.Ack(ctx, nil))
+
+	require.NoError(t, strm.StopGracefully(ctx))
+}
+
+func TestStreamDeadLetterOutputRejections(t *testing.T) {
+	conf, err := testutil.StreamFromYAML(`
+input:
+  generate:
+    count: 1
+    interval: ""
+    mapping: 'root = "hello world"'
+output:
+  label: sink
+  reject: 'nope'
+dead_letter:
+  max_attempts: 3
+  output:
+    inproc: dead_letter_out_dlq
+`)
+	require.NoError(t, err)
+
+	newMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	dlqChan, err := newMgr.GetPipe("dead_letter_out_dlq")
+	require.NoError(t, err)
+
+	// Reject the message from the dead letter output once, which should
+	// result in it being attempted all over again.
+	tran := readPipeTran(t, ctx, dlqChan)
+	require.NoError(t, tran.Ack(ctx, errors.New("dead letter output failed")))
+
+	tran = readPipeTran(t, ctx, dlqChan)
+	require.Len(t, tran.Payload, 1)
+
+	p := tran.Payload[0]
+	assert.Equal(t, "hello world", string(p.AsBytes()))
+	assert.Equal(t, "nope", p.MetaGetStr("dead_letter_error"))
+	assert.Equal(t, "sink", p.MetaGetStr("dead_letter_label"))
+	assert.Equal(t, "3", p.MetaGetStr("dead_letter_attempts"))
+	require.NoError(t, tran.Ack(ctx, nil))
+
+	require.NoError(t, strm.StopGracefully(ctx))
+}
+
+func TestStreamIncrementalUpdate(t *testing.T) {
+	confA, err := testutil.StreamFromYAML(`
+input:
+  inproc: in_a
+pipeline:
+  processors:
+    - mapping: 'root = content().uppercase()'
+output:
+  inproc: out_a
+`)
+	require.NoError(t, err)
+
+	newMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	strm, err := stream.New(confA, newMgr, stream.OptIncrementalUpdates())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	// Inputs keep consuming from the pipe they connected to, and therefore each
+	// pipe is only set once.
+	inChans := map[string]chan message.Transaction{}
+	sendAndReceive := func(in, out, content string) {
+		t.Helper()
+
+		inChan, exists := inChans[in]
+		if !exists {
+			inChan = make(chan message.Transaction)
+			inChans[in] = inChan
+			newMgr.SetPipe(in, inChan)
+		}
+
+		resChan := make(chan error, 1)
+		select {
+		case inChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(content)}), resChan):
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+
+		var outChan <-chan message.Transaction
+		require.Eventually(t, func() bool {
+			outChan, err = newMgr.GetPipe(out)
+			return err == nil
+		}, time.Second*5, time.Millisecond*10)
+
+		select {
+		case tran := <-outChan:
+			require.Len(t, tran.Payload, 1)
+			assert.Equal(t, content, string(tran.Payload[0].AsBytes()))
+			require.NoError(t, tran.Ack(ctx, nil))
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+
+		select {
+		case err := <-resChan:
+			require.NoError(t, err)
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+
+	sendAndReceive("in_a", "out_a", "HELLO")
+
+	// Replace the input and output, leaving the pipeline running.
+	confB, err := testutil.StreamFromYAML(`
+input:
+  inproc: in_b
+pipeline:
+  processors:
+    - mapping: 'root = content().uppercase()'
+output:
+  inproc: out_b
+`)
+	require.NoError(t, err)
+
+	updated, err := strm.Update(ctx, confB)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	sendAndReceive("in_b", "out_b", "WORLD")
+
+	// Replace a processor, leaving the input and output running.
+	confC, err := testutil.StreamFromYAML(`
+input:
+  inproc: in_b
+pipeline:
+  processors:
+    - mapping: 'root = content().lowercase()'
+output:
+  inproc: out_b
+`)
+	require.NoError(t, err)
+
+	updated, err = strm.Update(ctx, confC)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	sendAndReceive("in_b", "out_b", "foo")
+
+	// A processor that fails to initialise leaves the stream untouched.
+	confD, err := testutil.StreamFromYAML(`
+input:
+  inproc: in_c
+pipeline:
+  processors:
+    - mapping: 'root = ('
+output:
+  inproc: out_b
+`)
+	require.NoError(t, err)
+
+	updated, err = strm.Update(ctx, confD)
+	require.Error(t, err)
+	assert.True(t, updated)
+
+	sendAndReceive("in_b", "out_b", "bar")
+
+	// An input that fails to initialise is replaced with the previous input.
+	confE, err := testutil.StreamFromYAML(`
+input:
+  generate:
+    mapping: 'root = ('
+pipeline:
+  processors:
+    - mapping: 'root = content().lowercase()'
+output:
+  inproc: out_b
+`)
+	require.NoError(t, err)
+
+	updated, err = strm.Update(ctx, confE)
+	require.Error(t, err)
+	assert.True(t, updated)
+
+	sendAndReceive("in_b", "out_b", "baz")
+
+	// Changing the number of pipeline threads requires the stream to be
+	// recreated.
+	confF, err := testutil.StreamFromYAML(`
+input:
+  inproc: in_b
+pipeline:
+  threads: 2
+  processors:
+    - mapping: 'root = content().lowercase()'
+output:
+  inproc: out_b
+`)
+	require.NoError(t, err)
+
+	updated, err = strm.Update(ctx, confF)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	require.NoError(t, strm.Stop(ctx))
+}
+
+func TestStreamPauseResume(t *testing.T) {
+	conf, err := testutil.StreamFromYAML(`
+input:
+  inproc: in_pause
+output:
+  inproc: out_pause
+`)
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	newMgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetMetrics(metrics.NewNamespaced(stats)))
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+	require.ErrorIs(t, strm.Pause(), stream.ErrNotPausable)
+	assert.False(t, strm.IsPaused())
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+	require.NoError(t, strm.Stop(ctx))
+
+	strm, err = stream.New(conf, newMgr, stream.OptPausable())
+	require.NoError(t, err)
+
+	inChan := make(chan message.Transaction)
+	newMgr.SetPipe("in_pause", inChan)
+
+	outChan, err := newMgr.GetPipe("out_pause")
+	require.NoError(t, err)
+
+	require.NoError(t, strm.Pause())
+	assert.True(t, strm.IsPaused())
+	assert.Equal(t, int64(1), stats.GetCounters()["stream_paused"])
+
+	resChan := make(chan error, 1)
+	select {
+	case inChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), resChan):
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+
+	select {
+	case <-outChan:
+		t.Fatal("received message whilst paused")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	require.NoError(t, strm.Resume())
+	assert.False(t, strm.IsPaused())
+	assert.Equal(t, int64(0), stats.GetCounters()["stream_paused"])
+
+	select {
+	case tran := <-outChan:
+		assert.Equal(t, "hello", string(tran.Payload.Get(0).AsBytes()))
+		require.NoError(t, tran.Ack(ctx, nil))
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+	require.NoError(t, <-resChan)
+
+	require.NoError(t, strm.Stop(ctx))
+}
+
+func TestStreamStartPaused(t *testing.T) {
+	conf, err := testutil.StreamFromYAML(`
+input:
+  inproc: in_start_paused
+output:
+  inproc: out_start_paused
+`)
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	newMgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetMetrics(metrics.NewNamespaced(stats)))
+	require.NoError(t, err)
+
+	inChan := make(chan message.Transaction)
+	newMgr.SetPipe("in_start_paused", inChan)
+
+	strm, err := stream.New(conf, newMgr, stream.OptPaused())
+	require.NoError(t, err)
+	assert.True(t, strm.IsPaused())
+	assert.Equal(t, int64(1), stats.GetCounters()["stream_paused"])
+
+	outChan, err := newMgr.GetPipe("out_start_paused")
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	resChan := make(chan error, 1)
+	go func() {
+		select {
+		case inChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), resChan):
+		case <-ctx.Done():
+		}
+	}()
+
+	select {
+	case <-outChan:
+		t.Fatal("received message whilst paused")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	require.NoError(t, strm.Resume())
+	assert.False(t, strm.IsPaused())
+	assert.Equal(t, int64(0), stats.GetCounters()["stream_paused"])
+
+	select {
+	case tran := <-outChan:
+		assert.Equal(t, "hello", string(tran.Payload.Get(0).AsBytes()))
+		require.NoError(t, tran.Ack(ctx, nil))
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+	require.NoError(t, <-resChan)
+
+	require.NoError(t, strm.Stop(ctx))
+}
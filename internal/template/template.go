@@ -103,19 +103,25 @@ func RegisterTemplateYAML(env *bundle.Environment, template []byte) error {
 // RegisterTemplate attempts to add a template component to the global list of
 // component types.
 func registerTemplate(env *bundle.Environment, tmpl *compiled) error {
+	var err error
 	switch tmpl.spec.Type {
 	case docs.TypeCache:
-		return registerCacheTemplate(tmpl, env)
+		err = registerCacheTemplate(tmpl, env)
 	case docs.TypeInput:
-		return registerInputTemplate(tmpl, env)
+		err = registerInputTemplate(tmpl, env)
 	case docs.TypeOutput:
-		return registerOutputTemplate(tmpl, env)
+		err = registerOutputTemplate(tmpl, env)
 	case docs.TypeProcessor:
-		return registerProcessorTemplate(tmpl, env)
+		err = registerProcessorTemplate(tmpl, env)
 	case docs.TypeRateLimit:
-		return registerRateLimitTemplate(tmpl, env)
+		err = registerRateLimitTemplate(tmpl, env)
+	default:
+		err = fmt.Errorf("unable to register template for component type %v", tmpl.spec.Type)
+	}
+	if err == nil {
+		env.TemplateAdd(tmpl.spec.Type, tmpl.spec.Name, tmpl.Render)
 	}
-	return fmt.Errorf("unable to register template for component type %v", tmpl.spec.Type)
+	return err
 }
 
 // WithMetricsMapping attempts to wrap the metrics of a manager with a metrics
@@ -8,11 +8,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/template"
@@ -299,3 +301,36 @@ mapping: |
 	assert.Greater(t, d, time.Hour-time.Minute)
 	assert.Less(t, d, time.Hour+time.Minute)
 }
+
+func TestTemplateRenderClonedEnvironment(t *testing.T) {
+	env := bundle.GlobalEnvironment.Clone()
+
+	require.NoError(t, template.RegisterTemplateYAML(env, []byte(`
+name: foo_cloned_memory
+type: cache
+
+fields:
+  - name: foovalue
+    type: string
+
+mapping: |
+  root.memory.init_values.foo = this.foovalue
+`)))
+
+	_, exists, err := bundle.GlobalEnvironment.TemplateRender(docs.TypeCache, "foo_cloned_memory", map[string]any{})
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	for _, e := range []*bundle.Environment{env, env.Clone()} {
+		res, exists, err := e.TemplateRender(docs.TypeCache, "foo_cloned_memory", map[string]any{
+			"foovalue": "meow",
+		})
+		require.NoError(t, err)
+		require.True(t, exists)
+		assert.Equal(t, map[string]any{
+			"memory": map[string]any{
+				"init_values": map[string]any{"foo": "meow"},
+			},
+		}, res)
+	}
+}
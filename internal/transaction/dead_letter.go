@@ -0,0 +1,176 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// Metadata keys added to messages that are routed to a dead letter output.
+const (
+	DeadLetterMetaError     = "dead_letter_error"
+	DeadLetterMetaLabel     = "dead_letter_label"
+	DeadLetterMetaAttempts  = "dead_letter_attempts"
+	DeadLetterMetaTimestamp = "dead_letter_timestamp"
+)
+
+// ComponentError is an error that identifies the component responsible for a
+// message failing, which is used in order to annotate messages routed to a dead
+// letter output.
+type ComponentError struct {
+	Label string
+	Err   error
+}
+
+// Error implements the common error interface.
+func (e *ComponentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying common error.
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// DeadLetterRouter determines what happens to the messages of DeadLetter
+// transactions that are rejected by downstream components.
+type DeadLetterRouter struct {
+	// MaxAttempts is the number of times a message is attempted before it is
+	// routed to the dead letter output.
+	MaxAttempts int
+
+	// DefaultLabel is used to annotate failed messages when the error does
+	// not identify the component responsible.
+	DefaultLabel string
+
+	// Retry dispatches a transaction downstream once again.
+	Retry func(ctx context.Context, t *DeadLetter) error
+
+	// Route delivers a batch of failed messages to the dead letter output and
+	// blocks until it is acknowledged.
+	Route func(ctx context.Context, b message.Batch) error
+}
+
+// DeadLetter is a transaction type that retains a copy of the original payload
+// of its messages. When downstream components reject messages the original
+// payloads of those messages are either attempted again or routed to a dead
+// letter output, and only if the dead letter output also rejects them is the
+// error propagated upstream.
+type DeadLetter struct {
+	router   *DeadLetterRouter
+	original message.Batch
+	msg      message.Batch
+	group    *message.SortGroup
+	attempts int
+	ackFn    func(context.Context, error) error
+}
+
+// NewTransaction creates a DeadLetter transaction from a message batch and an
+// upstream acknowledgement func.
+func (r *DeadLetterRouter) NewTransaction(msg message.Batch, ackFn func(context.Context, error) error) *DeadLetter {
+	return r.newTransaction(msg.DeepCopy(), 1, ackFn)
+}
+
+func (r *DeadLetterRouter) newTransaction(original message.Batch, attempts int, ackFn func(context.Context, error) error) *DeadLetter {
+	group, trackedMsg := message.NewSortGroup(original.DeepCopy())
+	return &DeadLetter{
+		router:   r,
+		original: original,
+		msg:      trackedMsg,
+		group:    group,
+		attempts: attempts,
+		ackFn:    ackFn,
+	}
+}
+
+// Message returns the message owned by this transaction.
+func (t *DeadLetter) Message() message.Batch {
+	return t.msg
+}
+
+// failures returns the errors of each message of the transaction that has
+// failed, keyed by their index.
+func (t *DeadLetter) failures(err error) map[int]error {
+	failed := make(map[int]error, len(t.msg))
+
+	var walkable *batch.Error
+	if !errors.As(err, &walkable) {
+		for i := range t.msg {
+			failed[i] = err
+		}
+		return failed
+	}
+
+	// Messages of ours that aren't represented within the batch error at all
+	// are assumed to have failed with the general error.
+	remainingIndexes := make(map[int]struct{}, len(t.msg))
+	for i := range t.msg {
+		remainingIndexes[i] = struct{}{}
+	}
+	walkable.WalkPartsBySource(t.group, t.msg, func(index int, p *message.Part, err error) bool {
+		if err != nil {
+			failed[index] = err
+		}
+		delete(remainingIndexes, index)
+		return true
+	})
+	for i := range remainingIndexes {
+		failed[i] = errors.Unwrap(walkable)
+	}
+	return failed
+}
+
+// Ack provides a response to the upstream service from an error.
+func (t *DeadLetter) Ack(ctx context.Context, err error) error {
+	if err == nil {
+		return t.ackFn(ctx, nil)
+	}
+
+	failed := t.failures(err)
+	if len(failed) == 0 {
+		return t.ackFn(ctx, nil)
+	}
+
+	if t.attempts < t.router.MaxAttempts {
+		retryBatch := make(message.Batch, 0, len(failed))
+		for i, p := range t.original {
+			if _, exists := failed[i]; exists {
+				retryBatch = append(retryBatch, p)
+			}
+		}
+		if rErr := t.router.Retry(ctx, t.router.newTransaction(retryBatch, t.attempts+1, t.ackFn)); rErr != nil {
+			return t.ackFn(ctx, err)
+		}
+		return nil
+	}
+
+	tNow := time.Now().Format(time.RFC3339Nano)
+	deadBatch := make(message.Batch, 0, len(failed))
+	for i, p := range t.original {
+		pErr, exists := failed[i]
+		if !exists {
+			continue
+		}
+
+		label := t.router.DefaultLabel
+		var cErr *ComponentError
+		if errors.As(pErr, &cErr) {
+			label = cErr.Label
+		}
+
+		p = p.ShallowCopy()
+		p.MetaSetMut(DeadLetterMetaError, pErr.Error())
+		p.MetaSetMut(DeadLetterMetaLabel, label)
+		p.MetaSetMut(DeadLetterMetaAttempts, int64(t.attempts))
+		p.MetaSetMut(DeadLetterMetaTimestamp, tNow)
+		deadBatch = append(deadBatch, p)
+	}
+
+	if rErr := t.router.Route(ctx, deadBatch); rErr != nil {
+		return t.ackFn(ctx, err)
+	}
+	return t.ackFn(ctx, nil)
+}
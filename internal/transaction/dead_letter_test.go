@@ -0,0 +1,113 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestDeadLetterRouting(t *testing.T) {
+	ctx := context.Background()
+
+	var retries []*DeadLetter
+	var routed []message.Batch
+	router := &DeadLetterRouter{
+		MaxAttempts:  2,
+		DefaultLabel: "output",
+		Retry: func(ctx context.Context, t *DeadLetter) error {
+			retries = append(retries, t)
+			return nil
+		},
+		Route: func(ctx context.Context, b message.Batch) error {
+			routed = append(routed, b)
+			return nil
+		},
+	}
+
+	var upstreamErrs []error
+	ackFn := func(ctx context.Context, err error) error {
+		upstreamErrs = append(upstreamErrs, err)
+		return nil
+	}
+
+	tran := router.NewTransaction(message.QuickBatch([][]byte{
+		[]byte("foo"),
+		[]byte("bar"),
+		[]byte("baz"),
+	}), ackFn)
+
+	// Mutations downstream must not impact the original payload.
+	tran.Message()[1].SetBytes([]byte("mutated"))
+
+	batchErr := batch.NewError(tran.Message(), errors.New("general"))
+	batchErr.Failed(1, &ComponentError{Label: "checker", Err: errors.New("bar failed")})
+	require.NoError(t, tran.Ack(ctx, batchErr))
+
+	assert.Empty(t, upstreamErrs)
+	assert.Empty(t, routed)
+	require.Len(t, retries, 1)
+
+	retryTran := retries[0]
+	require.Len(t, retryTran.Message(), 1)
+	assert.Equal(t, "bar", string(retryTran.Message()[0].AsBytes()))
+
+	require.NoError(t, retryTran.Ack(ctx, &ComponentError{Label: "checker", Err: errors.New("bar failed again")}))
+	assert.Equal(t, []error{nil}, upstreamErrs)
+	require.Len(t, retries, 1)
+	require.Len(t, routed, 1)
+	require.Len(t, routed[0], 1)
+
+	p := routed[0][0]
+	assert.Equal(t, "bar", string(p.AsBytes()))
+	assert.Equal(t, "bar failed again", p.MetaGetStr(DeadLetterMetaError))
+	assert.Equal(t, "checker", p.MetaGetStr(DeadLetterMetaLabel))
+	assert.Equal(t, "2", p.MetaGetStr(DeadLetterMetaAttempts))
+	assert.NotEmpty(t, p.MetaGetStr(DeadLetterMetaTimestamp))
+}
+
+func TestDeadLetterRouteFailure(t *testing.T) {
+	ctx := context.Background()
+
+	var routed []message.Batch
+	router := &DeadLetterRouter{
+		MaxAttempts:  1,
+		DefaultLabel: "output",
+		Route: func(ctx context.Context, b message.Batch) error {
+			routed = append(routed, b)
+			return errors.New("dead letter output is down")
+		},
+	}
+
+	var upstreamErrs []error
+	tran := router.NewTransaction(message.QuickBatch([][]byte{
+		[]byte("foo"),
+		[]byte("bar"),
+	}), func(ctx context.Context, err error) error {
+		upstreamErrs = append(upstreamErrs, err)
+		return nil
+	})
+
+	// Successful acknowledgements are propagated as is.
+	require.NoError(t, tran.Ack(ctx, nil))
+	assert.Equal(t, []error{nil}, upstreamErrs)
+	assert.Empty(t, routed)
+
+	// When the dead letter output fails the original error is propagated.
+	require.NoError(t, tran.Ack(ctx, errors.New("output failed")))
+	require.Len(t, upstreamErrs, 2)
+	assert.EqualError(t, upstreamErrs[1], "output failed")
+
+	require.Len(t, routed, 1)
+	require.Len(t, routed[0], 2)
+	for _, p := range routed[0] {
+		assert.Equal(t, "output failed", p.MetaGetStr(DeadLetterMetaError))
+		assert.Equal(t, "output", p.MetaGetStr(DeadLetterMetaLabel))
+		assert.Equal(t, "1", p.MetaGetStr(DeadLetterMetaAttempts))
+	}
+}
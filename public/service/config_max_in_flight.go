@@ -1,5 +1,9 @@
 package service
 
+import (
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+)
+
 // NewOutputMaxInFlightField creates a common field for determining the maximum
 // number of in-flight messages an output should allow. This function is a
 // short-hand way of creating an integer field with the common name
@@ -16,3 +20,66 @@ func NewOutputMaxInFlightField() *ConfigField {
 func (p *ParsedConfig) FieldMaxInFlight() (int, error) {
 	return p.FieldInt("max_in_flight")
 }
+
+const (
+	acFieldName             = "adaptive_concurrency"
+	acFieldEnabled          = "enabled"
+	acFieldMinInFlight      = "min_in_flight"
+	acFieldLatencyTolerance = "latency_tolerance"
+	acFieldBackoffRatio     = "backoff_ratio"
+)
+
+// NewOutputAdaptiveConcurrencyField creates a common field for enabling
+// adaptive concurrency on an output, where the number of parallel writes grows
+// and shrinks based on the observed latency and errors of writes, with the
+// value of max_in_flight as an upper bound.
+//
+// When this field is added to the config spec of an output registered with
+// RegisterOutput or RegisterBatchOutput it is applied automatically, and the
+// current limit is exposed as the gauge metric output_in_flight_limit.
+func NewOutputAdaptiveConcurrencyField() *ConfigField {
+	return NewObjectField(acFieldName,
+		NewBoolField(acFieldEnabled).
+			Description("Whether to adjust the number of parallel writes automatically, where `max_in_flight` becomes the upper bound.").
+			Default(false),
+		NewIntField(acFieldMinInFlight).
+			Description("The lowest number of parallel writes to allow, which is also the number of parallel writes to begin with.").
+			Default(1),
+		NewFloatField(acFieldLatencyTolerance).
+			Description("A multiple of the lowest recently observed write latency, where writes that take longer than this are treated as a sign of congestion.").
+			Default(2.0),
+		NewFloatField(acFieldBackoffRatio).
+			Description("The ratio by which the number of parallel writes is multiplied when a write fails or is congested.").
+			Default(0.9),
+	).
+		Description("Adjusts the number of parallel writes based on the observed latency and errors of writes, growing the number by one for each round of healthy writes and shrinking it multiplicatively when a write fails or takes longer than the `latency_tolerance` allows. The current limit is exposed as the gauge metric `output_in_flight_limit`.").
+		Advanced().
+		Version("4.28.0")
+}
+
+// asyncWriterOpts returns the options for an async writer derived from common
+// fields of a parsed output config.
+func (p *ParsedConfig) asyncWriterOpts() ([]output.AsyncWriterOpt, error) {
+	if !p.Contains(acFieldName) {
+		return nil, nil
+	}
+
+	acConf := p.Namespace(acFieldName)
+	if enabled, err := acConf.FieldBool(acFieldEnabled); err != nil || !enabled {
+		return nil, err
+	}
+
+	conf := output.NewAdaptiveConcurrencyConfig()
+
+	var err error
+	if conf.MinInFlight, err = acConf.FieldInt(acFieldMinInFlight); err != nil {
+		return nil, err
+	}
+	if conf.LatencyTolerance, err = acConf.FieldFloat(acFieldLatencyTolerance); err != nil {
+		return nil, err
+	}
+	if conf.BackoffRatio, err = acConf.FieldFloat(acFieldBackoffRatio); err != nil {
+		return nil, err
+	}
+	return []output.AsyncWriterOpt{output.AsyncWriterOptAdaptiveConcurrency(conf)}, nil
+}
@@ -0,0 +1,104 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/pure"
+)
+
+type adaptiveTestOutput struct {
+	mut     sync.Mutex
+	written []string
+}
+
+func (a *adaptiveTestOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (a *adaptiveTestOutput) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	for _, m := range b {
+		mBytes, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+		a.written = append(a.written, string(mBytes))
+	}
+	return nil
+}
+
+func (a *adaptiveTestOutput) Close(ctx context.Context) error {
+	return nil
+}
+
+func runAdaptiveTestOutput(t *testing.T, outputConf string) (*adaptiveTestOutput, error) {
+	t.Helper()
+
+	env := service.NewEnvironment()
+	out := &adaptiveTestOutput{}
+	require.NoError(t, env.RegisterBatchOutput("adaptive_test", service.NewConfigSpec().Fields(
+		service.NewOutputMaxInFlightField(),
+		service.NewOutputAdaptiveConcurrencyField(),
+	), func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchOutput, service.BatchPolicy, int, error) {
+		mIF, err := conf.FieldMaxInFlight()
+		return out, service.BatchPolicy{}, mIF, err
+	}))
+
+	builder := env.NewStreamBuilder()
+	require.NoError(t, builder.SetYAML(`
+input:
+  generate:
+    count: 10
+    interval: ""
+    mapping: 'root = "hello world"'
+
+output:
+`+outputConf+`
+
+logger:
+  level: none
+`))
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+	return out, strm.Run(ctx)
+}
+
+func TestOutputAdaptiveConcurrency(t *testing.T) {
+	out, err := runAdaptiveTestOutput(t, `
+  adaptive_test:
+    max_in_flight: 4
+    adaptive_concurrency:
+      enabled: true
+      min_in_flight: 2
+`)
+	require.NoError(t, err)
+
+	out.mut.Lock()
+	assert.Len(t, out.written, 10)
+	out.mut.Unlock()
+}
+
+func TestOutputAdaptiveConcurrencyBadConfig(t *testing.T) {
+	_, err := runAdaptiveTestOutput(t, `
+  adaptive_test:
+    max_in_flight: 4
+    adaptive_concurrency:
+      enabled: true
+      min_in_flight: 5
+`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "min_in_flight")
+}
@@ -19,6 +19,19 @@ func (c *ConfigField) XUnwrapper() any {
 	return fieldUnwrapper{child: c.field}
 }
 
+type configSpecUnwrapper struct {
+	spec *ConfigSpec
+}
+
+func (c configSpecUnwrapper) SetFixerFunc(fn docs.FixFunc) {
+	c.spec.component.Config = c.spec.component.Config.FixerFunc(fn)
+}
+
+// XUnwrapper is for internal use only, do not use this.
+func (c *ConfigSpec) XUnwrapper() any {
+	return configSpecUnwrapper{spec: c}
+}
+
 func extractConfig(
 	nm bundle.NewManagement,
 	spec *ConfigSpec,
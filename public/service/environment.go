@@ -270,8 +270,13 @@ func (e *Environment) RegisterOutput(name string, spec *ConfigSpec, ctor OutputC
 			if maxInFlight < 1 {
 				return nil, fmt.Errorf("invalid maxInFlight parameter: %v", maxInFlight)
 			}
+			awOpts, err := pluginConf.asyncWriterOpts()
+			if err != nil {
+				return nil, err
+			}
+
 			w := newAirGapWriter(op)
-			o, err := output.NewAsyncWriter(conf.Type, maxInFlight, w, nm)
+			o, err := output.NewAsyncWriter(conf.Type, maxInFlight, w, nm, awOpts...)
 			if err != nil {
 				return nil, err
 			}
@@ -316,8 +321,13 @@ func (e *Environment) RegisterBatchOutput(name string, spec *ConfigSpec, ctor Ba
 				return nil, fmt.Errorf("invalid maxInFlight parameter: %v", maxInFlight)
 			}
 
+			awOpts, err := pluginConf.asyncWriterOpts()
+			if err != nil {
+				return nil, err
+			}
+
 			w := newAirGapBatchWriter(op)
-			o, err := output.NewAsyncWriter(conf.Type, maxInFlight, w, nm)
+			o, err := output.NewAsyncWriter(conf.Type, maxInFlight, w, nm, awOpts...)
 			if err != nil {
 				return nil, err
 			}
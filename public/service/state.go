@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/state"
+)
+
+// StateStore provides keyed access to state held within a cache resource,
+// where keys are scoped to a namespace.
+//
+// Mutations made on behalf of a message are staged and only committed to the
+// cache once the batch that the message belongs to has been acknowledged, and
+// are discarded if the batch is rejected. Reads observe the mutations staged by
+// the batch of the message, followed by the mutations staged by other batches
+// that are yet to be acknowledged. When a message is not provided, or it isn't
+// being processed within a pipeline, mutations are applied to the cache
+// immediately.
+//
+// Staged mutations are committed in the order in which they were made,
+// regardless of the order in which their batches are acknowledged. However,
+// reading and then writing a key is not atomic, and therefore batches that are
+// processed in parallel can overwrite each other's mutations.
+type StateStore struct {
+	s *state.Store
+}
+
+// NewStateStore returns a state store where keys of a given namespace are
+// stored within a cache resource. Values are stored under the key
+// `<namespace>:<key>`, or just `<key>` when the namespace is empty.
+func (r *Resources) NewStateStore(cacheName, namespace string) *StateStore {
+	return &StateStore{s: state.NewStore(r.mgr, cacheName, namespace)}
+}
+
+func stateTxn(msg *Message) *state.Txn {
+	if msg == nil {
+		return nil
+	}
+	return state.TxnFromContext(msg.part.GetContext())
+}
+
+// Get the value of a key on behalf of a message, returns ErrKeyNotFound if the
+// key does not exist.
+func (s *StateStore) Get(ctx context.Context, msg *Message, key string) ([]byte, error) {
+	v, err := s.s.Get(ctx, stateTxn(msg), key)
+	if errors.Is(err, component.ErrKeyNotFound) {
+		err = ErrKeyNotFound
+	}
+	return v, err
+}
+
+// Set the value of a key on behalf of a message.
+func (s *StateStore) Set(ctx context.Context, msg *Message, key string, value []byte) error {
+	return s.s.Set(ctx, stateTxn(msg), key, value)
+}
+
+// Delete a key on behalf of a message.
+func (s *StateStore) Delete(ctx context.Context, msg *Message, key string) error {
+	return s.s.Delete(ctx, stateTxn(msg), key)
+}
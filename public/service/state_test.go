@@ -0,0 +1,135 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/pure"
+)
+
+func TestStateStoreImmediate(t *testing.T) {
+	ctx := context.Background()
+	res := service.MockResources(service.MockResourcesOptAddCache("foo"))
+
+	store := res.NewStateStore("foo", "bar")
+
+	_, err := store.Get(ctx, nil, "a")
+	require.ErrorIs(t, err, service.ErrKeyNotFound)
+
+	// Messages that aren't part of a pipeline apply mutations immediately.
+	require.NoError(t, store.Set(ctx, service.NewMessage(nil), "a", []byte("hello")))
+
+	require.NoError(t, res.AccessCache(ctx, "foo", func(c service.Cache) {
+		v, cErr := c.Get(ctx, "bar:a")
+		require.NoError(t, cErr)
+		assert.Equal(t, "hello", string(v))
+	}))
+
+	v, err := store.Get(ctx, nil, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(v))
+
+	require.NoError(t, store.Delete(ctx, nil, "a"))
+
+	_, err = store.Get(ctx, nil, "a")
+	require.ErrorIs(t, err, service.ErrKeyNotFound)
+}
+
+type stateCounterProc struct {
+	store *service.StateStore
+}
+
+func (s *stateCounterProc) ProcessBatch(ctx context.Context, b service.MessageBatch) ([]service.MessageBatch, error) {
+	for _, msg := range b {
+		var count int
+		v, err := s.store.Get(ctx, msg, "count")
+		if err == nil {
+			if count, err = strconv.Atoi(string(v)); err != nil {
+				return nil, err
+			}
+		} else if !errors.Is(err, service.ErrKeyNotFound) {
+			return nil, err
+		}
+		count++
+
+		countStr := strconv.Itoa(count)
+		if err := s.store.Set(ctx, msg, "count", []byte(countStr)); err != nil {
+			return nil, err
+		}
+		msg.MetaSetMut("go_count", countStr)
+	}
+	return []service.MessageBatch{b}, nil
+}
+
+func (s *stateCounterProc) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestStateStoreStream(t *testing.T) {
+	env := service.NewEnvironment()
+	require.NoError(t, env.RegisterBatchProcessor("state_counter", service.NewConfigSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return &stateCounterProc{store: mgr.NewStateStore("counts", "go")}, nil
+		}))
+
+	builder := env.NewStreamBuilder()
+	require.NoError(t, builder.SetYAML(`
+input:
+  generate:
+    count: 3
+    batch_size: 3
+    interval: ""
+    mapping: 'root = {}'
+
+pipeline:
+  processors:
+    - state_counter: {}
+    - mapping: |
+        root.go_count = @go_count
+        root.blobl_count = state_set("counts", "count", state_get("counts", "count", "blobl").or(0) + 1, "blobl")
+
+cache_resources:
+  - label: counts
+    memory: {}
+
+logger:
+  level: none
+`))
+
+	var resMut sync.Mutex
+	var results []string
+	require.NoError(t, builder.AddBatchConsumerFunc(func(ctx context.Context, b service.MessageBatch) error {
+		resMut.Lock()
+		defer resMut.Unlock()
+		for _, msg := range b {
+			mBytes, err := msg.AsBytes()
+			require.NoError(t, err)
+			results = append(results, string(mBytes))
+		}
+		return nil
+	}))
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+	require.NoError(t, strm.Run(ctx))
+
+	resMut.Lock()
+	defer resMut.Unlock()
+	assert.Equal(t, []string{
+		`{"blobl_count":1,"go_count":"1"}`,
+		`{"blobl_count":2,"go_count":"2"}`,
+		`{"blobl_count":3,"go_count":"3"}`,
+	}, results)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/cli"
 	"github.com/benthosdev/benthos/v4/internal/component/buffer"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/health"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
@@ -148,7 +149,7 @@ type muxWrapper struct {
 	m HTTPMultiplexer
 }
 
-func (w *muxWrapper) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
+func (w *muxWrapper) RegisterEndpoint(path, desc string, h http.HandlerFunc, ops ...api.OperationSpec) {
 	w.m.HandleFunc(path, h)
 }
 
@@ -883,8 +884,8 @@ func (s *StreamBuilder) buildWithEnv(env *bundle.Environment) (*Stream, error) {
 		}
 		apiMut = apiType
 	} else if hler := stats.HandlerFunc(); hler != nil {
-		apiMut.RegisterEndpoint("/stats", "Exposes service-wide metrics in the format configured.", hler)
-		apiMut.RegisterEndpoint("/metrics", "Exposes service-wide metrics in the format configured.", hler)
+		apiMut.RegisterEndpoint("/stats", "Exposes service-wide metrics in the format configured.", hler, api.MetricsOperations()...)
+		apiMut.RegisterEndpoint("/metrics", "Exposes service-wide metrics in the format configured.", hler, api.MetricsOperations()...)
 	}
 
 	mgr, err := manager.New(
@@ -901,6 +902,7 @@ func (s *StreamBuilder) buildWithEnv(env *bundle.Environment) (*Stream, error) {
 	if err != nil {
 		return nil, err
 	}
+	apiMut.RegisterEndpoint("/health", health.HandlerDescription, health.Handler(mgr.Health()), health.HandlerOperations()...)
 
 	if s.producerChan != nil {
 		mgr.SetPipe(s.producerID, s.producerChan)